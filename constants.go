@@ -7,6 +7,11 @@ import (
 // WebAPIVersion is a current webapi version
 const WebAPIVersion = "v1"
 
+// VersionHeader is the HTTP header a client sets on every API request to
+// report its own component version, so the auth server can check client/
+// server version skew.
+const VersionHeader = "X-Teleport-Version"
+
 // ForeverTTL means that object TTL will not expire unless deleted
 const ForeverTTL time.Duration = 0
 
@@ -40,6 +45,13 @@ const (
 
 	// HTTPProxy is an environment variable pointing to a HTTP proxy.
 	HTTPProxy = "HTTP_PROXY"
+
+	// AllProxy is an environment variable pointing to a proxy to use for
+	// all outbound connections, following the de facto convention shared
+	// by curl and most other CLI tools. A "socks5://" or "socks5h://"
+	// scheme selects a SOCKS5 proxy; anything else is treated the same
+	// way HTTPProxy/HTTPSProxy are.
+	AllProxy = "ALL_PROXY"
 )
 
 const (
@@ -98,6 +110,12 @@ const (
 	// ConnectorSAML means connector type SAML
 	ConnectorSAML = "oidc"
 
+	// ConnectorLDAP means connector type LDAP
+	ConnectorLDAP = "ldap"
+
+	// ConnectorOkta means connector type Okta
+	ConnectorOkta = "okta"
+
 	// DataDirParameterName is the name of the data dir configuration parameter passed
 	// to all backends during initialization
 	DataDirParameterName = "data_dir"
@@ -177,6 +195,22 @@ const (
 	CompatibilityNone = ""
 )
 
+const (
+	// TextFormat is the default, human-readable table output format for CLI
+	// list commands.
+	TextFormat = "text"
+
+	// JSONFormat outputs a JSON array so list commands can feed scripts and
+	// inventory tooling.
+	JSONFormat = "json"
+
+	// YAMLFormat outputs a YAML sequence, see JSONFormat.
+	YAMLFormat = "yaml"
+
+	// NamesFormat prints only the resource names, one per line.
+	NamesFormat = "names"
+)
+
 const (
 	// TraitInternalPrefix is the role variable prefix that indicates it's for
 	// local accounts.
@@ -198,3 +232,10 @@ const DefaultRoleName = "default"
 // DefaultImplicitRole is implicit role that gets added to all service.RoleSet
 // objects.
 const DefaultImplicitRole = "default-implicit-role"
+
+// CanaryLabel marks a node as a honeypot: any label named CanaryLabel
+// (regardless of its value) makes every login attempt against that node
+// raise events.CanaryAccessEvent, whether or not the attempt is ultimately
+// allowed by RBAC. There's no legitimate reason for a real user to touch
+// such a node, so any hit is treated as a high-priority signal.
+const CanaryLabel = "canary"
@@ -715,7 +715,11 @@ func (s *IntSuite) TestMapRoles(c *check.C) {
 	err = aux.Process.GetAuthServer().UpsertRole(role, backend.Forever)
 	c.Assert(err, check.IsNil)
 	trustedClusterToken := "trusted-clsuter-token"
-	err = main.Process.GetAuthServer().UpsertToken(trustedClusterToken, []teleport.Role{teleport.RoleTrustedCluster}, backend.Forever)
+	err = main.Process.GetAuthServer().UpsertToken(services.ProvisionToken{
+		Token:   trustedClusterToken,
+		Roles:   []teleport.Role{teleport.RoleTrustedCluster},
+		Expires: time.Now().UTC().Add(backend.Forever),
+	})
 	c.Assert(err, check.IsNil)
 	trustedCluster := main.Secrets.AsTrustedCluster(trustedClusterToken, services.RoleMap{
 		{Remote: mainDevs, Local: []string{auxDevs}},
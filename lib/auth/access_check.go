@@ -0,0 +1,83 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// AccessCheckRequest describes a single "would this be allowed" question,
+// answered without actually performing the checked action. It is the
+// payload for the RBAC simulation API used by compliance tooling and the
+// 'tctl access explain' command.
+type AccessCheckRequest struct {
+	// User is the Teleport user whose access is being simulated
+	User string `json:"user"`
+	// Login is the OS login the user would use
+	Login string `json:"login"`
+	// Node is the name (or hostname) of the server being accessed
+	Node string `json:"node"`
+	// Namespace is the namespace the node belongs to
+	Namespace string `json:"namespace"`
+}
+
+// AccessCheckResponse is the answer to an AccessCheckRequest
+type AccessCheckResponse struct {
+	// Allowed is true if the simulated access would be permitted
+	Allowed bool `json:"allowed"`
+	// Reason explains why access was allowed or denied
+	Reason string `json:"reason"`
+}
+
+// CheckAccessToServer answers whether req.User, logging in as req.Login,
+// would be permitted to connect to req.Node. It performs the same RBAC
+// evaluation the auth server runs when a real SSH connection is made, but
+// does not create a session, grant a certificate, or emit an audit event.
+func (s *AuthServer) CheckAccessToServer(req AccessCheckRequest) (*AccessCheckResponse, error) {
+	if req.Namespace == "" {
+		req.Namespace = defaults.Namespace
+	}
+	user, err := s.Identity.GetUser(req.User)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	nodes, err := s.Presence.GetNodes(req.Namespace)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var node services.Server
+	for _, n := range nodes {
+		if n.GetName() == req.Node || n.GetHostname() == req.Node {
+			node = n
+			break
+		}
+	}
+	if node == nil {
+		return nil, trace.NotFound("node %q not found in namespace %q", req.Node, req.Namespace)
+	}
+	roleSet, err := services.FetchRoles(user.GetRoles(), s, user.GetTraits())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := roleSet.CheckAccessToServer(req.Login, node); err != nil {
+		return &AccessCheckResponse{Allowed: false, Reason: err.Error()}, nil
+	}
+	return &AccessCheckResponse{Allowed: true, Reason: "access granted by assigned roles"}, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"sort"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// defaultAccessMatrixLimit bounds a page's size when AccessMatrixRequest
+// doesn't set Limit.
+const defaultAccessMatrixLimit = 1000
+
+// AccessMatrixRequest asks for a page of the full user x node x login
+// access matrix -- see AuthServer.GetAccessMatrix.
+type AccessMatrixRequest struct {
+	// Namespace is the namespace to compute access for.
+	Namespace string `json:"namespace"`
+	// StartKey resumes a previous request where it left off; empty starts
+	// from the beginning.
+	StartKey string `json:"start_key"`
+	// Limit caps how many entries are returned in a single page.
+	Limit int `json:"limit"`
+}
+
+// AccessMatrixEntry is a single (user, node, login) combination the access
+// matrix found to be permitted.
+type AccessMatrixEntry struct {
+	// User is the Teleport user.
+	User string `json:"user"`
+	// Node is the node's name.
+	Node string `json:"node"`
+	// Login is the OS login User may use on Node.
+	Login string `json:"login"`
+}
+
+// AccessMatrixResponse is a page of the access matrix.
+type AccessMatrixResponse struct {
+	// Entries are the permitted (user, node, login) combinations found on
+	// this page, sorted by user then node then login so pagination is
+	// stable across calls.
+	Entries []AccessMatrixEntry `json:"entries"`
+	// NextKey resumes the matrix after this page's last entry; empty once
+	// there's nothing left.
+	NextKey string `json:"next_key"`
+}
+
+// GetAccessMatrix computes the full user x node x login access matrix for
+// req.Namespace: for every user, every node they could reach with some
+// login granted by their roles. It's built for periodic access reviews,
+// not for making real-time access decisions, so it pays the full
+// O(users*nodes*logins) cost of asking every combination outright instead
+// of taking the shortcuts a live connection's RBAC check can afford to.
+func (s *AuthServer) GetAccessMatrix(req AccessMatrixRequest) (*AccessMatrixResponse, error) {
+	if req.Namespace == "" {
+		req.Namespace = defaults.Namespace
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultAccessMatrixLimit
+	}
+
+	users, err := s.Identity.GetUsers()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	nodes, err := s.Presence.GetNodes(req.Namespace)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var all []AccessMatrixEntry
+	for _, user := range users {
+		checker, err := services.FetchRoles(user.GetRoles(), s, user.GetTraits())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		ttl := checker.AdjustSessionTTL(defaults.MaxCertDuration)
+		logins, err := checker.CheckLoginDuration(ttl)
+		if err != nil {
+			// this user's roles grant no logins at all (e.g. an
+			// admin-only role set); they simply contribute no rows.
+			continue
+		}
+		for _, node := range nodes {
+			for _, login := range logins {
+				if err := checker.CheckAccessToServer(login, node); err != nil {
+					continue
+				}
+				all = append(all, AccessMatrixEntry{User: user.GetName(), Node: node.GetName(), Login: login})
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].User != all[j].User {
+			return all[i].User < all[j].User
+		}
+		if all[i].Node != all[j].Node {
+			return all[i].Node < all[j].Node
+		}
+		return all[i].Login < all[j].Login
+	})
+
+	start := 0
+	if req.StartKey != "" {
+		start = sort.Search(len(all), func(i int) bool {
+			return accessMatrixKey(all[i]) > req.StartKey
+		})
+	}
+	if start >= len(all) {
+		return &AccessMatrixResponse{}, nil
+	}
+	end := start + limit
+	var nextKey string
+	if end < len(all) {
+		nextKey = accessMatrixKey(all[end-1])
+	} else {
+		end = len(all)
+	}
+	return &AccessMatrixResponse{Entries: all[start:end], NextKey: nextKey}, nil
+}
+
+// accessMatrixKey is the pagination cursor for entry: a string that sorts
+// the same way the matrix itself does, so "resume after this entry" is
+// just a string comparison.
+func accessMatrixKey(entry AccessMatrixEntry) string {
+	return entry.User + "\x00" + entry.Node + "\x00" + entry.Login
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/auth/slack"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateAccessRequest records user's request for roles, and notifies
+// whichever of the cluster's SlackPlugin/EmailPlugin are enabled. start is
+// when the requested roles should take effect (the zero Time meaning
+// immediately upon approval) and ttl is how long they stay in effect once
+// granted (zero meaning they don't expire on their own).
+// Notification delivery failures are logged, not returned, since the
+// request itself has already been recorded and can still be resolved via
+// tctl/the API even if a notification failed.
+func (s *AuthServer) CreateAccessRequest(user string, roles []string, reason string, start time.Time, ttl time.Duration) (*services.AccessRequest, error) {
+	req := services.AccessRequest{
+		ID:      uuid.New(),
+		User:    user,
+		Roles:   roles,
+		Reason:  reason,
+		Created: time.Now().UTC(),
+		Start:   start,
+		TTL:     services.NewDuration(ttl),
+	}
+	if err := s.AccessRequests.CreateAccessRequest(req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	s.notifyEmailAccessRequest(&req, "Teleport access request",
+		accessRequestMessage(&req))
+
+	plugin, err := s.GetSlackPlugin()
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			log.Warningf("[AUTH] failed to load Slack plugin config: %v", err)
+		}
+		return &req, nil
+	}
+	if !plugin.GetEnabled() {
+		return &req, nil
+	}
+
+	threadTS, err := slack.PostMessage(slackConfig(plugin), plugin.GetChannel(),
+		accessRequestMessage(&req))
+	if err != nil {
+		log.Warningf("[AUTH] failed to post access request %v to Slack: %v", req.ID, err)
+		return &req, nil
+	}
+	if err := s.AccessRequests.SetAccessRequestSlackThread(req.ID, plugin.GetChannel(), threadTS); err != nil {
+		log.Warningf("[AUTH] failed to record Slack thread for access request %v: %v", req.ID, err)
+		return &req, nil
+	}
+	req.SlackChannel, req.SlackThreadTS = plugin.GetChannel(), threadTS
+	return &req, nil
+}
+
+// GetAccessRequests lists all access requests, most recently created first.
+func (s *AuthServer) GetAccessRequests() ([]services.AccessRequest, error) {
+	return s.AccessRequests.GetAccessRequests()
+}
+
+// ResolveAccessRequest approves or denies a pending access request. On
+// approval, the requested roles are added to the requesting user's role
+// list, in effect for req.TTL (or indefinitely, if it's zero); this
+// snapshot has no notion of a time-limited certificate elevation, so a
+// TTL is enforced by RunAccessRequestSweep revoking the roles once it
+// elapses, rather than by the issued certificate itself expiring early --
+// an already-established SSH session using the elevated role isn't
+// forcibly disconnected when that happens, only future logins/cert
+// renewals are affected. If req.Start is in the future, approval records
+// the decision but defers granting the roles until RunAccessRequestSweep
+// activates the request at Start. If the request has an associated Slack
+// thread, the resolution is posted there as a reply.
+//
+// If any requested role sets RequestApproverRole, resolver must hold that
+// role to approve or deny the request. If it also sets
+// RequestApproverCount greater than 1, approval doesn't fulfill the
+// request outright: it records resolver's approval and the request stays
+// pending until that many distinct members of RequestApproverRole have
+// approved it, at which point the roles are granted and the request is
+// marked resolved.
+func (s *AuthServer) ResolveAccessRequest(id string, state services.AccessRequestState, resolver, reason string) (*services.AccessRequest, error) {
+	req, err := s.AccessRequests.GetAccessRequest(id)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.IsResolved() {
+		return nil, trace.BadParameter("access request %v has already been resolved", id)
+	}
+
+	approverRole, approverCount, err := s.accessRequestApprovalRequirement(req.Roles)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if approverRole != "" {
+		approver, err := s.GetUser(resolver)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !utils.SliceContainsStr(approver.GetRoles(), approverRole) {
+			return nil, trace.AccessDenied("resolving this request requires role %q", approverRole)
+		}
+	}
+
+	if state == services.AccessRequestApproved {
+		if approverCount > 1 {
+			if req.HasApproval(resolver) {
+				return nil, trace.BadParameter("%v has already approved access request %v", resolver, id)
+			}
+			if err := s.AccessRequests.AddAccessRequestApproval(id, resolver); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			req, err = s.AccessRequests.GetAccessRequest(id)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if len(req.Approvals) < approverCount {
+				// still awaiting more approvers; leave the request pending.
+				return req, nil
+			}
+		}
+		if req.Start.IsZero() || !time.Now().Before(req.Start) {
+			if err := s.activateAccessRequest(req); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+	}
+	if err := s.AccessRequests.SetAccessRequestState(id, state, resolver, reason); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req, err = s.AccessRequests.GetAccessRequest(id)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	s.notifyEmailAccessRequest(req, "Teleport access request "+string(state),
+		fmt.Sprintf("%v %v this request: %v", resolver, state, reason))
+
+	if req.SlackChannel != "" && req.SlackThreadTS != "" {
+		plugin, err := s.GetSlackPlugin()
+		if err != nil {
+			log.Warningf("[AUTH] failed to load Slack plugin config: %v", err)
+			return req, nil
+		}
+		text := fmt.Sprintf("%v %v this request: %v", resolver, state, reason)
+		if err := slack.ThreadReply(slackConfig(plugin), req.SlackChannel, req.SlackThreadTS, text); err != nil {
+			log.Warningf("[AUTH] failed to post resolution of access request %v to Slack: %v", id, err)
+		}
+	}
+	return req, nil
+}
+
+// grantRequestedRoles adds req's requested roles to req.User's role list,
+// skipping any the user already has.
+func (s *AuthServer) grantRequestedRoles(req *services.AccessRequest) error {
+	user, err := s.GetUser(req.User)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	existing := make(map[string]bool)
+	for _, role := range user.GetRoles() {
+		existing[role] = true
+	}
+	roles := user.GetRoles()
+	for _, role := range req.Roles {
+		if !existing[role] {
+			roles = append(roles, role)
+		}
+	}
+	user.SetRoles(roles)
+	return trace.Wrap(s.UpsertUser(user))
+}
+
+// activateAccessRequest grants req's requested roles and records when
+// that grant expires, per req.TTL.
+func (s *AuthServer) activateAccessRequest(req *services.AccessRequest) error {
+	if err := s.grantRequestedRoles(req); err != nil {
+		return trace.Wrap(err)
+	}
+	var expires time.Time
+	if req.TTL.Duration > 0 {
+		expires = time.Now().UTC().Add(req.TTL.Duration)
+	}
+	return trace.Wrap(s.AccessRequests.ActivateAccessRequest(req.ID, expires))
+}
+
+// revokeRequestedRoles removes req's requested roles from req.User's role
+// list, leaving any the user holds for other reasons untouched.
+func (s *AuthServer) revokeRequestedRoles(req *services.AccessRequest) error {
+	user, err := s.GetUser(req.User)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	requested := make(map[string]bool, len(req.Roles))
+	for _, role := range req.Roles {
+		requested[role] = true
+	}
+	var roles []string
+	for _, role := range user.GetRoles() {
+		if !requested[role] {
+			roles = append(roles, role)
+		}
+	}
+	user.SetRoles(roles)
+	return trace.Wrap(s.UpsertUser(user))
+}
+
+// accessRequestSweepLock is the RunWhileLeader election lock name for
+// RunAccessRequestSweep, so only one auth server in an HA deployment
+// activates or expires a given request.
+const accessRequestSweepLock = "access-request-sweep"
+
+// RunAccessRequestSweep periodically activates approved access requests
+// whose scheduled Start has arrived, and revokes the roles of Activated
+// requests whose TTL has elapsed, for as long as this auth server holds
+// the accessRequestSweepLock election and ctx is live.
+func (s *AuthServer) RunAccessRequestSweep(ctx context.Context) {
+	s.RunWhileLeader(ctx, accessRequestSweepLock, defaults.AccessRequestSweepInterval, defaults.AccessRequestSweepInterval, func() {
+		if err := s.sweepAccessRequests(); err != nil {
+			log.Warningf("[AUTH] access request sweep failed: %v", err)
+		}
+	})
+}
+
+// sweepAccessRequests activates any approved, not-yet-Activated request
+// whose Start has arrived, and expires any Activated request whose TTL
+// has elapsed.
+func (s *AuthServer) sweepAccessRequests() error {
+	requests, err := s.AccessRequests.GetAccessRequests()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	now := time.Now().UTC()
+	for i := range requests {
+		req := requests[i]
+		if req.State != services.AccessRequestApproved {
+			continue
+		}
+		switch {
+		case !req.Activated:
+			if req.Start.IsZero() || now.Before(req.Start) {
+				continue
+			}
+			if err := s.activateAccessRequest(&req); err != nil {
+				log.Warningf("[AUTH] failed to activate access request %v: %v", req.ID, err)
+			}
+		case !req.Expires.IsZero() && !now.Before(req.Expires):
+			if err := s.revokeRequestedRoles(&req); err != nil {
+				log.Warningf("[AUTH] failed to revoke roles for expired access request %v: %v", req.ID, err)
+				continue
+			}
+			if err := s.AccessRequests.ExpireAccessRequest(req.ID); err != nil {
+				log.Warningf("[AUTH] failed to mark access request %v as expired: %v", req.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// accessRequestApprovalRequirement inspects the roles being requested and
+// returns the strictest dual-authorization requirement among them: the
+// approver role and how many of its distinct members must approve. Roles
+// that don't set RequestApproverRole are ignored. If more than one
+// requested role sets RequestApproverRole, only the requirement with the
+// highest approverCount is enforced; mixing distinct approver roles on a
+// single request isn't supported.
+func (s *AuthServer) accessRequestApprovalRequirement(roles []string) (approverRole string, approverCount int, err error) {
+	for _, roleName := range roles {
+		role, err := s.GetRole(roleName)
+		if err != nil {
+			return "", 0, trace.Wrap(err)
+		}
+		options := role.GetOptions()
+		r, err := options.GetString(services.RequestApproverRole)
+		if err != nil || r == "" {
+			continue
+		}
+		count, err := options.GetInt(services.RequestApproverCount)
+		if err != nil {
+			count = 1
+		}
+		if count > approverCount {
+			approverRole, approverCount = r, count
+		}
+	}
+	return approverRole, approverCount, nil
+}
+
+func slackConfig(plugin services.SlackPlugin) slack.Config {
+	return slack.Config{
+		BotToken:      plugin.GetBotToken(),
+		SigningSecret: plugin.GetSigningSecret(),
+	}
+}
+
+func accessRequestMessage(req *services.AccessRequest) string {
+	msg := fmt.Sprintf("*%v* is requesting roles: %v", req.User, req.Roles)
+	if req.Reason != "" {
+		msg += fmt.Sprintf("\nReason: %v", req.Reason)
+	}
+	msg += fmt.Sprintf("\nRun `tctl requests approve %v` or `tctl requests deny %v` to resolve.", req.ID, req.ID)
+	return msg
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+// TestResolveAccessRequestDenyRequiresApproverRole verifies that denying an
+// access request whose requested role sets RequestApproverRole is gated to
+// members of that role, same as approving it is.
+func (s *AuthSuite) TestResolveAccessRequestDenyRequiresApproverRole(c *C) {
+	approverRole, err := services.NewRole("approvers", services.RoleSpecV3{})
+	c.Assert(err, IsNil)
+	c.Assert(s.a.UpsertRole(approverRole, 0), IsNil)
+
+	elevatedRole, err := services.NewRole("elevated", services.RoleSpecV3{})
+	c.Assert(err, IsNil)
+	options := elevatedRole.GetOptions()
+	options.Set(services.RequestApproverRole, "approvers")
+	elevatedRole.SetOptions(options)
+	c.Assert(s.a.UpsertRole(elevatedRole, 0), IsNil)
+
+	requester, err := services.NewUser("requester")
+	c.Assert(err, IsNil)
+	c.Assert(s.a.UpsertUser(requester), IsNil)
+
+	outsider, err := services.NewUser("outsider")
+	c.Assert(err, IsNil)
+	c.Assert(s.a.UpsertUser(outsider), IsNil)
+
+	approver, err := services.NewUser("approver")
+	c.Assert(err, IsNil)
+	approver.AddRole("approvers")
+	c.Assert(s.a.UpsertUser(approver), IsNil)
+
+	req, err := s.a.CreateAccessRequest("requester", []string{"elevated"}, "because", time.Time{}, 0)
+	c.Assert(err, IsNil)
+
+	// a user who isn't a member of the configured approver role must not
+	// be able to deny the request either.
+	_, err = s.a.ResolveAccessRequest(req.ID, services.AccessRequestDenied, "outsider", "no")
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+
+	// a member of the approver role can deny it.
+	resolved, err := s.a.ResolveAccessRequest(req.ID, services.AccessRequestDenied, "approver", "no")
+	c.Assert(err, IsNil)
+	c.Assert(resolved.State, Equals, services.AccessRequestDenied)
+}
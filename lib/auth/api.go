@@ -17,6 +17,8 @@ limitations under the License.
 package auth
 
 import (
+	"time"
+
 	"github.com/gravitational/teleport/lib/services"
 )
 
@@ -42,10 +44,21 @@ type AccessPoint interface {
 	// for the specified duration with second resolution if it's >= 1 second
 	UpsertNode(s services.Server) error
 
+	// KeepAliveNode extends the TTL of a node that already registered its
+	// full spec via UpsertNode, without resending that spec
+	KeepAliveNode(namespace, name string, expires time.Time) error
+
+	// DeleteNode deletes node by name
+	DeleteNode(namespace string, name string) error
+
 	// UpsertProxy registers server presence, permanently if ttl is 0 or
 	// for the specified duration with second resolution if it's >= 1 second
 	UpsertProxy(s services.Server) error
 
+	// KeepAliveProxy extends the TTL of a proxy that already registered its
+	// full spec via UpsertProxy, without resending that spec
+	KeepAliveProxy(name string, expires time.Time) error
+
 	// GetProxies returns a list of proxy servers registered in the cluster
 	GetProxies() ([]services.Server, error)
 
@@ -55,6 +68,9 @@ type AccessPoint interface {
 	// GetUsers returns a list of local users registered with this domain
 	GetUsers() ([]services.User, error)
 
+	// GetUser returns a single local user by name
+	GetUser(name string) (services.User, error)
+
 	// GetRole returns role by name
 	GetRole(name string) (services.Role, error)
 
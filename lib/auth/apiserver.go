@@ -85,6 +85,7 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	// Passwords and sessions
 	srv.POST("/:version/users", srv.withAuth(srv.upsertUser))
 	srv.POST("/:version/users/:user/web/password", srv.withAuth(srv.upsertPassword))
+	srv.POST("/:version/users/:user/totp", srv.withAuth(srv.upsertTOTP))
 	srv.POST("/:version/users/:user/web/password/check", srv.withAuth(srv.checkPassword))
 	srv.POST("/:version/users/:user/web/signin", srv.withAuth(srv.signIn))
 	srv.GET("/:version/users/:user/web/signin/preauth", srv.withAuth(srv.preAuthenticatedSignIn))
@@ -97,10 +98,16 @@ func NewAPIServer(config *APIConfig) http.Handler {
 
 	// Servers and presence heartbeat
 	srv.POST("/:version/namespaces/:namespace/nodes", srv.withAuth(srv.upsertNode))
+	srv.PUT("/:version/namespaces/:namespace/nodes/:name/keepalive", srv.withAuth(srv.keepAliveNode))
 	srv.GET("/:version/namespaces/:namespace/nodes", srv.withAuth(srv.getNodes))
+	srv.DELETE("/:version/namespaces/:namespace/nodes/:name", srv.withAuth(srv.deleteNode))
+	srv.DELETE("/:version/namespaces/:namespace/nodes", srv.withAuth(srv.deleteAllNodes))
+	srv.PUT("/:version/namespaces/:namespace/nodes/:name/approve", srv.withAuth(srv.approveNode))
+	srv.PUT("/:version/namespaces/:namespace/nodes/:name/quarantine", srv.withAuth(srv.quarantineNode))
 	srv.POST("/:version/authservers", srv.withAuth(srv.upsertAuthServer))
 	srv.GET("/:version/authservers", srv.withAuth(srv.getAuthServers))
 	srv.POST("/:version/proxies", srv.withAuth(srv.upsertProxy))
+	srv.PUT("/:version/proxies/:name/keepalive", srv.withAuth(srv.keepAliveProxy))
 	srv.GET("/:version/proxies", srv.withAuth(srv.getProxies))
 
 	// Reverse tunnels
@@ -129,6 +136,7 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/namespaces/:namespace/sessions/:id/stream", srv.withAuth(srv.postSessionChunk))
 	srv.GET("/:version/namespaces/:namespace/sessions/:id/stream", srv.withAuth(srv.getSessionChunk))
 	srv.GET("/:version/namespaces/:namespace/sessions/:id/events", srv.withAuth(srv.getSessionEvents))
+	srv.DELETE("/:version/namespaces/:namespace/sessions/:id/data", srv.withAuth(srv.purgeSessionData))
 
 	// Namespaces
 	srv.POST("/:version/namespaces", srv.withAuth(srv.upsertNamespace))
@@ -142,6 +150,10 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.GET("/:version/roles/:role", srv.withAuth(srv.getRole))
 	srv.DELETE("/:version/roles/:role", srv.withAuth(srv.deleteRole))
 
+	// RBAC simulation, used by 'tctl access explain' and compliance tooling
+	srv.POST("/:version/accesschecks/server", srv.withAuth(srv.checkAccessToServer))
+	srv.POST("/:version/accesschecks/matrix", srv.withAuth(srv.getAccessMatrix))
+
 	// cluster configuration
 	srv.GET("/:version/configuration/name", srv.withAuth(srv.getClusterName))
 	srv.POST("/:version/configuration/name", srv.withAuth(srv.setClusterName))
@@ -149,6 +161,40 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/configuration/static_tokens", srv.withAuth(srv.setStaticTokens))
 	srv.GET("/:version/authentication/preference", srv.withAuth(srv.getClusterAuthPreference))
 	srv.POST("/:version/authentication/preference", srv.withAuth(srv.setClusterAuthPreference))
+	srv.GET("/:version/configuration/session-recording-key", srv.withAuth(srv.getSessionRecordingKey))
+	srv.POST("/:version/configuration/session-recording-key", srv.withAuth(srv.upsertSessionRecordingKey))
+	srv.GET("/:version/configuration/notification", srv.withAuth(srv.getNotificationConfig))
+	srv.POST("/:version/configuration/notification", srv.withAuth(srv.setNotificationConfig))
+	srv.GET("/:version/configuration/ldap", srv.withAuth(srv.getLDAPConnector))
+	srv.POST("/:version/configuration/ldap", srv.withAuth(srv.setLDAPConnector))
+	srv.GET("/:version/configuration/okta", srv.withAuth(srv.getOktaConfig))
+	srv.POST("/:version/configuration/okta", srv.withAuth(srv.setOktaConfig))
+	srv.POST("/:version/configuration/okta/sync", srv.withAuth(srv.syncOktaGroups))
+
+	srv.GET("/:version/configuration/slack", srv.withAuth(srv.getSlackPlugin))
+	srv.POST("/:version/configuration/slack", srv.withAuth(srv.setSlackPlugin))
+
+	srv.GET("/:version/configuration/email", srv.withAuth(srv.getEmailPlugin))
+	srv.POST("/:version/configuration/email", srv.withAuth(srv.setEmailPlugin))
+
+	srv.POST("/:version/accessrequests", srv.withAuth(srv.createAccessRequest))
+	srv.GET("/:version/accessrequests", srv.withAuth(srv.getAccessRequests))
+	srv.POST("/:version/accessrequests/:id/resolve", srv.withAuth(srv.resolveAccessRequest))
+
+	srv.POST("/:version/reviewcampaigns", srv.withAuth(srv.createReviewCampaign))
+	srv.GET("/:version/reviewcampaigns", srv.withAuth(srv.getReviewCampaigns))
+	srv.POST("/:version/reviewcampaigns/:id/vote", srv.withAuth(srv.recordReviewVote))
+	srv.POST("/:version/reviewcampaigns/:id/close", srv.withAuth(srv.closeReviewCampaign))
+
+	srv.POST("/:version/clusteralerts", srv.withAuth(srv.createClusterAlert))
+	srv.GET("/:version/clusteralerts", srv.withAuth(srv.getClusterAlerts))
+	srv.GET("/:version/clusteralerts/active", srv.withAuth(srv.getActiveClusterAlerts))
+	srv.POST("/:version/clusteralerts/:id/acknowledge", srv.withAuth(srv.acknowledgeClusterAlert))
+
+	srv.POST("/:version/trustedhostkeys", srv.withAuth(srv.upsertTrustedHostKey))
+	srv.GET("/:version/trustedhostkeys", srv.withAuth(srv.getTrustedHostKeys))
+	srv.GET("/:version/trustedhostkeys/:hostname", srv.withAuth(srv.getTrustedHostKey))
+	srv.DELETE("/:version/trustedhostkeys/:hostname", srv.withAuth(srv.deleteTrustedHostKey))
 
 	// OIDC
 	srv.POST("/:version/oidc/connectors", srv.withAuth(srv.upsertOIDCConnector))
@@ -218,6 +264,14 @@ func (s *APIServer) withAuth(handler HandlerWithAuthFunc) httprouter.Handle {
 		if version == "" {
 			return nil, trace.BadParameter("missing version")
 		}
+		if skew := utils.CheckVersionSkew(teleport.Version, r.Header.Get(teleport.VersionHeader)); !skew.Compatible {
+			return nil, trace.AccessDenied("%v: %v, this auth server requires a client version within one minor release of %v", utils.VersionSkewErrorPrefix, skew.Message, teleport.Version)
+		} else if skew.PeerVersion != "" && skew.PeerVersion != teleport.Version {
+			// within the supported window, but not an exact match: not
+			// worth rejecting, but worth a warning so admins can see
+			// version drift building up before it becomes incompatible
+			log.Warnf("client reported version %v, this auth server is running %v", skew.PeerVersion, teleport.Version)
+		}
 		return handler(auth, w, r, p, version)
 	})
 }
@@ -279,6 +333,27 @@ func (s *APIServer) upsertNode(auth ClientI, w http.ResponseWriter, r *http.Requ
 	return s.upsertServer(auth, teleport.RoleNode, w, r, p, version)
 }
 
+type keepAliveReq struct {
+	Expires time.Time `json:"expires"`
+}
+
+// keepAliveNode is called by remote SSH nodes between full heartbeats to
+// extend the TTL of a node whose spec hasn't changed
+func (s *APIServer) keepAliveNode(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+	var req keepAliveReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.KeepAliveNode(namespace, p.ByName("name"), req.Expires); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 // getNodes returns registered SSH nodes
 func (s *APIServer) getNodes(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	namespace := p.ByName("namespace")
@@ -292,11 +367,77 @@ func (s *APIServer) getNodes(auth ClientI, w http.ResponseWriter, r *http.Reques
 	return marshalServers(servers, version)
 }
 
+// deleteNode removes a node from the backend
+func (s *APIServer) deleteNode(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+	name := p.ByName("name")
+	if err := auth.DeleteNode(namespace, name); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("node '%v' deleted", name)), nil
+}
+
+// approveNode marks a pending or quarantined node as approved, allowing it
+// to receive sessions again
+func (s *APIServer) approveNode(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+	name := p.ByName("name")
+	if err := auth.ApproveNode(namespace, name); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("node '%v' approved", name)), nil
+}
+
+// quarantineNode marks a node as quarantined, immediately blocking new
+// sessions to it until it's approved again
+func (s *APIServer) quarantineNode(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+	name := p.ByName("name")
+	if err := auth.QuarantineNode(namespace, name); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("node '%v' quarantined", name)), nil
+}
+
+// deleteAllNodes removes every node registered in a namespace
+func (s *APIServer) deleteAllNodes(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+	if err := auth.DeleteAllNodes(namespace); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("all nodes in namespace %q deleted", namespace)), nil
+}
+
 // upsertProxy is called by remote SSH nodes when they ping back into the auth service
 func (s *APIServer) upsertProxy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	return s.upsertServer(auth, teleport.RoleProxy, w, r, p, version)
 }
 
+// keepAliveProxy is called by remote proxies between full heartbeats to
+// extend the TTL of a proxy whose spec hasn't changed
+func (s *APIServer) keepAliveProxy(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req keepAliveReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.KeepAliveProxy(p.ByName("name"), req.Expires); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 // getProxies returns registered proxies
 func (s *APIServer) getProxies(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	servers, err := auth.GetProxies()
@@ -597,6 +738,25 @@ func (s *APIServer) upsertPassword(auth ClientI, w http.ResponseWriter, r *http.
 	return message(fmt.Sprintf("password for for user %q upserted", user)), nil
 }
 
+type upsertTOTPReq struct {
+	OTPSecret string `json:"otp_secret"`
+}
+
+func (s *APIServer) upsertTOTP(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req *upsertTOTPReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	user := p.ByName("user")
+	err := auth.UpsertTOTP(user, req.OTPSecret)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("TOTP secret for user %q upserted", user)), nil
+}
+
 type upsertUserRawReq struct {
 	User json.RawMessage `json:"user"`
 }
@@ -774,7 +934,7 @@ func (s *APIServer) registerUsingToken(auth ClientI, w http.ResponseWriter, r *h
 		return nil, trace.Wrap(err)
 	}
 
-	keys, err := auth.RegisterUsingToken(req.Token, req.HostID, req.NodeName, req.Role)
+	keys, err := auth.RegisterUsingToken(req.Token, req.HostID, req.NodeName, req.Role, r.RemoteAddr)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -945,6 +1105,21 @@ func (s *APIServer) getSessions(auth ClientI, w http.ResponseWriter, r *http.Req
 	return sessions, nil
 }
 
+func (s *APIServer) purgeSessionData(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	sid, err := session.ParseID(p.ByName("id"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+	if err := auth.PurgeSessionData(namespace, *sid); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 func (s *APIServer) getSession(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	sid, err := session.ParseID(p.ByName("id"))
 	if err != nil {
@@ -1572,6 +1747,26 @@ func (s *APIServer) getRole(auth ClientI, w http.ResponseWriter, r *http.Request
 	return rawMessage(services.GetRoleMarshaler().MarshalRole(role, services.WithVersion(version)))
 }
 
+// checkAccessToServer answers an RBAC simulation request without performing
+// the checked action
+func (s *APIServer) checkAccessToServer(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req AccessCheckRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return auth.CheckAccessToServer(req)
+}
+
+// getAccessMatrix returns a page of the full user x node x login access
+// matrix
+func (s *APIServer) getAccessMatrix(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req AccessMatrixRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return auth.GetAccessMatrix(req)
+}
+
 func (s *APIServer) getRoles(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	roles, err := auth.GetRoles()
 	if err != nil {
@@ -1664,6 +1859,404 @@ func (s *APIServer) setStaticTokens(auth ClientI, w http.ResponseWriter, r *http
 	return message(fmt.Sprintf("static tokens set: %+v", st)), nil
 }
 
+func (s *APIServer) getNotificationConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	nc, err := auth.GetNotificationConfig()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return rawMessage(services.GetNotificationConfigMarshaler().Marshal(nc, services.WithVersion(version)))
+}
+
+type setNotificationConfigReq struct {
+	NotificationConfig json.RawMessage `json:"notification_config"`
+}
+
+func (s *APIServer) setNotificationConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setNotificationConfigReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	nc, err := services.GetNotificationConfigMarshaler().Unmarshal(req.NotificationConfig)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = auth.SetNotificationConfig(nc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("notification config set: %+v", nc)), nil
+}
+
+func (s *APIServer) getLDAPConnector(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	lc, err := auth.GetLDAPConnector()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return rawMessage(services.GetLDAPConnectorMarshaler().Marshal(lc, services.WithVersion(version)))
+}
+
+type setLDAPConnectorReq struct {
+	LDAPConnector json.RawMessage `json:"ldap_connector"`
+}
+
+func (s *APIServer) setLDAPConnector(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setLDAPConnectorReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	lc, err := services.GetLDAPConnectorMarshaler().Unmarshal(req.LDAPConnector)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = auth.SetLDAPConnector(lc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("LDAP connector set: %+v", lc)), nil
+}
+
+func (s *APIServer) getOktaConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	oc, err := auth.GetOktaConfig()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.GetOktaConfigMarshaler().Marshal(oc, services.WithVersion(version)))
+}
+
+type setOktaConfigReq struct {
+	OktaConfig json.RawMessage `json:"okta_config"`
+}
+
+func (s *APIServer) setOktaConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setOktaConfigReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	oc, err := services.GetOktaConfigMarshaler().Unmarshal(req.OktaConfig)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = auth.SetOktaConfig(oc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("Okta config set: %+v", oc)), nil
+}
+
+type syncOktaGroupsReq struct {
+	OktaConfig json.RawMessage `json:"okta_config"`
+	DryRun     bool            `json:"dry_run"`
+}
+
+func (s *APIServer) syncOktaGroups(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req syncOktaGroupsReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	oc, err := services.GetOktaConfigMarshaler().Unmarshal(req.OktaConfig)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	report, err := auth.SyncOktaGroups(oc, req.DryRun)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return report, nil
+}
+
+func (s *APIServer) getSlackPlugin(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	sp, err := auth.GetSlackPlugin()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.GetSlackPluginMarshaler().Marshal(sp, services.WithVersion(version)))
+}
+
+type setSlackPluginReq struct {
+	SlackPlugin json.RawMessage `json:"slack_plugin"`
+}
+
+func (s *APIServer) setSlackPlugin(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setSlackPluginReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	sp, err := services.GetSlackPluginMarshaler().Unmarshal(req.SlackPlugin)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = auth.SetSlackPlugin(sp)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("Slack plugin set: %+v", sp)), nil
+}
+
+func (s *APIServer) getEmailPlugin(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	ep, err := auth.GetEmailPlugin()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.GetEmailPluginMarshaler().Marshal(ep, services.WithVersion(version)))
+}
+
+type setEmailPluginReq struct {
+	EmailPlugin json.RawMessage `json:"email_plugin"`
+}
+
+func (s *APIServer) setEmailPlugin(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req setEmailPluginReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ep, err := services.GetEmailPluginMarshaler().Unmarshal(req.EmailPlugin)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = auth.SetEmailPlugin(ep)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("Email plugin set: %+v", ep)), nil
+}
+
+type createAccessRequestReq struct {
+	Roles  []string      `json:"roles"`
+	Reason string        `json:"reason"`
+	Start  time.Time     `json:"start"`
+	TTL    time.Duration `json:"ttl"`
+}
+
+func (s *APIServer) createAccessRequest(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req createAccessRequestReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	accessRequest, err := auth.CreateAccessRequest(req.Roles, req.Reason, req.Start, req.TTL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return accessRequest, nil
+}
+
+func (s *APIServer) getAccessRequests(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	accessRequests, err := auth.GetAccessRequests()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return accessRequests, nil
+}
+
+type resolveAccessRequestReq struct {
+	State  services.AccessRequestState `json:"state"`
+	Reason string                      `json:"reason"`
+}
+
+func (s *APIServer) resolveAccessRequest(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req resolveAccessRequestReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	accessRequest, err := auth.ResolveAccessRequest(p.ByName("id"), req.State, req.Reason)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return accessRequest, nil
+}
+
+type createReviewCampaignReq struct {
+	Name      string    `json:"name"`
+	Reviewers []string  `json:"reviewers"`
+	Deadline  time.Time `json:"deadline"`
+}
+
+func (s *APIServer) createReviewCampaign(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req createReviewCampaignReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	campaign, err := auth.CreateReviewCampaign(req.Name, req.Reviewers, req.Deadline)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return campaign, nil
+}
+
+func (s *APIServer) getReviewCampaigns(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	campaigns, err := auth.GetReviewCampaigns()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return campaigns, nil
+}
+
+type recordReviewVoteReq struct {
+	User     string                  `json:"user"`
+	Decision services.ReviewDecision `json:"decision"`
+	Reason   string                  `json:"reason"`
+}
+
+func (s *APIServer) recordReviewVote(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req recordReviewVoteReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	campaign, err := auth.RecordReviewVote(p.ByName("id"), req.User, req.Decision, req.Reason)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return campaign, nil
+}
+
+func (s *APIServer) closeReviewCampaign(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	campaign, err := auth.CloseReviewCampaign(p.ByName("id"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return campaign, nil
+}
+
+type createClusterAlertReq struct {
+	Severity services.AlertSeverity `json:"severity"`
+	Message  string                 `json:"message"`
+	Expires  time.Time              `json:"expires"`
+}
+
+func (s *APIServer) createClusterAlert(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req createClusterAlertReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	alert, err := auth.CreateClusterAlert(req.Severity, req.Message, req.Expires)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return alert, nil
+}
+
+func (s *APIServer) getClusterAlerts(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	alerts, err := auth.GetClusterAlerts()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return alerts, nil
+}
+
+func (s *APIServer) getActiveClusterAlerts(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	alerts, err := auth.GetActiveClusterAlerts()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return alerts, nil
+}
+
+func (s *APIServer) acknowledgeClusterAlert(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	err := auth.AcknowledgeClusterAlert(p.ByName("id"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("cluster alert %v acknowledged", p.ByName("id"))), nil
+}
+
+func (s *APIServer) upsertTrustedHostKey(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var key services.TrustedHostKey
+
+	err := httplib.ReadJSON(r, &key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if err := auth.UpsertTrustedHostKey(key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("trusted host key for %v upserted", key.Hostname)), nil
+}
+
+func (s *APIServer) getTrustedHostKey(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	key, err := auth.GetTrustedHostKey(p.ByName("hostname"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return key, nil
+}
+
+func (s *APIServer) getTrustedHostKeys(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	keys, err := auth.GetTrustedHostKeys()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return keys, nil
+}
+
+func (s *APIServer) deleteTrustedHostKey(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteTrustedHostKey(p.ByName("hostname")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message(fmt.Sprintf("trusted host key for %v deleted", p.ByName("hostname"))), nil
+}
+
 func (s *APIServer) getClusterAuthPreference(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	cap, err := auth.GetAuthPreference()
 	if err != nil {
@@ -1698,6 +2291,38 @@ func (s *APIServer) setClusterAuthPreference(auth ClientI, w http.ResponseWriter
 	return message(fmt.Sprintf("cluster authenticaton preference set: %+v", cap)), nil
 }
 
+func (s *APIServer) getSessionRecordingKey(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	key, err := auth.GetSessionRecordingKey()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &sessionRecordingKeyResponse{Key: key}, nil
+}
+
+type sessionRecordingKeyResponse struct {
+	Key []byte `json:"key"`
+}
+
+type upsertSessionRecordingKeyReq struct {
+	Key []byte `json:"key"`
+}
+
+func (s *APIServer) upsertSessionRecordingKey(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req *upsertSessionRecordingKeyReq
+
+	err := httplib.ReadJSON(r, &req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if err := auth.UpsertSessionRecordingKey(req.Key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return message("session recording key set"), nil
+}
+
 func message(msg string) map[string]interface{} {
 	return map[string]interface{}{"message": msg}
 }
@@ -485,9 +485,12 @@ func (s *APISuite) TestServers(c *C) {
 
 	srv := newServer(services.KindNode, "id1", "host:1233", "host1", defaults.Namespace)
 	c.Assert(s.clt.UpsertNode(srv), IsNil)
+	// a brand new node lands in ServerStatePending until an admin approves it
+	srv.(*services.ServerV2).SetState(services.ServerStatePending)
 
 	srv1 := newServer(services.KindNode, "id2", "host:1234", "host2", defaults.Namespace)
 	c.Assert(s.clt.UpsertNode(srv1), IsNil)
+	srv1.(*services.ServerV2).SetState(services.ServerStatePending)
 
 	out, err = s.clt.GetNodes(defaults.Namespace)
 	c.Assert(err, IsNil)
@@ -51,6 +51,12 @@ type Authority interface {
 	// GenerateKeyPair generates new keypair
 	GenerateKeyPair(passphrase string) (privKey []byte, pubKey []byte, err error)
 
+	// GenerateCAKeyPair generates a new keypair of the given algorithm
+	// (one of teleport/lib/defaults.CAKeyAlgo*) for use as a CA, host, or
+	// user key. Implementations that can't produce every algorithm (e.g.
+	// test doubles) may ignore keyAlgo.
+	GenerateCAKeyPair(keyAlgo string) (privKey []byte, pubKey []byte, err error)
+
 	// GetNewKeyPairFromPool returns new keypair from pre-generated in memory pool
 	GetNewKeyPairFromPool() (privKey []byte, pubKey []byte, err error)
 
@@ -87,6 +93,18 @@ func NewAuthServer(cfg *InitConfig, opts ...AuthServerOption) *AuthServer {
 	if cfg.ClusterConfiguration == nil {
 		cfg.ClusterConfiguration = local.NewClusterConfigurationService(cfg.Backend)
 	}
+	if cfg.AccessRequests == nil {
+		cfg.AccessRequests = local.NewAccessRequestService(cfg.Backend)
+	}
+	if cfg.ReviewCampaigns == nil {
+		cfg.ReviewCampaigns = local.NewReviewCampaignService(cfg.Backend)
+	}
+	if cfg.ClusterAlerts == nil {
+		cfg.ClusterAlerts = local.NewClusterAlertService(cfg.Backend)
+	}
+	if cfg.TrustedHostKeys == nil {
+		cfg.TrustedHostKeys = local.NewTrustedHostKeyService(cfg.Backend)
+	}
 	closeCtx, cancelFunc := context.WithCancel(context.TODO())
 	as := AuthServer{
 		bk:                   cfg.Backend,
@@ -98,6 +116,10 @@ func NewAuthServer(cfg *InitConfig, opts ...AuthServerOption) *AuthServer {
 		Access:               cfg.Access,
 		AuthServiceName:      cfg.AuthServiceName,
 		ClusterConfiguration: cfg.ClusterConfiguration,
+		AccessRequests:       cfg.AccessRequests,
+		ReviewCampaigns:      cfg.ReviewCampaigns,
+		ClusterAlerts:        cfg.ClusterAlerts,
+		TrustedHostKeys:      cfg.TrustedHostKeys,
 		oidcClients:          make(map[string]*oidcClient),
 		samlProviders:        make(map[string]*samlProvider),
 		DeveloperMode:        cfg.DeveloperMode,
@@ -147,6 +169,10 @@ type AuthServer struct {
 	services.Identity
 	services.Access
 	services.ClusterConfiguration
+	services.AccessRequests
+	services.ReviewCampaigns
+	services.ClusterAlerts
+	services.TrustedHostKeys
 }
 
 func (a *AuthServer) Close() error {
@@ -227,6 +253,18 @@ func (s *AuthServer) GenerateUserCert(key []byte, user services.User, allowedLog
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	// if the caller didn't request a specific certificate format, fall back
+	// to the cluster-wide default so clusters that need to interoperate with
+	// stock OpenSSH sshd can set it once instead of on every client.
+	if compatibility == teleport.CompatibilityNone {
+		authPref, err := s.GetAuthPreference()
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		if authPref != nil {
+			compatibility = authPref.GetCertificateFormat()
+		}
+	}
 	return s.Authority.GenerateUserCert(services.UserCertParams{
 		PrivateCASigningKey:   privateKey,
 		PublicUserKey:         key,
@@ -255,6 +293,9 @@ func (s *AuthServer) withUserLock(username string, authenticateFn func() error)
 	if status.IsLocked && status.LockExpires.After(s.clock.Now().UTC()) {
 		return trace.AccessDenied("user %v is locked until %v", utils.HumanTimeFormat(status.LockExpires))
 	}
+	if expires := user.Expiry(); !expires.IsZero() && s.clock.Now().UTC().After(expires) {
+		return trace.AccessDenied("user %v has expired", username)
+	}
 	fnErr := authenticateFn()
 	if fnErr == nil {
 		return nil
@@ -442,8 +483,37 @@ func (s *AuthServer) CreateWebSession(user string) (services.WebSession, error)
 	return sess, nil
 }
 
+// GenerateTokenRequest bundles the parameters used to create a new
+// provisioning token.
+type GenerateTokenRequest struct {
+	// Roles lists the system roles the token allows its bearer to join as.
+	Roles teleport.Roles
+	// TTL is how long the token remains valid for.
+	TTL time.Duration
+	// CreatedBy identifies the user requesting the token, if known.
+	CreatedBy string
+	// OneTimeUse, if set, makes the token unusable after its first
+	// successful join. See services.ProvisionToken.OneTimeUse.
+	OneTimeUse bool
+	// AllowedHosts, if non-empty, restricts which nodes may join with
+	// this token. See services.ProvisionToken.AllowedHosts.
+	AllowedHosts []string
+}
+
 func (s *AuthServer) GenerateToken(roles teleport.Roles, ttl time.Duration) (string, error) {
-	for _, role := range roles {
+	return s.GenerateTokenAs(roles, ttl, "")
+}
+
+// GenerateTokenAs is GenerateToken, but also records createdBy as the
+// token's creator for audit purposes.
+func (s *AuthServer) GenerateTokenAs(roles teleport.Roles, ttl time.Duration, createdBy string) (string, error) {
+	return s.GenerateTokenExt(GenerateTokenRequest{Roles: roles, TTL: ttl, CreatedBy: createdBy})
+}
+
+// GenerateTokenExt is GenerateToken, but allows the caller to opt the token
+// into one-time-use and/or restrict which hosts may join with it.
+func (s *AuthServer) GenerateTokenExt(req GenerateTokenRequest) (string, error) {
+	for _, role := range req.Roles {
 		if err := role.Check(); err != nil {
 			return "", trace.Wrap(err)
 		}
@@ -452,7 +522,15 @@ func (s *AuthServer) GenerateToken(roles teleport.Roles, ttl time.Duration) (str
 	if err != nil {
 		return "", trace.Wrap(err)
 	}
-	if err := s.Provisioner.UpsertToken(token, roles, ttl); err != nil {
+	p := services.ProvisionToken{
+		Token:        token,
+		Roles:        req.Roles,
+		Expires:      time.Now().UTC().Add(req.TTL),
+		CreatedBy:    req.CreatedBy,
+		OneTimeUse:   req.OneTimeUse,
+		AllowedHosts: req.AllowedHosts,
+	}
+	if err := s.Provisioner.UpsertToken(p); err != nil {
 		return "", err
 	}
 	return token, nil
@@ -532,10 +610,11 @@ func (s *AuthServer) checkTokenTTL(token string) bool {
 // A node must also request a specific role (and the role must match one of the roles
 // the token was generated for).
 //
-// If a token was generated with a TTL, it gets enforced (can't register new nodes after TTL expires)
-// If a token was generated with a TTL=0, it means it's a single-use token and it gets destroyed
-// after a successful registration.
-func (s *AuthServer) RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role) (*PackedKeys, error) {
+// If a token was generated with a TTL, it gets enforced (can't register new nodes after TTL expires).
+// If a token restricts which hosts may use it (services.ProvisionToken.AllowedHosts), remoteAddr and
+// nodeName are checked against it and the join is rejected on mismatch.
+// If a token is marked OneTimeUse, it gets destroyed after a successful registration.
+func (s *AuthServer) RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role, remoteAddr string) (*PackedKeys, error) {
 	log.Infof("[AUTH] Node %q [%v] trying to join with role: %v", nodeName, hostID, role)
 	if hostID == "" {
 		return nil, trace.BadParameter("HostID cannot be empty")
@@ -563,6 +642,23 @@ func (s *AuthServer) RegisterUsingToken(token, hostID string, nodeName string, r
 		return nil, trace.AccessDenied("%q [%v] can not join the cluster. Token has expired", nodeName, hostID)
 	}
 
+	// static and signup tokens aren't tracked in the provisioner store and
+	// carry no binding/one-time-use restrictions, so they're exempt from
+	// what follows
+	if tok, err := s.Provisioner.GetToken(token); err == nil {
+		if err := tok.CheckBinding(nodeName, remoteAddr); err != nil {
+			log.Warningf("[AUTH] %q [%v] rejected: %v", nodeName, hostID, err)
+			return nil, trace.Wrap(err)
+		}
+		if tok.OneTimeUse {
+			if err := s.Provisioner.DeleteToken(token); err != nil {
+				log.Warningf("[AUTH] failed retiring one-time-use token used by %q [%v]: %v", nodeName, hostID, err)
+			}
+		} else if err := s.Provisioner.IncrementTokenUsage(token); err != nil {
+			log.Debugf("[AUTH] could not record usage of token used by %q [%v]: %v", nodeName, hostID, err)
+		}
+	}
+
 	// generate and return host certificate and keys
 	keys, err := s.GenerateServerKeys(hostID, nodeName, teleport.Roles{role})
 	if err != nil {
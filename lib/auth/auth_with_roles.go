@@ -64,16 +64,46 @@ func (a *AuthWithRoles) GetSessions(namespace string) ([]session.Session, error)
 		return nil, trace.Wrap(err)
 	}
 
-	return a.sessions.GetSessions(namespace)
+	sessions, err := a.sessions.GetSessions(namespace)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// a role scoped to SessionAccessScopeOwn only lists sessions the
+	// caller actually participated in
+	visible := sessions[:0]
+	for _, sess := range sessions {
+		if a.checker.CanViewSession(sess, a.user.GetName()) {
+			visible = append(visible, sess)
+		}
+	}
+	return visible, nil
 }
 
 func (a *AuthWithRoles) GetSession(namespace string, id session.ID) (*session.Session, error) {
 	if err := a.action(namespace, services.KindSession, services.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := a.checkSessionAccess(namespace, id); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return a.sessions.GetSession(namespace, id)
 }
 
+// checkSessionAccess re-checks a session read grant that's already passed
+// a.action against the session's actual record, so a role scoped to
+// SessionAccessScopeOwn can't be used to read/replay a session the caller
+// didn't participate in.
+func (a *AuthWithRoles) checkSessionAccess(namespace string, id session.ID) error {
+	sess, err := a.sessions.GetSession(namespace, id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !a.checker.CanViewSession(*sess, a.user.GetName()) {
+		return trace.AccessDenied("access to session %v is denied for %v", id, a.user.GetName())
+	}
+	return nil
+}
+
 func (a *AuthWithRoles) CreateSession(s session.Session) error {
 	if err := a.action(s.Namespace, services.KindSession, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
@@ -164,12 +194,33 @@ func (a *AuthWithRoles) GenerateToken(roles teleport.Roles, ttl time.Duration) (
 	if err := a.action(defaults.Namespace, services.KindToken, services.VerbCreate); err != nil {
 		return "", trace.Wrap(err)
 	}
-	return a.authServer.GenerateToken(roles, ttl)
+	token, err := a.authServer.GenerateTokenAs(roles, ttl, a.user.GetName())
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := a.alog.EmitAuditEvent(events.ProvisionTokenCreateEvent, events.EventFields{
+		events.EventUser:           a.user.GetName(),
+		events.ProvisionTokenRoles: roles.String(),
+	}); err != nil {
+		log.Warningf("failed to emit token creation audit event: %v", err)
+	}
+	return token, nil
 }
 
-func (a *AuthWithRoles) RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role) (*PackedKeys, error) {
+func (a *AuthWithRoles) RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role, remoteAddr string) (*PackedKeys, error) {
 	// tokens have authz mechanism  on their own, no need to check
-	return a.authServer.RegisterUsingToken(token, hostID, nodeName, role)
+	keys, err := a.authServer.RegisterUsingToken(token, hostID, nodeName, role, remoteAddr)
+	if err != nil && trace.IsAccessDenied(err) {
+		if auditErr := a.alog.EmitAuditEvent(events.NodeJoinRejectEvent, events.EventFields{
+			events.NodeJoinHostID:   hostID,
+			events.NodeJoinNodeName: nodeName,
+			events.RemoteAddr:       remoteAddr,
+			events.NodeJoinReason:   err.Error(),
+		}); auditErr != nil {
+			log.Warningf("failed to emit join rejection audit event: %v", auditErr)
+		}
+	}
+	return keys, err
 }
 
 func (a *AuthWithRoles) RegisterNewAuthServer(token string) error {
@@ -187,6 +238,13 @@ func (a *AuthWithRoles) UpsertNode(s services.Server) error {
 	return a.authServer.UpsertNode(s)
 }
 
+func (a *AuthWithRoles) KeepAliveNode(namespace, name string, expires time.Time) error {
+	if err := a.action(namespace, services.KindNode, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.KeepAliveNode(namespace, name, expires)
+}
+
 func (a *AuthWithRoles) GetNodes(namespace string) ([]services.Server, error) {
 	if err := a.action(namespace, services.KindNode, services.VerbList); err != nil {
 		return nil, trace.Wrap(err)
@@ -197,6 +255,45 @@ func (a *AuthWithRoles) GetNodes(namespace string) ([]services.Server, error) {
 	return a.authServer.GetNodes(namespace)
 }
 
+func (a *AuthWithRoles) DeleteNode(namespace string, node string) error {
+	if err := a.action(namespace, services.KindNode, services.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteNode(namespace, node)
+}
+
+// ApproveNode marks a pending or quarantined node as approved, allowing it
+// to receive sessions again.
+func (a *AuthWithRoles) ApproveNode(namespace, name string) error {
+	if err := a.action(namespace, services.KindNode, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.authServer.ApproveNode(namespace, name); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.alog.EmitAuditEvent(events.NodeApproveEvent, events.EventFields{
+		events.EventNamespace: namespace,
+		events.NodeName:       name,
+		events.EventUser:      a.user.GetName(),
+	})
+}
+
+// QuarantineNode marks a node as quarantined, immediately blocking new
+// sessions to it until it's approved again.
+func (a *AuthWithRoles) QuarantineNode(namespace, name string) error {
+	if err := a.action(namespace, services.KindNode, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.authServer.QuarantineNode(namespace, name); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.alog.EmitAuditEvent(events.NodeQuarantineEvent, events.EventFields{
+		events.EventNamespace: namespace,
+		events.NodeName:       name,
+		events.EventUser:      a.user.GetName(),
+	})
+}
+
 func (a *AuthWithRoles) UpsertAuthServer(s services.Server) error {
 	if err := a.action(defaults.Namespace, services.KindAuthServer, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
@@ -227,6 +324,13 @@ func (a *AuthWithRoles) UpsertProxy(s services.Server) error {
 	return a.authServer.UpsertProxy(s)
 }
 
+func (a *AuthWithRoles) KeepAliveProxy(name string, expires time.Time) error {
+	if err := a.action(defaults.Namespace, services.KindProxy, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.KeepAliveProxy(name, expires)
+}
+
 func (a *AuthWithRoles) GetProxies() ([]services.Server, error) {
 	if err := a.action(defaults.Namespace, services.KindProxy, services.VerbList); err != nil {
 		return nil, trace.Wrap(err)
@@ -268,7 +372,22 @@ func (a *AuthWithRoles) DeleteToken(token string) error {
 	if err := a.action(defaults.Namespace, services.KindToken, services.VerbDelete); err != nil {
 		return trace.Wrap(err)
 	}
-	return a.authServer.DeleteToken(token)
+	// best-effort: a token's roles are informative on the audit trail,
+	// but their absence shouldn't block revocation
+	roles := teleport.Roles{}
+	if tok, err := a.authServer.GetToken(token); err == nil {
+		roles = tok.Roles
+	}
+	if err := a.authServer.DeleteToken(token); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.alog.EmitAuditEvent(events.ProvisionTokenDeleteEvent, events.EventFields{
+		events.EventUser:           a.user.GetName(),
+		events.ProvisionTokenRoles: roles.String(),
+	}); err != nil {
+		log.Warningf("failed to emit token deletion audit event: %v", err)
+	}
+	return nil
 }
 
 func (a *AuthWithRoles) GetTokens() ([]services.ProvisionToken, error) {
@@ -295,7 +414,12 @@ func (a *AuthWithRoles) UpsertToken(token string, roles teleport.Roles, ttl time
 	if err := a.action(defaults.Namespace, services.KindToken, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
-	return a.authServer.UpsertToken(token, roles, ttl)
+	return a.authServer.UpsertToken(services.ProvisionToken{
+		Token:     token,
+		Roles:     roles,
+		Expires:   time.Now().UTC().Add(ttl),
+		CreatedBy: a.user.GetName(),
+	})
 }
 
 func (a *AuthWithRoles) UpsertPassword(user string, password []byte) error {
@@ -659,6 +783,9 @@ func (a *AuthWithRoles) GetSessionChunk(namespace string, sid session.ID, offset
 	if err := a.action(namespace, services.KindSession, services.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := a.checkSessionAccess(namespace, sid); err != nil {
+		return nil, trace.Wrap(err)
+	}
 
 	return a.alog.GetSessionChunk(namespace, sid, offsetBytes, maxBytes)
 }
@@ -667,10 +794,30 @@ func (a *AuthWithRoles) GetSessionEvents(namespace string, sid session.ID, after
 	if err := a.action(namespace, services.KindSession, services.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := a.checkSessionAccess(namespace, sid); err != nil {
+		return nil, trace.Wrap(err)
+	}
 
 	return a.alog.GetSessionEvents(namespace, sid, afterN)
 }
 
+// PurgeSessionData permanently deletes the recording and event log of a
+// single session. The purge itself is recorded as an audit event so there
+// remains a trace that the data existed and was removed.
+func (a *AuthWithRoles) PurgeSessionData(namespace string, sid session.ID) error {
+	if err := a.action(namespace, services.KindSession, services.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.alog.PurgeSessionData(namespace, sid); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.alog.EmitAuditEvent(events.SessionPurgeEvent, events.EventFields{
+		events.EventNamespace: namespace,
+		events.SessionEventID: string(sid),
+		events.EventUser:      a.user.GetName(),
+	})
+}
+
 func (a *AuthWithRoles) SearchEvents(from, to time.Time, query string) ([]events.EventFields, error) {
 	if err := a.action(defaults.Namespace, services.KindEvent, services.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
@@ -730,6 +877,32 @@ func (a *AuthWithRoles) GetRoles() ([]services.Role, error) {
 	return a.authServer.GetRoles()
 }
 
+// CheckAccessToServer simulates access the same way GetRoles/GetUser require
+// read access; it does not grant or deny anything, so it is safe to allow to
+// anyone who can already read users and roles.
+func (a *AuthWithRoles) CheckAccessToServer(req AccessCheckRequest) (*AccessCheckResponse, error) {
+	if err := a.action(defaults.Namespace, services.KindUser, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindRole, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.CheckAccessToServer(req)
+}
+
+// GetAccessMatrix computes a page of the full user x node x login access
+// matrix; it is read access to the same resources CheckAccessToServer
+// reads, so the same permission applies.
+func (a *AuthWithRoles) GetAccessMatrix(req AccessMatrixRequest) (*AccessMatrixResponse, error) {
+	if err := a.action(defaults.Namespace, services.KindUser, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindRole, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetAccessMatrix(req)
+}
+
 // UpsertRole creates or updates role
 func (a *AuthWithRoles) UpsertRole(role services.Role, ttl time.Duration) error {
 	if err := a.action(defaults.Namespace, services.KindRole, services.VerbCreate); err != nil {
@@ -818,6 +991,328 @@ func (a *AuthWithRoles) SetAuthPreference(cap services.AuthPreference) error {
 	return a.authServer.SetAuthPreference(cap)
 }
 
+// GetSessionRecordingKey returns the cluster-managed session recording
+// encryption key. Access is scoped the same as other cluster secrets: it
+// requires read on the session recording config, not general namespace
+// access, since the key can decrypt every recorded session in the cluster.
+func (a *AuthWithRoles) GetSessionRecordingKey() ([]byte, error) {
+	if err := a.action(defaults.Namespace, services.KindSessionRecordingConfig, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetSessionRecordingKey()
+}
+
+// UpsertSessionRecordingKey rotates the cluster-managed session recording
+// encryption key.
+func (a *AuthWithRoles) UpsertSessionRecordingKey(key []byte) error {
+	if err := a.action(defaults.Namespace, services.KindSessionRecordingConfig, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindSessionRecordingConfig, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.UpsertSessionRecordingKey(key)
+}
+
+// GetNotificationConfig gets the webhook notification configuration.
+func (a *AuthWithRoles) GetNotificationConfig() (services.NotificationConfig, error) {
+	if err := a.action(defaults.Namespace, services.KindNotificationConfig, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetNotificationConfig()
+}
+
+// SetNotificationConfig sets the webhook notification configuration.
+func (a *AuthWithRoles) SetNotificationConfig(c services.NotificationConfig) error {
+	if err := a.action(defaults.Namespace, services.KindNotificationConfig, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindNotificationConfig, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.SetNotificationConfig(c)
+}
+
+// GetLDAPConnector gets the LDAP connector settings.
+func (a *AuthWithRoles) GetLDAPConnector() (services.LDAPConnector, error) {
+	if err := a.action(defaults.Namespace, services.KindLDAPConnector, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetLDAPConnector()
+}
+
+// SetLDAPConnector sets the LDAP connector settings.
+func (a *AuthWithRoles) SetLDAPConnector(c services.LDAPConnector) error {
+	if err := a.action(defaults.Namespace, services.KindLDAPConnector, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindLDAPConnector, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.SetLDAPConnector(c)
+}
+
+// GetOktaConfig gets the Okta sync service settings.
+func (a *AuthWithRoles) GetOktaConfig() (services.OktaConfig, error) {
+	if err := a.action(defaults.Namespace, services.KindOktaConfig, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetOktaConfig()
+}
+
+// SetOktaConfig sets the Okta sync service settings.
+func (a *AuthWithRoles) SetOktaConfig(c services.OktaConfig) error {
+	if err := a.action(defaults.Namespace, services.KindOktaConfig, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindOktaConfig, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.SetOktaConfig(c)
+}
+
+// SyncOktaGroups runs (or, if dryRun, previews) an Okta group sync.
+func (a *AuthWithRoles) SyncOktaGroups(cfg services.OktaConfig, dryRun bool) (*services.OktaSyncReport, error) {
+	if err := a.action(defaults.Namespace, services.KindOktaConfig, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !dryRun {
+		if err := a.action(defaults.Namespace, services.KindUser, services.VerbUpdate); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	return a.authServer.SyncOktaGroups(cfg, dryRun)
+}
+
+// GetSlackPlugin gets the Slack access request plugin settings.
+func (a *AuthWithRoles) GetSlackPlugin() (services.SlackPlugin, error) {
+	if err := a.action(defaults.Namespace, services.KindSlackPlugin, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetSlackPlugin()
+}
+
+// SetSlackPlugin sets the Slack access request plugin settings.
+func (a *AuthWithRoles) SetSlackPlugin(c services.SlackPlugin) error {
+	if err := a.action(defaults.Namespace, services.KindSlackPlugin, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindSlackPlugin, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.SetSlackPlugin(c)
+}
+
+// GetEmailPlugin gets the email notification plugin settings.
+func (a *AuthWithRoles) GetEmailPlugin() (services.EmailPlugin, error) {
+	if err := a.action(defaults.Namespace, services.KindEmailPlugin, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetEmailPlugin()
+}
+
+// SetEmailPlugin sets the email notification plugin settings.
+func (a *AuthWithRoles) SetEmailPlugin(c services.EmailPlugin) error {
+	if err := a.action(defaults.Namespace, services.KindEmailPlugin, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindEmailPlugin, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.SetEmailPlugin(c)
+}
+
+// CreateAccessRequest submits a new access request on behalf of the
+// caller. start is when the requested roles should take effect (the zero
+// Time meaning immediately upon approval) and ttl is how long they stay
+// in effect once granted (zero meaning they don't expire on their own).
+func (a *AuthWithRoles) CreateAccessRequest(roles []string, reason string, start time.Time, ttl time.Duration) (*services.AccessRequest, error) {
+	if err := a.action(defaults.Namespace, services.KindAccessRequest, services.VerbCreate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.CreateAccessRequest(a.user.GetName(), roles, reason, start, ttl)
+}
+
+// GetAccessRequests lists all access requests.
+func (a *AuthWithRoles) GetAccessRequests() ([]services.AccessRequest, error) {
+	if err := a.action(defaults.Namespace, services.KindAccessRequest, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetAccessRequests()
+}
+
+// ResolveAccessRequest approves or denies a pending access request.
+func (a *AuthWithRoles) ResolveAccessRequest(id string, state services.AccessRequestState, reason string) (*services.AccessRequest, error) {
+	if err := a.action(defaults.Namespace, services.KindAccessRequest, services.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.ResolveAccessRequest(id, state, a.user.GetName(), reason)
+}
+
+// CreateReviewCampaign starts a new periodic access review campaign,
+// snapshotting every user's current role grants for reviewers to decide
+// on.
+func (a *AuthWithRoles) CreateReviewCampaign(name string, reviewers []string, deadline time.Time) (*services.ReviewCampaign, error) {
+	if err := a.action(defaults.Namespace, services.KindReviewCampaign, services.VerbCreate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	campaign, err := a.authServer.CreateReviewCampaign(name, reviewers, deadline)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if auditErr := a.alog.EmitAuditEvent(events.ReviewCampaignCloseEvent, events.EventFields{
+		events.ReviewCampaignID: campaign.ID,
+		events.EventUser:        a.user.GetName(),
+	}); auditErr != nil {
+		log.Warningf("[AUTH] failed to emit audit event for review campaign %v: %v", campaign.ID, auditErr)
+	}
+	return campaign, nil
+}
+
+// GetReviewCampaigns lists all review campaigns.
+func (a *AuthWithRoles) GetReviewCampaigns() ([]services.ReviewCampaign, error) {
+	if err := a.action(defaults.Namespace, services.KindReviewCampaign, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetReviewCampaigns()
+}
+
+// RecordReviewVote records the caller's decision on user's grant in the
+// review campaign named by id.
+func (a *AuthWithRoles) RecordReviewVote(id, user string, decision services.ReviewDecision, reason string) (*services.ReviewCampaign, error) {
+	if err := a.action(defaults.Namespace, services.KindReviewCampaign, services.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.RecordReviewVote(id, a.user.GetName(), user, decision, reason)
+}
+
+// CloseReviewCampaign closes the review campaign named by id early,
+// revoking the roles reviewers voted to revoke.
+func (a *AuthWithRoles) CloseReviewCampaign(id string) (*services.ReviewCampaign, error) {
+	if err := a.action(defaults.Namespace, services.KindReviewCampaign, services.VerbUpdate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	campaign, err := a.authServer.CloseReviewCampaign(id)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if auditErr := a.alog.EmitAuditEvent(events.ReviewCampaignCloseEvent, events.EventFields{
+		events.ReviewCampaignID: campaign.ID,
+		events.EventUser:        a.user.GetName(),
+	}); auditErr != nil {
+		log.Warningf("[AUTH] failed to emit audit event for review campaign %v: %v", campaign.ID, auditErr)
+	}
+	for _, grant := range campaign.Revoked {
+		if auditErr := a.alog.EmitAuditEvent(events.ReviewCampaignRevokeEvent, events.EventFields{
+			events.ReviewCampaignID:           campaign.ID,
+			events.EventUser:                  grant.User,
+			events.ReviewCampaignRevokedRoles: grant.Roles,
+		}); auditErr != nil {
+			log.Warningf("[AUTH] failed to emit audit event for revocation of %v by review campaign %v: %v", grant.User, campaign.ID, auditErr)
+		}
+	}
+	return campaign, nil
+}
+
+// CreateClusterAlert creates a new cluster alert.
+func (a *AuthWithRoles) CreateClusterAlert(severity services.AlertSeverity, message string, expires time.Time) (*services.ClusterAlert, error) {
+	if err := a.action(defaults.Namespace, services.KindClusterAlert, services.VerbCreate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.CreateClusterAlert(severity, message, expires)
+}
+
+// GetClusterAlerts lists every cluster alert.
+func (a *AuthWithRoles) GetClusterAlerts() ([]services.ClusterAlert, error) {
+	if err := a.action(defaults.Namespace, services.KindClusterAlert, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetClusterAlerts()
+}
+
+// GetActiveClusterAlerts lists the cluster alerts the caller hasn't
+// acknowledged and that haven't expired.
+func (a *AuthWithRoles) GetActiveClusterAlerts() ([]services.ClusterAlert, error) {
+	if err := a.action(defaults.Namespace, services.KindClusterAlert, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetActiveClusterAlerts(a.user.GetName())
+}
+
+// AcknowledgeClusterAlert records that the caller has acknowledged the
+// cluster alert named by id.
+func (a *AuthWithRoles) AcknowledgeClusterAlert(id string) error {
+	if err := a.action(defaults.Namespace, services.KindClusterAlert, services.VerbList); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.AcknowledgeClusterAlert(id, a.user.GetName())
+}
+
+// UpsertTrustedHostKey creates or replaces the given operator-vouched-for
+// host key.
+func (a *AuthWithRoles) UpsertTrustedHostKey(key services.TrustedHostKey) error {
+	if err := a.action(defaults.Namespace, services.KindTrustedHostKey, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindTrustedHostKey, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.UpsertTrustedHostKey(key)
+}
+
+// GetTrustedHostKey returns the trusted host key registered for hostname.
+// Any authenticated user may read it: it's a public key clients are meant
+// to trust in advance, no different from the cluster's own host CA.
+func (a *AuthWithRoles) GetTrustedHostKey(hostname string) (*services.TrustedHostKey, error) {
+	if err := a.action(defaults.Namespace, services.KindTrustedHostKey, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetTrustedHostKey(hostname)
+}
+
+// GetTrustedHostKeys lists every registered trusted host key. Any
+// authenticated user may read them: they're public keys clients are meant
+// to trust in advance, no different from the cluster's own host CA.
+func (a *AuthWithRoles) GetTrustedHostKeys() ([]services.TrustedHostKey, error) {
+	if err := a.action(defaults.Namespace, services.KindTrustedHostKey, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return a.authServer.GetTrustedHostKeys()
+}
+
+// DeleteTrustedHostKey removes the trusted host key registered for
+// hostname.
+func (a *AuthWithRoles) DeleteTrustedHostKey(hostname string) error {
+	if err := a.action(defaults.Namespace, services.KindTrustedHostKey, services.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.DeleteTrustedHostKey(hostname)
+}
+
 // DeleteAllCertAuthorities deletes all certificate authorities of a certain type
 func (a *AuthWithRoles) DeleteAllCertAuthorities(caType services.CertAuthType) error {
 	return trace.BadParameter("not implemented")
@@ -838,9 +1333,15 @@ func (a *AuthWithRoles) DeleteAllProxies() error {
 	return trace.BadParameter("not implemented")
 }
 
-// DeleteAllNodes deletes all nodes in a given namespace
+// DeleteAllNodes deletes all nodes in a given namespace. Unlike most other
+// "delete all" admin operations, this one is namespace-scoped: a role that
+// is only allowed to delete nodes in one namespace must not be able to wipe
+// out another namespace by calling this.
 func (a *AuthWithRoles) DeleteAllNodes(namespace string) error {
-	return trace.BadParameter("not implemented")
+	if err := a.action(namespace, services.KindNode, services.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteAllNodes(namespace)
 }
 
 // DeleteAllRoles deletes all roles
@@ -861,7 +1362,18 @@ func (a *AuthWithRoles) GetTrustedClusters() ([]services.TrustedCluster, error)
 		return nil, trace.Wrap(err)
 	}
 
-	return a.authServer.GetTrustedClusters()
+	clusters, err := a.authServer.GetTrustedClusters()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	visible := make([]services.TrustedCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		if err := a.checker.CheckAccessToCluster(cluster); err != nil {
+			continue
+		}
+		visible = append(visible, cluster)
+	}
+	return visible, nil
 }
 
 func (a *AuthWithRoles) GetTrustedCluster(name string) (services.TrustedCluster, error) {
@@ -869,7 +1381,14 @@ func (a *AuthWithRoles) GetTrustedCluster(name string) (services.TrustedCluster,
 		return nil, trace.Wrap(err)
 	}
 
-	return a.authServer.GetTrustedCluster(name)
+	cluster, err := a.authServer.GetTrustedCluster(name)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.checker.CheckAccessToCluster(cluster); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return cluster, nil
 }
 
 func (a *AuthWithRoles) UpsertTrustedCluster(tc services.TrustedCluster) error {
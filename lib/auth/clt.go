@@ -79,7 +79,7 @@ func NewClient(addr string, dialer Dialer, params ...roundtrip.ClientParam) (*Cl
 	}
 	params = append(params,
 		roundtrip.HTTPClient(&http.Client{
-			Transport: transport,
+			Transport: versionHeaderTransport{RoundTripper: transport},
 		}),
 		// TODO (ekontsevoy) this tracer pollutes the logs making it harder to work
 		// on issues that have nothing to do with the auth API, consider activating it
@@ -101,6 +101,18 @@ func (c *Client) GetTransport() *http.Transport {
 	return c.transport
 }
 
+// versionHeaderTransport wraps an http.RoundTripper to set
+// teleport.VersionHeader on every outgoing request, so the auth server
+// can check this client's version for compatibility.
+type versionHeaderTransport struct {
+	http.RoundTripper
+}
+
+func (t versionHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set(teleport.VersionHeader, teleport.Version)
+	return t.RoundTripper.RoundTrip(req)
+}
+
 // PostJSON is a generic method that issues http POST request to the server
 func (c *Client) PostJSON(
 	endpoint string, val interface{}) (*roundtrip.Response, error) {
@@ -312,8 +324,9 @@ func (c *Client) GenerateToken(roles teleport.Roles, ttl time.Duration) (string,
 }
 
 // RegisterUsingToken calls the auth service API to register a new node using a registration token
-// which was previously issued via GenerateToken.
-func (c *Client) RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role) (*PackedKeys, error) {
+// which was previously issued via GenerateToken. remoteAddr is ignored -- the auth server always
+// binds any host restriction on the token to the address it actually observes the connection from.
+func (c *Client) RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role, remoteAddr string) (*PackedKeys, error) {
 	out, err := c.PostJSON(c.Endpoint("tokens", "register"),
 		registerUsingTokenReq{
 			HostID:   hostID,
@@ -390,6 +403,22 @@ func (c *Client) UpsertNode(s services.Server) error {
 	return trace.Wrap(err)
 }
 
+// KeepAliveNode extends the TTL of a node that already registered its full
+// spec via UpsertNode, without resending that spec
+func (c *Client) KeepAliveNode(namespace, name string, expires time.Time) error {
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	args := &keepAliveReq{
+		Expires: expires,
+	}
+	_, err := c.PutJSON(c.Endpoint("namespaces", namespace, "nodes", name, "keepalive"), args)
+	return trace.Wrap(err)
+}
+
 // GetNodes returns the list of servers registered in the cluster.
 func (c *Client) GetNodes(namespace string) ([]services.Server, error) {
 	if namespace == "" {
@@ -414,6 +443,44 @@ func (c *Client) GetNodes(namespace string) ([]services.Server, error) {
 	return re, nil
 }
 
+// DeleteNode deletes an existing node by name
+func (c *Client) DeleteNode(namespace string, name string) error {
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	_, err := c.Delete(c.Endpoint("namespaces", namespace, "nodes", name))
+	return trace.Wrap(err)
+}
+
+// ApproveNode marks a pending or quarantined node as approved, allowing it
+// to receive sessions again.
+func (c *Client) ApproveNode(namespace, name string) error {
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	_, err := c.PutJSON(c.Endpoint("namespaces", namespace, "nodes", name, "approve"), struct{}{})
+	return trace.Wrap(err)
+}
+
+// QuarantineNode marks a node as quarantined, immediately blocking new
+// sessions to it until it's approved again.
+func (c *Client) QuarantineNode(namespace, name string) error {
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	_, err := c.PutJSON(c.Endpoint("namespaces", namespace, "nodes", name, "quarantine"), struct{}{})
+	return trace.Wrap(err)
+}
+
 // UpsertReverseTunnel is used by admins to create a new reverse tunnel
 // to the remote proxy to bypass firewall restrictions
 func (c *Client) UpsertReverseTunnel(tunnel services.ReverseTunnel) error {
@@ -510,6 +577,19 @@ func (c *Client) UpsertProxy(s services.Server) error {
 	return trace.Wrap(err)
 }
 
+// KeepAliveProxy extends the TTL of a proxy that already registered its
+// full spec via UpsertProxy, without resending that spec
+func (c *Client) KeepAliveProxy(name string, expires time.Time) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	args := &keepAliveReq{
+		Expires: expires,
+	}
+	_, err := c.PutJSON(c.Endpoint("proxies", name, "keepalive"), args)
+	return trace.Wrap(err)
+}
+
 // GetProxies returns the list of auth servers registered in the cluster.
 func (c *Client) GetProxies() ([]services.Server, error) {
 	out, err := c.Get(c.Endpoint("proxies"), url.Values{})
@@ -558,6 +638,20 @@ func (c *Client) UpsertPassword(user string, password []byte) error {
 	return nil
 }
 
+// UpsertTOTP creates or updates a user's TOTP second factor secret
+func (c *Client) UpsertTOTP(user string, otpSecret string) error {
+	_, err := c.PostJSON(
+		c.Endpoint("users", user, "totp"),
+		upsertTOTPReq{
+			OTPSecret: otpSecret,
+		})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
 // UpsertUser user updates or inserts user entry
 func (c *Client) UpsertUser(user services.User) error {
 	data, err := services.GetUserMarshaler().MarshalUser(user)
@@ -1227,6 +1321,17 @@ func (c *Client) SearchEvents(from, to time.Time, query string) ([]events.EventF
 	return retval, nil
 }
 
+// PurgeSessionData permanently deletes the recording and event log of a
+// single session, e.g. to satisfy a data retention or right-to-erasure
+// request.
+func (c *Client) PurgeSessionData(namespace string, sid session.ID) error {
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	_, err := c.Delete(c.Endpoint("namespaces", namespace, "sessions", string(sid), "data"))
+	return trace.Wrap(err)
+}
+
 // GetNamespaces returns a list of namespaces
 func (c *Client) GetNamespaces() ([]services.Namespace, error) {
 	out, err := c.Get(c.Endpoint("namespaces"), url.Values{})
@@ -1268,6 +1373,34 @@ func (c *Client) DeleteNamespace(name string) error {
 	return trace.Wrap(err)
 }
 
+// CheckAccessToServer simulates whether a user would be allowed to connect
+// to a node with a given login, without performing the access
+func (c *Client) CheckAccessToServer(req AccessCheckRequest) (*AccessCheckResponse, error) {
+	out, err := c.PostJSON(c.Endpoint("accesschecks", "server"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var resp AccessCheckResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &resp, nil
+}
+
+// GetAccessMatrix returns a page of the full user x node x login access
+// matrix, computed from current roles and labels.
+func (c *Client) GetAccessMatrix(req AccessMatrixRequest) (*AccessMatrixResponse, error) {
+	out, err := c.PostJSON(c.Endpoint("accesschecks", "matrix"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var resp AccessMatrixResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &resp, nil
+}
+
 // GetRoles returns a list of roles
 func (c *Client) GetRoles() ([]services.Role, error) {
 	out, err := c.Get(c.Endpoint("roles"), url.Values{})
@@ -1377,6 +1510,365 @@ func (c *Client) SetStaticTokens(st services.StaticTokens) error {
 	return nil
 }
 
+func (c *Client) GetNotificationConfig() (services.NotificationConfig, error) {
+	out, err := c.Get(c.Endpoint("configuration", "notification"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	nc, err := services.GetNotificationConfigMarshaler().Unmarshal(out.Bytes())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return nc, err
+}
+
+func (c *Client) SetNotificationConfig(nc services.NotificationConfig) error {
+	data, err := services.GetNotificationConfigMarshaler().Marshal(nc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PostJSON(c.Endpoint("configuration", "notification"), &setNotificationConfigReq{NotificationConfig: data})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetLDAPConnector() (services.LDAPConnector, error) {
+	out, err := c.Get(c.Endpoint("configuration", "ldap"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	lc, err := services.GetLDAPConnectorMarshaler().Unmarshal(out.Bytes())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return lc, err
+}
+
+func (c *Client) SetLDAPConnector(lc services.LDAPConnector) error {
+	data, err := services.GetLDAPConnectorMarshaler().Marshal(lc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PostJSON(c.Endpoint("configuration", "ldap"), &setLDAPConnectorReq{LDAPConnector: data})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetOktaConfig() (services.OktaConfig, error) {
+	out, err := c.Get(c.Endpoint("configuration", "okta"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	oc, err := services.GetOktaConfigMarshaler().Unmarshal(out.Bytes())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return oc, err
+}
+
+func (c *Client) SetOktaConfig(oc services.OktaConfig) error {
+	data, err := services.GetOktaConfigMarshaler().Marshal(oc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PostJSON(c.Endpoint("configuration", "okta"), &setOktaConfigReq{OktaConfig: data})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// SyncOktaGroups runs (or, if dryRun, previews) an Okta group sync.
+func (c *Client) SyncOktaGroups(cfg services.OktaConfig, dryRun bool) (*services.OktaSyncReport, error) {
+	data, err := services.GetOktaConfigMarshaler().Marshal(cfg)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	out, err := c.PostJSON(c.Endpoint("configuration", "okta", "sync"), &syncOktaGroupsReq{OktaConfig: data, DryRun: dryRun})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var report services.OktaSyncReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &report, nil
+}
+
+func (c *Client) GetSlackPlugin() (services.SlackPlugin, error) {
+	out, err := c.Get(c.Endpoint("configuration", "slack"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	sp, err := services.GetSlackPluginMarshaler().Unmarshal(out.Bytes())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sp, err
+}
+
+func (c *Client) SetSlackPlugin(sp services.SlackPlugin) error {
+	data, err := services.GetSlackPluginMarshaler().Marshal(sp)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PostJSON(c.Endpoint("configuration", "slack"), &setSlackPluginReq{SlackPlugin: data})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetEmailPlugin() (services.EmailPlugin, error) {
+	out, err := c.Get(c.Endpoint("configuration", "email"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ep, err := services.GetEmailPluginMarshaler().Unmarshal(out.Bytes())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return ep, err
+}
+
+func (c *Client) SetEmailPlugin(ep services.EmailPlugin) error {
+	data, err := services.GetEmailPluginMarshaler().Marshal(ep)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, err = c.PostJSON(c.Endpoint("configuration", "email"), &setEmailPluginReq{EmailPlugin: data})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// CreateAccessRequest submits a new access request on behalf of the
+// caller. start is when the requested roles should take effect (the zero
+// Time meaning immediately upon approval) and ttl is how long they stay
+// in effect once granted (zero meaning they don't expire on their own).
+func (c *Client) CreateAccessRequest(roles []string, reason string, start time.Time, ttl time.Duration) (*services.AccessRequest, error) {
+	out, err := c.PostJSON(c.Endpoint("accessrequests"), &createAccessRequestReq{Roles: roles, Reason: reason, Start: start, TTL: ttl})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var req services.AccessRequest
+	if err := json.Unmarshal(out.Bytes(), &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &req, nil
+}
+
+// GetAccessRequests lists all access requests.
+func (c *Client) GetAccessRequests() ([]services.AccessRequest, error) {
+	out, err := c.Get(c.Endpoint("accessrequests"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var reqs []services.AccessRequest
+	if err := json.Unmarshal(out.Bytes(), &reqs); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return reqs, nil
+}
+
+// ResolveAccessRequest approves or denies a pending access request.
+func (c *Client) ResolveAccessRequest(id string, state services.AccessRequestState, reason string) (*services.AccessRequest, error) {
+	out, err := c.PostJSON(c.Endpoint("accessrequests", id, "resolve"), &resolveAccessRequestReq{State: state, Reason: reason})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var req services.AccessRequest
+	if err := json.Unmarshal(out.Bytes(), &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &req, nil
+}
+
+// CreateReviewCampaign starts a new periodic access review campaign,
+// snapshotting every user's current role grants for reviewers to decide
+// on.
+func (c *Client) CreateReviewCampaign(name string, reviewers []string, deadline time.Time) (*services.ReviewCampaign, error) {
+	out, err := c.PostJSON(c.Endpoint("reviewcampaigns"), &createReviewCampaignReq{Name: name, Reviewers: reviewers, Deadline: deadline})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var campaign services.ReviewCampaign
+	if err := json.Unmarshal(out.Bytes(), &campaign); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &campaign, nil
+}
+
+// GetReviewCampaigns lists all review campaigns.
+func (c *Client) GetReviewCampaigns() ([]services.ReviewCampaign, error) {
+	out, err := c.Get(c.Endpoint("reviewcampaigns"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var campaigns []services.ReviewCampaign
+	if err := json.Unmarshal(out.Bytes(), &campaigns); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return campaigns, nil
+}
+
+// RecordReviewVote records the caller's decision on user's grant in the
+// review campaign named by id.
+func (c *Client) RecordReviewVote(id, user string, decision services.ReviewDecision, reason string) (*services.ReviewCampaign, error) {
+	out, err := c.PostJSON(c.Endpoint("reviewcampaigns", id, "vote"), &recordReviewVoteReq{User: user, Decision: decision, Reason: reason})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var campaign services.ReviewCampaign
+	if err := json.Unmarshal(out.Bytes(), &campaign); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &campaign, nil
+}
+
+// CloseReviewCampaign closes the review campaign named by id early,
+// revoking the roles reviewers voted to revoke.
+func (c *Client) CloseReviewCampaign(id string) (*services.ReviewCampaign, error) {
+	out, err := c.PostJSON(c.Endpoint("reviewcampaigns", id, "close"), struct{}{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var campaign services.ReviewCampaign
+	if err := json.Unmarshal(out.Bytes(), &campaign); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &campaign, nil
+}
+
+// CreateClusterAlert creates a new cluster alert. expires is when the
+// alert stops being shown on its own (the zero Time meaning it doesn't
+// and must be acknowledged or deleted).
+func (c *Client) CreateClusterAlert(severity services.AlertSeverity, message string, expires time.Time) (*services.ClusterAlert, error) {
+	out, err := c.PostJSON(c.Endpoint("clusteralerts"), &createClusterAlertReq{Severity: severity, Message: message, Expires: expires})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var alert services.ClusterAlert
+	if err := json.Unmarshal(out.Bytes(), &alert); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &alert, nil
+}
+
+// GetClusterAlerts lists every cluster alert.
+func (c *Client) GetClusterAlerts() ([]services.ClusterAlert, error) {
+	out, err := c.Get(c.Endpoint("clusteralerts"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var alerts []services.ClusterAlert
+	if err := json.Unmarshal(out.Bytes(), &alerts); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return alerts, nil
+}
+
+// GetActiveClusterAlerts lists the cluster alerts the caller hasn't
+// acknowledged and that haven't expired -- what tsh login and the web UI
+// should show.
+func (c *Client) GetActiveClusterAlerts() ([]services.ClusterAlert, error) {
+	out, err := c.Get(c.Endpoint("clusteralerts", "active"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var alerts []services.ClusterAlert
+	if err := json.Unmarshal(out.Bytes(), &alerts); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return alerts, nil
+}
+
+// AcknowledgeClusterAlert records that the caller has acknowledged the
+// cluster alert named by id, so it stops being shown to them.
+func (c *Client) AcknowledgeClusterAlert(id string) error {
+	_, err := c.PostJSON(c.Endpoint("clusteralerts", id, "acknowledge"), struct{}{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// UpsertTrustedHostKey creates or replaces the given operator-vouched-for
+// host key.
+func (c *Client) UpsertTrustedHostKey(key services.TrustedHostKey) error {
+	_, err := c.PostJSON(c.Endpoint("trustedhostkeys"), key)
+	return trace.Wrap(err)
+}
+
+// GetTrustedHostKey returns the trusted host key registered for hostname.
+func (c *Client) GetTrustedHostKey(hostname string) (*services.TrustedHostKey, error) {
+	out, err := c.Get(c.Endpoint("trustedhostkeys", hostname), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var key services.TrustedHostKey
+	if err := json.Unmarshal(out.Bytes(), &key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &key, nil
+}
+
+// GetTrustedHostKeys lists every registered trusted host key.
+func (c *Client) GetTrustedHostKeys() ([]services.TrustedHostKey, error) {
+	out, err := c.Get(c.Endpoint("trustedhostkeys"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var keys []services.TrustedHostKey
+	if err := json.Unmarshal(out.Bytes(), &keys); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return keys, nil
+}
+
+// DeleteTrustedHostKey removes the trusted host key registered for
+// hostname.
+func (c *Client) DeleteTrustedHostKey(hostname string) error {
+	_, err := c.Delete(c.Endpoint("trustedhostkeys", hostname))
+	return trace.Wrap(err)
+}
+
 func (c *Client) GetAuthPreference() (services.AuthPreference, error) {
 	out, err := c.Get(c.Endpoint("authentication", "preference"), url.Values{})
 	if err != nil {
@@ -1405,6 +1897,33 @@ func (c *Client) SetAuthPreference(cap services.AuthPreference) error {
 	return nil
 }
 
+// GetSessionRecordingKey returns the cluster-managed session recording
+// encryption key.
+func (c *Client) GetSessionRecordingKey() ([]byte, error) {
+	out, err := c.Get(c.Endpoint("configuration", "session-recording-key"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var resp sessionRecordingKeyResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return resp.Key, nil
+}
+
+// UpsertSessionRecordingKey rotates the cluster-managed session recording
+// encryption key.
+func (c *Client) UpsertSessionRecordingKey(key []byte) error {
+	_, err := c.PostJSON(c.Endpoint("configuration", "session-recording-key"), &upsertSessionRecordingKeyReq{Key: key})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
 // GetLocalClusterName returns local cluster name
 func (c *Client) GetLocalClusterName() (string, error) {
 	return c.GetDomainName()
@@ -1437,7 +1956,11 @@ func (c *Client) DeleteAllProxies() error {
 
 // DeleteAllNodes deletes all nodes in a given namespace
 func (c *Client) DeleteAllNodes(namespace string) error {
-	return trace.BadParameter("not implemented")
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	_, err := c.Delete(c.Endpoint("namespaces", namespace, "nodes"))
+	return trace.Wrap(err)
 }
 
 // DeleteAllRoles deletes all roles
@@ -1561,6 +2084,12 @@ type IdentityService interface {
 	// UpsertPassword updates web access password for the user
 	UpsertPassword(user string, password []byte) error
 
+	// UpsertTOTP creates or updates a user's TOTP second factor secret,
+	// bypassing the signup/reset token flow -- used when bootstrapping the
+	// very first admin of a fresh cluster, where no proxy is reachable yet
+	// to complete the usual token-URL dance.
+	UpsertTOTP(user string, otpSecret string) error
+
 	// UpsertOIDCConnector updates or creates OIDC connector
 	UpsertOIDCConnector(connector services.OIDCConnector) error
 
@@ -1687,7 +2216,7 @@ type ProvisioningService interface {
 
 	// RegisterUsingToken calls the auth service API to register a new node via registration token
 	// which has been previously issued via GenerateToken
-	RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role) (*PackedKeys, error)
+	RegisterUsingToken(token, hostID string, nodeName string, role teleport.Role, remoteAddr string) (*PackedKeys, error)
 
 	// RegisterNewAuthServer is used to register new auth server with token
 	RegisterNewAuthServer(token string) error
@@ -1707,4 +2236,49 @@ type ClientI interface {
 
 	ValidateTrustedCluster(*ValidateTrustedClusterRequest) (*ValidateTrustedClusterResponse, error)
 	GetDomainName() (string, error)
+
+	// CheckAccessToServer simulates whether a user would be allowed to
+	// connect to a node with a given login, without performing the access
+	CheckAccessToServer(req AccessCheckRequest) (*AccessCheckResponse, error)
+
+	// GetAccessMatrix returns a page of the full user x node x login
+	// access matrix, computed from current roles and labels.
+	GetAccessMatrix(req AccessMatrixRequest) (*AccessMatrixResponse, error)
+
+	// SyncOktaGroups runs the Okta group sync described by cfg and reports
+	// the drift it found (and, unless dryRun is set, closed) between Okta
+	// group membership and Teleport users' roles.
+	SyncOktaGroups(cfg services.OktaConfig, dryRun bool) (*services.OktaSyncReport, error)
+
+	// CreateAccessRequest submits a new access request on behalf of the
+	// caller.
+	CreateAccessRequest(roles []string, reason string, start time.Time, ttl time.Duration) (*services.AccessRequest, error)
+	// GetAccessRequests lists all access requests.
+	GetAccessRequests() ([]services.AccessRequest, error)
+	// ResolveAccessRequest approves or denies a pending access request.
+	ResolveAccessRequest(id string, state services.AccessRequestState, reason string) (*services.AccessRequest, error)
+
+	// CreateReviewCampaign starts a new periodic access review campaign.
+	CreateReviewCampaign(name string, reviewers []string, deadline time.Time) (*services.ReviewCampaign, error)
+	// GetReviewCampaigns lists all review campaigns.
+	GetReviewCampaigns() ([]services.ReviewCampaign, error)
+	// RecordReviewVote records the caller's decision on a user's grant in
+	// a review campaign.
+	RecordReviewVote(id, user string, decision services.ReviewDecision, reason string) (*services.ReviewCampaign, error)
+	// CloseReviewCampaign closes a review campaign early, revoking the
+	// roles reviewers voted to revoke.
+	CloseReviewCampaign(id string) (*services.ReviewCampaign, error)
+
+	// CreateClusterAlert creates a new cluster alert.
+	CreateClusterAlert(severity services.AlertSeverity, message string, expires time.Time) (*services.ClusterAlert, error)
+	// GetClusterAlerts lists every cluster alert.
+	GetClusterAlerts() ([]services.ClusterAlert, error)
+	// GetActiveClusterAlerts lists the cluster alerts the caller hasn't
+	// acknowledged and that haven't expired.
+	GetActiveClusterAlerts() ([]services.ClusterAlert, error)
+	// AcknowledgeClusterAlert records that the caller has acknowledged
+	// the cluster alert named by id.
+	AcknowledgeClusterAlert(id string) error
+
+	services.TrustedHostKeys
 }
@@ -0,0 +1,63 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package auth
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+)
+
+// CreateClusterAlert creates a new ClusterAlert with the given severity
+// and message. expires is when the alert stops being shown on its own
+// (the zero Time meaning it doesn't and must be acknowledged or deleted).
+func (s *AuthServer) CreateClusterAlert(severity services.AlertSeverity, message string, expires time.Time) (*services.ClusterAlert, error) {
+	alert := services.ClusterAlert{
+		ID:       uuid.New(),
+		Severity: severity,
+		Message:  message,
+		Created:  time.Now().UTC(),
+		Expires:  expires,
+	}
+	if err := s.ClusterAlerts.CreateClusterAlert(alert); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &alert, nil
+}
+
+// GetActiveClusterAlerts returns every ClusterAlert that hasn't expired
+// and that user hasn't already acknowledged, most recently created
+// first -- what tsh login and the web UI actually show.
+func (s *AuthServer) GetActiveClusterAlerts(user string) ([]services.ClusterAlert, error) {
+	alerts, err := s.ClusterAlerts.GetClusterAlerts()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	now := time.Now().UTC()
+	active := make([]services.ClusterAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.IsExpired(now) || alert.IsAcknowledgedBy(user) {
+			continue
+		}
+		active = append(active, alert)
+	}
+	return active, nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/auth/email"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// emailExpiryCheckLock is the RunWhileLeader election lock name for the
+// provisioning token expiry check, so only one auth server in an HA
+// deployment sends warning emails.
+const emailExpiryCheckLock = "email-expiry-check"
+
+// RunEmailExpiryCheck periodically scans for provisioning tokens nearing
+// expiry and emails a warning about them, for as long as this auth server
+// holds the emailExpiryCheckLock election and ctx is live. It's a no-op
+// for as long as no EmailPlugin has been set, or it's disabled.
+func (s *AuthServer) RunEmailExpiryCheck(ctx context.Context) {
+	s.RunWhileLeader(ctx, emailExpiryCheckLock, defaults.EmailExpiryCheckInterval, defaults.EmailExpiryCheckInterval, func() {
+		cfg, err := s.GetEmailPlugin()
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				log.Warningf("[AUTH] failed to load email plugin config: %v", err)
+			}
+			return
+		}
+		if !cfg.GetEnabled() {
+			return
+		}
+		if err := s.checkTokenExpirations(cfg); err != nil {
+			log.Warningf("[AUTH] provisioning token expiry check failed: %v", err)
+		}
+	})
+}
+
+// checkTokenExpirations emails a warning, to every recipient of a
+// wildcard EmailRoute, about every provisioning token expiring within
+// cfg.GetExpiryWarning().
+func (s *AuthServer) checkTokenExpirations(cfg services.EmailPlugin) error {
+	to := recipientsForRoles(cfg, nil)
+	if len(to) == 0 {
+		return nil
+	}
+
+	tokens, err := s.GetTokens()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	now := time.Now().UTC()
+	for _, token := range tokens {
+		if token.Expires.IsZero() {
+			continue
+		}
+		remaining := token.Expires.Sub(now)
+		if remaining <= 0 || remaining > cfg.GetExpiryWarning() {
+			continue
+		}
+		subject := "Teleport provisioning token expiring soon"
+		body := fmt.Sprintf("Provisioning token for roles %v expires at %v (in %v).",
+			token.Roles, token.Expires.Format(time.RFC1123), remaining.Round(time.Minute))
+		if err := email.Send(smtpConfig(cfg), to, subject, body); err != nil {
+			log.Warningf("[AUTH] failed to send provisioning token expiry email: %v", err)
+		}
+	}
+	return nil
+}
+
+// recipientsForRoles returns the union of every EmailRoute's addresses
+// that either names one of roles, or has no roles of its own (a wildcard
+// route that matches everything).
+func recipientsForRoles(cfg services.EmailPlugin, roles []string) []string {
+	wanted := make(map[string]bool, len(roles))
+	for _, r := range roles {
+		wanted[r] = true
+	}
+
+	seen := make(map[string]bool)
+	var to []string
+	for _, route := range cfg.GetRoutes() {
+		matches := len(route.Roles) == 0
+		for _, r := range route.Roles {
+			if wanted[r] {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+		for _, addr := range route.Addresses {
+			if !seen[addr] {
+				seen[addr] = true
+				to = append(to, addr)
+			}
+		}
+	}
+	return to
+}
+
+func smtpConfig(cfg services.EmailPlugin) email.Config {
+	return email.Config{
+		Host:     cfg.GetSMTPHost(),
+		Port:     cfg.GetSMTPPort(),
+		Username: cfg.GetUsername(),
+		Password: cfg.GetPassword(),
+		From:     cfg.GetFrom(),
+	}
+}
+
+// notifyEmailAccessRequest emails everyone req.Roles routes to about a
+// newly created or resolved access request. Failures are logged, not
+// returned: email notification is a side effect of the request, not a
+// precondition for it.
+func (s *AuthServer) notifyEmailAccessRequest(req *services.AccessRequest, subject, body string) {
+	cfg, err := s.GetEmailPlugin()
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			log.Warningf("[AUTH] failed to load email plugin config: %v", err)
+		}
+		return
+	}
+	if !cfg.GetEnabled() {
+		return
+	}
+	to := recipientsForRoles(cfg, req.Roles)
+	if len(to) == 0 {
+		return
+	}
+	if err := email.Send(smtpConfig(cfg), to, subject, body); err != nil {
+		log.Warningf("[AUTH] failed to send access request email for %v: %v", req.ID, err)
+	}
+}
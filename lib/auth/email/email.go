@@ -0,0 +1,67 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package email sends plain text notifications over SMTP, using only
+// net/smtp from the standard library -- no mail SDK is vendored in this
+// tree.
+package email
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// Config is the SMTP server and credentials used to send mail.
+type Config struct {
+	// Host is the SMTP server's hostname.
+	Host string
+	// Port is the SMTP server's port.
+	Port int
+	// Username authenticates to the SMTP server, if set.
+	Username string
+	// Password authenticates to the SMTP server, if set.
+	Password string
+	// From is the From address on sent mail.
+	From string
+}
+
+// Send sends a plain text email with subject and body to every address in
+// to, authenticating with cfg's credentials if a username is set.
+func Send(cfg Config, to []string, subject, body string) error {
+	if len(to) == 0 {
+		return trace.BadParameter("email: no recipients")
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	msg := fmt.Sprintf("From: %v\r\nTo: %v\r\nSubject: %v\r\n\r\n%v",
+		cfg.From, strings.Join(to, ", "), subject, body)
+
+	if err := smtp.SendMail(addr, auth, cfg.From, to, []byte(msg)); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
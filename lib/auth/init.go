@@ -90,6 +90,20 @@ type InitConfig struct {
 	// ClusterConfiguration is a services that holds cluster wide configuration.
 	ClusterConfiguration services.ClusterConfiguration
 
+	// AccessRequests is a service that manages access request lifecycle.
+	AccessRequests services.AccessRequests
+
+	// ReviewCampaigns is a service that manages periodic access review
+	// campaign lifecycle.
+	ReviewCampaigns services.ReviewCampaigns
+
+	// ClusterAlerts is a service that manages cluster alerts.
+	ClusterAlerts services.ClusterAlerts
+
+	// TrustedHostKeys is a service that manages manually-vouched-for
+	// OpenSSH host keys, for migrations off plain sshd.
+	TrustedHostKeys services.TrustedHostKeys
+
 	// Roles is a set of roles to create
 	Roles []services.Role
 
@@ -106,6 +120,11 @@ type InitConfig struct {
 	// unsafe things like log sensitive information to console as well as
 	// not verify certificates.
 	DeveloperMode bool
+
+	// CAKeyAlgo is the key algorithm (defaults.CAKeyAlgoRSA/ECDSA/ED25519)
+	// used to generate the user and host CAs on first start. Defaults to
+	// defaults.CAKeyAlgoRSA when empty.
+	CAKeyAlgo string
 }
 
 // Init instantiates and configures an instance of AuthServer
@@ -127,14 +146,14 @@ func Init(cfg InitConfig, dynamicConfig bool) (*AuthServer, *Identity, error) {
 	// check that user CA and host CA are present and set the certs if needed
 	asrv := NewAuthServer(&cfg)
 
-	// INTERNAL: Authorities (plus Roles) and ReverseTunnels don't follow the
-	// same pattern as the rest of the configuration (they are not configuration
+	// INTERNAL: Authorities and ReverseTunnels don't follow the same
+	// pattern as the rest of the configuration (they are not configuration
 	// singletons). However, we need to keep them around while Telekube uses them.
-	for _, role := range cfg.Roles {
-		if err := asrv.UpsertRole(role, backend.Forever); err != nil {
-			return nil, nil, trace.Wrap(err)
-		}
-		log.Infof("[INIT] Created Role: %v", role)
+	if err := reconcileRoles(asrv, cfg.Roles); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	if err := reconcileOIDCConnectors(asrv, cfg.OIDCConnectors); err != nil {
+		return nil, nil, trace.Wrap(err)
 	}
 	for i := range cfg.Authorities {
 		ca := cfg.Authorities[i]
@@ -197,7 +216,7 @@ func Init(cfg InitConfig, dynamicConfig bool) (*AuthServer, *Identity, error) {
 		}
 
 		log.Infof("[FIRST START]: Generating user certificate authority (CA)")
-		priv, pub, err := asrv.GenerateKeyPair("")
+		priv, pub, err := asrv.GenerateCAKeyPair(cfg.CAKeyAlgo)
 		if err != nil {
 			return nil, nil, trace.Wrap(err)
 		}
@@ -229,7 +248,7 @@ func Init(cfg InitConfig, dynamicConfig bool) (*AuthServer, *Identity, error) {
 		}
 
 		log.Infof("[FIRST START]: Generating host certificate authority (CA)")
-		priv, pub, err := asrv.GenerateKeyPair("")
+		priv, pub, err := asrv.GenerateCAKeyPair(cfg.CAKeyAlgo)
 		if err != nil {
 			return nil, nil, trace.Wrap(err)
 		}
@@ -281,6 +300,82 @@ func Init(cfg InitConfig, dynamicConfig bool) (*AuthServer, *Identity, error) {
 	return asrv, identity, nil
 }
 
+// withConfigFileOrigin returns labels with services.OriginLabel set to
+// services.OriginConfigFile, so a later reconciliation pass can tell a
+// resource declared in the config file apart from one created by an
+// admin through tctl or the web UI.
+func withConfigFileOrigin(labels map[string]string) map[string]string {
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+	labels[services.OriginLabel] = services.OriginConfigFile
+	return labels
+}
+
+// reconcileRoles makes the config file's "roles" section authoritative:
+// every role it declares is created or updated and tagged with
+// services.OriginConfigFile, and any role that carries that tag but is no
+// longer declared is deleted. Roles created by other means (tctl, the web
+// UI) are never tagged, so they're left untouched either way.
+func reconcileRoles(asrv *AuthServer, declared []services.Role) error {
+	declaredNames := make(map[string]bool, len(declared))
+	for _, role := range declared {
+		declaredNames[role.GetName()] = true
+		if r, ok := role.(*services.RoleV3); ok {
+			r.Metadata.Labels = withConfigFileOrigin(r.Metadata.Labels)
+		}
+		if err := asrv.UpsertRole(role, backend.Forever); err != nil {
+			return trace.Wrap(err)
+		}
+		log.Infof("[INIT] Reconciled Role: %v", role.GetName())
+	}
+	existing, err := asrv.GetRoles()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, role := range existing {
+		if declaredNames[role.GetName()] || role.GetMetadata().Labels[services.OriginLabel] != services.OriginConfigFile {
+			continue
+		}
+		if err := asrv.DeleteRole(role.GetName()); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		log.Infof("[INIT] Pruned Role no longer declared in config: %v", role.GetName())
+	}
+	return nil
+}
+
+// reconcileOIDCConnectors makes the config file's "oidc_connectors"
+// section authoritative, following the same create/update/prune pattern
+// as reconcileRoles.
+func reconcileOIDCConnectors(asrv *AuthServer, declared []services.OIDCConnector) error {
+	declaredNames := make(map[string]bool, len(declared))
+	for _, connector := range declared {
+		declaredNames[connector.GetName()] = true
+		if c, ok := connector.(*services.OIDCConnectorV2); ok {
+			c.Metadata.Labels = withConfigFileOrigin(c.Metadata.Labels)
+		}
+		if err := asrv.UpsertOIDCConnector(connector); err != nil {
+			return trace.Wrap(err)
+		}
+		log.Infof("[INIT] Reconciled OIDC Connector: %v", connector.GetName())
+	}
+	existing, err := asrv.GetOIDCConnectors(false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, connector := range existing {
+		if declaredNames[connector.GetName()] || connector.GetMetadata().Labels[services.OriginLabel] != services.OriginConfigFile {
+			continue
+		}
+		if err := asrv.DeleteOIDCConnector(connector.GetName()); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		log.Infof("[INIT] Pruned OIDC Connector no longer declared in config: %v", connector.GetName())
+	}
+	return nil
+}
+
 func migrateLegacyResources(cfg InitConfig, asrv *AuthServer) error {
 	err := migrateUsers(asrv)
 	if err != nil {
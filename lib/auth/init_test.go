@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/teleport/lib/auth/testauthority"
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/backend/boltbk"
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/utils"
 
@@ -209,3 +210,75 @@ func (s *AuthInitSuite) TestAuthPreference(c *C) {
 	c.Assert(u.AppID, Equals, "foo")
 	c.Assert(u.Facets, DeepEquals, []string{"bar", "baz"})
 }
+
+// TestReconcileRoles ensures that roles declared in the config file are
+// created/updated on every start, tagged with services.OriginConfigFile,
+// and that a role carrying that tag is pruned once it's no longer
+// declared, while a role created by other means is left alone.
+func (s *AuthInitSuite) TestReconcileRoles(c *C) {
+	bk, err := boltbk.New(backend.Params{"path": s.tempDir})
+	c.Assert(err, IsNil)
+
+	clusterName, err := services.NewClusterName(services.ClusterNameSpecV2{
+		ClusterName: "me.localhost",
+	})
+	c.Assert(err, IsNil)
+	staticTokens, err := services.NewStaticTokens(services.StaticTokensSpecV2{
+		StaticTokens: []services.ProvisionToken{},
+	})
+	c.Assert(err, IsNil)
+	ap, err := services.NewAuthPreference(services.AuthPreferenceSpecV2{})
+	c.Assert(err, IsNil)
+
+	baseConfig := InitConfig{
+		DataDir:        s.tempDir,
+		HostUUID:       "00000000-0000-0000-0000-000000000000",
+		NodeName:       "foo",
+		Backend:        bk,
+		Authority:      testauthority.New(),
+		ClusterName:    clusterName,
+		StaticTokens:   staticTokens,
+		AuthPreference: ap,
+	}
+
+	declaredRole := services.RoleV3{
+		Kind:    services.KindRole,
+		Version: services.V3,
+		Metadata: services.Metadata{
+			Name:      "declared",
+			Namespace: defaults.Namespace,
+		},
+	}
+
+	// first start: create the declared role, plus one that's never
+	// declared (as if an admin had created it through tctl)
+	ac := baseConfig
+	ac.Roles = []services.Role{&declaredRole}
+	as, _, err := Init(ac, false)
+	c.Assert(err, IsNil)
+	c.Assert(as.UpsertRole(services.NewDefaultRole(), backend.Forever), IsNil)
+	c.Assert(as.UpsertRole(&services.RoleV3{
+		Kind:    services.KindRole,
+		Version: services.V3,
+		Metadata: services.Metadata{
+			Name:      "manual",
+			Namespace: defaults.Namespace,
+		},
+	}, backend.Forever), IsNil)
+
+	got, err := as.GetRole("declared")
+	c.Assert(err, IsNil)
+	c.Assert(got.GetMetadata().Labels[services.OriginLabel], Equals, services.OriginConfigFile)
+
+	// second start: the declared role is gone from the config, the
+	// manually-created one is untouched
+	ac.Roles = nil
+	as, _, err = Init(ac, false)
+	c.Assert(err, IsNil)
+
+	_, err = as.GetRole("declared")
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%#v", err))
+
+	_, err = as.GetRole("manual")
+	c.Assert(err, IsNil)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package auth
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth/ldap"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// checkLDAPPassword authenticates user/password against the cluster's
+// configured LDAP connector (bind as the service account, look up the
+// user's DN, re-bind as that DN with password to verify it), then syncs
+// the user's Teleport roles from their LDAP/AD group membership.
+func (s *AuthServer) checkLDAPPassword(connector services.LDAPConnector, user string, password []byte) error {
+	groups, err := ldap.Authenticate(ldap.Config{
+		Addr:               connector.GetAddr(),
+		InsecureSkipVerify: connector.GetInsecureSkipVerify(),
+		BindDN:             connector.GetBindDN(),
+		BindPassword:       connector.GetBindPassword(),
+		UserSearchBaseDN:   connector.GetUserSearchBaseDN(),
+		UserSearchFilter:   connector.GetUserSearchFilter(),
+		GroupSearchBaseDN:  connector.GetGroupSearchBaseDN(),
+		GroupSearchFilter:  connector.GetGroupSearchFilter(),
+		GroupAttribute:     connector.GetGroupAttribute(),
+	}, user, password)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := s.createOrUpdateLDAPUser(connector, user, groups); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// createOrUpdateLDAPUser maps groups to Teleport roles via connector's
+// GroupsToRoles and creates or updates user with them, the same way OIDC
+// and SAML logins keep a local user record in sync with the identity
+// provider on every login.
+func (s *AuthServer) createOrUpdateLDAPUser(connector services.LDAPConnector, user string, groups []string) error {
+	roles := rolesFromLDAPGroups(connector, groups)
+
+	newUser, err := services.GetUserMarshaler().GenerateUser(&services.UserV2{
+		Kind:    services.KindUser,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      user,
+			Namespace: defaults.Namespace,
+		},
+		Spec: services.UserSpecV2{
+			Roles: roles,
+			CreatedBy: services.CreatedBy{
+				User: services.UserRef{Name: "system"},
+				Time: time.Now().UTC(),
+				Connector: &services.ConnectorRef{
+					Type:     teleport.ConnectorLDAP,
+					ID:       connector.GetName(),
+					Identity: user,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	existingUser, err := s.GetUser(user)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if existingUser != nil {
+		connectorRef := existingUser.GetCreatedBy().Connector
+		if connectorRef == nil || connectorRef.Type != teleport.ConnectorLDAP {
+			return trace.AlreadyExists("user %q already exists and is not an LDAP user", user)
+		}
+	}
+
+	return trace.Wrap(s.UpsertUser(newUser))
+}
+
+// rolesFromLDAPGroups maps groups to Teleport role names via connector's
+// GroupsToRoles, de-duplicating the result.
+func rolesFromLDAPGroups(connector services.LDAPConnector, groups []string) []string {
+	memberOf := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		memberOf[g] = true
+	}
+	seen := map[string]bool{}
+	var roles []string
+	for _, mapping := range connector.GetGroupsToRoles() {
+		if !memberOf[mapping.Group] {
+			continue
+		}
+		for _, role := range mapping.Roles {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package ldap
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// This file implements just enough BER (the wire encoding LDAPv3 uses) to
+// build the handful of request messages this package sends and parse the
+// handful of response messages it reads. It's not a general purpose
+// ASN.1/BER codec -- see the package doc comment in ldap.go for why.
+
+const (
+	classUniversal   = 0x00
+	classApplication = 0x40
+	classContext     = 0x80
+	constructed      = 0x20
+
+	tagInteger    = 0x02
+	tagOctetStr   = 0x04
+	tagEnumerated = 0x0a
+	tagSequence   = classUniversal | constructed | 0x10
+	tagSet        = classUniversal | constructed | 0x11
+
+	// LDAPMessage protocolOp application tags.
+	tagBindRequest    = classApplication | constructed | 0
+	tagBindResponse   = classApplication | 1
+	tagUnbindRequest  = classApplication | 2
+	tagSearchRequest  = classApplication | constructed | 3
+	tagSearchResEntry = classApplication | constructed | 4
+	tagSearchResDone  = classApplication | 5
+
+	// Filter CHOICE and AuthenticationChoice context tags used here.
+	tagFilterEquality = classContext | constructed | 3
+	tagAuthSimple     = classContext | 0
+)
+
+// element is one decoded BER TLV. For a constructed element, content holds
+// the encoded child elements, which readElements can parse further.
+type element struct {
+	tag     byte
+	content []byte
+}
+
+// encodeLength encodes n using the definite short or long form.
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var raw []byte
+	for n > 0 {
+		raw = append([]byte{byte(n & 0xff)}, raw...)
+		n >>= 8
+	}
+	return append([]byte{byte(0x80 | len(raw))}, raw...)
+}
+
+// tlv encodes a single tag/length/value element.
+func tlv(tag byte, content []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(tag)
+	buf.Write(encodeLength(len(content)))
+	buf.Write(content)
+	return buf.Bytes()
+}
+
+// berInt encodes n as a BER INTEGER's content.
+func berInt(n int) []byte {
+	if n == 0 {
+		return []byte{0}
+	}
+	var out []byte
+	for n > 0 {
+		out = append([]byte{byte(n & 0xff)}, out...)
+		n >>= 8
+	}
+	// an integer whose top bit is set needs a leading 0 byte so it isn't
+	// read back as negative.
+	if out[0]&0x80 != 0 {
+		out = append([]byte{0}, out...)
+	}
+	return out
+}
+
+// seq wraps children in a constructed element tagged tag (SEQUENCE, SET, or
+// an application/context tag standing in for one).
+func seq(tag byte, children ...[]byte) []byte {
+	var content []byte
+	for _, c := range children {
+		content = append(content, c...)
+	}
+	return tlv(tag, content)
+}
+
+// byteReader is what readElement/readLength need: both a live net.Conn
+// wrapped in a *bufio.Reader and a *bytes.Reader over an already-decoded
+// element's content satisfy it.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// readElement reads a single TLV from r.
+func readElement(r byteReader) (element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return element{}, trace.Wrap(err)
+	}
+	length, err := readLength(r)
+	if err != nil {
+		return element{}, trace.Wrap(err)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return element{}, trace.Wrap(err)
+	}
+	return element{tag: tag, content: content}, nil
+}
+
+// readLength reads a BER length in definite short or long form.
+func readLength(r byteReader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	if b < 0x80 {
+		return int(b), nil
+	}
+	n := int(b &^ 0x80)
+	if n == 0 || n > 4 {
+		return 0, trace.BadParameter("ldap: unsupported BER length encoding")
+	}
+	length := 0
+	for i := 0; i < n; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, trace.Wrap(err)
+		}
+		length = length<<8 | int(b)
+	}
+	return length, nil
+}
+
+// children parses e's content as a sequence of elements, for constructed
+// elements like SEQUENCE and SET.
+func (e element) children() ([]element, error) {
+	r := bytes.NewReader(e.content)
+	var out []element
+	for r.Len() > 0 {
+		child, err := readElement(r)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, child)
+	}
+	return out, nil
+}
+
+// int interprets e.content as a big-endian two's complement INTEGER.
+func (e element) int() int {
+	n := 0
+	for _, b := range e.content {
+		n = n<<8 | int(b)
+	}
+	return n
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package ldap
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/utils"
+)
+
+// tlsListener starts a TLS listener on 127.0.0.1 using a freshly generated
+// self-signed certificate, accepting (and immediately closing) connections
+// in the background so dial() has something to complete a handshake with.
+func tlsListener(t *testing.T) net.Addr {
+	creds, err := utils.GenerateSelfSignedCert([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("GenerateSelfSignedCert failed: %v", err)
+	}
+	cert, err := tls.X509KeyPair(creds.Cert, creds.PrivateKey)
+	if err != nil {
+		t.Fatalf("X509KeyPair failed: %v", err)
+	}
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("tls.Listen failed: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			// force the handshake to actually run before closing; Accept on
+			// a tls.Listener defers it to the first Read/Write.
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return l.Addr()
+}
+
+func TestDialUsesTLS(t *testing.T) {
+	addr := tlsListener(t)
+
+	// a plain TCP listener never sends a TLS ServerHello, so dial() against
+	// a TLS listener must perform a real handshake to succeed at all.
+	conn, err := dial(Config{Addr: addr.String(), InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("dial with InsecureSkipVerify failed: %v", err)
+	}
+	conn.Close()
+}
+
+func TestDialVerifiesCertificateByDefault(t *testing.T) {
+	addr := tlsListener(t)
+
+	// the listener's certificate is self-signed, so verification must fail
+	// unless InsecureSkipVerify is set.
+	if _, err := dial(Config{Addr: addr.String()}); err == nil {
+		t.Fatal("dial with a self-signed certificate and InsecureSkipVerify=false should have failed")
+	}
+}
+
+func TestDialRejectsPlaintextServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// a plaintext listener can't complete a TLS handshake, so dial() must
+	// fail instead of silently falling back to an unencrypted connection.
+	if _, err := dial(Config{Addr: l.Addr().String(), InsecureSkipVerify: true}); err == nil {
+		t.Fatal("dial against a plaintext listener should have failed the TLS handshake")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// electionLockPrefix namespaces leader-election lock tokens in the shared
+// backend so they can't collide with unrelated uses of the same
+// AcquireLock/ReleaseLock primitive (e.g. the one-shot first-boot lock
+// taken in Init).
+const electionLockPrefix = "leader-election/"
+
+// RunWhileLeader runs job once every period, but only on the one auth
+// server (out of however many share this backend in an HA deployment) that
+// currently holds the named election lock. It's meant for singleton
+// background maintenance -- CA rotation reminders, heartbeat expiry
+// sweeps, backend pruning -- where every auth server running the same job
+// concurrently would be wasteful or racy, but the job running a little
+// late after a failover is harmless.
+//
+// electionTTL bounds how long a leader that crashes without releasing the
+// lock keeps the other auth servers locked out: RunWhileLeader renews the
+// lock at electionTTL/2 for as long as it's alive, so a healthy leader
+// never loses the election, and a dead one is replaced within electionTTL.
+//
+// RunWhileLeader blocks until ctx is done.
+func (a *AuthServer) RunWhileLeader(ctx context.Context, lockName string, electionTTL, period time.Duration, job func()) {
+	token := electionLockPrefix + lockName
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if err := a.bk.AcquireLock(token, electionTTL); err != nil {
+			log.Warningf("[AUTH] leader election for %q failed, will retry: %v", lockName, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(period):
+			}
+			continue
+		}
+		log.Infof("[AUTH] this auth server is now the leader for %q", lockName)
+		a.holdLeadershipAndRunJob(ctx, token, electionTTL, period, job)
+		return
+	}
+}
+
+// holdLeadershipAndRunJob runs job once per period for as long as ctx is
+// live, renewing the election lock along the way. It gives up leadership
+// (by releasing the lock) as soon as it can no longer renew it or ctx is
+// canceled, so a healthy standby can take over promptly.
+func (a *AuthServer) holdLeadershipAndRunJob(ctx context.Context, token string, electionTTL, period time.Duration, job func()) {
+	defer a.bk.ReleaseLock(token)
+
+	jobTicker := time.NewTicker(period)
+	defer jobTicker.Stop()
+
+	// renew well before electionTTL elapses, so a brief backend hiccup
+	// doesn't cost us the election.
+	renewTicker := time.NewTicker(electionTTL / 2)
+	defer renewTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-renewTicker.C:
+			// this backend lock has no "extend" operation, so renewing
+			// means releasing and immediately re-acquiring it. There's a
+			// small window here where another auth server could win the
+			// lock instead; that's an accepted trade-off of this simple
+			// primitive, and it just means an early, harmless handover.
+			if err := a.bk.ReleaseLock(token); err != nil {
+				log.Warningf("[AUTH] failed releasing election lock %q for renewal: %v", token, err)
+			}
+			if err := a.bk.AcquireLock(token, electionTTL); err != nil {
+				log.Warningf("[AUTH] lost leader election for %q, stepping down: %v", token, err)
+				return
+			}
+		case <-jobTicker.C:
+			job()
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// TestRunWhileLeaderRunsJob checks that a lone auth server wins the
+// election and runs its job repeatedly.
+func (s *AuthSuite) TestRunWhileLeaderRunsJob(c *C) {
+	var runs int32
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	s.a.RunWhileLeader(ctx, "test-job", time.Second, 50*time.Millisecond, func() {
+		atomic.AddInt32(&runs, 1)
+	})
+
+	c.Assert(int(atomic.LoadInt32(&runs)) > 0, Equals, true)
+}
+
+// TestRunWhileLeaderExcludesRivals checks that a second auth server
+// sharing the same backend never runs its job while the first one holds
+// the election lock.
+func (s *AuthSuite) TestRunWhileLeaderExcludesRivals(c *C) {
+	rival := NewAuthServer(&InitConfig{
+		Backend:   s.bk,
+		Authority: s.a.Authority,
+	})
+
+	var leaderRuns, rivalRuns int32
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	go s.a.RunWhileLeader(ctx, "shared-job", time.Second, 20*time.Millisecond, func() {
+		atomic.AddInt32(&leaderRuns, 1)
+	})
+	// give the first server a head start so it reliably wins the election.
+	time.Sleep(50 * time.Millisecond)
+	rival.RunWhileLeader(ctx, "shared-job", time.Second, 20*time.Millisecond, func() {
+		atomic.AddInt32(&rivalRuns, 1)
+	})
+
+	c.Assert(int(atomic.LoadInt32(&leaderRuns)) > 0, Equals, true)
+	c.Assert(atomic.LoadInt32(&rivalRuns), Equals, int32(0))
+}
@@ -16,6 +16,8 @@ limitations under the License.
 package native
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -31,6 +33,7 @@ import (
 
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -115,6 +118,27 @@ func (n *nauth) precalculateKeys() {
 
 // GenerateKeyPair returns fresh priv/pub keypair, takes about 300ms to execute
 func (n *nauth) GenerateKeyPair(passphrase string) ([]byte, []byte, error) {
+	return n.GenerateCAKeyPair(defaults.CAKeyAlgoRSA)
+}
+
+// GenerateCAKeyPair is like GenerateKeyPair, but lets the caller pick the key
+// algorithm (one of defaults.CAKeyAlgoRSA/ECDSA/ED25519). It's used to mint
+// CA, host, and user keys of a non-default algorithm, e.g. for clusters that
+// require Ed25519 or ECDSA rather than RSA.
+func (n *nauth) GenerateCAKeyPair(keyAlgo string) ([]byte, []byte, error) {
+	switch keyAlgo {
+	case "", defaults.CAKeyAlgoRSA:
+		return generateRSAKeyPair()
+	case defaults.CAKeyAlgoECDSA:
+		return generateECDSAKeyPair()
+	case defaults.CAKeyAlgoED25519:
+		return generateED25519KeyPair()
+	default:
+		return nil, nil, trace.BadParameter("unsupported key algorithm: %q", keyAlgo)
+	}
+}
+
+func generateRSAKeyPair() ([]byte, []byte, error) {
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
 		return nil, nil, err
@@ -135,6 +159,117 @@ func (n *nauth) GenerateKeyPair(passphrase string) ([]byte, []byte, error) {
 	return privPem, pubBytes, nil
 }
 
+func generateECDSAKeyPair() ([]byte, []byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	privPem := pem.EncodeToMemory(&pem.Block{
+		Type:  "EC PRIVATE KEY",
+		Bytes: privDer,
+	})
+
+	pub, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubBytes := ssh.MarshalAuthorizedKey(pub)
+	return privPem, pubBytes, nil
+}
+
+func generateED25519KeyPair() ([]byte, []byte, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	privPem, err := marshalOpenSSHPrivateKey(priv, pub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubBytes := ssh.MarshalAuthorizedKey(sshPub)
+	return privPem, pubBytes, nil
+}
+
+// marshalOpenSSHPrivateKey encodes an unencrypted Ed25519 key pair as the
+// "OPENSSH PRIVATE KEY" PEM format described in
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key, the
+// only private key format the vendored ssh.ParsePrivateKey knows how to
+// decode an Ed25519 key from (there's no PKCS#1/SEC1 equivalent for it).
+func marshalOpenSSHPrivateKey(priv ed25519.PrivateKey, pub ed25519.PublicKey) ([]byte, error) {
+	pubKeyBlob := ssh.Marshal(struct {
+		KeyType string
+		Pub     []byte
+	}{
+		KeyType: ssh.KeyAlgoED25519,
+		Pub:     []byte(pub),
+	})
+
+	// checkint is a random uint32 repeated twice so a decrypting party can
+	// verify a (possibly passphrase-protected) key decrypted correctly; we
+	// don't encrypt, but the format still requires the field.
+	var checkBuf [4]byte
+	if _, err := rand.Read(checkBuf[:]); err != nil {
+		return nil, err
+	}
+	checkInt := uint32(checkBuf[0])<<24 | uint32(checkBuf[1])<<16 | uint32(checkBuf[2])<<8 | uint32(checkBuf[3])
+
+	privKeyBlock := struct {
+		Check1  uint32
+		Check2  uint32
+		Keytype string
+		Pub     []byte
+		Priv    []byte
+		Comment string
+	}{
+		Check1:  checkInt,
+		Check2:  checkInt,
+		Keytype: ssh.KeyAlgoED25519,
+		Pub:     []byte(pub),
+		Priv:    []byte(priv),
+		Comment: "",
+	}
+	privKeyBlob := ssh.Marshal(privKeyBlock)
+
+	// the private key section is padded with 1, 2, 3, ... up to the next
+	// multiple of the (unencrypted) cipher block size of 8 bytes.
+	for i := 0; len(privKeyBlob)%8 != 0; i++ {
+		privKeyBlob = append(privKeyBlob, byte(i+1))
+	}
+
+	w := struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{
+		CipherName:   "none",
+		KdfName:      "none",
+		KdfOpts:      "",
+		NumKeys:      1,
+		PubKey:       pubKeyBlob,
+		PrivKeyBlock: privKeyBlob,
+	}
+
+	body := append([]byte("openssh-key-v1"), 0)
+	body = append(body, ssh.Marshal(w)...)
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: body,
+	}), nil
+}
+
 func (n *nauth) GenerateHostCert(c services.HostCertParams) ([]byte, error) {
 	if err := c.Check(); err != nil {
 		return nil, trace.Wrap(err)
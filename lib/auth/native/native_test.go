@@ -24,6 +24,7 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth/test"
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/utils"
 
@@ -61,6 +62,68 @@ func (s *NativeSuite) TestGenerateUserCert(c *C) {
 	s.suite.GenerateUserCert(c)
 }
 
+// TestGenerateCAKeyPair makes sure host and user certificates can
+// be issued from CA keys of every supported algorithm, and that the
+// generated private keys round-trip through ssh.ParsePrivateKey.
+func (s *NativeSuite) TestGenerateCAKeyPair(c *C) {
+	algos := []string{defaults.CAKeyAlgoRSA, defaults.CAKeyAlgoECDSA, defaults.CAKeyAlgoED25519}
+
+	for _, algo := range algos {
+		comment := Commentf("key algorithm %v", algo)
+
+		caPrivateKey, _, err := s.suite.A.GenerateCAKeyPair(algo)
+		c.Assert(err, IsNil, comment)
+
+		_, err = ssh.ParsePrivateKey(caPrivateKey)
+		c.Assert(err, IsNil, comment)
+
+		_, hostPublicKey, err := s.suite.A.GenerateKeyPair("")
+		c.Assert(err, IsNil, comment)
+
+		hostCertificateBytes, err := s.suite.A.GenerateHostCert(
+			services.HostCertParams{
+				PrivateCASigningKey: caPrivateKey,
+				PublicHostKey:       hostPublicKey,
+				HostID:              "00000000-0000-0000-0000-000000000000",
+				NodeName:            "auth",
+				ClusterName:         "example.com",
+				Roles:               teleport.Roles{teleport.RoleAdmin},
+				TTL:                 time.Hour,
+			})
+		c.Assert(err, IsNil, comment)
+
+		publicKey, _, _, _, err := ssh.ParseAuthorizedKey(hostCertificateBytes)
+		c.Assert(err, IsNil, comment)
+		_, ok := publicKey.(*ssh.Certificate)
+		c.Assert(ok, Equals, true, comment)
+
+		_, userPublicKey, err := s.suite.A.GenerateKeyPair("")
+		c.Assert(err, IsNil, comment)
+
+		userCertificateBytes, err := s.suite.A.GenerateUserCert(services.UserCertParams{
+			PrivateCASigningKey: caPrivateKey,
+			PublicUserKey:       userPublicKey,
+			Username:            "user",
+			AllowedLogins:       []string{"centos", "root"},
+			TTL:                 time.Hour,
+			Roles:               []string{"foo"},
+		})
+		c.Assert(err, IsNil, comment)
+
+		publicKey, _, _, _, err = ssh.ParseAuthorizedKey(userCertificateBytes)
+		c.Assert(err, IsNil, comment)
+		_, ok = publicKey.(*ssh.Certificate)
+		c.Assert(ok, Equals, true, comment)
+	}
+}
+
+// TestGenerateCAKeyPairRejectsUnknown makes sure an unsupported
+// key algorithm name is rejected rather than silently falling back to RSA.
+func (s *NativeSuite) TestGenerateCAKeyPairRejectsUnknown(c *C) {
+	_, _, err := s.suite.A.GenerateCAKeyPair("bogus")
+	c.Assert(err, NotNil)
+}
+
 // TestBuildPrincipals makes sure that the list of principals for a host
 // certificate is correctly built.
 //   * If the node has role admin, then only the host ID should be listed
@@ -171,6 +171,20 @@ func (a *AuthServer) ValidateOIDCAuthCallback(q url.Values) (*OIDCAuthResponse,
 		Req:      *req,
 	}
 
+	if req.SSOTestFlow {
+		roles, err := a.buildRoles(connector, ident, claims)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		response.Username = ident.Email
+		response.TestFlowResult = &services.SSOTestFlowResult{
+			Claims:      map[string]interface{}(claims),
+			MappedRoles: roles,
+			Traits:      services.ApplyTraitMappings(claimsToTraitMap(claims), connector.GetTraitMappings()),
+		}
+		return response, nil
+	}
+
 	log.Debugf("[OIDC] Applying %v claims to roles mappings", len(connector.GetClaimsToRoles()))
 	if len(connector.GetClaimsToRoles()) != 0 {
 		if err := a.createOIDCUser(connector, ident, claims); err != nil {
@@ -252,6 +266,10 @@ type OIDCAuthResponse struct {
 	// HostSigners is a list of signing host public keys
 	// trusted by proxy, used in console login
 	HostSigners []services.CertAuthority `json:"host_signers"`
+	// TestFlowResult is set instead of Cert/Session when Req.SSOTestFlow was
+	// set, reporting the claims and mappings produced by the connector
+	// without creating a user or session.
+	TestFlowResult *services.SSOTestFlowResult `json:"test_flow_result,omitempty"`
 }
 
 // buildRoles takes a connector and claims and returns a slice of roles. If the claims
@@ -308,7 +326,7 @@ func (a *AuthServer) createOIDCUser(connector services.OIDCConnector, ident *oid
 		return trace.Wrap(err)
 	}
 
-	traits := claimsToTraitMap(claims)
+	traits := services.ApplyTraitMappings(claimsToTraitMap(claims), connector.GetTraitMappings())
 
 	log.Debugf("[OIDC] Generating dynamic identity %v/%v with roles: %v", connector.GetName(), ident.Email, roles)
 	user, err := services.GetUserMarshaler().GenerateUser(&services.UserV2{
@@ -0,0 +1,191 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth/okta"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// oktaSyncLock is the RunWhileLeader election lock name for the Okta group
+// sync background job, so only one auth server in an HA deployment queries
+// Okta at a time.
+const oktaSyncLock = "okta-sync"
+
+// RunOktaSync runs the Okta group sync job (see SyncOktaGroups) once per
+// the cluster's configured OktaConfig.GetSyncInterval, for as long as this
+// auth server holds the oktaSyncLock election and ctx is live. It's a
+// no-op for as long as no OktaConfig has been set, or it's disabled.
+func (s *AuthServer) RunOktaSync(ctx context.Context) {
+	s.RunWhileLeader(ctx, oktaSyncLock, defaults.OktaSyncInterval, defaults.OktaSyncInterval, func() {
+		cfg, err := s.GetOktaConfig()
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				log.Warningf("[AUTH] failed to load Okta config: %v", err)
+			}
+			return
+		}
+		if !cfg.GetEnabled() {
+			return
+		}
+		if _, err := s.SyncOktaGroups(cfg, false); err != nil {
+			log.Warningf("[AUTH] Okta group sync failed: %v", err)
+		}
+	})
+}
+
+// SyncOktaGroups reads the Okta groups named in cfg.GetGroupsToRoles(),
+// computes the Teleport roles each member should have, and reports the
+// drift between that and the roles Teleport users currently have. Unless
+// dryRun is true, it also creates/updates the affected users to close
+// that drift, the same way OIDC/SAML/LDAP logins keep a local user record
+// in sync with the identity provider.
+func (s *AuthServer) SyncOktaGroups(cfg services.OktaConfig, dryRun bool) (*services.OktaSyncReport, error) {
+	desired, err := desiredOktaRoles(cfg)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	report := &services.OktaSyncReport{DryRun: dryRun}
+	for user, newRoles := range desired {
+		existingUser, err := s.GetUser(user)
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+
+		var oldRoles []string
+		isNew := existingUser == nil
+		if !isNew {
+			connectorRef := existingUser.GetCreatedBy().Connector
+			if connectorRef != nil && connectorRef.Type != teleport.ConnectorOkta {
+				log.Warningf("[AUTH] not syncing Okta roles for %q: already exists and is not an Okta-managed user", user)
+				continue
+			}
+			oldRoles = existingUser.GetRoles()
+		}
+
+		if !isNew && sameRoles(oldRoles, newRoles) {
+			continue
+		}
+		report.Changes = append(report.Changes, services.OktaUserRoleDrift{
+			User:     user,
+			New:      isNew,
+			OldRoles: oldRoles,
+			NewRoles: newRoles,
+		})
+
+		if dryRun {
+			continue
+		}
+		if err := s.createOrUpdateOktaUser(user, newRoles); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	sort.Slice(report.Changes, func(i, j int) bool { return report.Changes[i].User < report.Changes[j].User })
+	return report, nil
+}
+
+// desiredOktaRoles queries every group in cfg.GetGroupsToRoles() and
+// returns, for every user found in at least one of them, the union of the
+// roles their group memberships map to.
+func desiredOktaRoles(cfg services.OktaConfig) (map[string][]string, error) {
+	oktaCfg := okta.Config{OrgURL: cfg.GetOrgURL(), APIToken: cfg.GetAPIToken()}
+
+	rolesByUser := map[string]map[string]bool{}
+	for _, mapping := range cfg.GetGroupsToRoles() {
+		members, err := okta.GroupMembers(oktaCfg, mapping.Group)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, user := range members {
+			if rolesByUser[user] == nil {
+				rolesByUser[user] = map[string]bool{}
+			}
+			for _, role := range mapping.Roles {
+				rolesByUser[user][role] = true
+			}
+		}
+	}
+
+	desired := make(map[string][]string, len(rolesByUser))
+	for user, roleSet := range rolesByUser {
+		var roles []string
+		for role := range roleSet {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+		desired[user] = roles
+	}
+	return desired, nil
+}
+
+// createOrUpdateOktaUser creates or updates user with roles, recording it
+// as owned by the Okta connector.
+func (s *AuthServer) createOrUpdateOktaUser(user string, roles []string) error {
+	newUser, err := services.GetUserMarshaler().GenerateUser(&services.UserV2{
+		Kind:    services.KindUser,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      user,
+			Namespace: defaults.Namespace,
+		},
+		Spec: services.UserSpecV2{
+			Roles: roles,
+			CreatedBy: services.CreatedBy{
+				User: services.UserRef{Name: "system"},
+				Time: time.Now().UTC(),
+				Connector: &services.ConnectorRef{
+					Type:     teleport.ConnectorOkta,
+					ID:       "okta",
+					Identity: user,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(s.UpsertUser(newUser))
+}
+
+// sameRoles reports whether a and b contain the same roles, ignoring
+// order. Both are expected to already be sorted, as desiredOktaRoles and
+// services.User.GetRoles() do internally, but this doesn't assume that.
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
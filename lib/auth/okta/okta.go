@@ -0,0 +1,147 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package okta reads group membership from the Okta API, for syncing Okta
+// groups to Teleport roles.
+//
+// No Okta client library is vendored in this tree, so this package speaks
+// just enough of the Okta REST API (https://developer.okta.com/docs/reference/api/groups/)
+// directly over net/http to look a group up by name and list its members.
+package okta
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// requestTimeout bounds how long a single Okta API request may take.
+const requestTimeout = 30 * time.Second
+
+// Config is the connection configuration needed to query the Okta API.
+type Config struct {
+	// OrgURL is the Okta org's base URL, e.g. "https://example.okta.com".
+	OrgURL string
+	// APIToken authenticates requests, sent as an "SSWS" Authorization
+	// header.
+	APIToken string
+	// Client is the HTTP client used to talk to Okta. If nil, a client
+	// with requestTimeout is used.
+	Client *http.Client
+}
+
+// client wraps Config with the http.Client to use.
+type client struct {
+	Config
+	http *http.Client
+}
+
+// newClient returns a client from cfg, defaulting the HTTP client if unset.
+func newClient(cfg Config) *client {
+	httpClient := cfg.Client
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+	return &client{Config: cfg, http: httpClient}
+}
+
+// GroupMembers returns the login (per the "login" profile attribute) of
+// every member of the Okta group named groupName. It returns
+// trace.NotFound if no group with that name exists.
+func GroupMembers(cfg Config, groupName string) ([]string, error) {
+	c := newClient(cfg)
+	groupID, err := c.findGroupID(groupName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return c.listGroupMembers(groupID)
+}
+
+// oktaGroup is the subset of Okta's Group object this package cares about.
+type oktaGroup struct {
+	ID      string `json:"id"`
+	Profile struct {
+		Name string `json:"name"`
+	} `json:"profile"`
+}
+
+// findGroupID looks up the id of the group named groupName.
+func (c *client) findGroupID(groupName string) (string, error) {
+	var groups []oktaGroup
+	if err := c.get("/api/v1/groups", url.Values{"q": {groupName}}, &groups); err != nil {
+		return "", trace.Wrap(err)
+	}
+	for _, g := range groups {
+		if g.Profile.Name == groupName {
+			return g.ID, nil
+		}
+	}
+	return "", trace.NotFound("no Okta group named %q found", groupName)
+}
+
+// oktaUser is the subset of Okta's User object this package cares about.
+type oktaUser struct {
+	Profile struct {
+		Login string `json:"login"`
+	} `json:"profile"`
+}
+
+// listGroupMembers returns the logins of every user in the group groupID.
+func (c *client) listGroupMembers(groupID string) ([]string, error) {
+	var users []oktaUser
+	if err := c.get(fmt.Sprintf("/api/v1/groups/%s/users", groupID), nil, &users); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	logins := make([]string, 0, len(users))
+	for _, u := range users {
+		logins = append(logins, u.Profile.Login)
+	}
+	return logins, nil
+}
+
+// get issues a GET request against path (relative to OrgURL) with query
+// params, and decodes a successful JSON response into out.
+func (c *client) get(path string, query url.Values, out interface{}) error {
+	u := c.OrgURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.Header.Set("Authorization", "SSWS "+c.APIToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("okta: %v %v returned %v", req.Method, path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
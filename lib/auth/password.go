@@ -21,7 +21,19 @@ var fakePasswordHash = []byte(`$2a$10$Yy.e6BmS2SrGbBDsyDLVkOANZmvjjMR890nUGSXFJH
 // CheckPasswordWOToken checks just password without checking OTP tokens
 // used in case of SSH authentication, when token has been validated.
 func (s *AuthServer) CheckPasswordWOToken(user string, password []byte) error {
-	err := services.VerifyPassword(password)
+	if err := s.checkLocalAuthAllowed(user); err != nil {
+		return trace.Wrap(err)
+	}
+
+	connector, err := s.GetLDAPConnector()
+	if err == nil {
+		return trace.Wrap(s.checkLDAPPassword(connector, user, password))
+	}
+	if !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+
+	err = services.VerifyPassword(password)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -43,6 +55,35 @@ func (s *AuthServer) CheckPasswordWOToken(user string, password []byte) error {
 	return nil
 }
 
+// checkLocalAuthAllowed returns an access denied error if the cluster's
+// configured auth Type is oidc or saml, local login has not been enabled
+// alongside it, and this user is not pinned to local auth via
+// User.GetLocalAuthOnly (e.g. a break-glass admin account).
+func (s *AuthServer) checkLocalAuthAllowed(user string) error {
+	cap, err := s.GetAuthPreference()
+	if err != nil {
+		if trace.IsNotFound(err) {
+			// no cluster auth preference has been set, local login is the
+			// implicit default.
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	if cap.GetType() == teleport.Local || cap.GetAllowLocalAuth() {
+		return nil
+	}
+
+	u, err := s.GetUser(user)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if u != nil && u.GetLocalAuthOnly() {
+		return nil
+	}
+
+	return trace.AccessDenied("local login is disabled, use %v to sign in", cap.GetType())
+}
+
 // CheckPassword checks the password and OTP token. Called by tsh or lib/web/*.
 func (s *AuthServer) CheckPassword(user string, password []byte, otpToken string) error {
 	err := s.CheckPasswordWOToken(user, password)
@@ -19,13 +19,29 @@ package auth
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/services"
 
 	"github.com/gravitational/trace"
+	"github.com/gravitational/ttlmap"
+	log "github.com/sirupsen/logrus"
 )
 
+// authContextCacheTTL is how long authorizeLocalUser reuses an assembled
+// AuthContext for a given user before going back to the backend to
+// reassemble it. This tree has no backend change-notification mechanism to
+// invalidate the cache the moment a user's roles (or a role itself) are
+// edited, so the TTL is what bounds the staleness instead -- any edit is
+// visible within authContextCacheTTL.
+const authContextCacheTTL = 5 * time.Second
+
+// authContextCacheCapacity bounds the number of distinct (user, roles)
+// combinations kept in memory at once.
+const authContextCacheCapacity = 4096
+
 // NewRoleAuthorizer authorizes everyone as predefined role
 func NewRoleAuthorizer(r teleport.Role) (Authorizer, error) {
 	authContext, err := contextForBuiltinRole(r)
@@ -66,7 +82,16 @@ func NewAuthorizer(access services.Access, identity services.Identity, trust ser
 	if trust == nil {
 		return nil, trace.BadParameter("missing parameter trust")
 	}
-	return &authorizer{access: access, identity: identity, trust: trust}, nil
+	authContextCache, err := ttlmap.New(authContextCacheCapacity)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &authorizer{
+		access:           access,
+		identity:         identity,
+		trust:            trust,
+		authContextCache: authContextCache,
+	}, nil
 }
 
 // Authorizer authorizes identity and returns auth context
@@ -80,6 +105,16 @@ type authorizer struct {
 	access   services.Access
 	identity services.Identity
 	trust    services.Trust
+
+	// authContextCacheMu guards authContextCache, which itself is not safe
+	// for concurrent access.
+	authContextCacheMu sync.Mutex
+	// authContextCache holds recently assembled local-user AuthContexts,
+	// keyed by authContextCacheKey, so that repeated API calls from the
+	// same user (with the same roles and traits) within
+	// authContextCacheTTL don't each re-read the user and every one of
+	// their roles from the backend.
+	authContextCache *ttlmap.TTLMap
 }
 
 // AuthzContext is authorization context
@@ -108,9 +143,44 @@ func (a *authorizer) Authorize(ctx context.Context) (*AuthContext, error) {
 	}
 }
 
-// authorizeLocalUser returns authz context based on the username
+// authorizeLocalUser returns authz context based on the username, reusing a
+// recently assembled AuthContext for this user if one is cached -- see
+// authContextCacheTTL.
 func (a *authorizer) authorizeLocalUser(u teleport.LocalUser) (*AuthContext, error) {
-	return contextForLocalUser(u.Username, a.identity, a.access)
+	if authContext := a.getCachedAuthContext(u.Username); authContext != nil {
+		return authContext, nil
+	}
+	authContext, err := contextForLocalUser(u.Username, a.identity, a.access)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	a.setCachedAuthContext(u.Username, authContext)
+	return authContext, nil
+}
+
+// getCachedAuthContext returns the cached AuthContext for username, or nil
+// if there isn't one (or it's expired).
+func (a *authorizer) getCachedAuthContext(username string) *AuthContext {
+	a.authContextCacheMu.Lock()
+	defer a.authContextCacheMu.Unlock()
+	val, ok := a.authContextCache.Get(username)
+	if !ok {
+		return nil
+	}
+	authContext, ok := val.(*AuthContext)
+	if !ok {
+		return nil
+	}
+	return authContext
+}
+
+// setCachedAuthContext caches authContext for username for authContextCacheTTL.
+func (a *authorizer) setCachedAuthContext(username string, authContext *AuthContext) {
+	a.authContextCacheMu.Lock()
+	defer a.authContextCacheMu.Unlock()
+	if err := a.authContextCache.Set(username, authContext, authContextCacheTTL); err != nil {
+		log.Warningf("failed to cache auth context for %v: %v", username, err)
+	}
 }
 
 // authorizeRemoteUser returns checker based on cert authority roles
@@ -63,7 +63,7 @@ func Register(dataDir, token string, id IdentityID, servers []utils.NetAddr) err
 	defer client.Close()
 
 	// create the host certificate and keys
-	keys, err := client.RegisterUsingToken(tok, id.HostUUID, id.NodeName, id.Role)
+	keys, err := client.RegisterUsingToken(tok, id.HostUUID, id.NodeName, id.Role, "")
 	if err != nil {
 		return trace.Wrap(err)
 	}
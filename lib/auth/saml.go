@@ -130,7 +130,7 @@ func (a *AuthServer) createSAMLUser(connector services.SAMLConnector, assertionI
 		return trace.Wrap(err)
 	}
 
-	traits := assertionsToTraitMap(assertionInfo)
+	traits := services.ApplyTraitMappings(assertionsToTraitMap(assertionInfo), connector.GetTraitMappings())
 
 	log.Debugf("[SAML] Generating dynamic identity %v/%v with roles: %v", connector.GetName(), assertionInfo.NameID, roles)
 	user, err := services.GetUserMarshaler().GenerateUser(&services.UserV2{
@@ -240,6 +240,10 @@ type SAMLAuthResponse struct {
 	// HostSigners is a list of signing host public keys
 	// trusted by proxy, used in console login
 	HostSigners []services.CertAuthority `json:"host_signers"`
+	// TestFlowResult is set instead of Cert/Session when Req.SSOTestFlow was
+	// set, reporting the assertions and mappings produced by the connector
+	// without creating a user or session.
+	TestFlowResult *services.SSOTestFlowResult `json:"test_flow_result,omitempty"`
 }
 
 // ValidateSAMLResponse consumes attribute statements from SAML identity provider
@@ -292,6 +296,32 @@ func (a *AuthServer) ValidateSAMLResponse(samlResponse string) (*SAMLAuthRespons
 	}
 	// TODO(klizhentas) use SessionNotOnOrAfter to calculate expiration time
 	expiresAt := a.clock.Now().Add(defaults.CertDuration)
+
+	if request.SSOTestFlow {
+		roles, err := a.buildSAMLRoles(connector, *assertionInfo, expiresAt)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		claims := make(map[string]interface{}, len(assertionInfo.Values))
+		for key, val := range assertionInfo.Values {
+			var vals []string
+			for _, vv := range val.Values {
+				vals = append(vals, vv.Value)
+			}
+			claims[key] = vals
+		}
+		return &SAMLAuthResponse{
+			Req:      *request,
+			Identity: services.ExternalIdentity{ConnectorID: request.ConnectorID, Username: assertionInfo.NameID},
+			Username: assertionInfo.NameID,
+			TestFlowResult: &services.SSOTestFlowResult{
+				Claims:      claims,
+				MappedRoles: roles,
+				Traits:      services.ApplyTraitMappings(assertionsToTraitMap(*assertionInfo), connector.GetTraitMappings()),
+			},
+		}, nil
+	}
+
 	if err := a.createSAMLUser(connector, *assertionInfo, expiresAt); err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -0,0 +1,130 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package slack posts access request notifications to Slack.
+//
+// No Slack client library is vendored in this tree, so this package
+// speaks just enough of the Slack Web API
+// (https://api.slack.com/methods/chat.postMessage) directly over
+// net/http to do that.
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// apiURL is the base URL for the Slack Web API.
+const apiURL = "https://slack.com/api"
+
+// requestTimeout bounds how long a single Slack API request may take.
+const requestTimeout = 15 * time.Second
+
+// Config is the credentials needed to post to a Slack app's channels.
+type Config struct {
+	// BotToken authenticates chat.postMessage calls.
+	BotToken string
+	// SigningSecret is not currently used to verify anything (this
+	// cluster doesn't run a public callback endpoint for Slack button
+	// clicks), but is kept alongside BotToken as the SlackPlugin's other
+	// half of the app's credentials, for when that lands.
+	SigningSecret string
+	// Client is the HTTP client used to talk to Slack. If nil, a client
+	// with requestTimeout is used.
+	Client *http.Client
+}
+
+func httpClient(cfg Config) *http.Client {
+	if cfg.Client != nil {
+		return cfg.Client
+	}
+	return &http.Client{Timeout: requestTimeout}
+}
+
+// PostMessage posts text to cfg's channel and returns the message's
+// timestamp (its "ts"), which doubles as an ID for later threading under
+// the message via ThreadReply.
+func PostMessage(cfg Config, channel, text string) (string, error) {
+	var resp struct {
+		OK    bool   `json:"ok"`
+		TS    string `json:"ts"`
+		Error string `json:"error"`
+	}
+	err := post(cfg, "chat.postMessage", map[string]interface{}{
+		"channel": channel,
+		"text":    text,
+	}, &resp)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if !resp.OK {
+		return "", trace.BadParameter("slack: chat.postMessage failed: %v", resp.Error)
+	}
+	return resp.TS, nil
+}
+
+// ThreadReply posts text as a threaded reply under the message identified
+// by threadTS in channel, e.g. to record an approve/deny decision.
+func ThreadReply(cfg Config, channel, threadTS, text string) error {
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	err := post(cfg, "chat.postMessage", map[string]interface{}{
+		"channel":   channel,
+		"text":      text,
+		"thread_ts": threadTS,
+	}, &resp)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !resp.OK {
+		return trace.BadParameter("slack: chat.postMessage (thread reply) failed: %v", resp.Error)
+	}
+	return nil
+}
+
+func post(cfg Config, method string, body interface{}, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.BotToken)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := httpClient(cfg).Do(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("slack: %v returned %v", method, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -44,6 +44,13 @@ func (n *Keygen) GenerateKeyPair(passphrase string) ([]byte, []byte, error) {
 	return randomKey.Priv, randomKey.Pub, nil
 }
 
+// GenerateCAKeyPair ignores keyAlgo and returns one of the pre-generated RSA
+// testPairs: this test double only ships RSA fixtures, and tests that care
+// about a specific CA key algorithm exercise lib/auth/native directly.
+func (n *Keygen) GenerateCAKeyPair(keyAlgo string) ([]byte, []byte, error) {
+	return n.GenerateKeyPair("")
+}
+
 func (n *Keygen) GenerateHostCert(c services.HostCertParams) ([]byte, error) {
 	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(c.PublicHostKey)
 	if err != nil {
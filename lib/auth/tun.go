@@ -65,6 +65,11 @@ type AuthTunnel struct {
 	hostCertChecker ssh.CertChecker
 	userCertChecker ssh.CertChecker
 	limiter         *limiter.Limiter
+
+	// listener, if set, is an already-open listener (e.g. one imported via
+	// systemd socket activation) that the tunnel accepts connections on
+	// instead of binding addr itself
+	listener net.Listener
 }
 
 // TunClient is HTTP client that works over SSH tunnel
@@ -107,6 +112,15 @@ func SetLimiter(limiter *limiter.Limiter) ServerOption {
 	}
 }
 
+// SetListener makes the auth tunnel accept connections on an already-open
+// listener instead of binding addr itself.
+func SetListener(l net.Listener) ServerOption {
+	return func(s *AuthTunnel) error {
+		s.listener = l
+		return nil
+	}
+}
+
 // NewTunnel creates a new SSH tunnel server which is not started yet.
 // This is how "site API" (aka "auth API") is served: by creating
 // an "tunnel server" which serves HTTP via SSH.
@@ -130,6 +144,10 @@ func NewTunnel(addr utils.NetAddr,
 		}
 	}
 	// create an SSH server and assign the tunnel to be it's "new SSH channel handler"
+	sshServerOpts := []sshutils.ServerOption{sshutils.SetLimiter(tunnel.limiter)}
+	if tunnel.listener != nil {
+		sshServerOpts = append(sshServerOpts, sshutils.SetListener(tunnel.listener))
+	}
 	tunnel.sshServer, err = sshutils.NewServer(
 		teleport.ComponentAuth,
 		addr,
@@ -139,7 +157,7 @@ func NewTunnel(addr utils.NetAddr,
 			Password:  tunnel.passwordAuth,
 			PublicKey: tunnel.keyAuth,
 		},
-		sshutils.SetLimiter(tunnel.limiter),
+		sshServerOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -834,7 +852,9 @@ func (c *TunClient) GetDialer() AccessPointDialer {
 			if err == nil {
 				return conn, nil
 			}
-			time.Sleep(4 * time.Duration(attempt) * dialRetryInterval)
+			// jitter the backoff so a fleet of nodes/proxies reconnecting
+			// after an auth server restart doesn't hammer it in lockstep
+			time.Sleep(4*time.Duration(attempt)*dialRetryInterval + utils.RandomDuration(dialRetryInterval))
 		}
 		log.Errorf("%v: ", err)
 		return nil, trace.Wrap(err)
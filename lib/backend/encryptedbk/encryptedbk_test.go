@@ -0,0 +1,115 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryptedbk
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/backend/dir"
+	"github.com/gravitational/teleport/lib/backend/test"
+
+	. "gopkg.in/check.v1"
+)
+
+func TestEncryptedBackend(t *testing.T) { TestingT(t) }
+
+type EncryptedSuite struct {
+	inner backend.Backend
+	bk    backend.Backend
+	suite test.BackendSuite
+}
+
+var _ = Suite(&EncryptedSuite{})
+
+func (s *EncryptedSuite) SetUpTest(c *C) {
+	inner, err := dir.New(backend.Params{"path": c.MkDir()})
+	c.Assert(err, IsNil)
+	s.inner = inner
+
+	bk, err := New(inner, writeKeyFile(c, 0))
+	c.Assert(err, IsNil)
+	s.bk = bk
+
+	s.suite.B = s.bk
+}
+
+func (s *EncryptedSuite) TestBasicCRUD(c *C) {
+	s.suite.BasicCRUD(c)
+}
+
+func (s *EncryptedSuite) TestValueAndTTL(c *C) {
+	s.suite.ValueAndTTL(c)
+}
+
+// TestOpaqueOnDisk verifies that a value written through the encrypted
+// backend is not readable in the clear from the wrapped backend directly.
+func (s *EncryptedSuite) TestOpaqueOnDisk(c *C) {
+	bucket := []string{"secrets"}
+	c.Assert(s.bk.CreateVal(bucket, "key", []byte("top-secret"), backend.Forever), IsNil)
+
+	raw, err := s.inner.GetVal(bucket, "key")
+	c.Assert(err, IsNil)
+	c.Assert(string(raw), Not(Equals), "top-secret")
+
+	val, err := s.bk.GetVal(bucket, "key")
+	c.Assert(err, IsNil)
+	c.Assert(string(val), Equals, "top-secret")
+}
+
+// TestTransparentMigration verifies that a plaintext value written before
+// encryption was turned on is still readable, and is rewritten encrypted
+// as soon as anything upserts it.
+func (s *EncryptedSuite) TestTransparentMigration(c *C) {
+	bucket := []string{"legacy"}
+	c.Assert(s.inner.CreateVal(bucket, "key", []byte("pre-existing"), backend.Forever), IsNil)
+
+	val, err := s.bk.GetVal(bucket, "key")
+	c.Assert(err, IsNil)
+	c.Assert(string(val), Equals, "pre-existing")
+
+	c.Assert(s.bk.UpsertVal(bucket, "key", []byte("pre-existing"), backend.Forever), IsNil)
+
+	raw, err := s.inner.GetVal(bucket, "key")
+	c.Assert(err, IsNil)
+	c.Assert(string(raw), Not(Equals), "pre-existing")
+}
+
+// TestWrongKey verifies that data encrypted with one key cannot be read
+// back with another.
+func (s *EncryptedSuite) TestWrongKey(c *C) {
+	bucket := []string{"secrets"}
+	c.Assert(s.bk.CreateVal(bucket, "key", []byte("top-secret"), backend.Forever), IsNil)
+
+	otherBk, err := New(s.inner, writeKeyFile(c, 1))
+	c.Assert(err, IsNil)
+
+	_, err = otherBk.GetVal(bucket, "key")
+	c.Assert(err, NotNil)
+}
+
+func writeKeyFile(c *C, seed byte) string {
+	path := filepath.Join(c.MkDir(), "key")
+	key := make([]byte, keySize)
+	for i := range key {
+		key[i] = byte(i) + seed
+	}
+	c.Assert(ioutil.WriteFile(path, key, 0600), IsNil)
+	return path
+}
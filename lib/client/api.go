@@ -20,6 +20,7 @@ package client
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -47,6 +48,7 @@ import (
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/state"
 	"github.com/gravitational/teleport/lib/utils"
+	proxyutils "github.com/gravitational/teleport/lib/utils/proxy"
 
 	"github.com/gravitational/trace"
 	"github.com/moby/moby/pkg/term"
@@ -119,6 +121,12 @@ type Config struct {
 	// InsecureSkipVerify is an option to skip HTTPS cert check
 	InsecureSkipVerify bool
 
+	// TLSRoutingEnabled tunnels the SSH protocol inside a TLS connection to
+	// the proxy's HTTPS port (ALPN-negotiated) instead of dialing its SSH
+	// port directly, so tsh can reach the cluster from networks that only
+	// allow outbound HTTPS.
+	TLSRoutingEnabled bool
+
 	// SkipLocalAuth tells the client to use AuthMethods parameter for authentication and NOT
 	// use its own SSH agent or ask user for passwords. This is used by external programs linking
 	// against Teleport client and obtaining credentials from elsewhere.
@@ -157,6 +165,20 @@ type Config struct {
 	// if empty, they'll go to ~/.tsh
 	KeysDir string
 
+	// KeyStoreType selects where private keys and certificates are stored.
+	// Only KeyStoreFile (default) is implemented in this build; KeyStorePIV
+	// and KeyStoreOSKeychain are reserved names that NewLocalKeyStore
+	// currently rejects. See NewLocalKeyStore.
+	KeyStoreType string
+
+	// LocalRecordSession tells tsh to locally capture the output of an
+	// interactive session and upload it to the auth server once the session
+	// ends, in addition to (or instead of) whatever recording the node or
+	// proxy might already be doing. This is for agentless/plain OpenSSH
+	// hosts and clusters where proxy recording isn't enabled, where nothing
+	// on the server side ever sees the session.
+	LocalRecordSession bool
+
 	// Env is a map of environmnent variables to send when opening session
 	Env map[string]string
 
@@ -213,6 +235,8 @@ func (c *Config) LoadProfile(profileDir string, proxyName string) error {
 	c.SetProxy(cp.ProxyHost, cp.ProxyWebPort, cp.ProxySSHPort)
 	c.Username = cp.Username
 	c.SiteName = cp.SiteName
+	c.KeyStoreType = cp.KeyStoreType
+	c.HostLogin = cp.HostLogin
 	c.LocalForwardPorts, err = ParsePortForwardSpec(cp.ForwardedPorts)
 	if err != nil {
 		log.Warnf("Error parsing user profile: %v", err)
@@ -236,6 +260,8 @@ func (c *Config) SaveProfile(profileDir string) error {
 	cp.ProxyWebPort = c.ProxyWebPort()
 	cp.ForwardedPorts = c.LocalForwardPorts.ToStringSpec()
 	cp.SiteName = c.SiteName
+	cp.KeyStoreType = c.KeyStoreType
+	cp.HostLogin = c.HostLogin
 
 	// create a profile file:
 	if err := cp.SaveTo(profilePath, ProfileMakeCurrent); err != nil {
@@ -335,13 +361,6 @@ func NewClient(c *Config) (tc *TeleportClient, err error) {
 	if c.ProxyHostPort == "" {
 		return nil, trace.Errorf("No proxy address specified, missed --proxy flag?")
 	}
-	if c.HostLogin == "" {
-		c.HostLogin, err = Username()
-		if err != nil {
-			return nil, trace.Wrap(err)
-		}
-		log.Infof("no host login given. defaulting to %s", c.HostLogin)
-	}
 	if c.KeyTTL == 0 {
 		c.KeyTTL = defaults.CertDuration
 	} else if c.KeyTTL > defaults.MaxCertDuration || c.KeyTTL < defaults.MinCertDuration {
@@ -369,7 +388,7 @@ func NewClient(c *Config) (tc *TeleportClient, err error) {
 		}
 	} else {
 		// initialize the local agent (auth agent which uses local SSH keys signed by the CA):
-		tc.localAgent, err = NewLocalAgent(c.KeysDir, c.Username)
+		tc.localAgent, err = NewLocalAgent(c.KeysDir, c.Username, c.KeyStoreType)
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -378,9 +397,52 @@ func NewClient(c *Config) (tc *TeleportClient, err error) {
 		}
 	}
 
+	if tc.Config.HostLogin == "" {
+		// no --login, no "user@host" and no default saved in the profile:
+		// if this cluster already granted us a certificate with exactly one
+		// allowed login, adopt it instead of guessing the OS username (which
+		// is frequently not a valid principal on the target host and just
+		// leads to a confusing "access denied").
+		if login, ok := tc.singleAllowedLogin(); ok {
+			tc.Config.HostLogin = login
+			log.Infof("no host login given. defaulting to %s, the only login this cluster granted", tc.Config.HostLogin)
+		} else {
+			tc.Config.HostLogin, err = Username()
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			log.Infof("no host login given. defaulting to %s", tc.Config.HostLogin)
+		}
+	}
+
 	return tc, nil
 }
 
+// singleAllowedLogin looks at the certificate this cluster already issued us
+// (if any) and returns its one allowed login, if it has exactly one. It's
+// used to pick a sensible default host login without asking the user to
+// spell out "user@host" every time.
+func (tc *TeleportClient) singleAllowedLogin() (string, bool) {
+	if tc.localAgent == nil {
+		return "", false
+	}
+	keys, err := tc.localAgent.GetKeys(tc.Username)
+	if err != nil {
+		return "", false
+	}
+	for _, key := range keys {
+		if key.ProxyHost != tc.Config.ProxyHost() {
+			continue
+		}
+		principals, err := key.Principals()
+		if err != nil || len(principals) != 1 {
+			return "", false
+		}
+		return principals[0], true
+	}
+	return "", false
+}
+
 // accessPoint returns access point based on the cache policy
 func (tc *TeleportClient) accessPoint(clt auth.AccessPoint, proxyHostPort string, clusterName string) (auth.AccessPoint, error) {
 	if tc.CachePolicy == nil {
@@ -474,6 +536,29 @@ func (tc *TeleportClient) SSH(ctx context.Context, command []string, runLocally
 		nodeAddrs[0]+"@"+tc.Namespace+"@"+siteInfo.Name,
 		tc.Config.HostLogin,
 		false)
+	if err != nil && utils.IsCertExpiredError(err) {
+		// the node checked our certificate independently of the proxy and
+		// rejected it as expired. Re-run the login flow (SSO redirect,
+		// password/OTP prompt -- whatever this cluster uses, same as a
+		// fresh "tsh login") and retry the connection once with the newly
+		// issued certificate, instead of failing the whole command with a
+		// cryptic handshake error.
+		fmt.Println("Your Teleport session has expired, refreshing credentials...")
+		if _, loginErr := tc.Login(true); loginErr != nil {
+			return trace.Wrap(err)
+		}
+		freshProxyClient, proxyErr := tc.ConnectToProxy()
+		if proxyErr != nil {
+			return trace.Wrap(proxyErr)
+		}
+		defer freshProxyClient.Close()
+		proxyClient = freshProxyClient
+		nodeClient, err = proxyClient.ConnectToNode(
+			ctx,
+			nodeAddrs[0]+"@"+tc.Namespace+"@"+siteInfo.Name,
+			tc.Config.HostLogin,
+			false)
+	}
 	if err != nil {
 		tc.ExitStatus = 1
 		return trace.Wrap(err)
@@ -814,6 +899,67 @@ func (tc *TeleportClient) ListNodes(ctx context.Context) ([]services.Server, err
 	return proxyClient.FindServersByLabels(ctx, tc.Namespace, tc.Labels)
 }
 
+// DialHostViaProxy opens a raw, un-authenticated byte stream to the SSH port
+// of the node identified by tc.Host/tc.HostPort, tunneled through the proxy.
+// It's the plumbing behind "tsh proxy ssh": a native SSH client (with its own
+// key) can be pointed at the returned conn to authenticate directly against
+// the destination node, using only the Teleport proxy connection (already
+// authenticated with the caller's Teleport certs) as the transport.
+func (tc *TeleportClient) DialHostViaProxy(ctx context.Context) (net.Conn, error) {
+	if !tc.Config.ProxySpecified() {
+		return nil, trace.BadParameter("proxy server is not specified")
+	}
+	// if a "tsh daemon" is already holding an authenticated connection open
+	// for this proxy, reuse it instead of paying for a fresh proxy handshake
+	// (and, on SSO-backed clusters, a fresh login prompt). Only the default
+	// namespace/cluster is supported over the control socket; anything else
+	// falls through to a direct connection below.
+	if len(tc.Labels) == 0 && tc.Namespace == defaults.Namespace && tc.SiteName == "" {
+		nodeAddress := net.JoinHostPort(tc.Host, strconv.Itoa(tc.HostPort))
+		if conn, err := DialViaControlDaemon(ctx, ControlPath(tc.Config.ProxySSHHostPort()), nodeAddress); err == nil {
+			return conn, nil
+		}
+	}
+	proxyClient, err := tc.ConnectToProxy()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	siteInfo, err := proxyClient.currentCluster()
+	if err != nil {
+		proxyClient.Close()
+		return nil, trace.Wrap(err)
+	}
+	nodeAddrs, err := tc.getTargetNodes(ctx, proxyClient)
+	if err != nil {
+		proxyClient.Close()
+		return nil, trace.Wrap(err)
+	}
+	if len(nodeAddrs) != 1 {
+		proxyClient.Close()
+		return nil, trace.BadParameter("a single target host is required")
+	}
+	conn, err := proxyClient.DialHost(nodeAddrs[0] + "@" + tc.Namespace + "@" + siteInfo.Name)
+	if err != nil {
+		proxyClient.Close()
+		return nil, trace.Wrap(err)
+	}
+	return &proxyClientConn{Conn: conn, proxyClient: proxyClient}, nil
+}
+
+// proxyClientConn wraps a net.Conn tunneled through a ProxyClient so that
+// closing the connection also releases the underlying proxy SSH client,
+// mirroring how NodeClient.Close releases its Proxy.
+type proxyClientConn struct {
+	net.Conn
+	proxyClient *ProxyClient
+}
+
+func (c *proxyClientConn) Close() error {
+	err := c.Conn.Close()
+	c.proxyClient.Close()
+	return err
+}
+
 // runCommand executes a given bash command on a bunch of remote nodes
 func (tc *TeleportClient) runCommand(
 	ctx context.Context, siteName string, nodeAddresses []string, proxyClient *ProxyClient, command []string) error {
@@ -874,11 +1020,21 @@ func (tc *TeleportClient) runCommand(
 // runShell starts an interactive SSH session/shell.
 // sessionID : when empty, creates a new shell. otherwise it tries to join the existing session.
 func (tc *TeleportClient) runShell(nodeClient *NodeClient, sessToJoin *session.Session) error {
-	nodeSession, err := newSession(nodeClient, sessToJoin, tc.Env, tc.Stdin, tc.Stdout, tc.Stderr)
+	stdout := tc.Stdout
+	var recorder *LocalSessionRecorder
+	if tc.Config.LocalRecordSession {
+		recorder = NewLocalSessionRecorder()
+		stdout = io.MultiWriter(tc.Stdout, recorder)
+	}
+	nodeSession, err := newSession(nodeClient, sessToJoin, tc.Env, tc.Stdin, stdout, tc.Stderr)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	if err = nodeSession.runShell(tc.OnShellCreated); err != nil {
+	err = nodeSession.runShell(tc.OnShellCreated)
+	if recorder != nil {
+		tc.uploadLocalRecording(recorder, nodeClient)
+	}
+	if err != nil {
 		return trace.Wrap(err)
 	}
 	if nodeSession.ExitMsg == "" {
@@ -889,6 +1045,24 @@ func (tc *TeleportClient) runShell(nodeClient *NodeClient, sessToJoin *session.S
 	return nil
 }
 
+// uploadLocalRecording uploads a locally-captured session recording to the
+// auth server. It's best-effort: the interactive session the user was
+// watching has already finished by the time this runs, so a failure here is
+// reported but doesn't turn an otherwise successful "tsh ssh" into an error.
+func (tc *TeleportClient) uploadLocalRecording(recorder *LocalSessionRecorder, nodeClient *NodeClient) {
+	alog, err := nodeClient.Proxy.ConnectToSite(context.TODO(), true)
+	if err != nil {
+		fmt.Fprintf(tc.Stderr, "warning: could not upload local session recording: %v\n", err)
+		return
+	}
+	sid, err := recorder.Upload(alog, tc.Namespace, tc.Config.HostLogin)
+	if err != nil {
+		fmt.Fprintf(tc.Stderr, "warning: could not upload local session recording: %v\n", err)
+		return
+	}
+	fmt.Fprintf(tc.Stdout, "Session recording uploaded, replay with: tsh play %v\n", sid)
+}
+
 // getProxyLogin determines which SSH principal to use when connecting to proxy.
 func (tc *TeleportClient) getProxySSHPrincipal() string {
 	proxyPrincipal := tc.Config.HostLogin
@@ -919,12 +1093,60 @@ func (tc *TeleportClient) authMethods() []ssh.AuthMethod {
 	return m
 }
 
+// sshDial is like ssh.Dial, but takes the already-established net.Conn from
+// dial instead of dialing "tcp" itself, so callers can tunnel the SSH
+// protocol over something other than a raw TCP connection (e.g. TLS).
+func sshDial(dial func() (net.Conn, error), addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
 // ConnectToProxy dials the proxy server and returns ProxyClient if successful
 func (tc *TeleportClient) ConnectToProxy() (*ProxyClient, error) {
 	var err error
 
 	proxyPrincipal := tc.getProxySSHPrincipal()
 	proxyAddr := tc.Config.ProxySSHHostPort()
+	// proxyutils.DialProxyFromEnvironment routes through a corporate HTTP
+	// CONNECT or SOCKS5 proxy configured via http_proxy/https_proxy/
+	// all_proxy, if one is set, or dials directly otherwise
+	dialProxy := func() (net.Conn, error) {
+		return proxyutils.DialProxyFromEnvironment(proxyAddr)
+	}
+	if tc.Config.TLSRoutingEnabled {
+		// tunnel the SSH protocol inside a TLS connection to the proxy's
+		// HTTPS port instead, negotiated via ALPN, for networks that only
+		// allow outbound HTTPS
+		proxyAddr = tc.Config.ProxyWebHostPort()
+		dialProxy = func() (net.Conn, error) {
+			conn, err := proxyutils.DialProxyFromEnvironment(proxyAddr)
+			if err != nil {
+				return nil, err
+			}
+			host, _, err := net.SplitHostPort(proxyAddr)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			tlsConn := tls.Client(conn, &tls.Config{
+				ServerName:         host,
+				NextProtos:         []string{utils.ALPNAuthProtocol},
+				InsecureSkipVerify: tc.Config.InsecureSkipVerify,
+			})
+			if err := tlsConn.Handshake(); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		}
+	}
 	sshConfig := &ssh.ClientConfig{
 		User:            proxyPrincipal,
 		HostKeyCallback: tc.HostKeyCallback,
@@ -951,7 +1173,7 @@ func (tc *TeleportClient) ConnectToProxy() (*ProxyClient, error) {
 		var sshClient *ssh.Client
 
 		sshConfig.Auth = []ssh.AuthMethod{m}
-		sshClient, err = ssh.Dial("tcp", proxyAddr, sshConfig)
+		sshClient, err = sshDial(dialProxy, proxyAddr, sshConfig)
 		if err != nil {
 			if utils.IsHandshakeFailedError(err) {
 				log.Warn(err)
@@ -990,7 +1212,7 @@ func (tc *TeleportClient) ConnectToProxy() (*ProxyClient, error) {
 	// and greatest auth information, try it now
 	sshConfig.Auth = []ssh.AuthMethod{authMethod}
 	sshConfig.User = proxyPrincipal
-	sshClient, err := ssh.Dial("tcp", proxyAddr, sshConfig)
+	sshClient, err := sshDial(dialProxy, proxyAddr, sshConfig)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -1024,6 +1246,9 @@ func (tc *TeleportClient) Login(activateKey bool) (*Key, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if pr.MessageOfTheDay != "" {
+		fmt.Println(pr.MessageOfTheDay)
+	}
 
 	// generate a new keypair. the public key will be signed via proxy if our
 	// password+OTP are legit
@@ -1074,10 +1299,44 @@ func (tc *TeleportClient) Login(activateKey bool) (*Key, error) {
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+
+		// pre-trust any operator-registered OpenSSH host keys, so tsh
+		// doesn't prompt for hosts that are mid-migration to Teleport host
+		// certs. Best-effort: an older cluster without this API, or a
+		// transient failure, shouldn't block login.
+		tc.cacheTrustedHostKeys()
 	}
 	return key, nil
 }
 
+// cacheTrustedHostKeys fetches the cluster's operator-registered trusted
+// host keys and pre-trusts them in the local keystore. See
+// LocalKeyAgent.AddTrustedHostKeys.
+func (tc *TeleportClient) cacheTrustedHostKeys() {
+	proxyClient, err := tc.ConnectToProxy()
+	if err != nil {
+		log.Debugf("failed fetching trusted host keys: %v", err)
+		return
+	}
+	defer proxyClient.Close()
+
+	site, err := proxyClient.ConnectToSite(context.TODO(), false)
+	if err != nil {
+		log.Debugf("failed fetching trusted host keys: %v", err)
+		return
+	}
+
+	hostKeys, err := site.GetTrustedHostKeys()
+	if err != nil {
+		log.Debugf("failed fetching trusted host keys: %v", err)
+		return
+	}
+
+	if err := tc.localAgent.AddTrustedHostKeys(hostKeys); err != nil {
+		log.Warningf("failed caching trusted host keys: %v", err)
+	}
+}
+
 func (tc *TeleportClient) localLogin(secondFactor string, pub []byte) (*SSHLoginResponse, error) {
 	var err error
 	var response *SSHLoginResponse
@@ -1395,7 +1654,7 @@ func ParsePortForwardSpec(spec []string) (ports ForwardedPorts, err error) {
 	ports = make([]ForwardedPort, len(spec), len(spec))
 
 	for i, str := range spec {
-		parts := strings.Split(str, ":")
+		parts := splitHostPortSpec(str)
 		if len(parts) < 3 || len(parts) > 4 {
 			return nil, fmt.Errorf(errTemplate, str)
 		}
@@ -1403,12 +1662,12 @@ func ParsePortForwardSpec(spec []string) (ports ForwardedPorts, err error) {
 			parts = append([]string{"127.0.0.1"}, parts...)
 		}
 		p := &ports[i]
-		p.SrcIP = parts[0]
+		p.SrcIP = strings.Trim(parts[0], "[]")
 		p.SrcPort, err = strconv.Atoi(parts[1])
 		if err != nil {
 			return nil, fmt.Errorf(errTemplate, str)
 		}
-		p.DestHost = parts[2]
+		p.DestHost = strings.Trim(parts[2], "[]")
 		p.DestPort, err = strconv.Atoi(parts[3])
 		if err != nil {
 			return nil, fmt.Errorf(errTemplate, str)
@@ -1416,3 +1675,29 @@ func ParsePortForwardSpec(spec []string) (ports ForwardedPorts, err error) {
 	}
 	return ports, nil
 }
+
+// splitHostPortSpec splits an OpenSSH "-L"-style forwarding spec on ':',
+// treating a bracketed IPv6 literal like "[::1]" as a single field so its
+// own colons aren't mistaken for field separators.
+func splitHostPortSpec(spec string) []string {
+	var parts []string
+	var field strings.Builder
+	depth := 0
+	for _, r := range spec {
+		switch {
+		case r == '[':
+			depth++
+			field.WriteRune(r)
+		case r == ']':
+			depth--
+			field.WriteRune(r)
+		case r == ':' && depth == 0:
+			parts = append(parts, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+	parts = append(parts, field.String())
+	return parts
+}
@@ -156,4 +156,90 @@ func (s *APITestSuite) TestPortsParsing(c *check.C) {
 	ports, err = ParsePortForwardSpec(spec)
 	c.Assert(ports, check.IsNil)
 	c.Assert(err, check.ErrorMatches, "^Invalid port forwarding spec: .foo.*")
+
+	// IPv6 literals: their own colons must not be mistaken for field
+	// separators
+	spec = []string{
+		"[::1]:80:remote.host:180",
+		"8080:[2001:db8::1]:443",
+	}
+	ports, err = ParsePortForwardSpec(spec)
+	c.Assert(err, check.IsNil)
+	c.Assert(ports, check.DeepEquals, ForwardedPorts{
+		{
+			SrcIP:    "::1",
+			SrcPort:  80,
+			DestHost: "remote.host",
+			DestPort: 180,
+		},
+		{
+			SrcIP:    "127.0.0.1",
+			SrcPort:  8080,
+			DestHost: "2001:db8::1",
+			DestPort: 443,
+		},
+	})
+	// ToString omits a loopback source IP (::1 included), same as it does
+	// for 127.0.0.1
+	clone = ports.ToStringSpec()
+	c.Assert(clone[0], check.Equals, "80:remote.host:180")
+	c.Assert(clone[1], check.Equals, spec[1])
+}
+
+func (s *APITestSuite) TestProxyTemplates(c *check.C) {
+	tpl := ProxyTemplate{
+		Template: `(?P<host>[^.]+)\.prod`,
+		Query:    `host=$host`,
+		Cluster:  "prod",
+	}
+	c.Assert(tpl.CheckAndSetDefaults(), check.IsNil)
+
+	newHost, query, cluster, matched := tpl.Apply("db1.prod")
+	c.Assert(matched, check.Equals, true)
+	c.Assert(newHost, check.Equals, "db1.prod")
+	c.Assert(query, check.Equals, "host=db1")
+	c.Assert(cluster, check.Equals, "prod")
+
+	_, _, _, matched = tpl.Apply("db1.staging")
+	c.Assert(matched, check.Equals, false)
+
+	// missing template
+	bad := ProxyTemplate{Cluster: "prod"}
+	c.Assert(bad.CheckAndSetDefaults(), check.NotNil)
+
+	// no target specified
+	bad = ProxyTemplate{Template: "foo"}
+	c.Assert(bad.CheckAndSetDefaults(), check.NotNil)
+}
+
+func (s *APITestSuite) TestTSHConfig(c *check.C) {
+	config := TSHConfig{
+		ProxyTemplates: []ProxyTemplate{
+			{
+				Template: `(?P<host>[^.]+)\.prod`,
+				Query:    `host=$host`,
+				Cluster:  "prod",
+			},
+		},
+	}
+	c.Assert(config.CheckAndSetDefaults(), check.IsNil)
+
+	newHost, query, cluster, matched := config.ApplyProxyTemplates("db1.prod")
+	c.Assert(matched, check.Equals, true)
+	c.Assert(newHost, check.Equals, "db1.prod")
+	c.Assert(query, check.Equals, "host=db1")
+	c.Assert(cluster, check.Equals, "prod")
+
+	newHost, query, cluster, matched = config.ApplyProxyTemplates("db1.staging")
+	c.Assert(matched, check.Equals, false)
+	c.Assert(newHost, check.Equals, "db1.staging")
+	c.Assert(query, check.Equals, "")
+	c.Assert(cluster, check.Equals, "")
+}
+
+func (s *APITestSuite) TestLoadTSHConfig(c *check.C) {
+	// missing file isn't an error
+	config, err := LoadTSHConfig("/no/such/file.yaml")
+	c.Assert(err, check.IsNil)
+	c.Assert(config.ProxyTemplates, check.HasLen, 0)
 }
@@ -209,10 +209,13 @@ func nodeName(node string) string {
 	return n
 }
 
-// ConnectToNode connects to the ssh server via Proxy.
-// It returns connected and authenticated NodeClient
-func (proxy *ProxyClient) ConnectToNode(ctx context.Context, nodeAddress string, user string, quiet bool) (*NodeClient, error) {
-	log.Infof("[CLIENT] client=%v connecting to node=%s", proxy.clientAddr, nodeAddress)
+// DialHost opens the proxy's "proxy:" subsystem to nodeAddress and returns
+// the raw, un-authenticated byte stream to the destination host's SSH port.
+// It's the same tunnel ConnectToNode upgrades into an ssh.Client; DialHost
+// hands back the tunnel itself for callers (like "tsh proxy ssh") that want
+// to let some other SSH client authenticate over it directly.
+func (proxy *ProxyClient) DialHost(nodeAddress string) (net.Conn, error) {
+	log.Infof("[CLIENT] client=%v dialing node=%s", proxy.clientAddr, nodeAddress)
 
 	// parse destination first:
 	localAddr, err := utils.ParseAddr("tcp://" + proxy.proxyAddress)
@@ -257,13 +260,22 @@ func (proxy *ProxyClient) ConnectToNode(ctx context.Context, nodeAddress string,
 		return nil, trace.ConnectionProblem(err, "failed connecting to node %v. %s",
 			nodeName(strings.Split(nodeAddress, "@")[0]), serverErrorMsg)
 	}
-	pipeNetConn := utils.NewPipeNetConn(
+	return utils.NewPipeNetConn(
 		proxyReader,
 		proxyWriter,
 		proxySession,
 		localAddr,
 		fakeAddr,
-	)
+	), nil
+}
+
+// ConnectToNode connects to the ssh server via Proxy.
+// It returns connected and authenticated NodeClient
+func (proxy *ProxyClient) ConnectToNode(ctx context.Context, nodeAddress string, user string, quiet bool) (*NodeClient, error) {
+	pipeNetConn, err := proxy.DialHost(nodeAddress)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	sshConfig := &ssh.ClientConfig{
 		User:            user,
 		Auth:            []ssh.AuthMethod{proxy.authMethod},
@@ -271,8 +283,12 @@ func (proxy *ProxyClient) ConnectToNode(ctx context.Context, nodeAddress string,
 	}
 	conn, chans, reqs, err := newClientConn(ctx, pipeNetConn, nodeAddress, sshConfig)
 	if err != nil {
+		if utils.IsCertExpiredError(err) {
+			pipeNetConn.Close()
+			return nil, trace.Wrap(err)
+		}
 		if utils.IsHandshakeFailedError(err) {
-			proxySession.Close()
+			pipeNetConn.Close()
 			parts := strings.Split(nodeAddress, "@")
 			hostname := parts[0]
 			if len(hostname) == 0 && len(parts) > 1 {
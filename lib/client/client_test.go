@@ -20,6 +20,8 @@ package client
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/gravitational/teleport/lib/sshutils"
 
@@ -37,6 +39,16 @@ func (s *ClientTestSuite) TestHelperFunctions(c *check.C) {
 	c.Assert(nodeName("one:22"), check.Equals, "one")
 }
 
+func (s *ClientTestSuite) TestControlPath(c *check.C) {
+	// each proxy gets its own control socket, and the path must not contain
+	// characters a shell/filesystem would treat specially, like the colon in
+	// "host:port".
+	p1 := ControlPath("proxy.example.com:3023")
+	p2 := ControlPath("other.example.com:3023")
+	c.Assert(p1, check.Not(check.Equals), p2)
+	c.Assert(strings.Contains(filepath.Base(p1), ":"), check.Equals, false)
+}
+
 func (s *ClientTestSuite) SetUpSuite(c *check.C) {
 	// create the client:
 	client, err := NewClient(&Config{
@@ -0,0 +1,170 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ControlDaemon holds a single, long-lived connection to a proxy and shares
+// it with other tsh invocations over a local Unix socket, the same way
+// OpenSSH's ControlMaster/ControlPath reuse one authenticated connection
+// across many "ssh" invocations. It's what backs "tsh daemon".
+type ControlDaemon struct {
+	tc          *TeleportClient
+	proxyClient *ProxyClient
+	listener    net.Listener
+}
+
+// ControlPath returns the default path of the control socket for the given
+// proxy address, e.g. ~/.tsh/proxy.example.com_3080-control.sock. Each proxy
+// gets its own socket since the daemon's cached connection is proxy-specific.
+func ControlPath(proxyHostPort string) string {
+	sanitized := strings.NewReplacer(":", "_", "/", "_").Replace(proxyHostPort)
+	return filepath.Join(FullProfilePath(""), sanitized+"-control.sock")
+}
+
+// NewControlDaemon creates a ControlDaemon that will serve tunnels using tc's
+// already-configured proxy address and credentials.
+func NewControlDaemon(tc *TeleportClient) *ControlDaemon {
+	return &ControlDaemon{tc: tc}
+}
+
+// Listen opens the control socket. It removes a stale socket file left
+// behind by a daemon that didn't shut down cleanly before binding.
+func (d *ControlDaemon) Listen(socketPath string) error {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return trace.Wrap(err)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	d.listener = listener
+	return nil
+}
+
+// Addr returns the address of the control socket once Listen has succeeded.
+func (d *ControlDaemon) Addr() net.Addr {
+	return d.listener.Addr()
+}
+
+// Serve connects to the proxy once, then accepts client connections and
+// services them until the listener is closed. Each connection requests
+// exactly one tunnel: it sends a single "DIAL <nodeAddress>\n" line and, on
+// success, the raw bytes that follow are forwarded to/from the target host
+// through the daemon's single, shared proxy connection.
+func (d *ControlDaemon) Serve() error {
+	proxyClient, err := d.tc.ConnectToProxy()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	d.proxyClient = proxyClient
+	defer proxyClient.Close()
+
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			if strings.Contains(err.Error(), "use of closed network connection") {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		go d.handleConn(conn)
+	}
+}
+
+// Close shuts down the control socket.
+func (d *ControlDaemon) Close() error {
+	return d.listener.Close()
+}
+
+func (d *ControlDaemon) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		log.Errorf("tsh daemon: failed reading tunnel request: %v", err)
+		return
+	}
+	nodeAddress := strings.TrimSpace(strings.TrimPrefix(line, "DIAL "))
+	if nodeAddress == "" || !strings.HasPrefix(line, "DIAL ") {
+		fmt.Fprintf(conn, "ERR malformed request\n")
+		return
+	}
+
+	remote, err := d.proxyClient.DialHost(nodeAddress)
+	if err != nil {
+		fmt.Fprintf(conn, "ERR %v\n", err)
+		return
+	}
+	defer remote.Close()
+
+	if _, err := fmt.Fprintf(conn, "OK\n"); err != nil {
+		return
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(remote, reader)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(conn, remote)
+		errCh <- err
+	}()
+	<-errCh
+}
+
+// DialViaControlDaemon requests a tunnel from a running "tsh daemon" over its
+// control socket instead of opening a fresh, freshly-authenticated
+// connection to the proxy. It returns trace.ConnectionProblem if no daemon
+// is listening on socketPath, so callers can fall back to dialing directly.
+func DialViaControlDaemon(ctx context.Context, socketPath, nodeAddress string) (net.Conn, error) {
+	conn, err := new(net.Dialer).DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, trace.ConnectionProblem(err, "no control daemon listening on %v", socketPath)
+	}
+	if _, err := fmt.Fprintf(conn, "DIAL %v\n", nodeAddress); err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+	reader := bufio.NewReader(conn)
+	status, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+	status = strings.TrimSpace(status)
+	if status != "OK" {
+		conn.Close()
+		return nil, trace.Errorf("control daemon: %v", status)
+	}
+	return conn, nil
+}
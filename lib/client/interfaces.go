@@ -108,6 +108,20 @@ func (k *Key) CertValidBefore() (t time.Time, err error) {
 	return time.Unix(int64(cert.ValidBefore), 0), nil
 }
 
+// Principals returns the list of logins (SSH principals) this key's
+// certificate is valid for, e.g. the "logins" trait a role granted the user.
+func (k *Key) Principals() ([]string, error) {
+	pcert, _, _, _, err := ssh.ParseAuthorizedKey(k.Cert)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cert, ok := pcert.(*ssh.Certificate)
+	if !ok {
+		return nil, trace.Errorf("not supported certificate type")
+	}
+	return cert.ValidPrincipals, nil
+}
+
 // AsAuthMethod returns an "auth method" interface, a common abstraction
 // used by Golang SSH library. This is how you actually use a Key to feed
 // it into the SSH lib.
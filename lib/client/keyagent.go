@@ -45,10 +45,11 @@ type LocalKeyAgent struct {
 	hostPromptFunc func(host string, k ssh.PublicKey) error
 }
 
-// NewLocalAgent reads all Teleport certificates from disk (using FSLocalKeyStore),
-// creates a LocalKeyAgent, loads all certificates into it, and returns the agent.
-func NewLocalAgent(keyDir, username string) (a *LocalKeyAgent, err error) {
-	keystore, err := NewFSLocalKeyStore(keyDir)
+// NewLocalAgent reads all Teleport certificates from disk (using the key
+// store selected by keyStoreType, KeyStoreFile by default), creates a
+// LocalKeyAgent, loads all certificates into it, and returns the agent.
+func NewLocalAgent(keyDir, username, keyStoreType string) (a *LocalKeyAgent, err error) {
+	keystore, err := NewLocalKeyStore(keyStoreType, keyDir)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -207,6 +208,29 @@ func (a *LocalKeyAgent) AddHostSignersToCache(hostSigners []services.CertAuthori
 	return nil
 }
 
+// AddTrustedHostKeys pre-trusts the given operator-vouched-for OpenSSH host
+// keys, so tsh never prompts for them even though they weren't issued by a
+// Teleport CA. This is meant for migrations: a node's raw sshd host key can
+// be registered as trusted while the fleet is converted to Teleport host
+// certs gradually.
+func (a *LocalKeyAgent) AddTrustedHostKeys(hostKeys []services.TrustedHostKey) error {
+	for _, hostKey := range hostKeys {
+		var publicKeys []ssh.PublicKey
+		for _, authorizedKey := range hostKey.AuthorizedKeys {
+			publicKey, _, _, _, err := ssh.ParseAuthorizedKey(authorizedKey)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			publicKeys = append(publicKeys, publicKey)
+		}
+		log.Debugf("[KEY AGENT] adding trusted host key for %s", hostKey.Hostname)
+		if err := a.keyStore.AddKnownHostKeys(hostKey.Hostname, publicKeys); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 // UserRefusedHosts returns 'true' if a user refuses connecting to remote hosts
 // when prompted during host authorization
 func (a *LocalKeyAgent) UserRefusedHosts() bool {
@@ -88,7 +88,7 @@ func (s *KeyAgentTestSuite) SetUpTest(c *check.C) {
 //     a teleport key with the teleport username.
 func (s *KeyAgentTestSuite) TestAddKey(c *check.C) {
 	// make a new local agent
-	lka, err := NewLocalAgent(s.keyDir, s.username)
+	lka, err := NewLocalAgent(s.keyDir, s.username, KeyStoreFile)
 	c.Assert(err, check.IsNil)
 
 	// add the key to the local agent, this should write the key
@@ -147,7 +147,7 @@ func (s *KeyAgentTestSuite) TestLoadKey(c *check.C) {
 	userdata := []byte("hello, world")
 
 	// make a new local agent
-	lka, err := NewLocalAgent(s.keyDir, s.username)
+	lka, err := NewLocalAgent(s.keyDir, s.username, KeyStoreFile)
 	c.Assert(err, check.IsNil)
 
 	// unload any keys that might be in the agent for this user
@@ -205,7 +205,7 @@ func (s *KeyAgentTestSuite) TestLoadKey(c *check.C) {
 
 func (s *KeyAgentTestSuite) TestHostVerification(c *check.C) {
 	// make a new local agent
-	lka, err := NewLocalAgent(s.keyDir, s.username)
+	lka, err := NewLocalAgent(s.keyDir, s.username, KeyStoreFile)
 	c.Assert(err, check.IsNil)
 
 	// by default user has not refused any hosts:
@@ -52,11 +52,45 @@ const (
 	keyFilePerms os.FileMode = 0600
 )
 
+const (
+	// KeyStoreFile stores keys and certificates as plaintext files under
+	// ~/.tsh, using FSLocalKeyStore. This is the default.
+	KeyStoreFile = "file"
+
+	// KeyStorePIV stores keys on a hardware token (e.g. a YubiKey) in a PIV
+	// slot, protected by the token's touch/PIN policy.
+	KeyStorePIV = "piv"
+
+	// KeyStoreOSKeychain stores keys in the operating system's credential
+	// store (macOS Keychain, Windows Credential Manager, etc).
+	KeyStoreOSKeychain = "keychain"
+)
+
+// NewLocalKeyStore returns the LocalKeyStore backend requested by storeType.
+// Only "file" (the default) is implemented in this build, backed by
+// FSLocalKeyStore; "piv" and "keychain" are reserved names for hardware
+// token/OS-keychain-backed storage that has not been built yet (no PC/SC
+// (PIV smart card) library or platform keychain bindings are linked into
+// this build, and neither is available as a pure Go stdlib or
+// already-vendored package). Requesting them fails here, immediately and
+// by name, rather than constructing something that later errors on the
+// first key read/write -- callers should not treat "piv"/"keychain"
+// reaching this function as that feature being available.
+func NewLocalKeyStore(storeType, dirPath string) (LocalKeyStore, error) {
+	switch storeType {
+	case "", KeyStoreFile:
+		return NewFSLocalKeyStore(dirPath)
+	case KeyStorePIV, KeyStoreOSKeychain:
+		return nil, trace.BadParameter("key_store %q is not implemented in this build of tsh yet: "+
+			"no PIV/PC-SC or OS keychain backend is linked in, use %q (the default)", storeType, KeyStoreFile)
+	default:
+		return nil, trace.BadParameter("unsupported key_store %q, must be one of: file, piv, keychain", storeType)
+	}
+}
+
 // LocalKeyStore interface allows for different storage back-ends for TSH to
-// load/save its keys
-//
-// The _only_ filesystem-based implementation of LocalKeyStore is declared
-// below (FSLocalKeyStore)
+// load/save its keys. FSLocalKeyStore, below, is the filesystem-based
+// implementation; see NewLocalKeyStore for the others.
 type LocalKeyStore interface {
 	// client key management
 	GetKeys(username string) ([]Key, error)
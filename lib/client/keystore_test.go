@@ -126,6 +126,28 @@ func (s *KeyStoreTestSuite) TestKeyExpiration(c *check.C) {
 	c.Assert(keys[0].EqualsTo(good), check.Equals, true)
 }
 
+func (s *KeyStoreTestSuite) TestNewLocalKeyStore(c *check.C) {
+	// empty and "file" both select the on-disk store
+	for _, storeType := range []string{"", KeyStoreFile} {
+		store, err := NewLocalKeyStore(storeType, s.storeDir)
+		c.Assert(err, check.IsNil)
+		_, ok := store.(*FSLocalKeyStore)
+		c.Assert(ok, check.Equals, true)
+	}
+
+	// piv and keychain are reserved for a hardware-backed store this build
+	// doesn't implement yet; requesting them fails immediately.
+	for _, storeType := range []string{KeyStorePIV, KeyStoreOSKeychain} {
+		_, err := NewLocalKeyStore(storeType, s.storeDir)
+		c.Assert(err, check.NotNil)
+		c.Assert(trace.IsBadParameter(err), check.Equals, true)
+	}
+
+	// anything else is a bad parameter
+	_, err := NewLocalKeyStore("bogus", s.storeDir)
+	c.Assert(err, check.NotNil)
+}
+
 func (s *KeyStoreTestSuite) TestKnownHosts(c *check.C) {
 	os.MkdirAll(s.store.KeyDir, 0777)
 	pub, _, _, _, err := ssh.ParseAuthorizedKey(CAPub)
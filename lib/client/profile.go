@@ -50,6 +50,15 @@ type ClientProfile struct {
 	// SiteName is equivalient to --cluster argument
 	SiteName string `yaml:"cluster,omitempty"`
 
+	// HostLogin is the default remote host login (equivalent to --login),
+	// used when the user didn't specify one via "user@host" or --login.
+	HostLogin string `yaml:"host_login,omitempty"`
+
+	// KeyStoreType selects where this cluster's keys and certificates are
+	// stored. Only KeyStoreFile (default) is implemented in this build; see
+	// client.NewLocalKeyStore.
+	KeyStoreType string `yaml:"key_store,omitempty"`
+
 	//
 	// other stuff
 	//
@@ -133,8 +142,10 @@ func LogoutFromEverywhere(username string) error {
 		}
 		username = me.Username
 	}
-	// load all current keys:
-	agent, err := NewLocalAgent("", username)
+	// load all current keys. this only walks the on-disk session key store:
+	// hardware/keychain-backed clusters don't leave anything under ~/.tsh to
+	// log out of here.
+	agent, err := NewLocalAgent("", username, KeyStoreFile)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -0,0 +1,96 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+)
+
+// LocalSessionRecorder captures the output of a client-side SSH session as it
+// happens and, once the session ends, uploads it to the auth server. It's the
+// tsh-side counterpart of the recording the Teleport SSH service normally
+// does on the node: when the node is a plain OpenSSH host with no Teleport
+// service running on it (or proxy recording mode is off), nothing on the
+// server side ever sees the session, so tsh records what it already shows
+// the user and submits it afterwards, signed by the user's own certificate.
+type LocalSessionRecorder struct {
+	sid   session.ID
+	start time.Time
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// NewLocalSessionRecorder creates a recorder for a new session with a
+// freshly generated session ID.
+func NewLocalSessionRecorder() *LocalSessionRecorder {
+	return &LocalSessionRecorder{
+		sid:   session.NewID(),
+		start: time.Now(),
+	}
+}
+
+// Write implements io.Writer, so a LocalSessionRecorder can be plugged into
+// an io.MultiWriter alongside the client's usual terminal output.
+func (r *LocalSessionRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.Write(p)
+}
+
+// Upload submits the captured recording to alog (typically obtained via
+// ProxyClient.ConnectToSite) as a single session chunk framed with
+// session.start/session.end events, so it shows up next to server-recorded
+// sessions and can be replayed with "tsh play". It returns the ID the
+// session was recorded under.
+func (r *LocalSessionRecorder) Upload(alog events.IAuditLog, namespace, login string) (session.ID, error) {
+	r.mu.Lock()
+	data := make([]byte, r.buf.Len())
+	copy(data, r.buf.Bytes())
+	r.mu.Unlock()
+
+	fields := events.EventFields{
+		events.EventNamespace: namespace,
+		events.SessionEventID: string(r.sid),
+		events.EventLogin:     login,
+		events.EventUser:      login,
+	}
+	if err := alog.EmitAuditEvent(events.SessionStartEvent, fields); err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := alog.PostSessionSlice(events.SessionSlice{
+		Namespace: namespace,
+		SessionID: string(r.sid),
+		Chunks: []*events.SessionChunk{{
+			Data: data,
+			Time: r.start.UTC().UnixNano(),
+		}},
+	}); err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := alog.EmitAuditEvent(events.SessionEndEvent, fields); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return r.sid, nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/gravitational/teleport/lib/events"
+
+	"gopkg.in/check.v1"
+)
+
+type LocalSessionRecorderSuite struct{}
+
+var _ = check.Suite(&LocalSessionRecorderSuite{})
+
+func (s *LocalSessionRecorderSuite) TestUpload(c *check.C) {
+	recorder := NewLocalSessionRecorder()
+	_, err := recorder.Write([]byte("hello "))
+	c.Assert(err, check.IsNil)
+	_, err = recorder.Write([]byte("world"))
+	c.Assert(err, check.IsNil)
+
+	alog := events.NewMockAuditLog(10)
+	sid, err := recorder.Upload(alog, "default", "alice")
+	c.Assert(err, check.IsNil)
+	c.Assert(sid, check.Equals, recorder.sid)
+
+	select {
+	case slice := <-alog.SlicesC:
+		c.Assert(slice.Namespace, check.Equals, "default")
+		c.Assert(slice.SessionID, check.Equals, string(sid))
+		c.Assert(slice.Chunks, check.HasLen, 1)
+		c.Assert(string(slice.Chunks[0].Data), check.Equals, "hello world")
+	default:
+		c.Fatalf("expected a session slice to have been posted")
+	}
+}
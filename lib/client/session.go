@@ -355,6 +355,15 @@ func (ns *NodeSession) isTerminalAttached() bool {
 }
 
 // runShell executes user's shell on the remote node under an interactive session
+//
+// A session owner could, in principle, send an sshutils.ModerateSessionReq
+// over s.Session the same way the web terminal does from
+// terminalHandler.moderateSession. What's missing on this side is a trigger:
+// tsh has no escape-sequence handling (no "press ~C to open a command line"
+// the way OpenSSH does), so there's no interactive way for a CLI session
+// owner to invoke it. Wiring that up is left for whoever adds escape
+// sequences to tsh generally, rather than bolting a one-off control channel
+// onto this callback.
 func (ns *NodeSession) runShell(callback ShellCreatedCallback) error {
 	return ns.interactiveSession(func(s *ssh.Session, shell io.ReadWriteCloser) error {
 		// start the shell on the server:
@@ -0,0 +1,144 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/gravitational/trace"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TSHConfigPath is the path, relative to the profile directory, of an
+// optional YAML config file tsh reads on every invocation.
+const TSHConfigPath = "config/config.yaml"
+
+// ProxyTemplate maps a requested "[user@]host" argument to a node search
+// query and/or a leaf cluster, so users can keep muscle-memory hostnames
+// (e.g. "db1.prod") instead of remembering which cluster a host lives in
+// or typing out its full label query by hand.
+type ProxyTemplate struct {
+	// Template is a regexp matched against the requested host (implicitly
+	// anchored at both ends). Named capture groups, e.g.
+	// "(?P<host>[^.]+)\\.prod", can be referenced from Host, Query and
+	// Cluster using regexp.Expand's "$name" syntax.
+	Template string `yaml:"template"`
+	// Host, if set, replaces the requested host before connecting, with
+	// capture groups from Template expanded into it. Left unset, the
+	// requested host is used unchanged.
+	Host string `yaml:"host,omitempty"`
+	// Query, if set, is a label spec (the same "key=value" syntax
+	// ParseLabelSpec accepts) used to look up the target node by its
+	// labels instead of by hostname, with capture groups from Template
+	// expanded into it.
+	Query string `yaml:"query,omitempty"`
+	// Cluster, if set, names the leaf cluster the matched host belongs
+	// to, with capture groups from Template expanded into it.
+	Cluster string `yaml:"cluster,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// CheckAndSetDefaults compiles Template into an anchored regexp and
+// validates that at least one of Host, Query or Cluster was given.
+func (t *ProxyTemplate) CheckAndSetDefaults() error {
+	if t.Template == "" {
+		return trace.BadParameter("proxy template: missing template")
+	}
+	if t.Host == "" && t.Query == "" && t.Cluster == "" {
+		return trace.BadParameter("proxy template %q: at least one of host, query or cluster must be set", t.Template)
+	}
+	re, err := regexp.Compile("^" + t.Template + "$")
+	if err != nil {
+		return trace.BadParameter("proxy template %q: %v", t.Template, err)
+	}
+	t.re = re
+	return nil
+}
+
+// Apply matches host against the template and, on a match, expands Host,
+// Query and Cluster's capture-group references using the match.
+func (t *ProxyTemplate) Apply(host string) (newHost, query, cluster string, matched bool) {
+	match := t.re.FindStringSubmatchIndex(host)
+	if match == nil {
+		return "", "", "", false
+	}
+	expand := func(tpl string) string {
+		if tpl == "" {
+			return ""
+		}
+		return string(t.re.ExpandString(nil, tpl, host, match))
+	}
+	newHost = expand(t.Host)
+	if newHost == "" {
+		newHost = host
+	}
+	return newHost, expand(t.Query), expand(t.Cluster), true
+}
+
+// TSHConfig is the optional per-user tsh configuration file, loaded from
+// TSHConfigPath inside the profile directory.
+type TSHConfig struct {
+	// ProxyTemplates are tried, in order, against every "[user@]host"
+	// argument; the first one that matches wins.
+	ProxyTemplates []ProxyTemplate `yaml:"proxy_templates,omitempty"`
+}
+
+// CheckAndSetDefaults validates every proxy template.
+func (c *TSHConfig) CheckAndSetDefaults() error {
+	for i := range c.ProxyTemplates {
+		if err := c.ProxyTemplates[i].CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// ApplyProxyTemplates runs host through ProxyTemplates in order and
+// returns the first match, if any.
+func (c *TSHConfig) ApplyProxyTemplates(host string) (newHost, query, cluster string, matched bool) {
+	for _, t := range c.ProxyTemplates {
+		if newHost, query, cluster, matched = t.Apply(host); matched {
+			return
+		}
+	}
+	return host, "", "", false
+}
+
+// LoadTSHConfig reads and validates the tsh config file at path. A missing
+// file isn't an error: it returns an empty, zero-value TSHConfig.
+func LoadTSHConfig(path string) (*TSHConfig, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &TSHConfig{}, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	var config TSHConfig
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &config, nil
+}
@@ -31,6 +31,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gravitational/teleport/lib/httplib"
 	"github.com/gravitational/teleport/lib/services"
 
 	"github.com/gravitational/roundtrip"
@@ -57,6 +58,10 @@ type SSHLoginResponse struct {
 	// HostSigners is a list of signing host public keys
 	// trusted by proxy
 	HostSigners []services.CertAuthorityV1 `json:"host_signers"`
+	// TestFlowResult is set instead of Cert/HostSigners when the login was
+	// a `tctl sso test` dry run: it reports the claims/assertions and the
+	// role/trait mapping the connector produced.
+	TestFlowResult *services.SSOTestFlowResult `json:"test_flow_result,omitempty"`
 }
 
 // SSOLoginConsoleReq is used to SSO for tsh
@@ -66,6 +71,10 @@ type SSOLoginConsoleReq struct {
 	CertTTL       time.Duration `json:"cert_ttl"`
 	ConnectorID   string        `json:"connector_id"`
 	Compatibility string        `json:"compatibility,omitempty"`
+	// SSOTestFlow, if set, asks the auth server to only report back the
+	// claims/assertions and mappings a connector produces, without
+	// creating a user or certificate. Used by `tctl sso test`.
+	SSOTestFlow bool `json:"sso_test_flow,omitempty"`
 }
 
 // SSOLoginConsoleResponse is a response to SSO console request
@@ -124,8 +133,33 @@ type sealData struct {
 	Nonce []byte `json:"nonce"`
 }
 
+// withRetryGuidance adds a human-readable hint about how long to wait
+// before retrying to err, if the proxy rejected the request because a
+// rate or connection limit was tripped. tsh has no automatic retry loop
+// of its own, so the best it can do is tell the user what the server
+// asked for.
+func withRetryGuidance(err error) error {
+	if period, ok := httplib.RetryAfter(err); ok {
+		return trace.Wrap(err, "rate limited by proxy, retry after %v", period)
+	}
+	return trace.Wrap(err)
+}
+
 // SSHAgentSSOLogin is used by SSH Agent (tsh) to login using OpenID connect
 func SSHAgentSSOLogin(proxyAddr, connectorID string, pubKey []byte, ttl time.Duration, insecure bool, pool *x509.CertPool, protocol string, compatibility string) (*SSHLoginResponse, error) {
+	return ssoLogin(proxyAddr, connectorID, pubKey, ttl, insecure, pool, protocol, compatibility, false)
+}
+
+// SSOTestFlowLogin drives an OIDC or SAML connector through a real sign-in
+// with the identity provider, the same way SSHAgentSSOLogin does, but asks
+// the auth server to only report back the claims/assertions and the roles
+// and traits its mappings produced, without creating a user or certificate.
+// It is used by `tctl sso test` to debug a connector's attribute mapping.
+func SSOTestFlowLogin(proxyAddr, connectorID string, insecure bool, pool *x509.CertPool, protocol string) (*SSHLoginResponse, error) {
+	return ssoLogin(proxyAddr, connectorID, nil, 0, insecure, pool, protocol, "", true)
+}
+
+func ssoLogin(proxyAddr, connectorID string, pubKey []byte, ttl time.Duration, insecure bool, pool *x509.CertPool, protocol string, compatibility string, ssoTestFlow bool) (*SSHLoginResponse, error) {
 	clt, proxyURL, err := initClient(proxyAddr, insecure, pool)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -210,9 +244,10 @@ func SSHAgentSSOLogin(proxyAddr, connectorID string, pubKey []byte, ttl time.Dur
 		CertTTL:       ttl,
 		ConnectorID:   connectorID,
 		Compatibility: compatibility,
+		SSOTestFlow:   ssoTestFlow,
 	})
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, withRetryGuidance(err)
 	}
 
 	var re *SSOLoginConsoleResponse
@@ -254,6 +289,9 @@ type PingResponse struct {
 	Auth AuthenticationSettings `json:"auth"`
 	// ServerVersion is the version of Teleport that is running.
 	ServerVersion string `json:"server_version"`
+	// MessageOfTheDay is a legal banner to display before login, e.g. to
+	// satisfy consent-to-monitoring requirements.
+	MessageOfTheDay string `json:"message_of_the_day,omitempty"`
 }
 
 // PingResponse contains the form of authentication the auth server supports.
@@ -269,6 +307,10 @@ type AuthenticationSettings struct {
 	OIDC *OIDCSettings `json:"oidc,omitempty"`
 	// SAML contains the SAML Connector settings needed for authentication.
 	SAML *SAMLSettings `json:"saml,omitempty"`
+	// LocalAuthEnabled is true if local username/password/2FA login remains
+	// available alongside an oidc or saml connector, e.g. for break-glass
+	// admin accounts to use if the identity provider is unreachable.
+	LocalAuthEnabled bool `json:"local_auth_enabled,omitempty"`
 }
 
 // U2FSettings contains the AppID for Universal Second Factor.
@@ -310,7 +352,7 @@ func Ping(proxyAddr string, insecure bool, pool *x509.CertPool, connectorName st
 
 	response, err := clt.Get(endpoint, url.Values{})
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, withRetryGuidance(err)
 	}
 
 	var pr *PingResponse
@@ -340,7 +382,7 @@ func SSHAgentLogin(proxyAddr, user, password, otpToken string, pubKey []byte, tt
 		Compatibility: compatibility,
 	})
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, withRetryGuidance(err)
 	}
 
 	var out *SSHLoginResponse
@@ -367,7 +409,7 @@ func SSHAgentU2FLogin(proxyAddr, user, password string, pubKey []byte, ttl time.
 		Pass: password,
 	})
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, withRetryGuidance(err)
 	}
 
 	// Pass the JSON-encoded data undecoded to the u2f-host binary
@@ -429,7 +471,7 @@ func SSHAgentU2FLogin(proxyAddr, user, password string, pubKey []byte, ttl time.
 		Compatibility:   compatibility,
 	})
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, withRetryGuidance(err)
 	}
 
 	var out *SSHLoginResponse
@@ -16,8 +16,8 @@ limitations under the License.
 
 // Package 'config' provides facilities for configuring Teleport daemons
 // including
-//	- parsing YAML configuration
-//	- parsing CLI flags
+//   - parsing YAML configuration
+//   - parsing CLI flags
 package config
 
 import (
@@ -84,6 +84,30 @@ type CommandLineFlags struct {
 	// PermitUserEnvironment enables reading of ~/.tsh/environment
 	// when creating a new session.
 	PermitUserEnvironment bool
+	// FIPS means Teleport starts in a FIPS 140-2 compliant mode, restricting
+	// SSH and TLS algorithms to the FIPS-approved subset.
+	FIPS bool
+	// Offline means this process must not make any outbound network calls
+	// other than to its own cluster's configured addresses, for operation
+	// on an air-gapped or classified network.
+	Offline bool
+	// ConfigureTest, set via 'teleport configure --test', validates the
+	// configuration at ConfigFile instead of printing a sample one.
+	ConfigureTest bool
+	// ConfigureOutput is the path 'teleport configure' writes the generated
+	// config file to. Prints to stdout when empty.
+	ConfigureOutput string
+	// ConfigureACME turns on ACME in the config 'teleport configure'
+	// generates.
+	ConfigureACME bool
+	// ConfigureACMEEmail is the contact address passed to the ACME provider.
+	ConfigureACMEEmail string
+	// ConfigurePublicAddr is the proxy's public-facing address in the
+	// config 'teleport configure' generates.
+	ConfigurePublicAddr string
+	// DataDir overrides defaults.DataDir in the config 'teleport configure'
+	// generates.
+	DataDir string
 }
 
 // readConfigFile reads /etc/teleport.yaml (or whatever is passed via --config flag)
@@ -218,6 +242,23 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 	if fc.MACAlgorithms != nil {
 		cfg.MACAlgorithms = fc.MACAlgorithms
 	}
+	if fc.CipherSuites != nil {
+		cipherSuites := make([]uint16, 0, len(fc.CipherSuites))
+		for _, cs := range fc.CipherSuites {
+			id, ok := utils.CipherSuiteMapping(cs)
+			if !ok {
+				return trace.BadParameter("TLS cipher suite %q not supported", cs)
+			}
+			cipherSuites = append(cipherSuites, id)
+		}
+		cfg.CipherSuites = cipherSuites
+	}
+	if fc.FIPS {
+		cfg.FIPS = true
+	}
+	if fc.Offline {
+		cfg.Offline = true
+	}
 
 	// apply connection throttling:
 	limiters := []limiter.LimiterConfig{
@@ -302,6 +343,37 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 
 		cfg.Proxy.TLSCert = fc.Proxy.CertFile
 	}
+	if fc.Proxy.ACME.Enabled() {
+		if cfg.Offline {
+			return trace.BadParameter("acme is enabled, but offline_mode forbids the outbound calls ACME requires to request a certificate")
+		}
+		if fc.Proxy.PublicAddr == "" {
+			return trace.BadParameter("acme is enabled, but public_addr is not set")
+		}
+		if fc.Proxy.KeyFile != "" || fc.Proxy.CertFile != "" {
+			return trace.BadParameter("acme is mutually exclusive with https_key_file/https_cert_file")
+		}
+		cfg.Proxy.ACME.Enabled = true
+		cfg.Proxy.ACME.Email = fc.Proxy.ACME.Email
+		cfg.Proxy.ACME.URI = fc.Proxy.ACME.URI
+	}
+	for _, vhost := range fc.Proxy.VirtualHosts {
+		if vhost.PublicAddr == "" {
+			return trace.BadParameter("virtual_hosts entry is missing public_addr")
+		}
+		if !fileExists(vhost.KeyFile) {
+			return trace.Errorf("https key does not exist: %s", vhost.KeyFile)
+		}
+		if !fileExists(vhost.CertFile) {
+			return trace.Errorf("https cert does not exist: %s", vhost.CertFile)
+		}
+		cfg.Proxy.VirtualHosts = append(cfg.Proxy.VirtualHosts, service.VirtualHost{
+			PublicAddr:    vhost.PublicAddr,
+			TLSKey:        vhost.KeyFile,
+			TLSCert:       vhost.CertFile,
+			ConnectorName: vhost.ConnectorName,
+		})
+	}
 
 	// apply "auth_service" section
 	if fc.Auth.ListenAddress != "" {
@@ -357,6 +429,19 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// read in the CA key algorithm, defaulting to RSA when unset
+	if fc.Auth.CAKeyAlgo != "" {
+		switch fc.Auth.CAKeyAlgo {
+		case defaults.CAKeyAlgoRSA, defaults.CAKeyAlgoECDSA, defaults.CAKeyAlgoED25519:
+			cfg.Auth.CAKeyAlgo = fc.Auth.CAKeyAlgo
+		default:
+			return trace.BadParameter("ca_key_algo %q not supported", fc.Auth.CAKeyAlgo)
+		}
+	}
+	// read in the audit events/session storage backend URI, if configured
+	cfg.Auth.AuditEventsURI = fc.Auth.AuditEventsURI
+	cfg.Auth.CompressSessions = fc.Auth.CompressSessions
+
 	// read in and set authentication preferences
 	if fc.Auth.Authentication != nil {
 		authPreference, oidcConnector, err := fc.Auth.Authentication.Parse()
@@ -691,9 +776,93 @@ func Configure(clf *CommandLineFlags, cfg *service.Config) error {
 		cfg.SSH.PermitUserEnvironment = true
 	}
 
+	// apply --fips flag:
+	if clf.FIPS {
+		cfg.FIPS = true
+	}
+
+	// apply --offline flag:
+	if clf.Offline {
+		cfg.Offline = true
+	}
+	if cfg.Offline && cfg.Proxy.ACME.Enabled {
+		return trace.BadParameter("acme is enabled, but offline_mode forbids the outbound calls ACME requires to request a certificate")
+	}
+
+	if cfg.FIPS {
+		if err := applyFIPSDefaults(cfg); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	return nil
 }
 
+// applyFIPSDefaults restricts the SSH and TLS algorithms Teleport will
+// negotiate to the FIPS 140-2 approved subset. If the operator explicitly
+// configured ciphers, KEX, MACs, or TLS cipher suites, every one of them
+// must already be FIPS-approved; Teleport refuses to start otherwise rather
+// than silently loosen an operator's config.
+func applyFIPSDefaults(cfg *service.Config) error {
+	if err := checkFIPSAllowed("cipher", cfg.Ciphers, defaults.FIPSCiphers); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := checkFIPSAllowed("KEX algorithm", cfg.KEXAlgorithms, defaults.FIPSKEXAlgorithms); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := checkFIPSAllowed("MAC algorithm", cfg.MACAlgorithms, defaults.FIPSMACAlgorithms); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if len(cfg.Ciphers) == 0 {
+		cfg.Ciphers = defaults.FIPSCiphers
+	}
+	if len(cfg.KEXAlgorithms) == 0 {
+		cfg.KEXAlgorithms = defaults.FIPSKEXAlgorithms
+	}
+	if len(cfg.MACAlgorithms) == 0 {
+		cfg.MACAlgorithms = defaults.FIPSMACAlgorithms
+	}
+	if len(cfg.CipherSuites) == 0 {
+		for _, name := range defaults.FIPSCipherSuites {
+			id, ok := utils.CipherSuiteMapping(name)
+			if !ok {
+				return trace.BadParameter("FIPS TLS cipher suite %q is not recognized", name)
+			}
+			cfg.CipherSuites = append(cfg.CipherSuites, id)
+		}
+	} else {
+		for _, id := range cfg.CipherSuites {
+			if !fipsCipherSuiteAllowed(id) {
+				return trace.BadParameter("TLS cipher suite is not FIPS 140-2 approved, remove tls_cipher_suites overrides or fips mode")
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkFIPSAllowed returns an error if configured contains any algorithm
+// that isn't in the FIPS-approved allowed list.
+func checkFIPSAllowed(kind string, configured []string, allowed []string) error {
+	for _, c := range configured {
+		if !utils.SliceContainsStr(allowed, c) {
+			return trace.BadParameter("%v %q is not FIPS 140-2 approved, remove it or disable fips mode", kind, c)
+		}
+	}
+	return nil
+}
+
+// fipsCipherSuiteAllowed returns true if id is one of defaults.FIPSCipherSuites.
+func fipsCipherSuiteAllowed(id uint16) bool {
+	for _, name := range defaults.FIPSCipherSuites {
+		if allowedID, ok := utils.CipherSuiteMapping(name); ok && allowedID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // parseLabels takes the value of --labels flag and tries to correctly populate
 // sshConf.Labels and sshConf.CmdLabels
 func parseLabels(spec string, sshConf *service.SSHConfig) error {
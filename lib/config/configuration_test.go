@@ -115,6 +115,62 @@ func (s *ConfigTestSuite) TestSampleConfig(c *check.C) {
 
 }
 
+// TestConfigureFileConfig verifies that 'teleport configure' bootstrap
+// choices produce a parseable, internally-consistent config file.
+func (s *ConfigTestSuite) TestConfigureFileConfig(c *check.C) {
+	fc, err := MakeConfigureFileConfig(ConfigureParams{
+		Roles: "auth,proxy",
+		Token: "test-join-token",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(fc.Auth.Enabled(), check.Equals, true)
+	c.Assert(fc.Proxy.Enabled(), check.Equals, true)
+	c.Assert(fc.SSH.Enabled(), check.Equals, false)
+
+	fn := filepath.Join(c.MkDir(), "configured.yaml")
+	c.Assert(ioutil.WriteFile(fn, []byte(fc.DebugDumpToYAML()), 0660), check.IsNil)
+	_, err = ReadFromFile(fn)
+	c.Assert(err, check.IsNil)
+
+	// --roles auth may not be combined with --auth-server: this node would
+	// be joining a remote cluster, not running its own auth service.
+	_, err = MakeConfigureFileConfig(ConfigureParams{
+		Roles:      "auth",
+		AuthServer: "10.0.0.1:3025",
+	})
+	c.Assert(err, check.NotNil)
+
+	// --acme requires --public-addr
+	_, err = MakeConfigureFileConfig(ConfigureParams{
+		Roles:       "proxy",
+		ACMEEnabled: true,
+	})
+	c.Assert(err, check.NotNil)
+}
+
+// TestOfflineMode verifies that offline_mode is rejected when combined with
+// acme, since ACME inherently requires outbound calls that offline_mode
+// forbids.
+func (s *ConfigTestSuite) TestOfflineMode(c *check.C) {
+	fc, err := MakeConfigureFileConfig(ConfigureParams{
+		Roles:       "proxy",
+		ACMEEnabled: true,
+		ACMEEmail:   "[email protected]",
+		PublicAddr:  "proxy.example.com:443",
+	})
+	c.Assert(err, check.IsNil)
+	fc.Offline = true
+
+	cfg := service.MakeDefaultConfig()
+	err = ApplyFileConfig(fc, cfg)
+	c.Assert(err, check.NotNil)
+
+	fc.Offline = false
+	cfg = service.MakeDefaultConfig()
+	c.Assert(ApplyFileConfig(fc, cfg), check.IsNil)
+	c.Assert(cfg.Proxy.ACME.Enabled, check.Equals, true)
+}
+
 func (s *ConfigTestSuite) TestConfigReading(c *check.C) {
 	// invalid config file type:
 	conf, err := ReadFromFile("/bin/true")
@@ -25,6 +25,8 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -129,6 +131,11 @@ var (
 		"kex_algos":          false,
 		"mac_algos":          false,
 		"connector_name":     false,
+		"acme":               true,
+		"email":              false,
+		"uri":                false,
+		"virtual_hosts":      true,
+		"offline_mode":       false,
 	}
 )
 
@@ -171,6 +178,39 @@ func ReadFromString(configString string) (*FileConfig, error) {
 	return ReadConfig(bytes.NewBuffer(data))
 }
 
+// envVarRe matches a ${VAR_NAME} reference in a config file. Only the
+// braced form is supported (not bare $VAR) so that literal '$' characters
+// elsewhere in the file, e.g. in a password, aren't misinterpreted.
+var envVarRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvVars replaces every ${VAR_NAME} reference in data with the value
+// of the VAR_NAME environment variable, so secrets like passwords and
+// tokens don't have to be written into the config file in plain text.
+// It's an error for the config to reference a variable that isn't set --
+// silently expanding to an empty string would be far more confusing to
+// debug than refusing to start.
+func expandEnvVars(data []byte) ([]byte, error) {
+	var missing []string
+	seen := make(map[string]bool)
+	expanded := envVarRe.ReplaceAllStringFunc(string(data), func(match string) string {
+		name := envVarRe.FindStringSubmatch(match)[1]
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		if !seen[name] {
+			seen[name] = true
+			missing = append(missing, name)
+		}
+		return match
+	})
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, trace.BadParameter(
+			"configuration references undefined environment variable(s): %v", strings.Join(missing, ", "))
+	}
+	return []byte(expanded), nil
+}
+
 // ReadConfig reads Teleport configuration from reader in YAML format
 func ReadConfig(reader io.Reader) (*FileConfig, error) {
 	// read & parse YAML config:
@@ -178,6 +218,10 @@ func ReadConfig(reader io.Reader) (*FileConfig, error) {
 	if err != nil {
 		return nil, trace.Wrap(err, "failed reading Teleport configuration")
 	}
+	bytes, err = expandEnvVars(bytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	var fc FileConfig
 	if err = yaml.Unmarshal(bytes, &fc); err != nil {
 		return nil, trace.BadParameter("failed to parse Teleport configuration: %v", err)
@@ -281,6 +325,122 @@ func MakeSampleFileConfig() (fc *FileConfig) {
 	return fc
 }
 
+// ConfigureParams holds the choices made via 'teleport configure' flags (or,
+// eventually, an interactive prompt) used to generate a working config file,
+// as opposed to MakeSampleFileConfig's fixed illustrative example.
+type ConfigureParams struct {
+	// Roles is a comma-separated subset of "auth", "node", "proxy". Defaults
+	// to defaults.StartRoles (all three) if empty.
+	Roles string
+	// AuthServer is the address of an existing cluster's auth server to
+	// join. When set, the generated config does not enable a local auth
+	// service, regardless of Roles.
+	AuthServer string
+	// Token is the join token used to register with AuthServer, or, when
+	// this node runs its own auth service, the token other nodes can use to
+	// join it. A random one is generated when left empty and needed.
+	Token string
+	// DataDir overrides defaults.DataDir.
+	DataDir string
+	// NodeName overrides the local hostname.
+	NodeName string
+	// ACMEEnabled turns on automatic TLS certificate acquisition for the
+	// proxy's web listener via ACME (e.g. Let's Encrypt).
+	ACMEEnabled bool
+	// ACMEEmail is the contact address passed to the ACME provider.
+	ACMEEmail string
+	// PublicAddr is the public-facing address clients use to reach the
+	// proxy, e.g. "teleport.example.com:443".
+	PublicAddr string
+}
+
+// MakeConfigureFileConfig generates a working (not merely illustrative)
+// FileConfig from the choices in params, for 'teleport configure'.
+func MakeConfigureFileConfig(params ConfigureParams) (*FileConfig, error) {
+	conf := service.MakeDefaultConfig()
+
+	roles := defaults.StartRoles
+	if params.Roles != "" {
+		roles = strings.Split(params.Roles, ",")
+	}
+	hasRole := func(role string) bool {
+		return utils.SliceContainsStr(roles, role)
+	}
+	if params.AuthServer != "" && hasRole(defaults.RoleAuthService) {
+		return nil, trace.BadParameter("--roles cannot include %q when --auth-server is set -- this node would be joining %v, not running its own auth service", defaults.RoleAuthService, params.AuthServer)
+	}
+
+	token := params.Token
+	if token == "" {
+		generated, err := utils.CryptoRandomHex(auth.TokenLenBytes)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		token = generated
+	}
+
+	var g Global
+	g.NodeName = conf.Hostname
+	if params.NodeName != "" {
+		g.NodeName = params.NodeName
+	}
+	g.Logger.Output = "stderr"
+	g.Logger.Severity = "INFO"
+	g.Limits.MaxConnections = defaults.LimiterMaxConnections
+	g.Limits.MaxUsers = defaults.LimiterMaxConcurrentUsers
+	g.DataDir = defaults.DataDir
+	if params.DataDir != "" {
+		g.DataDir = params.DataDir
+	}
+	g.PIDFile = "/var/run/teleport.pid"
+	if params.AuthServer != "" {
+		g.AuthServers = []string{params.AuthServer}
+		g.AuthToken = token
+	} else {
+		g.AuthServers = []string{defaults.AuthListenAddr().Addr}
+	}
+
+	fc := &FileConfig{Global: g}
+
+	fc.SSH.EnabledFlag = "no"
+	if hasRole(defaults.RoleNode) {
+		fc.SSH.EnabledFlag = "yes"
+		fc.SSH.ListenAddress = conf.SSH.Addr.Addr
+	}
+
+	fc.Auth.EnabledFlag = "no"
+	if hasRole(defaults.RoleAuthService) && params.AuthServer == "" {
+		fc.Auth.EnabledFlag = "yes"
+		fc.Auth.ListenAddress = conf.Auth.SSHAddr.Addr
+		fc.Auth.StaticTokens = []StaticToken{
+			StaticToken(fmt.Sprintf("%s,%s,%s:%s", defaults.RoleProxy, defaults.RoleNode, defaults.RoleAuthService, token)),
+		}
+	}
+
+	fc.Proxy.EnabledFlag = "no"
+	if hasRole(defaults.RoleProxy) {
+		fc.Proxy.EnabledFlag = "yes"
+		fc.Proxy.ListenAddress = conf.Proxy.SSHAddr.Addr
+		fc.Proxy.WebAddr = conf.Proxy.WebAddr.Addr
+		fc.Proxy.TunAddr = conf.Proxy.ReverseTunnelListenAddr.Addr
+		fc.Proxy.PublicAddr = params.PublicAddr
+		if params.ACMEEnabled {
+			fc.Proxy.ACME = &ACME{
+				EnabledFlag: "yes",
+				Email:       params.ACMEEmail,
+			}
+			if params.PublicAddr == "" {
+				return nil, trace.BadParameter("--acme requires --public-addr, ACME needs a DNS name to request a certificate for")
+			}
+		} else {
+			fc.Proxy.CertFile = "/etc/teleport/teleport.crt"
+			fc.Proxy.KeyFile = "/etc/teleport/teleport.key"
+		}
+	}
+
+	return fc, nil
+}
+
 // DebugDumpToYAML allows for quick YAML dumping of the config
 func (conf *FileConfig) DebugDumpToYAML() string {
 	bytes, err := yaml.Marshal(&conf)
@@ -312,6 +472,11 @@ func (conf *FileConfig) Check() error {
 			return trace.BadParameter("MAC %q not supported", m)
 		}
 	}
+	for _, cs := range conf.CipherSuites {
+		if _, ok := utils.CipherSuiteMapping(cs); !ok {
+			return trace.BadParameter("TLS cipher suite %q not supported", cs)
+		}
+	}
 
 	return nil
 }
@@ -369,6 +534,23 @@ type Global struct {
 	// MACAlgorithms is a list of message authentication codes (MAC) that
 	// the server supports. If omitted the defaults will be used.
 	MACAlgorithms []string `yaml:"mac_algos,omitempty"`
+
+	// CipherSuites is a list of TLS cipher suites that the proxy web
+	// listener supports, e.g. "tls-ecdhe-rsa-with-aes-128-gcm-sha256". If
+	// omitted, the defaults will be used.
+	CipherSuites []string `yaml:"tls_cipher_suites,omitempty"`
+
+	// FIPS turns on FIPS 140-2 mode, restricting SSH and TLS algorithms to
+	// the FIPS-approved subset. Refuses to start if Ciphers, KEXAlgorithms,
+	// MACAlgorithms, or CipherSuites are explicitly set to anything outside
+	// that subset.
+	FIPS bool `yaml:"fips,omitempty"`
+
+	// Offline, for an air-gapped or classified network, refuses to start
+	// if a feature that inherently requires reaching an external service
+	// (currently: ACME) is also configured, instead of discovering that
+	// the network call fails only once the feature actually runs.
+	Offline bool `yaml:"offline_mode,omitempty"`
 }
 
 // CachePolicy is used to control  local cache
@@ -495,6 +677,22 @@ type Auth struct {
 	// DynamicConfig determines when file configuration is pushed to the backend. Setting
 	// it here overrides defaults.
 	DynamicConfig *bool `yaml:"dynamic_config,omitempty"`
+
+	// CAKeyAlgo is the key algorithm ("rsa", "ecdsa", or "ed25519") used to
+	// generate the user and host CAs on first start. If omitted, "rsa" is
+	// used.
+	CAKeyAlgo string `yaml:"ca_key_algo,omitempty"`
+
+	// AuditEventsURI selects the storage backend for audit events and
+	// session recordings, e.g. "file:///var/lib/teleport/log" or a
+	// third-party backend registered under its own URI scheme via
+	// events.RegisterAuditLog. Defaults to a "file://" URI under data_dir.
+	AuditEventsURI string `yaml:"audit_events_uri,omitempty"`
+
+	// CompressSessions, when true, gzip-compresses stored session
+	// recordings on disk. Has no effect on AuditEventsURI-based backends,
+	// only on the default local file storage.
+	CompressSessions bool `yaml:"compress_session_recordings,omitempty"`
 }
 
 // TrustedCluster struct holds configuration values under "trusted_clusters" key
@@ -566,6 +764,11 @@ type AuthenticationConfig struct {
 	ConnectorName string                 `yaml:"connector_name,omitempty"`
 	U2F           *UniversalSecondFactor `yaml:"u2f,omitempty"`
 	OIDC          *OIDCConnector         `yaml:"oidc,omitempty"`
+
+	// CertificateFormat is the default certificate extension format used
+	// for user certificates issued by this cluster, e.g. "oldssh" to
+	// interoperate with stock OpenSSH sshd versions.
+	CertificateFormat string `yaml:"cert_format,omitempty"`
 }
 
 // Parse returns the Authentication Configuration in two parts: AuthPreference
@@ -579,10 +782,11 @@ func (a *AuthenticationConfig) Parse() (services.AuthPreference, services.OIDCCo
 	}
 
 	ap, err := services.NewAuthPreference(services.AuthPreferenceSpecV2{
-		Type:          a.Type,
-		SecondFactor:  a.SecondFactor,
-		ConnectorName: a.ConnectorName,
-		U2F:           &u,
+		Type:              a.Type,
+		SecondFactor:      a.SecondFactor,
+		ConnectorName:     a.ConnectorName,
+		U2F:               &u,
+		CertificateFormat: a.CertificateFormat,
 	})
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
@@ -640,6 +844,47 @@ type Proxy struct {
 	KeyFile    string `yaml:"https_key_file,omitempty"`
 	CertFile   string `yaml:"https_cert_file,omitempty"`
 	PublicAddr string `yaml:"public_addr,omitempty"`
+	ACME       *ACME  `yaml:"acme,omitempty"`
+
+	// VirtualHosts lists additional public hostnames served off the same
+	// web_listen_addr, each with its own certificate and (optionally) a
+	// default connector, selected via TLS SNI.
+	VirtualHosts []VirtualHost `yaml:"virtual_hosts,omitempty"`
+}
+
+// VirtualHost is `virtual_hosts` element of `proxy_service`, an additional
+// public hostname served via TLS SNI off the same web listener.
+type VirtualHost struct {
+	PublicAddr    string `yaml:"public_addr"`
+	KeyFile       string `yaml:"https_key_file"`
+	CertFile      string `yaml:"https_cert_file"`
+	ConnectorName string `yaml:"connector_name,omitempty"`
+}
+
+// ACME is `acme` sub-section of `proxy_service` that configures automatic
+// TLS certificate acquisition and renewal for the web proxy.
+type ACME struct {
+	// EnabledFlag is whether ACME is on or off, unlike Service.EnabledFlag
+	// an empty value means "off", since ACME is opt-in.
+	EnabledFlag string `yaml:"enabled,omitempty"`
+	// Email is the contact address for the ACME account, used for expiry
+	// and revocation notices.
+	Email string `yaml:"email,omitempty"`
+	// URI is the ACME directory URL to use. Defaults to Let's Encrypt's
+	// production endpoint if unset.
+	URI string `yaml:"uri,omitempty"`
+}
+
+// Enabled determines if ACME has been set to 'true'
+func (a *ACME) Enabled() bool {
+	if a == nil {
+		return false
+	}
+	switch strings.ToLower(a.EnabledFlag) {
+	case "yes", "yeah", "y", "true", "1":
+		return true
+	}
+	return false
 }
 
 // ReverseTunnel is a SSH reverse tunnel mantained by one cluster's
@@ -18,6 +18,7 @@ package config
 import (
 	"encoding/base64"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/gravitational/teleport/lib/defaults"
@@ -249,3 +250,25 @@ func (s *FileTestSuite) TestLegacyAuthenticationSection(c *check.C) {
 	c.Assert(fc.Auth.U2F.Facets, check.HasLen, 1)
 	c.Assert(fc.Auth.U2F.Facets[0], check.Equals, "https://graviton:3080")
 }
+
+// TestEnvVarExpansion verifies that ${VAR} references in a config file are
+// expanded against the environment, and that referencing an unset variable
+// is rejected rather than silently expanding to an empty string.
+func (s *FileTestSuite) TestEnvVarExpansion(c *check.C) {
+	c.Assert(os.Setenv("TELEPORT_TEST_AUTH_TOKEN", "expanded-token-value"), check.IsNil)
+	defer os.Unsetenv("TELEPORT_TEST_AUTH_TOKEN")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(`
+teleport:
+  auth_token: ${TELEPORT_TEST_AUTH_TOKEN}
+`))
+	fc, err := ReadFromString(encoded)
+	c.Assert(err, check.IsNil)
+	c.Assert(fc.AuthToken, check.Equals, "expanded-token-value")
+
+	_, err = ReadFromString(base64.StdEncoding.EncodeToString([]byte(`
+teleport:
+  auth_token: ${TELEPORT_TEST_UNSET_VAR}
+`)))
+	c.Assert(err, check.NotNil)
+}
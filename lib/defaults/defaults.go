@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/utils"
 )
 
@@ -132,6 +131,12 @@ const (
 	// LogRotationPeriod defines how frequently to rotate the audit log file
 	LogRotationPeriod = (time.Hour * 24)
 
+	// LogRetentionPeriod defines how long rotated audit log partitions are
+	// kept on disk before the retention sweeper deletes them. Does not
+	// apply to session recordings, which are retained until explicitly
+	// purged (see events.AuditLog.PurgeSessionData).
+	LogRetentionPeriod = (time.Hour * 24 * 90)
+
 	// MaxLoginAttempts sets the max. number of allowed failed login attempts
 	// before a user account is locked for AccountLockInterval
 	MaxLoginAttempts int = 5
@@ -169,6 +174,19 @@ var (
 	// TerminalSizeRefreshPeriod is how frequently clients who share sessions sync up
 	// their terminal sizes
 	TerminalSizeRefreshPeriod = 2 * time.Second
+
+	// ExpiredUserCheckPeriod is how often an SSH server re-checks the
+	// Teleport user account behind every session it's currently hosting,
+	// disconnecting any party whose account has expired since the session
+	// started.
+	ExpiredUserCheckPeriod = 30 * time.Second
+
+	// WebSocketKeepAlivePeriod is how frequently the web proxy sends a
+	// keepalive frame down an otherwise idle web terminal WebSocket. Many
+	// corporate proxies and load balancers silently kill idle or
+	// long-lived WebSocket connections, dropping the user's session
+	// without warning.
+	WebSocketKeepAlivePeriod = 30 * time.Second
 )
 
 // Default connection limits, they can be applied separately on any of the Teleport
@@ -191,6 +209,16 @@ const (
 	CertDuration = 12 * time.Hour
 )
 
+// CA and host/user key algorithms accepted by lib/auth/native.
+const (
+	// CAKeyAlgoRSA generates 2048 bit RSA keys, the long-standing default.
+	CAKeyAlgoRSA = "rsa"
+	// CAKeyAlgoECDSA generates NIST P-256 ECDSA keys.
+	CAKeyAlgoECDSA = "ecdsa"
+	// CAKeyAlgoED25519 generates Ed25519 keys.
+	CAKeyAlgoED25519 = "ed25519"
+)
+
 // list of roles teleport service can run as:
 const (
 	// RoleNode is SSH stateless node
@@ -237,13 +265,90 @@ const (
 	SelfSignedPubPath = "webproxy_pub.pem"
 	// path to a self-signed TLS cert file for HTTPS connection for the web proxy
 	SelfSignedCertPath = "webproxy_cert.pem"
+
+	// ACMEKeyPath is the path to the TLS private key obtained via ACME for
+	// the web proxy, relative to the data dir.
+	ACMEKeyPath = "acme_key.pem"
+	// ACMECertPath is the path to the TLS certificate obtained via ACME for
+	// the web proxy, relative to the data dir.
+	ACMECertPath = "acme_cert.pem"
+	// ACMEDirectoryURI is the default ACME directory used to request certs,
+	// Let's Encrypt's production endpoint.
+	ACMEDirectoryURI = "https://acme-v02.api.letsencrypt.org/directory"
+	// ACMERenewInterval is how often the proxy checks whether its ACME
+	// certificate needs to be renewed.
+	ACMERenewInterval = 12 * time.Hour
+	// ACMERenewBeforeExpiry is how far ahead of certificate expiry Teleport
+	// attempts to renew it.
+	ACMERenewBeforeExpiry = 30 * 24 * time.Hour
+
+	// OktaSyncInterval is how often the Okta group sync service runs by
+	// default, when a cluster's OktaConfig doesn't specify its own.
+	OktaSyncInterval = 5 * time.Minute
+
+	// EmailSMTPPort is the SMTP port used when a cluster's EmailPlugin
+	// doesn't specify its own.
+	EmailSMTPPort = 587
+
+	// EmailExpiryWarning is how far ahead of a provisioning token's expiry
+	// the email plugin warns about it by default, when a cluster's
+	// EmailPlugin doesn't specify its own.
+	EmailExpiryWarning = 24 * time.Hour
+
+	// EmailExpiryCheckInterval is how often the email plugin scans for
+	// provisioning tokens nearing expiry.
+	EmailExpiryCheckInterval = 1 * time.Hour
+
+	// AccessRequestSweepInterval is how often approved access requests are
+	// scanned for a scheduled start that has arrived, or a TTL that has
+	// elapsed.
+	AccessRequestSweepInterval = 1 * time.Minute
+
+	// ReviewCampaignSweepInterval is how often active review campaigns are
+	// scanned for a Deadline that has arrived.
+	ReviewCampaignSweepInterval = 1 * time.Minute
 )
 
-// ConfigureLimiter assigns the default parameters to a connection throttler (AKA limiter)
-func ConfigureLimiter(lc *limiter.LimiterConfig) {
-	lc.MaxConnections = LimiterMaxConnections
-	lc.MaxNumberOfUsers = LimiterMaxConcurrentUsers
-}
+// FIPS 140-2 approved SSH and TLS algorithms. When Config.FIPS is set, these
+// replace the (larger) sets golang.org/x/crypto/ssh and crypto/tls otherwise
+// negotiate, so a cluster can be run in a mode federal customers can accept.
+// Every entry here must also be a member of the corresponding
+// golang.org/x/crypto/ssh supported* list, since FIPS mode narrows the
+// existing negotiated set rather than adding new algorithms.
+var (
+	// FIPSCiphers is the SSH cipher allow list in FIPS mode. All are AES in
+	// an AEAD or CTR-with-HMAC construction; RC4 (arcfour) is excluded.
+	FIPSCiphers = []string{
+		"aes128-gcm@openssh.com",
+		"aes128-ctr",
+		"aes192-ctr",
+		"aes256-ctr",
+	}
+
+	// FIPSKEXAlgorithms is the SSH key exchange allow list in FIPS mode.
+	// curve25519-sha256 is excluded because Curve25519 is not a NIST curve;
+	// the diffie-hellman-group*-sha1 exchanges are excluded because SHA-1
+	// is not FIPS-approved for key exchange.
+	FIPSKEXAlgorithms = []string{
+		"ecdh-sha2-nistp256",
+		"ecdh-sha2-nistp384",
+		"ecdh-sha2-nistp521",
+	}
+
+	// FIPSMACAlgorithms is the SSH MAC allow list in FIPS mode. Only the
+	// HMAC-SHA2 variant is approved; HMAC-SHA1 and its *-etm@openssh.com
+	// counterpart are excluded.
+	FIPSMACAlgorithms = []string{
+		"hmac-sha2-256",
+	}
+
+	// FIPSCipherSuites is the TLS cipher suite allow list in FIPS mode. Only
+	// AES-GCM suites, which use FIPS-approved AES and SHA2, are permitted.
+	FIPSCipherSuites = []string{
+		"tls-ecdhe-ecdsa-with-aes-128-gcm-sha256",
+		"tls-ecdhe-rsa-with-aes-128-gcm-sha256",
+	}
+)
 
 // AuthListenAddr returns the default listening address for the Auth service
 func AuthListenAddr() *utils.NetAddr {
@@ -58,14 +58,54 @@ const (
 	SessionEventID  = "sid"
 	SessionServerID = "server_id"
 
+	// SessionServerLabels is the target node's static and dynamic labels,
+	// merged together, recorded on session.start and session.join so a
+	// ChatOps integration watching for those events can tell, say, a prod
+	// session from a staging one without a separate lookup.
+	SessionServerLabels = "server_labels"
+
+	// SessionReason is an optional incident/ticket reference the user
+	// supplied when starting the session (e.g. via 'tsh ssh --reason'),
+	// recorded on session.start so it shows up in the audit trail
+	// alongside everything else reviewers need to know about the session.
+	SessionReason = "reason"
+
 	// SessionByteOffset is the number of bytes written to session stream since
 	// the beginning
 	SessionByteOffset = "offset"
 
+	// SessionBytesIn is the total number of bytes the server read from
+	// clients over the life of the session (keystrokes, uploaded data),
+	// recorded on session.end.
+	SessionBytesIn = "bytes_in"
+
+	// SessionBytesOut is the total number of bytes the server wrote to
+	// clients over the life of the session (terminal output, downloaded
+	// data), recorded on session.end.
+	SessionBytesOut = "bytes_out"
+
+	// SessionLatencyP50/P90/P99 are round-trip latency percentiles, in
+	// milliseconds, sampled over the life of the session by periodically
+	// timing an SSH channel keepalive request. Omitted from session.end
+	// if too few samples were collected to be meaningful.
+	SessionLatencyP50 = "latency_p50_ms"
+	SessionLatencyP90 = "latency_p90_ms"
+	SessionLatencyP99 = "latency_p99_ms"
+
 	// Join & Leave events indicate when someone joins/leaves a session
 	SessionJoinEvent  = "session.join"
 	SessionLeaveEvent = "session.leave"
 
+	// SessionCommandEvent is a command line heuristically extracted from an
+	// interactive session's terminal output (as opposed to ExecEvent, which
+	// is a single non-interactive command run via the exec channel). It
+	// lets "who ran rm -rf on prod" searches find commands typed inside a
+	// shell without replaying the whole session.
+	SessionCommandEvent = "session.command"
+	// SessionCommandLine is the extracted command line text for
+	// SessionCommandEvent
+	SessionCommandLine = "command"
+
 	// ExecEvent is an exec command executed by script or user on
 	// the server side
 	ExecEvent        = "exec"
@@ -92,12 +132,156 @@ const (
 	// ResizeEvent means that some user resized PTY on the client
 	ResizeEvent  = "resize"
 	TerminalSize = "size" // expressed as 'W:H'
+
+	// SessionModerateEvent is emitted when a session's owner grants or
+	// revokes a participant's write access on the fly.
+	SessionModerateEvent = "session.moderate"
+	// SessionModerateParty identifies the participant whose write access
+	// was changed by a SessionModerateEvent.
+	SessionModerateParty = "party"
+	// SessionModerateCanWrite is the write access SessionModerateParty was
+	// set to by a SessionModerateEvent.
+	SessionModerateCanWrite = "can_write"
+
+	// ClientVersion is the SSH version string the connecting client
+	// identified itself with, recorded on session.start so incident review
+	// can distinguish automation from humans and spot outdated clients.
+	ClientVersion = "version"
+
+	// SessionCgroupID identifies the cgroup this session's processes were
+	// placed into (see lib/srv/cgroup.go), recorded on session.start so a
+	// host-level resource usage spike can be traced back to the session
+	// that caused it.
+	SessionCgroupID = "cgroup_id"
+
+	// SessionLeftoverProcessesEvent records that one or more processes
+	// spawned by a session were still running when it ended and had to be
+	// forcibly terminated (see services.RoleSet.ShouldKillProcessGroup and
+	// lib/srv's reapSessionProcesses), instead of being left to outlive
+	// the session silently as an unaccounted-for orphan.
+	SessionLeftoverProcessesEvent = "session.leftover_processes"
+	// SessionLeftoverPIDs is the list of PIDs a
+	// SessionLeftoverProcessesEvent killed.
+	SessionLeftoverPIDs = "pids"
+
+	// SessionPurgeEvent is emitted when a session's recording and event log
+	// are permanently deleted, e.g. to satisfy a data retention policy or a
+	// right-to-erasure request. It is recorded in the cluster-wide event log
+	// so there remains a trace that the data existed and was removed.
+	SessionPurgeEvent = "session.purge"
+
+	// RateLimitExceededEvent is emitted whenever a per-IP or per-user rate
+	// or connection limit configured on an auth, proxy or SSH endpoint
+	// rejects a connection or request.
+	RateLimitExceededEvent = "rate_limit.exceeded"
+	// RateLimitType says which kind of limit tripped, e.g. "connection" or
+	// "rate"
+	RateLimitType = "limit_type"
+	// RateLimitToken is the identity the limit was tracked against, usually
+	// a client IP address or a Teleport username
+	RateLimitToken = "token"
+
+	// AlertEvent is raised by a Hook (see hooks.go) when it judges some
+	// other event suspicious, e.g. impossible travel, a login at an
+	// unusual hour, or a user appearing on a node they've never used
+	// before.
+	AlertEvent = "alert"
+	// AlertHook names the Hook that raised the AlertEvent.
+	AlertHook = "hook"
+	// AlertReason is a human-readable explanation of why the hook raised
+	// the alert.
+	AlertReason = "reason"
+	// AlertSourceEvent is the event type the alert was raised about.
+	AlertSourceEvent = "source_event"
+
+	// CanaryAccessEvent fires immediately whenever a login is attempted
+	// against a node labeled teleport.CanaryLabel, before its outcome is
+	// known to anyone but the node itself. Unlike AlertEvent, which is
+	// raised after the fact by a Hook judging an event suspicious, this is
+	// raised unconditionally at the point of attempt -- there's no
+	// legitimate reason to ever touch a canary node, so RBAC allowing or
+	// denying the login doesn't change how serious the hit is.
+	CanaryAccessEvent = "canary.access"
+	// CanaryAccessServerID identifies which canary node was touched.
+	CanaryAccessServerID = "server_id"
+	// CanaryAccessGranted records whether the RBAC check that followed the
+	// attempt ended up allowing the login.
+	CanaryAccessGranted = "granted"
+
+	// ProvisionTokenCreateEvent fires whenever a provisioning token is
+	// generated, whether for joining a node/proxy/auth server or for a
+	// user invitation.
+	ProvisionTokenCreateEvent = "token.create"
+	// ProvisionTokenDeleteEvent fires whenever a provisioning token is
+	// revoked, either explicitly by an administrator or automatically
+	// once it's consumed or expires.
+	ProvisionTokenDeleteEvent = "token.delete"
+	// ProvisionTokenRoles lists the roles a provisioning token grants its
+	// bearer.
+	ProvisionTokenRoles = "roles"
+	// ProvisionTokenExpires records when a provisioning token stops being
+	// valid.
+	ProvisionTokenExpires = "expires"
+
+	// NodeJoinRejectEvent fires whenever a node's attempt to join the
+	// cluster using a provisioning token is denied -- most notably when the
+	// token restricts which hosts may use it (see
+	// services.ProvisionToken.AllowedHosts) and the joining node didn't
+	// match. A leaked token being replayed from an unexpected host is
+	// exactly the scenario this is meant to catch.
+	NodeJoinRejectEvent = "node.join.reject"
+	// NodeJoinHostID identifies the HostID the rejected node presented.
+	NodeJoinHostID = "host_id"
+	// NodeJoinNodeName identifies the node name the rejected node presented.
+	NodeJoinNodeName = "node_name"
+	// NodeJoinReason is a human-readable explanation of why the join was
+	// rejected.
+	NodeJoinReason = "reason"
+
+	// NodeApproveEvent fires when an administrator approves a pending or
+	// quarantined node, clearing it to receive sessions.
+	NodeApproveEvent = "node.approve"
+	// NodeQuarantineEvent fires when an administrator quarantines a node,
+	// immediately blocking new sessions to it.
+	NodeQuarantineEvent = "node.quarantine"
+	// NodeName identifies the node a NodeApproveEvent or NodeQuarantineEvent
+	// was raised about.
+	NodeName = "node"
+
+	// ReviewCampaignCloseEvent fires when a periodic access review
+	// campaign closes, whether by its deadline passing or by an
+	// administrator closing it early.
+	ReviewCampaignCloseEvent = "review_campaign.close"
+	// ReviewCampaignRevokeEvent fires for each user whose role grant a
+	// review campaign's reviewers voted to revoke, once that revocation is
+	// actually applied at campaign close.
+	ReviewCampaignRevokeEvent = "review_campaign.revoke"
+	// ReviewCampaignID identifies the review campaign a
+	// ReviewCampaignCloseEvent or ReviewCampaignRevokeEvent was raised for.
+	ReviewCampaignID = "campaign_id"
+	// ReviewCampaignRevokedRoles lists the roles removed from a user by a
+	// ReviewCampaignRevokeEvent.
+	ReviewCampaignRevokedRoles = "revoked_roles"
 )
 
 const (
 	// MaxChunkBytes defines the maximum size of a session stream chunk that
 	// can be requested via AuditLog.GetSessionChunk(). Set to 5MB
 	MaxChunkBytes = 1024 * 1024 * 5
+
+	// SessionStreamPartSizeBytes is the maximum number of logical bytes of
+	// session stream data a single on-disk part file holds before the
+	// recorder rolls over to a new one. Keeps an individual recording file
+	// from growing without bound for very long sessions, and lets playback
+	// fetch a single part instead of the whole recording. Set to 64MB.
+	SessionStreamPartSizeBytes = 1024 * 1024 * 64
+
+	// MaxLogFileBytes is the maximum size of the main (cluster-wide) event
+	// log's current partition before AuditLog rotates to a new one, on top
+	// of the usual day-based rotation. Keeps a single partition from
+	// growing without bound on a very busy cluster in between daily
+	// rotations. Set to 100MB.
+	MaxLogFileBytes = 1024 * 1024 * 100
 )
 
 // IAuditLog is the primary (and the only external-facing) interface for AUditLogger.
@@ -141,6 +325,11 @@ type IAuditLog interface {
 	// The only mandatory requirement is a date range (UTC). Results must always
 	// show up sorted by date (newest first)
 	SearchEvents(fromUTC, toUTC time.Time, query string) ([]EventFields, error)
+
+	// PurgeSessionData permanently deletes the recording and event log of a
+	// single session, e.g. to satisfy a data retention or right-to-erasure
+	// request. It does not touch the main cluster-wide event log.
+	PurgeSessionData(namespace string, sid session.ID) error
 }
 
 // EventFields instance is attached to every logged event
@@ -19,18 +19,27 @@ Package events currently implements the audit log using a simple filesystem back
 "Implements" means it implements events.IAuditLog interface (see events/api.go)
 
 The main log files are saved as:
+
 	/var/lib/teleport/log/<date>.log
 
 Each session has its own session log stored as two files
+
 	/var/lib/teleport/log/<session-id>.session.log
-	/var/lib/teleport/log/<session-id>.session.bytes
+	/var/lib/teleport/log/<session-id>.session.bytes.0000
 
 Where:
-	- .session.log   (same events as in the main log, but related to the session)
-	- .session.bytes (recorded session bytes: PTY IO)
-
-The log file is rotated every 24 hours. The old files must be cleaned
-up or archived by an external tool.
+  - .session.log        (same events as in the main log, but related to the session)
+  - .session.bytes.NNNN (recorded session bytes: PTY IO, split into bounded-size
+    parts once a session runs long; .session.bytes.index lists them)
+
+The log file is rotated every 24 hours (see AuditLog.RotationPeriod), or
+sooner if it grows past AuditLog.MaxLogFileBytes -- in which case the
+partition name gets a ".N" sequence suffix so it doesn't collide with the
+one before it. A rotated-away partition is gzip-compressed in the
+background to "<date>.log.gz", then removed once it's older than
+AuditLog.RetentionPeriod by a periodic sweep (see AuditLog.sweepRetention).
+Session recordings are not subject to this sweep; they're kept until
+explicitly purged, see PurgeSessionData.
 
 Log file format:
 utc_date,action,json_fields
@@ -51,9 +60,12 @@ package events
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -87,6 +99,19 @@ const (
 	// SessionStreamPrefix defines the ending of session stream files,
 	// that's where interactive PTY I/O is saved.
 	SessionStreamPrefix = ".session.bytes"
+
+	// SessionStreamIndexSuffix defines the ending of the file listing the
+	// part files a session's stream has been split into, see
+	// SessionLogger.rollPart.
+	SessionStreamIndexSuffix = ".index"
+
+	// CompressedLogfileExt defines the ending of a rotated, gzip-compressed
+	// daily event log partition, see AuditLog.compressLogFile.
+	CompressedLogfileExt = LogfileExt + ".gz"
+
+	// defaultRetentionSweepPeriod is how often AuditLog.sweepRetention
+	// wakes up to look for expired log partitions, absent an override.
+	defaultRetentionSweepPeriod = time.Hour
 )
 
 var (
@@ -120,11 +145,53 @@ type AuditLog struct {
 	// currently opened file
 	fileTime time.Time
 
+	// fileSeq counts how many times the current fileTime's partition has
+	// been rolled over because of MaxLogFileBytes, so each one gets a
+	// distinct name -- see logFileName. Reset to 0 whenever fileTime
+	// advances.
+	fileSeq int
+
+	// fileBytes counts how many bytes have been written to file since it
+	// was opened, so rotateLog knows when to roll over early because of
+	// MaxLogFileBytes.
+	fileBytes int64
+
 	// RotationPeriod defines how frequently to rotate the log file
 	RotationPeriod time.Duration
 
+	// MaxLogFileBytes is the same as the package-level MaxLogFileBytes
+	// constant, but overridable, which helps with testing size-triggered
+	// rotation without writing a full-size partition on every test run.
+	MaxLogFileBytes int64
+
+	// RetentionPeriod is how long rotated log partitions are kept on disk
+	// before sweepRetention deletes them.
+	RetentionPeriod time.Duration
+
+	// retentionSweepPeriod is how often sweepRetention wakes up to check
+	// for expired partitions. Overridable so tests don't have to wait out
+	// a real retentionSweepPeriod.
+	retentionSweepPeriod time.Duration
+
+	// closer broadcasts shutdown to the retention sweeper goroutine.
+	closer *utils.CloseBroadcaster
+
 	// same as time.Now(), but helps with testing
 	TimeSource TimeSourceFunc
+
+	// PartSizeBytes is the same as SessionStreamPartSizeBytes, but
+	// overridable, which helps with testing rollover without writing out a
+	// full-size part on every test run.
+	PartSizeBytes int64
+
+	// encryptionKey, when set, is the cluster-managed AES-256 key used to
+	// encrypt session recordings at rest. Nil means recordings are stored
+	// in plaintext, as before.
+	encryptionKey []byte
+
+	// compressSessions, when true, gzip-compresses session recordings
+	// before they're written to disk.
+	compressSessions bool
 }
 
 // BaseSessionLogger implements the common features of a session logger. The imporant
@@ -139,9 +206,36 @@ type SessionLogger struct {
 	// these are all associated with this session
 	eventsFile *os.File
 
-	// streamFile stores bytes from the session terminal I/O for replaying
+	// streamBaseFn is the session stream's path without a part suffix,
+	// e.g. ".../<sid>.session.bytes". Part files and the index file are
+	// named off of it, see sessionPartFn and sessionIndexFn.
+	streamBaseFn string
+
+	// streamFile stores bytes from the session terminal I/O for replaying.
+	// It's the currently open part file; once it grows past
+	// SessionStreamPartSizeBytes, rollPart closes it and opens the next one.
 	streamFile *os.File
 
+	// indexFile records, one JSON line per completed part, the logical and
+	// physical byte range each part file of this session covers.
+	indexFile *os.File
+
+	// partNum is the part number currently being written to streamFile.
+	partNum int
+
+	// partLogicalStart is the value writtenBytes had when the current part
+	// was opened.
+	partLogicalStart int64
+
+	// partPhysicalStart is the value physicalBytes had when the current
+	// part was opened. Only meaningful (differs from partLogicalStart) when
+	// compressed is true.
+	partPhysicalStart int64
+
+	// partSizeBytes is the logical size, in bytes, a part is allowed to
+	// reach before rollPart starts a new one. Copied from AuditLog.PartSizeBytes.
+	partSizeBytes int64
+
 	// counter of how many bytes have been written during this session
 	writtenBytes int64
 
@@ -149,6 +243,20 @@ type SessionLogger struct {
 	timeSource TimeSourceFunc
 
 	createdTime time.Time
+
+	// encryptionKey, when set, encrypts stream bytes at rest with
+	// AES-256-CTR, keyed for this session (see newStreamCipher).
+	encryptionKey []byte
+
+	// compressed, when true, gzip-compresses every chunk written to
+	// streamFile (see writeCompressedChunk).
+	compressed bool
+
+	// physicalBytes counts the bytes actually written to streamFile so
+	// far. It's only tracked (and differs from writtenBytes) when
+	// compressed is true, since compression changes each chunk's on-disk
+	// size relative to its logical size.
+	physicalBytes int64
 }
 
 // LogEvent logs an event associated with this session
@@ -201,14 +309,61 @@ func (sl *SessionLogger) Finalize() error {
 	if sl.streamFile != nil {
 		auditOpenFiles.Dec()
 		log.Infof("sessionLogger.Finalize(sid=%s)", sl.sid)
-		sl.streamFile.Close()
+		if err := sl.finishPartLocked(); err != nil {
+			log.Error(err)
+		}
+		sl.indexFile.Close()
 		sl.eventsFile.Close()
-		sl.streamFile = nil
+		sl.indexFile = nil
 		sl.eventsFile = nil
 	}
 	return nil
 }
 
+// finishPartLocked appends an index record describing the part file
+// currently open for writing, then closes it. Callers must hold sl.Lock().
+func (sl *SessionLogger) finishPartLocked() error {
+	if sl.streamFile == nil {
+		return nil
+	}
+	entry := sessionStreamIndexEntry{
+		Part:          sl.partNum,
+		LogicalStart:  sl.partLogicalStart,
+		PhysicalStart: sl.partPhysicalStart,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := fmt.Fprintln(sl.indexFile, string(line)); err != nil {
+		return trace.Wrap(err)
+	}
+	err = sl.streamFile.Close()
+	sl.streamFile = nil
+	return trace.Wrap(err)
+}
+
+// rollPart closes the part file currently being written to, records it in
+// the index, and opens the next one. It's called once a part has
+// accumulated SessionStreamPartSizeBytes of logical data, so no single
+// recording file grows without bound over a very long session.
+func (sl *SessionLogger) rollPart() error {
+	sl.Lock()
+	defer sl.Unlock()
+	if err := sl.finishPartLocked(); err != nil {
+		return trace.Wrap(err)
+	}
+	sl.partNum++
+	sl.partLogicalStart = atomic.LoadInt64(&sl.writtenBytes)
+	sl.partPhysicalStart = atomic.LoadInt64(&sl.physicalBytes)
+	fstream, err := os.OpenFile(sessionPartFn(sl.streamBaseFn, sl.partNum), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	sl.streamFile = fstream
+	return nil
+}
+
 // WriteChunk takes a stream of bytes (usually the output from a session terminal)
 // and writes it into a "stream file", for future replay of interactive sessions.
 func (sl *SessionLogger) WriteChunk(chunk *SessionChunk) (written int, err error) {
@@ -216,9 +371,24 @@ func (sl *SessionLogger) WriteChunk(chunk *SessionChunk) (written int, err error
 		err := trace.Errorf("session %v error: attempt to write to a closed file", sl.sid)
 		return 0, trace.Wrap(err)
 	}
-	if written, err = sl.streamFile.Write(chunk.Data); err != nil {
-		log.Error(err)
-		return written, trace.Wrap(err)
+	if sl.compressed {
+		if err := sl.writeCompressedChunk(chunk); err != nil {
+			return 0, trace.Wrap(err)
+		}
+	} else {
+		data := chunk.Data
+		if len(sl.encryptionKey) > 0 {
+			stream, err := newStreamCipher(sl.encryptionKey, string(sl.sid), atomic.LoadInt64(&sl.writtenBytes))
+			if err != nil {
+				return 0, trace.Wrap(err)
+			}
+			data = make([]byte, len(chunk.Data))
+			stream.XORKeyStream(data, chunk.Data)
+		}
+		if written, err = sl.streamFile.Write(data); err != nil {
+			log.Error(err)
+			return written, trace.Wrap(err)
+		}
 	}
 
 	// log this as a session event (but not more often than once a sec)
@@ -228,27 +398,144 @@ func (sl *SessionLogger) WriteChunk(chunk *SessionChunk) (written int, err error
 	}, time.Unix(0, chunk.Time))
 
 	// increase the total lengh of the stream
-	atomic.AddInt64(&sl.writtenBytes, int64(len(chunk.Data)))
-	return written, nil
+	newTotal := atomic.AddInt64(&sl.writtenBytes, int64(len(chunk.Data)))
+
+	// time to roll over to a new part file?
+	if newTotal-sl.partLogicalStart >= sl.partSizeBytes {
+		if err := sl.rollPart(); err != nil {
+			log.Error(err)
+		}
+	}
+	return len(chunk.Data), nil
+}
+
+// sessionStreamIndexEntry is one line of a session's stream index file,
+// recording the logical and physical byte range covered by one part file.
+// It's written for every part as it's rolled over (see
+// SessionLogger.rollPart), and read back by PurgeSessionData to find every
+// part file to remove. GetSessionChunk doesn't depend on it: it derives
+// part boundaries from SessionStreamPartSizeBytes arithmetic instead, so
+// that it keeps working against a session that's still being recorded and
+// whose current part hasn't been indexed yet.
+type sessionStreamIndexEntry struct {
+	Part          int   `json:"part"`
+	LogicalStart  int64 `json:"logical_start"`
+	PhysicalStart int64 `json:"physical_start"`
+}
+
+// sessionPartFn returns the path of the numbered part file that holds a
+// session stream's logical bytes [part*SessionStreamPartSizeBytes,
+// (part+1)*SessionStreamPartSizeBytes), given the stream's base path (as
+// returned by AuditLog.sessionStreamFn).
+func sessionPartFn(streamBaseFn string, part int) string {
+	return fmt.Sprintf("%s.%04d", streamBaseFn, part)
+}
+
+// sessionIndexFn returns the path of a session stream's index file, given
+// the stream's base path.
+func sessionIndexFn(streamBaseFn string) string {
+	return streamBaseFn + SessionStreamIndexSuffix
+}
+
+// compressedChunkHeaderSize is the size, in bytes, of the length prefix
+// written ahead of every record by writeCompressedChunk.
+const compressedChunkHeaderSize = 4
+
+// writeCompressedChunk gzip-compresses chunk.Data (encrypting the
+// compressed bytes too, if a recording encryption key is set) and appends
+// it to the stream file as a length-prefixed record. Compressed records
+// don't land at a fixed byte offset the way raw bytes do, so they need
+// framing to be read back individually during replay; see
+// readCompressedSessionChunk.
+func (sl *SessionLogger) writeCompressedChunk(chunk *SessionChunk) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(chunk.Data); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := gz.Close(); err != nil {
+		return trace.Wrap(err)
+	}
+	payload := buf.Bytes()
+
+	if len(sl.encryptionKey) > 0 {
+		payloadOffset := atomic.LoadInt64(&sl.physicalBytes) + compressedChunkHeaderSize
+		stream, err := newStreamCipher(sl.encryptionKey, string(sl.sid), payloadOffset)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		ciphertext := make([]byte, len(payload))
+		stream.XORKeyStream(ciphertext, payload)
+		payload = ciphertext
+	}
+
+	var header [compressedChunkHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(payload)))
+	if _, err := sl.streamFile.Write(header[:]); err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := sl.streamFile.Write(payload); err != nil {
+		return trace.Wrap(err)
+	}
+	atomic.AddInt64(&sl.physicalBytes, int64(len(header))+int64(len(payload)))
+	return nil
 }
 
 // Creates and returns a new Audit Log oboject whish will store its logfiles
 // in a given directory>
 func NewAuditLog(dataDir string) (IAuditLog, error) {
+	return NewAuditLogWithConfig(AuditLogConfig{DataDir: dataDir})
+}
+
+// NewAuditLogWithEncryptionKey is like NewAuditLog, but encrypts session
+// recordings at rest with the given cluster-managed AES-256 key. A nil key
+// stores recordings in plaintext, same as NewAuditLog.
+func NewAuditLogWithEncryptionKey(dataDir string, encryptionKey []byte) (IAuditLog, error) {
+	return NewAuditLogWithConfig(AuditLogConfig{DataDir: dataDir, EncryptionKey: encryptionKey})
+}
+
+// AuditLogConfig configures a local file-backed IAuditLog.
+type AuditLogConfig struct {
+	// DataDir is the directory event and session logs are stored under.
+	DataDir string
+
+	// EncryptionKey, when set, encrypts session recordings at rest with
+	// AES-256-CTR. A nil key stores recordings in plaintext.
+	EncryptionKey []byte
+
+	// CompressSessions, when true, gzip-compresses session recordings
+	// before they're written to disk.
+	CompressSessions bool
+}
+
+// NewAuditLogWithConfig creates a new Audit Log object which will store its
+// logfiles according to the given config.
+func NewAuditLogWithConfig(cfg AuditLogConfig) (IAuditLog, error) {
+	if len(cfg.EncryptionKey) > 0 && len(cfg.EncryptionKey) != EncryptionKeySize {
+		return nil, trace.BadParameter("session recording encryption key must be %v bytes, got %v", EncryptionKeySize, len(cfg.EncryptionKey))
+	}
 	// create a directory for session logs:
-	sessionDir := filepath.Join(dataDir, SessionLogsDir)
+	sessionDir := filepath.Join(cfg.DataDir, SessionLogsDir)
 	if err := os.MkdirAll(sessionDir, 0770); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	al := &AuditLog{
-		loggers:        make(map[session.ID]*SessionLogger, 0),
-		dataDir:        dataDir,
-		RotationPeriod: defaults.LogRotationPeriod,
-		TimeSource:     time.Now,
+		loggers:              make(map[session.ID]*SessionLogger, 0),
+		dataDir:              cfg.DataDir,
+		RotationPeriod:       defaults.LogRotationPeriod,
+		MaxLogFileBytes:      MaxLogFileBytes,
+		RetentionPeriod:      defaults.LogRetentionPeriod,
+		retentionSweepPeriod: defaultRetentionSweepPeriod,
+		TimeSource:           time.Now,
+		PartSizeBytes:        SessionStreamPartSizeBytes,
+		encryptionKey:        cfg.EncryptionKey,
+		compressSessions:     cfg.CompressSessions,
+		closer:               utils.NewCloseBroadcaster(),
 	}
 	if err := al.migrateSessions(); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	go al.sweepRetention()
 	return al, nil
 }
 
@@ -321,26 +608,181 @@ func (l *AuditLog) PostSessionChunk(namespace string, sid session.ID, reader io.
 // to receive a live stream of a given session. The reader allows access to a
 // session stream range from offsetBytes to offsetBytes+maxBytes
 //
+// Long sessions are split across multiple part files (see
+// SessionStreamPartSizeBytes), so a requested range can span more than one
+// of them; this walks forward through as many parts as it takes to satisfy
+// maxBytes, or until it runs out of recording.
 func (l *AuditLog) GetSessionChunk(namespace string, sid session.ID, offsetBytes, maxBytes int) ([]byte, error) {
 	log.Debugf("audit.log: getSessionReader(%v, %v)", namespace, sid)
 	if namespace == "" {
 		return nil, trace.BadParameter("missing parameter namespace")
 	}
-	fstream, err := os.OpenFile(l.sessionStreamFn(namespace, sid), os.O_RDONLY, 0640)
+	streamBaseFn := l.sessionStreamFn(namespace, sid)
+
+	// recordings made before session streams were split into parts are a
+	// single flat file with no part suffix; read it directly.
+	if _, err := os.Stat(streamBaseFn); err == nil {
+		return l.readSessionPart(streamBaseFn, sid, 0, offsetBytes, maxBytes)
+	}
+
+	part := int(int64(offsetBytes) / l.PartSizeBytes)
+	within := int(int64(offsetBytes) % l.PartSizeBytes)
+	physicalBase, err := l.partPhysicalBase(streamBaseFn, part)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var out bytes.Buffer
+	for out.Len() < maxBytes {
+		partFn := sessionPartFn(streamBaseFn, part)
+		data, err := l.readSessionPart(partFn, sid, physicalBase, within, maxBytes-out.Len())
+		if trace.IsNotFound(err) {
+			break // no such part: reached the end of the recording
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if len(data) == 0 {
+			break
+		}
+		out.Write(data)
+		size, err := fileSize(partFn)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		physicalBase += size
+		within = 0
+		part++
+	}
+	return out.Bytes(), nil
+}
+
+// partPhysicalBase returns the cumulative on-disk size of every part file
+// before the given one. readSessionPart needs it to keep the encryption
+// keystream for a compressed session correctly positioned when a read
+// starts partway through a recording, since a compressed part's on-disk
+// size differs from its logical one.
+func (l *AuditLog) partPhysicalBase(streamBaseFn string, part int) (int64, error) {
+	var total int64
+	for i := 0; i < part; i++ {
+		size, err := fileSize(sessionPartFn(streamBaseFn, i))
+		if err != nil {
+			return 0, trace.Wrap(err)
+		}
+		total += size
+	}
+	return total, nil
+}
+
+func fileSize(fn string) (int64, error) {
+	fi, err := os.Stat(fn)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	return fi.Size(), nil
+}
+
+// readSessionPart reads up to maxBytes of a session's stream starting
+// 'within' logical bytes into fn, which is either one numbered part file
+// or, for a legacy pre-chunking recording, the session's single stream
+// file. physicalBase is the combined on-disk size of every part before fn;
+// see partPhysicalBase.
+func (l *AuditLog) readSessionPart(fn string, sid session.ID, physicalBase int64, within, maxBytes int) ([]byte, error) {
+	fstream, err := os.OpenFile(fn, os.O_RDONLY, 0640)
 	if err != nil {
-		log.Warning(err)
 		return nil, trace.Wrap(err)
 	}
 	defer fstream.Close()
 
-	// seek to 'offset' from the beginning
-	fstream.Seek(int64(offsetBytes), 0)
+	if l.compressSessions {
+		return l.readCompressedSessionChunk(fstream, sid, physicalBase, within, maxBytes)
+	}
+
+	// seek to 'within' from the beginning
+	fstream.Seek(int64(within), 0)
 
 	// copy up to maxBytes from the offset position:
 	var buff bytes.Buffer
 	io.Copy(&buff, io.LimitReader(fstream, int64(maxBytes)))
 
-	return buff.Bytes(), nil
+	data := buff.Bytes()
+	if len(l.encryptionKey) > 0 {
+		stream, err := newStreamCipher(l.encryptionKey, string(sid), physicalBase+int64(within))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		stream.XORKeyStream(data, data)
+	}
+
+	return data, nil
+}
+
+// readCompressedSessionChunk reassembles a logical byte range from one
+// part of a compressed stream file. Each chunk written by
+// writeCompressedChunk is stored as an independently length-framed,
+// gzip-compressed (and, optionally, encrypted) record, so unlike the
+// plaintext format there's no way to seek straight to a logical byte
+// offset: every read walks the part from the start, decompressing each
+// record in turn until the requested window is covered. That trades away
+// O(1) replay seeks for the storage savings compression buys; recordings
+// are scrubbed occasionally rather than streamed continuously, and now
+// that streams are capped to SessionStreamPartSizeBytes per part, the scan
+// is bounded by a single part's size rather than the whole recording's.
+//
+// physicalOffset starts at physicalBase (the on-disk size of every earlier
+// part combined) rather than 0, so the decryption keystream -- which is
+// keyed once per session, not per part -- stays correctly positioned
+// across a part boundary.
+func (l *AuditLog) readCompressedSessionChunk(fstream *os.File, sid session.ID, physicalBase int64, offsetBytes, maxBytes int) ([]byte, error) {
+	var out bytes.Buffer
+	var logicalOffset int64
+	physicalOffset := physicalBase
+	var header [compressedChunkHeaderSize]byte
+	for out.Len() < maxBytes {
+		if _, err := io.ReadFull(fstream, header[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, trace.Wrap(err)
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, err := io.ReadFull(fstream, payload); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		physicalOffset += int64(len(header))
+		if len(l.encryptionKey) > 0 {
+			stream, err := newStreamCipher(l.encryptionKey, string(sid), physicalOffset)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			stream.XORKeyStream(payload, payload)
+		}
+		physicalOffset += int64(len(payload))
+
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		rawChunk, err := ioutil.ReadAll(gz)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+
+		chunkStart := logicalOffset
+		logicalOffset += int64(len(rawChunk))
+		if logicalOffset <= int64(offsetBytes) {
+			continue
+		}
+		from := int64(0)
+		if chunkStart < int64(offsetBytes) {
+			from = int64(offsetBytes) - chunkStart
+		}
+		to := int64(len(rawChunk))
+		if want := int64(maxBytes) - int64(out.Len()); to-from > want {
+			to = from + want
+		}
+		out.Write(rawChunk[from:to])
+	}
+	return out.Bytes(), nil
 }
 
 // Returns all events that happen during a session sorted by time
@@ -423,7 +865,11 @@ func (l *AuditLog) EmitAuditEvent(eventType string, fields EventFields) error {
 	}
 	// log it to the main log file:
 	if l.file != nil {
-		fmt.Fprintln(l.file, line)
+		n, err := fmt.Fprintln(l.file, line)
+		if err != nil {
+			log.Error(err)
+		}
+		atomic.AddInt64(&l.fileBytes, int64(n))
 	}
 	return nil
 }
@@ -454,7 +900,7 @@ func (l *AuditLog) SearchEvents(fromUTC, toUTC time.Time, query string) ([]Event
 	filtered := make([]os.FileInfo, 0, days)
 	for i := range entries {
 		fi := entries[i]
-		if fi.IsDir() || filepath.Ext(fi.Name()) != LogfileExt {
+		if fi.IsDir() || !isLogFileName(fi.Name()) {
 			continue
 		}
 		fd := fi.ModTime().UTC()
@@ -502,8 +948,20 @@ func (l *AuditLog) findInFile(fn string, query url.Values) ([]EventFields, error
 	}
 	defer lf.Close()
 
+	// a retention-swept partition that's been rotated away is
+	// gzip-compressed (see compressLogFile); transparently decompress it.
+	var reader io.Reader = lf
+	if strings.HasSuffix(fn, CompressedLogfileExt) {
+		gz, err := gzip.NewReader(lf)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
 	// for each line...
-	scanner := bufio.NewScanner(lf)
+	scanner := bufio.NewScanner(reader)
 	for lineNo := 0; scanner.Scan(); lineNo++ {
 		accepted := false
 		// optimization: to avoid parsing JSON unnecessarily, lets see if we
@@ -536,43 +994,156 @@ func (l *AuditLog) findInFile(fn string, query url.Values) ([]EventFields, error
 	return retval, nil
 }
 
-// rotateLog() checks if the current log file is older than a given duration,
-// and if it is, closes it and opens a new one
+// logFileName returns the path of the day partition for fileTime, seq. seq
+// is 0 for the first partition opened for fileTime, and increments every
+// time MaxLogFileBytes forces a rollover within the same period, so that
+// partition doesn't overwrite the one before it.
+func logFileName(dataDir string, fileTime time.Time, seq int) string {
+	name := fileTime.Format("2006-01-02.15:04:05")
+	if seq > 0 {
+		name = fmt.Sprintf("%s.%d", name, seq)
+	}
+	return filepath.Join(dataDir, name+LogfileExt)
+}
+
+// rotateLog() checks if the current log file is older than a given duration
+// or has grown past MaxLogFileBytes, and if so, closes it (compressing it
+// in the background) and opens a new one.
 func (l *AuditLog) rotateLog() (err error) {
 	// determine the timestamp for the current log file
 	fileTime := l.TimeSource().In(time.UTC).Round(l.RotationPeriod)
 
-	openLogFile := func() error {
+	openLogFile := func(seq int) error {
 		l.Lock()
 		defer l.Unlock()
-		logfname := filepath.Join(l.dataDir,
-			fileTime.Format("2006-01-02.15:04:05")+LogfileExt)
-		l.file, err = os.OpenFile(logfname, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+		l.file, err = os.OpenFile(logFileName(l.dataDir, fileTime, seq), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
 		if err != nil {
 			log.Error(err)
 		}
 		l.fileTime = fileTime
+		l.fileSeq = seq
+		atomic.StoreInt64(&l.fileBytes, 0)
 		return trace.Wrap(err)
 	}
 
 	// need to create a log file?
 	if l.file == nil {
-		return openLogFile()
+		return openLogFile(0)
 	}
 
-	// time to advance the logfile?
-	if l.fileTime.Before(fileTime) {
-		l.file.Close()
-		return openLogFile()
+	// time to advance the logfile, or has the current partition outgrown
+	// MaxLogFileBytes?
+	advancingPeriod := l.fileTime.Before(fileTime)
+	oversize := l.MaxLogFileBytes > 0 && atomic.LoadInt64(&l.fileBytes) >= l.MaxLogFileBytes
+	if advancingPeriod || oversize {
+		oldFile := l.file
+		oldFname := oldFile.Name()
+		oldFile.Close()
+		seq := 0
+		if !advancingPeriod {
+			seq = l.fileSeq + 1
+		}
+		go compressLogFile(oldFname)
+		return openLogFile(seq)
+	}
+	return nil
+}
+
+// compressLogFile gzip-compresses a rotated-away log partition and removes
+// the uncompressed original, so finished partitions don't go on taking up
+// as much disk space as they did while actively being written to. Run in
+// the background by rotateLog, since compression shouldn't hold up opening
+// the next partition.
+func compressLogFile(fn string) {
+	in, err := os.Open(fn)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(fn+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		log.Error(err)
+		return
+	}
+	if err := gz.Close(); err != nil {
+		log.Error(err)
+		return
+	}
+	if err := os.Remove(fn); err != nil {
+		log.Error(err)
+	}
+}
+
+// sweepRetention periodically deletes event log partitions (compressed or
+// not) older than RetentionPeriod, so the events directory doesn't grow
+// without bound. It does not touch session recordings -- those are kept
+// until explicitly purged via PurgeSessionData.
+func (l *AuditLog) sweepRetention() {
+	ticker := time.NewTicker(l.retentionSweepPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.sweepExpiredLogFiles(); err != nil {
+				log.Warningf("audit log retention sweep failed: %v", err)
+			}
+		case <-l.closer.C:
+			return
+		}
+	}
+}
+
+// sweepExpiredLogFiles removes every top-level event log partition whose
+// modification time is older than RetentionPeriod.
+func (l *AuditLog) sweepExpiredLogFiles() error {
+	if l.RetentionPeriod <= 0 {
+		return nil
+	}
+	cutoff := l.TimeSource().Add(-l.RetentionPeriod)
+	entries, err := ioutil.ReadDir(l.dataDir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, fi := range entries {
+		if fi.IsDir() || !isLogFileName(fi.Name()) {
+			continue
+		}
+		if fi.ModTime().After(cutoff) {
+			continue
+		}
+		fn := filepath.Join(l.dataDir, fi.Name())
+		if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
+			log.Warningf("failed to remove expired audit log partition %v: %v", fn, err)
+		}
 	}
 	return nil
 }
 
+// isLogFileName reports whether name looks like a main event log
+// partition, compressed or not, as opposed to a session log/stream file
+// (which lives under SessionLogsDir, a different directory, anyway) or
+// something unrelated an administrator dropped into dataDir.
+func isLogFileName(name string) bool {
+	return strings.HasSuffix(name, CompressedLogfileExt) || strings.HasSuffix(name, LogfileExt)
+}
+
 // Closes the audit log, which inluces closing all file handles and releasing
 // all session loggers
 func (l *AuditLog) Close() error {
 	l.Lock()
 	defer l.Unlock()
+	if l.closer != nil {
+		l.closer.Close()
+	}
 	if l.file != nil {
 		l.file.Close()
 		l.file = nil
@@ -604,6 +1175,37 @@ func (l *AuditLog) sessionLogFn(namespace string, sid session.ID) string {
 		fmt.Sprintf("%s%s", sid, SessionLogPrefix))
 }
 
+// PurgeSessionData permanently deletes the recording and event log of a
+// single session, e.g. to satisfy a data retention or right-to-erasure
+// request.
+func (l *AuditLog) PurgeSessionData(namespace string, sid session.ID) error {
+	l.Lock()
+	sl, active := l.loggers[sid]
+	if active {
+		delete(l.loggers, sid)
+	}
+	l.Unlock()
+	if active {
+		sl.Finalize()
+	}
+	// the session's stream may be a single legacy flat file, or split into
+	// part files plus an index (<streamBaseFn>.0000, .0001, ..., .index) --
+	// glob for every part/index file and remove the legacy path too, since
+	// at most one of the two layouts exists for any given session.
+	streamBaseFn := l.sessionStreamFn(namespace, sid)
+	parts, err := filepath.Glob(streamBaseFn + ".*")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	paths := append(parts, streamBaseFn, l.sessionLogFn(namespace, sid))
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 // LoggerFor creates a logger for a specified session. Session loggers allow
 // to group all events into special "session log files" for easier audit
 func (l *AuditLog) LoggerFor(namespace string, sid session.ID) (sl *SessionLogger, err error) {
@@ -624,8 +1226,15 @@ func (l *AuditLog) LoggerFor(namespace string, sid session.ID) (sl *SessionLogge
 		log.Error(err)
 		return nil, trace.Wrap(err)
 	}
-	// create a new session stream file:
-	fstream, err := os.OpenFile(l.sessionStreamFn(namespace, sid), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	// create the first part of a new session stream file:
+	streamBaseFn := l.sessionStreamFn(namespace, sid)
+	fstream, err := os.OpenFile(sessionPartFn(streamBaseFn, 0), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		log.Error(err)
+		return nil, trace.Wrap(err)
+	}
+	// create its index file:
+	findex, err := os.OpenFile(sessionIndexFn(streamBaseFn), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
 	if err != nil {
 		log.Error(err)
 		return nil, trace.Wrap(err)
@@ -637,11 +1246,16 @@ func (l *AuditLog) LoggerFor(namespace string, sid session.ID) (sl *SessionLogge
 		return nil, trace.Wrap(err)
 	}
 	sl = &SessionLogger{
-		sid:         sid,
-		streamFile:  fstream,
-		eventsFile:  fevents,
-		timeSource:  l.TimeSource,
-		createdTime: l.TimeSource().In(time.UTC).Round(time.Second),
+		sid:           sid,
+		streamBaseFn:  streamBaseFn,
+		streamFile:    fstream,
+		indexFile:     findex,
+		eventsFile:    fevents,
+		timeSource:    l.TimeSource,
+		createdTime:   l.TimeSource().In(time.UTC).Round(time.Second),
+		encryptionKey: l.encryptionKey,
+		compressed:    l.compressSessions,
+		partSizeBytes: l.PartSizeBytes,
 	}
 	l.loggers[sid] = sl
 	auditOpenFiles.Inc()
@@ -2,9 +2,12 @@ package events
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -154,3 +157,137 @@ func (a *AuditTestSuite) TestBasicLogging(c *check.C) {
 	c.Assert(string(bytes), check.Equals,
 		fmt.Sprintf("{\"apples?\":\"yes\",\"event\":\"user.farted\",\"time\":\"%s\"}\n", now.Format(time.RFC3339)))
 }
+
+// TestCompressedSessionChunk verifies that a session stream survives a
+// round trip through a compressed audit log, both when read in one piece
+// and when read at an offset that doesn't line up with a chunk boundary.
+func (a *AuditTestSuite) TestCompressedSessionChunk(c *check.C) {
+	dataDir := c.MkDir()
+	alogI, err := NewAuditLogWithConfig(AuditLogConfig{DataDir: dataDir, CompressSessions: true})
+	c.Assert(err, check.IsNil)
+	alog, ok := alogI.(*AuditLog)
+	c.Assert(ok, check.Equals, true)
+
+	err = alog.PostSessionChunk(defaults.Namespace, "500", bytes.NewBufferString("hello"))
+	c.Assert(err, check.IsNil)
+	err = alog.PostSessionChunk(defaults.Namespace, "500", bytes.NewBufferString(" world"))
+	c.Assert(err, check.IsNil)
+
+	buff, err := alog.GetSessionChunk(defaults.Namespace, "500", 0, 5000)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(buff), check.Equals, "hello world")
+
+	buff, err = alog.GetSessionChunk(defaults.Namespace, "500", 3, 5000)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(buff), check.Equals, "lo world")
+}
+
+// TestSessionStreamParts verifies that a session stream rolled over into
+// several part files reads back correctly, including a range that spans
+// a part boundary, for both plain and compressed recordings.
+func (a *AuditTestSuite) TestSessionStreamParts(c *check.C) {
+	for _, compressed := range []bool{false, true} {
+		dataDir := c.MkDir()
+		alogI, err := NewAuditLogWithConfig(AuditLogConfig{DataDir: dataDir, CompressSessions: compressed})
+		c.Assert(err, check.IsNil)
+		alog, ok := alogI.(*AuditLog)
+		c.Assert(ok, check.Equals, true)
+		alog.PartSizeBytes = 5
+
+		parts := []string{"hello", " worl", "d!"}
+		for _, p := range parts {
+			err = alog.PostSessionChunk(defaults.Namespace, "600", bytes.NewBufferString(p))
+			c.Assert(err, check.IsNil)
+		}
+
+		buff, err := alog.GetSessionChunk(defaults.Namespace, "600", 0, 5000)
+		c.Assert(err, check.IsNil)
+		c.Assert(string(buff), check.Equals, "hello world!")
+
+		// this range starts inside part 0 and ends inside part 2, so
+		// reading it exercises the part-boundary-crossing code path
+		buff, err = alog.GetSessionChunk(defaults.Namespace, "600", 3, 7)
+		c.Assert(err, check.IsNil)
+		c.Assert(string(buff), check.Equals, "lo worl")
+
+		c.Assert(alog.Close(), check.IsNil)
+	}
+}
+
+// TestLogFileSizeRotation verifies that a partition that grows past
+// MaxLogFileBytes is rolled over to a new, distinctly-named one instead of
+// waiting for the next RotationPeriod.
+func (a *AuditTestSuite) TestLogFileSizeRotation(c *check.C) {
+	dataDir := c.MkDir()
+	alogI, err := NewAuditLogWithConfig(AuditLogConfig{DataDir: dataDir})
+	c.Assert(err, check.IsNil)
+	alog, ok := alogI.(*AuditLog)
+	c.Assert(ok, check.Equals, true)
+	defer alog.Close()
+
+	alog.MaxLogFileBytes = 1 // force every event past the cap
+
+	c.Assert(alog.EmitAuditEvent("test.one", EventFields{}), check.IsNil)
+	c.Assert(alog.EmitAuditEvent("test.two", EventFields{}), check.IsNil)
+
+	entries, err := ioutil.ReadDir(dataDir)
+	c.Assert(err, check.IsNil)
+	var sawSeq bool
+	for _, fi := range entries {
+		// the first partition should have rolled over to a ".1" sequence
+		// suffix to make room for the second; the compression that follows
+		// a rollover runs in the background, so accept either the plain or
+		// already-compressed name.
+		if strings.Contains(fi.Name(), ".1.log") {
+			sawSeq = true
+		}
+	}
+	c.Assert(sawSeq, check.Equals, true)
+}
+
+// TestCompressLogFile verifies that a rotated-away partition is
+// gzip-compressed in place, with the uncompressed original removed.
+func (a *AuditTestSuite) TestCompressLogFile(c *check.C) {
+	dataDir := c.MkDir()
+	fn := filepath.Join(dataDir, "2016-04-25.00:00:00.log")
+	c.Assert(ioutil.WriteFile(fn, []byte("hello world\n"), 0640), check.IsNil)
+
+	compressLogFile(fn)
+
+	_, err := os.Stat(fn)
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+
+	compressed, err := ioutil.ReadFile(fn + ".gz")
+	c.Assert(err, check.IsNil)
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	c.Assert(err, check.IsNil)
+	raw, err := ioutil.ReadAll(gz)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(raw), check.Equals, "hello world\n")
+}
+
+// TestSweepExpiredLogFiles verifies that the retention sweep removes log
+// partitions older than RetentionPeriod and leaves newer ones alone.
+func (a *AuditTestSuite) TestSweepExpiredLogFiles(c *check.C) {
+	dataDir := c.MkDir()
+	alogI, err := NewAuditLogWithConfig(AuditLogConfig{DataDir: dataDir})
+	c.Assert(err, check.IsNil)
+	alog, ok := alogI.(*AuditLog)
+	c.Assert(ok, check.Equals, true)
+	defer alog.Close()
+	alog.RetentionPeriod = time.Hour
+
+	fresh := filepath.Join(dataDir, "fresh.log")
+	expired := filepath.Join(dataDir, "expired.log.gz")
+	c.Assert(ioutil.WriteFile(fresh, []byte("x"), 0640), check.IsNil)
+	c.Assert(ioutil.WriteFile(expired, []byte("x"), 0640), check.IsNil)
+	old := alog.TimeSource().Add(-2 * time.Hour)
+	c.Assert(os.Chtimes(expired, old, old), check.IsNil)
+
+	c.Assert(alog.sweepExpiredLogFiles(), check.IsNil)
+
+	_, err = os.Stat(fresh)
+	c.Assert(err, check.IsNil)
+	_, err = os.Stat(expired)
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2015 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+
+	"github.com/gravitational/trace"
+)
+
+// EncryptionKeySize is the size, in bytes, of a session recording
+// encryption key (AES-256).
+const EncryptionKeySize = 32
+
+// newStreamCipher returns an AES-256-CTR keystream for the given session,
+// seeked to start at byteOffset. CTR mode is used (rather than an AEAD like
+// GCM) specifically because it supports this kind of random access: session
+// replay and live tailing both read arbitrary [offset:offset+n) ranges out
+// of the recorded stream file, which an AEAD's authentication tag layout
+// would not allow without re-reading and re-verifying the whole file.
+//
+// The IV is derived from the key and session ID so that two sessions
+// encrypted with the same cluster key never reuse the same keystream.
+func newStreamCipher(key []byte, sid string, byteOffset int64) (cipher.Stream, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	iv := sessionIV(key, sid)
+	blockSize := int64(block.BlockSize())
+	advanceCounter(iv, byteOffset/blockSize)
+	stream := cipher.NewCTR(block, iv)
+	// discard the leading bytes of the block we seeked into so the
+	// keystream lines up exactly with byteOffset
+	if within := byteOffset % blockSize; within > 0 {
+		discard := make([]byte, within)
+		stream.XORKeyStream(discard, discard)
+	}
+	return stream, nil
+}
+
+// sessionIV derives a per-session initialization vector from the
+// encryption key and session ID.
+func sessionIV(key []byte, sid string) []byte {
+	h := sha256.Sum256(append(append([]byte{}, key...), []byte(sid)...))
+	return h[:aes.BlockSize]
+}
+
+// advanceCounter adds n to the big-endian counter represented by iv, the
+// same convention crypto/cipher's CTR implementation uses internally, so
+// that seeking to block n produces the identical keystream a sequential
+// XORKeyStream call would have reached by that point.
+func advanceCounter(iv []byte, n int64) {
+	for i := len(iv) - 1; i >= 0 && n > 0; i-- {
+		sum := int64(iv[i]) + n
+		iv[i] = byte(sum)
+		n = sum >> 8
+	}
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package elasticsearch implements an events.IAuditLog backend that indexes
+// audit events into Elasticsearch for full-text search (e.g. "what did this
+// user type during their session?"), while leaving raw session recordings
+// (which don't benefit from indexing) on local disk.
+//
+// It's registered under the "elasticsearch" audit_events_uri scheme, e.g.:
+//
+//	audit_events_uri: "elasticsearch://localhost:9200/teleport-events?data_dir=/var/lib/teleport"
+//
+// No Elasticsearch client library is vendored in this tree, so this talks to
+// Elasticsearch's HTTP API directly with net/http and encoding/json. That
+// keeps the dependency footprint at zero, at the cost of only covering the
+// handful of endpoints this backend actually needs (index template and ILM
+// policy setup, document indexing, and _search).
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultIndex is the index (and index template/ILM policy) name used
+	// when the audit_events_uri doesn't specify one.
+	defaultIndex = "teleport-events"
+
+	// defaultRetentionDays is how long an event document lives before the
+	// ILM policy deletes it, if retention_days isn't set in the URI.
+	defaultRetentionDays = 90
+)
+
+// Config is the configuration for the Elasticsearch backend, parsed out of
+// an audit_events_uri like:
+//
+//	elasticsearch://es1:9200,es2:9200/teleport-events?data_dir=/var/lib/teleport&retention_days=365&https=true
+type Config struct {
+	// Addrs are the "host:port" addresses of the Elasticsearch nodes to
+	// send requests to. The first reachable one is used for each request.
+	Addrs []string
+	// HTTPS makes requests to Addrs over https instead of http.
+	HTTPS bool
+	// Index is the name of the index events are stored in (rotated daily as
+	// "<Index>-YYYY.MM.DD"), and of the index template and ILM policy that
+	// govern it.
+	Index string
+	// RetentionDays is how many days an event is kept before the ILM policy
+	// deletes it.
+	RetentionDays int
+	// DataDir is where session recordings (which aren't indexed) are kept,
+	// using the same on-disk format as the local "file://" backend.
+	DataDir string
+}
+
+// ParseConfig extracts a Config from an "elasticsearch://" audit_events_uri.
+func ParseConfig(u *url.URL) (*Config, error) {
+	cfg := &Config{
+		Addrs:         strings.Split(u.Host, ","),
+		Index:         strings.Trim(u.Path, "/"),
+		RetentionDays: defaultRetentionDays,
+	}
+	if cfg.Index == "" {
+		cfg.Index = defaultIndex
+	}
+	q := u.Query()
+	cfg.DataDir = q.Get("data_dir")
+	if cfg.DataDir == "" {
+		return nil, trace.BadParameter("elasticsearch audit_events_uri is missing the required data_dir query parameter (session recordings still need a local directory)")
+	}
+	if q.Get("https") == "true" {
+		cfg.HTTPS = true
+	}
+	if days := q.Get("retention_days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return nil, trace.BadParameter("invalid retention_days %q", days)
+		}
+		cfg.RetentionDays = n
+	}
+	return cfg, nil
+}
+
+// Log is an events.IAuditLog that indexes events into Elasticsearch for
+// search, while delegating everything session-recording-related (and, as a
+// safety net, event storage/enumeration by session) to a local audit log.
+type Log struct {
+	Config
+	client *http.Client
+	local  events.IAuditLog
+}
+
+// New creates an Elasticsearch-backed audit log from a parsed
+// "elasticsearch://" audit_events_uri, and ensures the index template and
+// ILM retention policy it depends on exist.
+func New(u *url.URL) (events.IAuditLog, error) {
+	cfg, err := ParseConfig(u)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	local, err := events.NewAuditLog(cfg.DataDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	l := &Log{
+		Config: *cfg,
+		client: &http.Client{Timeout: requestTimeout},
+		local:  local,
+	}
+	if err := l.setupILMPolicy(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := l.setupIndexTemplate(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return l, nil
+}
+
+// requestTimeout bounds how long a single Elasticsearch HTTP request is
+// allowed to take.
+const requestTimeout = 10 * time.Second
+
+func init() {
+	events.RegisterAuditLog("elasticsearch", New)
+}
+
+// setupILMPolicy creates an index lifecycle management policy that deletes
+// event indices once they're older than RetentionDays, so operators don't
+// have to prune old audit data by hand.
+func (l *Log) setupILMPolicy() error {
+	policy := map[string]interface{}{
+		"policy": map[string]interface{}{
+			"phases": map[string]interface{}{
+				"delete": map[string]interface{}{
+					"min_age": fmt.Sprintf("%vd", l.RetentionDays),
+					"actions": map[string]interface{}{
+						"delete": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}
+	_, err := l.do("PUT", fmt.Sprintf("/_ilm/policy/%v-policy", l.Index), policy)
+	return trace.Wrap(err)
+}
+
+// setupIndexTemplate creates an index template so every daily "<Index>-*"
+// index gets consistent field mappings and is placed under the ILM policy,
+// without operators having to configure each new index by hand.
+func (l *Log) setupIndexTemplate() error {
+	template := map[string]interface{}{
+		"index_patterns": []string{l.Index + "-*"},
+		"settings": map[string]interface{}{
+			"index.lifecycle.name": l.Index + "-policy",
+		},
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				events.EventType: map[string]interface{}{"type": "keyword"},
+				events.EventTime: map[string]interface{}{"type": "date"},
+			},
+		},
+	}
+	_, err := l.do("PUT", fmt.Sprintf("/_index_template/%v", l.Index), template)
+	return trace.Wrap(err)
+}
+
+// indexName returns the name of today's rotating index, matching the
+// "<Index>-*" pattern the index template and ILM policy are set up for.
+func (l *Log) indexName() string {
+	return fmt.Sprintf("%v-%v", l.Index, time.Now().UTC().Format("2006.01.02"))
+}
+
+// EmitAuditEvent records eventType/fields in the local audit log (the
+// source of truth GetSessionEvents and friends read from), then indexes a
+// copy into Elasticsearch for full-text search. Indexing failures are
+// logged, not returned: search is a convenience on top of the audit trail,
+// and a search outage shouldn't fail sessions or auth attempts.
+func (l *Log) EmitAuditEvent(eventType string, fields events.EventFields) error {
+	if err := l.local.EmitAuditEvent(eventType, fields); err != nil {
+		return trace.Wrap(err)
+	}
+	doc := events.EventFields{}
+	for k, v := range fields {
+		doc[k] = v
+	}
+	doc[events.EventType] = eventType
+	if _, ok := doc[events.EventTime]; !ok {
+		doc[events.EventTime] = time.Now().UTC()
+	}
+	if _, err := l.do("POST", fmt.Sprintf("/%v/_doc", l.indexName()), doc); err != nil {
+		log.Warningf("failed to index audit event %v in elasticsearch: %v", eventType, err)
+	}
+	return nil
+}
+
+// SearchEvents runs query as an Elasticsearch query_string search (the same
+// Lucene-style syntax IAuditLog's doc comment already recommends) over
+// every event in [fromUTC, toUTC], across all of this cluster's daily
+// indices, letting security teams full-text search things like commands
+// typed in a session.
+func (l *Log) SearchEvents(fromUTC, toUTC time.Time, query string) ([]events.EventFields, error) {
+	must := []map[string]interface{}{
+		{
+			"range": map[string]interface{}{
+				events.EventTime: map[string]interface{}{
+					"gte": fromUTC.UTC().Format(time.RFC3339),
+					"lte": toUTC.UTC().Format(time.RFC3339),
+				},
+			},
+		},
+	}
+	if query != "" {
+		must = append(must, map[string]interface{}{
+			"query_string": map[string]interface{}{"query": query},
+		})
+	}
+	body := map[string]interface{}{
+		"size":  10000,
+		"sort":  []map[string]interface{}{{events.EventTime: "desc"}},
+		"query": map[string]interface{}{"bool": map[string]interface{}{"must": must}},
+	}
+	resp, err := l.do("GET", fmt.Sprintf("/%v-*/_search", l.Index), body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source events.EventFields `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]events.EventFields, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		out = append(out, hit.Source)
+	}
+	return out, nil
+}
+
+// PostSessionSlice is part of events.IAuditLog. Session data isn't indexed
+// for search, so it's kept on local disk only.
+func (l *Log) PostSessionSlice(slice events.SessionSlice) error {
+	return l.local.PostSessionSlice(slice)
+}
+
+// PostSessionChunk is part of events.IAuditLog.
+func (l *Log) PostSessionChunk(namespace string, sid session.ID, reader io.Reader) error {
+	return l.local.PostSessionChunk(namespace, sid, reader)
+}
+
+// GetSessionChunk is part of events.IAuditLog.
+func (l *Log) GetSessionChunk(namespace string, sid session.ID, offsetBytes, maxBytes int) ([]byte, error) {
+	return l.local.GetSessionChunk(namespace, sid, offsetBytes, maxBytes)
+}
+
+// GetSessionEvents is part of events.IAuditLog.
+func (l *Log) GetSessionEvents(namespace string, sid session.ID, after int) ([]events.EventFields, error) {
+	return l.local.GetSessionEvents(namespace, sid, after)
+}
+
+// PurgeSessionData is part of events.IAuditLog.
+func (l *Log) PurgeSessionData(namespace string, sid session.ID) error {
+	return l.local.PurgeSessionData(namespace, sid)
+}
+
+// Close is part of events.IAuditLog.
+func (l *Log) Close() error {
+	return l.local.Close()
+}
+
+// do sends a JSON request to the first reachable configured Elasticsearch
+// node and returns the response body.
+func (l *Log) do(method, path string, body interface{}) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	scheme := "http"
+	if l.HTTPS {
+		scheme = "https"
+	}
+	var lastErr error
+	for _, addr := range l.Addrs {
+		req, err := http.NewRequest(method, fmt.Sprintf("%v://%v%v", scheme, addr, path), bytes.NewReader(data))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := l.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			lastErr = trace.Errorf("elasticsearch %v %v returned %v: %v", method, path, resp.StatusCode, string(respBody))
+			continue
+		}
+		return respBody, nil
+	}
+	return nil, trace.Wrap(lastErr)
+}
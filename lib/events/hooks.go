@@ -0,0 +1,116 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package events
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Hook evaluates a single audit event as it's emitted and, if it judges the
+// event suspicious (impossible travel, an unusual hour, a user showing up
+// on a node they've never used before, ...), returns a non-nil Alert to
+// raise.
+//
+// Hooks run synchronously on every EmitAuditEvent call made through a
+// HookedAuditLog, so implementations must be fast and must not block on
+// anything that can stall -- do expensive analysis (e.g. calling out to an
+// ML model) in a goroutine and only report the alert for a later event, if
+// that fits the detection.
+//
+// Note: this tree has no lock or session-termination primitive, so a Hook
+// can only raise an AlertEvent for a human or another system to act on. A
+// hook wanting to automatically contain a user (disable their account,
+// terminate their sessions, ...) has to do so itself, e.g. through
+// services.Presence/services.Identity, rather than through this interface.
+type Hook interface {
+	// Name identifies the hook, e.g. in logs and in AlertHook.
+	Name() string
+	// Evaluate looks at eventType/fields and returns a non-nil Alert if it
+	// judges the event suspicious.
+	Evaluate(eventType string, fields EventFields) (*Alert, error)
+}
+
+// Alert is what a Hook returns when it judges an event suspicious.
+type Alert struct {
+	// Reason is a human-readable explanation of what tripped the hook.
+	Reason string
+}
+
+var hooksMutex sync.Mutex
+var hooks []Hook
+
+// RegisterHook registers a detection Hook that HookedAuditLog runs against
+// every emitted event. Typically called from a detection plugin's init(),
+// mirroring how audit log backends register themselves with
+// RegisterAuditLog.
+func RegisterHook(h Hook) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	hooks = append(hooks, h)
+}
+
+// registeredHooks returns a snapshot of the currently registered hooks.
+func registeredHooks() []Hook {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	out := make([]Hook, len(hooks))
+	copy(out, hooks)
+	return out
+}
+
+// HookedAuditLog is an IAuditLog that runs every registered Hook against
+// each emitted event, raising an AlertEvent for any hook that flags one.
+// With no hooks registered it costs nothing beyond the emit itself, so it's
+// safe to always wrap the audit log with it.
+type HookedAuditLog struct {
+	IAuditLog
+}
+
+// NewHookedAuditLog wraps server so every event emitted through it is also
+// evaluated by the hooks registered via RegisterHook.
+func NewHookedAuditLog(server IAuditLog) *HookedAuditLog {
+	return &HookedAuditLog{IAuditLog: server}
+}
+
+// EmitAuditEvent is part of IAuditLog. It records the event as usual, then
+// runs it past every registered Hook, emitting an AlertEvent for each one
+// that flags it. A hook error is logged, not returned: a broken detector
+// shouldn't fail the operation that triggered the event it's looking at.
+func (l *HookedAuditLog) EmitAuditEvent(eventType string, fields EventFields) error {
+	err := l.IAuditLog.EmitAuditEvent(eventType, fields)
+	for _, h := range registeredHooks() {
+		alert, evalErr := h.Evaluate(eventType, fields)
+		if evalErr != nil {
+			log.Warningf("detection hook %q failed to evaluate %v event: %v", h.Name(), eventType, evalErr)
+			continue
+		}
+		if alert == nil {
+			continue
+		}
+		if alertErr := l.IAuditLog.EmitAuditEvent(AlertEvent, EventFields{
+			AlertHook:        h.Name(),
+			AlertReason:      alert.Reason,
+			AlertSourceEvent: eventType,
+		}); alertErr != nil {
+			log.Warningf("failed to record alert raised by hook %q: %v", h.Name(), alertErr)
+		}
+	}
+	return err
+}
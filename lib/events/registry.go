@@ -0,0 +1,80 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package events
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/gravitational/trace"
+)
+
+// NewFunc constructs an IAuditLog from a parsed "audit_events_uri". The
+// scheme selects the implementation (e.g. "file", "dynamodb"); everything
+// else about the URI (host, path, query parameters) is up to that
+// implementation to interpret.
+type NewFunc func(uri *url.URL) (IAuditLog, error)
+
+var registry = struct {
+	sync.Mutex
+	m map[string]NewFunc
+}{m: make(map[string]NewFunc)}
+
+// RegisterAuditLog makes an IAuditLog implementation available under the
+// given URI scheme. It's meant to be called from the init() of a package
+// providing a custom events/session storage backend (e.g. Elasticsearch,
+// BigQuery), so that backend can be selected from config without patching
+// this package.
+//
+// Calling RegisterAuditLog twice with the same scheme is a bug and panics,
+// same as database/sql's driver registration.
+func RegisterAuditLog(scheme string, fn NewFunc) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.m[scheme]; ok {
+		panic("events: RegisterAuditLog called twice for scheme " + scheme)
+	}
+	registry.m[scheme] = fn
+}
+
+// NewAuditLogFromURI constructs the IAuditLog registered for uri's scheme.
+func NewAuditLogFromURI(uri string) (IAuditLog, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if u.Scheme == "" {
+		return nil, trace.BadParameter("audit_events_uri %q is missing a scheme, e.g. file://%v", uri, uri)
+	}
+	registry.Lock()
+	fn, ok := registry.m[u.Scheme]
+	registry.Unlock()
+	if !ok {
+		return nil, trace.BadParameter("no audit log backend is registered for scheme %q", u.Scheme)
+	}
+	return fn(u)
+}
+
+func init() {
+	RegisterAuditLog("file", func(u *url.URL) (IAuditLog, error) {
+		return NewAuditLog(u.Path)
+	})
+	RegisterAuditLog("discard", func(u *url.URL) (IAuditLog, error) {
+		return &DiscardAuditLog{}, nil
+	})
+}
@@ -26,6 +26,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gravitational/roundtrip"
 	"github.com/gravitational/trace"
@@ -91,7 +92,62 @@ func ConvertResponse(re *roundtrip.Response, err error) (*roundtrip.Response, er
 		}
 		return nil, trace.Wrap(err)
 	}
-	return re, trace.ReadError(re.Code(), re.Bytes())
+	convertedErr := trace.ReadError(re.Code(), re.Bytes())
+	if trace.IsLimitExceeded(convertedErr) {
+		if period, ok := GetRetryAfter(re.Headers()); ok {
+			convertedErr = &retryAfterError{error: convertedErr, period: period}
+		}
+	}
+	return re, convertedErr
+}
+
+// RetryAfterHeader is the standard HTTP header a rate or connection
+// limited server uses to tell the client how long to wait before
+// retrying the request.
+const RetryAfterHeader = "Retry-After"
+
+// SetRetryAfter sets the Retry-After header on h to period, rounded up to
+// the nearest whole second as required by the header's format.
+func SetRetryAfter(h http.Header, period time.Duration) {
+	seconds := int(period / time.Second)
+	if period%time.Second != 0 {
+		seconds++
+	}
+	h.Set(RetryAfterHeader, strconv.Itoa(seconds))
+}
+
+// GetRetryAfter reads the Retry-After header from h, if the server set
+// one.
+func GetRetryAfter(h http.Header) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(h.Get(RetryAfterHeader))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// retryAfterError decorates a rate or connection limited error with the
+// Retry-After period the server asked the client to wait, while still
+// answering trace.IsLimitExceeded the same way the error it wraps does.
+type retryAfterError struct {
+	error
+	period time.Duration
+}
+
+// IsLimitExceededError makes trace.IsLimitExceeded recognize a wrapped
+// error the same way it recognizes the error it decorates.
+func (e *retryAfterError) IsLimitExceededError() bool {
+	return true
+}
+
+// RetryAfter returns the Retry-After period the server attached to err
+// via ConvertResponse, if any. It sees through further trace.Wrap calls
+// the same way trace.IsLimitExceeded does.
+func RetryAfter(err error) (time.Duration, bool) {
+	if e, ok := trace.Unwrap(err).(*retryAfterError); ok {
+		return e.period, true
+	}
+	return 0, false
 }
 
 // SetNoCacheHeaders tells proxies and browsers do not cache the content
@@ -20,6 +20,9 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/httplib"
+
 	"github.com/gravitational/trace"
 	log "github.com/sirupsen/logrus"
 	"github.com/vulcand/oxy/connlimit"
@@ -32,6 +35,7 @@ type ConnectionsLimiter struct {
 	*sync.Mutex
 	connections    map[string]int64
 	maxConnections int64
+	auditLog       events.IAuditLog
 }
 
 // NewConnectionsLimiter returns new connection limiter, in case if connection
@@ -41,6 +45,7 @@ func NewConnectionsLimiter(config LimiterConfig) (*ConnectionsLimiter, error) {
 		Mutex:          &sync.Mutex{},
 		maxConnections: config.MaxConnections,
 		connections:    make(map[string]int64),
+		auditLog:       config.AuditLog,
 	}
 
 	ipExtractor, err := utils.NewExtractor("client.ip")
@@ -49,7 +54,14 @@ func NewConnectionsLimiter(config LimiterConfig) (*ConnectionsLimiter, error) {
 	}
 
 	limiter.ConnLimiter, err = connlimit.New(
-		nil, ipExtractor, config.MaxConnections)
+		nil, ipExtractor, config.MaxConnections,
+		connlimit.ErrorHandler(utils.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+			if token, _, extractErr := ipExtractor.Extract(r); extractErr == nil {
+				emitRateLimitExceeded(config.AuditLog, "connection", token)
+			}
+			httplib.SetRetryAfter(w.Header(), retryAfterPeriod)
+			utils.DefaultHandler.ServeHTTP(w, r, err)
+		})))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -77,6 +89,7 @@ func (l *ConnectionsLimiter) AcquireConnection(token string) error {
 		return nil
 	}
 	if numberOfConnections >= l.maxConnections {
+		emitRateLimitExceeded(l.auditLog, "connection", token)
 		return trace.LimitExceeded(
 			"too many connections from %v: %v, max is %v",
 			token, numberOfConnections, l.maxConnections)
@@ -20,6 +20,12 @@ package limiter
 import (
 	"encoding/json"
 	"net/http"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/gravitational/trace"
 	"github.com/mailgun/timetools"
@@ -43,6 +49,39 @@ type LimiterConfig struct {
 	MaxNumberOfUsers int
 	// Clock is an optional parameter, if not set, will use system time
 	Clock timetools.TimeProvider
+	// AuditLog, if set, receives a RateLimitExceededEvent every time this
+	// limiter rejects a connection or request, so a script hammering the
+	// auth or proxy endpoints shows up in the audit log instead of only in
+	// the process' own logs.
+	AuditLog events.IAuditLog
+}
+
+// retryAfterPeriod is sent to clients that get rejected by a connection or
+// rate limit, in a Retry-After header, so well-behaved clients (tsh, nodes
+// and proxies reconnecting after an auth restart) back off instead of
+// hammering the endpoint again immediately.
+const retryAfterPeriod = 5 * time.Second
+
+// emitRateLimitExceeded reports a tripped limit to config.AuditLog, if one
+// is set. It never fails the caller: an audit log outage shouldn't turn
+// into an even bigger denial of service on top of the one being blocked.
+func emitRateLimitExceeded(alog events.IAuditLog, limitType, token string) {
+	if alog == nil {
+		return
+	}
+	if err := alog.EmitAuditEvent(events.RateLimitExceededEvent, events.EventFields{
+		events.RateLimitType:  limitType,
+		events.RateLimitToken: token,
+	}); err != nil {
+		log.Warningf("failed to emit rate limit exceeded event: %v", err)
+	}
+}
+
+// ConfigureLimiter assigns the default parameters to a connection throttler
+// (AKA limiter)
+func ConfigureLimiter(lc *LimiterConfig) {
+	lc.MaxConnections = defaults.LimiterMaxConnections
+	lc.MaxNumberOfUsers = defaults.LimiterMaxConcurrentUsers
 }
 
 // SetEnv reads LimiterConfig from JSON string
@@ -16,9 +16,12 @@ limitations under the License.
 package limiter
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/utils"
 	"github.com/mailgun/timetools"
 
@@ -163,3 +166,35 @@ func (s *LimiterSuite) TestRateLimiter(c *C) {
 	}
 	c.Assert(err, NotNil)
 }
+
+func (s *LimiterSuite) TestAuditLog(c *C) {
+	dir, err := ioutil.TempDir("", "limiter")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+
+	alog, err := events.NewAuditLog(dir)
+	c.Assert(err, IsNil)
+	defer alog.Close()
+
+	limiter, err := NewLimiter(
+		LimiterConfig{
+			MaxConnections: 1,
+			AuditLog:       alog,
+		},
+	)
+	c.Assert(err, IsNil)
+
+	c.Assert(limiter.AcquireConnection("token1"), IsNil)
+	c.Assert(limiter.AcquireConnection("token1"), NotNil)
+
+	found, err := alog.SearchEvents(time.Now().UTC().Add(-time.Hour), time.Now().UTC().Add(time.Hour), "")
+	c.Assert(err, IsNil)
+
+	var seen bool
+	for _, e := range found {
+		if e[events.EventType] == events.RateLimitExceededEvent {
+			seen = true
+		}
+	}
+	c.Assert(seen, Equals, true)
+}
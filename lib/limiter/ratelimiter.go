@@ -22,6 +22,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/httplib"
+
 	"github.com/gravitational/trace"
 	"github.com/mailgun/timetools"
 	"github.com/mailgun/ttlmap"
@@ -35,8 +38,9 @@ type RateLimiter struct {
 	*ratelimit.TokenLimiter
 	rateLimits *ttlmap.TtlMap
 	*sync.Mutex
-	rates *ratelimit.RateSet
-	clock timetools.TimeProvider
+	rates    *ratelimit.RateSet
+	clock    timetools.TimeProvider
+	auditLog events.IAuditLog
 }
 
 // Rate defines connection rate
@@ -77,10 +81,18 @@ func NewRateLimiter(config LimiterConfig) (*RateLimiter, error) {
 	limiter.clock = config.Clock
 
 	limiter.TokenLimiter, err = ratelimit.New(nil, ipExtractor,
-		limiter.rates, ratelimit.Clock(config.Clock))
+		limiter.rates, ratelimit.Clock(config.Clock),
+		ratelimit.ErrorHandler(utils.ErrorHandlerFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+			if token, _, extractErr := ipExtractor.Extract(r); extractErr == nil {
+				emitRateLimitExceeded(config.AuditLog, "rate", token)
+			}
+			httplib.SetRetryAfter(w.Header(), retryAfterPeriod)
+			utils.DefaultHandler.ServeHTTP(w, r, err)
+		})))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	limiter.auditLog = config.AuditLog
 
 	maxNumberOfUsers := config.MaxNumberOfUsers
 	if maxNumberOfUsers <= 0 {
@@ -121,6 +133,7 @@ func (l *RateLimiter) RegisterRequest(token string) error {
 		return err
 	}
 	if delay > 0 {
+		emitRateLimitExceeded(l.auditLog, "rate", token)
 		return &ratelimit.MaxRateError{}
 	}
 	return nil
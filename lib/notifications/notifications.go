@@ -0,0 +1,262 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package notifications implements an events.IAuditLog decorator that fires
+// webhooks (Slack, PagerDuty, or a generic receiver) on selected security
+// events, using the services.NotificationConfig dynamic resource to pick
+// which events matter and where to send them, so operators can change that
+// without a restart.
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/session"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// requestTimeout bounds how long a single webhook delivery is allowed to
+// take, so a slow or unreachable receiver can't back up event processing.
+const requestTimeout = 10 * time.Second
+
+// Config is the configuration for the notifications audit log decorator.
+type Config struct {
+	// Server is the underlying audit log all calls are delegated to. It's
+	// the source of truth; webhook delivery is a side effect layered on
+	// top of it.
+	Server events.IAuditLog
+	// GetConfig fetches the current services.NotificationConfig. It's
+	// called on every event rather than cached, so enabling/disabling
+	// notifications or editing webhooks takes effect immediately.
+	GetConfig func() (services.NotificationConfig, error)
+	// Client is used to send webhook requests. Defaults to a client with
+	// requestTimeout if unset.
+	Client *http.Client
+	// SessionURL builds a web UI replay link for sid, included in the
+	// payload of webhooks fired for session.start/session.join/session.end
+	// so a ChatOps integration can jump straight from a notification to
+	// the session. Left nil, those payloads simply omit the link.
+	SessionURL func(sid session.ID) string
+}
+
+// CheckAndSetDefaults validates the config and sets defaults.
+func (cfg *Config) CheckAndSetDefaults() error {
+	if cfg.Server == nil {
+		return trace.BadParameter("notifications.Config is missing Server")
+	}
+	if cfg.GetConfig == nil {
+		return trace.BadParameter("notifications.Config is missing GetConfig")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: requestTimeout}
+	}
+	return nil
+}
+
+// AuditLog is an events.IAuditLog that fires webhooks on selected events,
+// as configured by services.NotificationConfig, in addition to recording
+// them normally.
+type AuditLog struct {
+	Config
+}
+
+// New wraps server with webhook notifications, as configured by whatever
+// services.NotificationConfig getConfig returns at the time of each event.
+func New(cfg Config) (*AuditLog, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &AuditLog{Config: cfg}, nil
+}
+
+// EmitAuditEvent is part of events.IAuditLog. It records the event as
+// usual, then fires any webhooks configured for eventType. Notification
+// failures are logged, not returned: a broken webhook shouldn't fail the
+// operation that triggered it.
+func (a *AuditLog) EmitAuditEvent(eventType string, fields events.EventFields) error {
+	err := a.Server.EmitAuditEvent(eventType, fields)
+	a.notify(eventType, fields)
+	return trace.Wrap(err)
+}
+
+// notify sends eventType/fields to every webhook configured to receive it.
+func (a *AuditLog) notify(eventType string, fields events.EventFields) {
+	cfg, err := a.GetConfig()
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			log.Warningf("failed to load notification config: %v", err)
+		}
+		return
+	}
+	if !cfg.GetEnabled() {
+		return
+	}
+	if !utils.SliceContainsStr(cfg.GetEvents(), eventType) {
+		return
+	}
+	for _, webhook := range cfg.GetWebhooks() {
+		go a.send(webhook, eventType, fields)
+	}
+}
+
+// payload is what a webhook receives when its Template is empty.
+type payload struct {
+	EventType string             `json:"event_type"`
+	Fields    events.EventFields `json:"fields"`
+	Time      time.Time          `json:"time"`
+	// SessionURL is a web UI replay link for the session the event
+	// belongs to, if any -- see Config.SessionURL.
+	SessionURL string `json:"session_url,omitempty"`
+}
+
+// sessionLifecycleEvents are the event types a replay link is meaningful
+// for.
+var sessionLifecycleEvents = map[string]bool{
+	events.SessionStartEvent: true,
+	events.SessionJoinEvent:  true,
+	events.SessionEndEvent:   true,
+}
+
+// send renders webhook's Template (or, if unset, a generic JSON payload)
+// and POSTs it to webhook.URL, logging a warning on failure.
+func (a *AuditLog) send(webhook services.Webhook, eventType string, fields events.EventFields) {
+	body, contentType, err := a.renderWebhook(webhook, eventType, fields)
+	if err != nil {
+		log.Warningf("failed to render notification webhook %q for %v: %v", webhook.Name, eventType, err)
+		return
+	}
+	req, err := http.NewRequest("POST", webhook.URL, body)
+	if err != nil {
+		log.Warningf("failed to build notification webhook %q request for %v: %v", webhook.Name, eventType, err)
+		return
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		log.Warningf("failed to deliver notification webhook %q for %v: %v", webhook.Name, eventType, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Warningf("notification webhook %q for %v returned %v", webhook.Name, eventType, resp.StatusCode)
+	}
+}
+
+// renderWebhook renders the request body for webhook.
+func (a *AuditLog) renderWebhook(webhook services.Webhook, eventType string, fields events.EventFields) (io.Reader, string, error) {
+	data := payload{EventType: eventType, Fields: fields, Time: time.Now().UTC()}
+	if a.SessionURL != nil && sessionLifecycleEvents[eventType] {
+		if sid := fields.GetString(events.SessionEventID); sid != "" {
+			data.SessionURL = a.SessionURL(session.ID(sid))
+		}
+	}
+	if webhook.Template == "" {
+		out, err := json.Marshal(data)
+		if err != nil {
+			return nil, "", trace.Wrap(err)
+		}
+		return bytes.NewReader(out), "application/json", nil
+	}
+	tmpl, err := template.New(webhook.Name).Parse(webhook.Template)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	// fields routinely carries attacker-influenced strings -- e.g. the key
+	// ID off an unauthenticated SSH certificate on a failed login attempt
+	// -- and an operator's template typically interpolates them directly
+	// into a hand-written JSON payload (e.g. "user": "{{.Fields.user}}").
+	// Escape them for safe embedding in a double-quoted JSON string so a
+	// field value can't break out of its string and inject/overwrite
+	// other fields, regardless of what the template itself does.
+	data.Fields = escapeFields(fields)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	return &buf, "application/json", nil
+}
+
+// escapeFields returns a copy of fields with every string value escaped
+// for safe embedding inside a double-quoted JSON string, without the
+// surrounding quotes themselves.
+func escapeFields(fields events.EventFields) events.EventFields {
+	escaped := make(events.EventFields, len(fields))
+	for k, v := range fields {
+		if s, ok := v.(string); ok {
+			escaped[k] = jsonEscapeString(s)
+			continue
+		}
+		escaped[k] = v
+	}
+	return escaped
+}
+
+// jsonEscapeString returns s escaped as the content of a JSON string,
+// minus the surrounding quotes json.Marshal would otherwise add.
+func jsonEscapeString(s string) string {
+	out, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(out), `"`), `"`)
+}
+
+// PostSessionSlice is part of events.IAuditLog.
+func (a *AuditLog) PostSessionSlice(slice events.SessionSlice) error {
+	return a.Server.PostSessionSlice(slice)
+}
+
+// PostSessionChunk is part of events.IAuditLog.
+func (a *AuditLog) PostSessionChunk(namespace string, sid session.ID, reader io.Reader) error {
+	return a.Server.PostSessionChunk(namespace, sid, reader)
+}
+
+// GetSessionChunk is part of events.IAuditLog.
+func (a *AuditLog) GetSessionChunk(namespace string, sid session.ID, offsetBytes, maxBytes int) ([]byte, error) {
+	return a.Server.GetSessionChunk(namespace, sid, offsetBytes, maxBytes)
+}
+
+// GetSessionEvents is part of events.IAuditLog.
+func (a *AuditLog) GetSessionEvents(namespace string, sid session.ID, after int) ([]events.EventFields, error) {
+	return a.Server.GetSessionEvents(namespace, sid, after)
+}
+
+// SearchEvents is part of events.IAuditLog.
+func (a *AuditLog) SearchEvents(fromUTC, toUTC time.Time, query string) ([]events.EventFields, error) {
+	return a.Server.SearchEvents(fromUTC, toUTC, query)
+}
+
+// PurgeSessionData is part of events.IAuditLog.
+func (a *AuditLog) PurgeSessionData(namespace string, sid session.ID) error {
+	return a.Server.PurgeSessionData(namespace, sid)
+}
+
+// Close is part of events.IAuditLog.
+func (a *AuditLog) Close() error {
+	return a.Server.Close()
+}
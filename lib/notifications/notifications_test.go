@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/services"
+)
+
+// TestRenderWebhookEscapesFields verifies that an attacker-controlled event
+// field (e.g. the key ID off an unauthenticated SSH certificate on a failed
+// login attempt) can't break out of its string and inject other fields into
+// an operator's hand-written JSON template.
+func TestRenderWebhookEscapesFields(t *testing.T) {
+	a := &AuditLog{}
+	webhook := services.Webhook{
+		Name:     "test",
+		Template: `{"user": "{{.Fields.user}}", "injected": false}`,
+	}
+	fields := events.EventFields{
+		"user": `attacker", "injected": true, "ignored": "`,
+	}
+
+	body, _, err := a.renderWebhook(webhook, events.AuthAttemptEvent, fields)
+	if err != nil {
+		t.Fatalf("renderWebhook failed: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.NewDecoder(body).Decode(&decoded); err != nil {
+		t.Fatalf("rendered payload is not valid JSON: %v", err)
+	}
+	if decoded["injected"] != false {
+		t.Fatalf("attacker field value overwrote \"injected\": got %v", decoded["injected"])
+	}
+}
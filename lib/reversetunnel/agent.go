@@ -249,6 +249,20 @@ func (a *Agent) proxyTransport(ch ssh.Channel, reqC <-chan *ssh.Request) {
 	}
 	req.Reply(true, []byte("connected"))
 
+	// the dialing side may immediately follow up with a request to
+	// compress this stream; wait briefly for it before starting to proxy
+	var rw io.ReadWriteCloser = ch
+	select {
+	case req = <-reqC:
+		if req != nil && req.Type == chanTransportCompressReq {
+			if req.WantReply {
+				req.Reply(true, nil)
+			}
+			rw = newCompressedChannel(ch)
+		}
+	case <-time.After(defaults.DefaultDialTimeout):
+	}
+
 	a.log.Infof("successfully dialed to %v, start proxying", server)
 
 	wg := sync.WaitGroup{}
@@ -260,12 +274,12 @@ func (a *Agent) proxyTransport(ch ssh.Channel, reqC <-chan *ssh.Request) {
 		// close, otherwise the other goroutine would never know
 		// as it will block on read from the connection
 		defer conn.Close()
-		io.Copy(conn, ch)
+		io.Copy(conn, rw)
 	}()
 
 	go func() {
 		defer wg.Done()
-		io.Copy(ch, conn)
+		io.Copy(rw, conn)
 	}()
 
 	wg.Wait()
@@ -352,12 +366,16 @@ func (a *Agent) runHeartbeat(conn *ssh.Client) {
 	}
 
 	if err != nil || conn == nil {
+		// jitter the reconnect delay so a proxy or auth server restart
+		// doesn't cause every agent to redial in lockstep
+		reconnectDelay := defaults.ReverseTunnelAgentHeartbeatPeriod/2 +
+			utils.RandomDuration(defaults.ReverseTunnelAgentHeartbeatPeriod/2)
 		select {
 		// abort if asked to stop:
 		case <-a.broadcastClose.C:
 			return
 			// reconnect
-		case <-ticker.C:
+		case <-time.After(reconnectDelay):
 			a.Start()
 		}
 	}
@@ -368,6 +386,15 @@ const (
 	chanAccessPoint      = "teleport-access-point"
 	chanTransport        = "teleport-transport"
 	chanTransportDialReq = "teleport-transport-dial"
+
+	// chanTransportCompressReq is sent by the dialing side of a
+	// teleport-transport channel right after a successful dial, to ask the
+	// agent to gzip-compress the proxied TCP/IP stream. A peer that
+	// doesn't know this request (e.g. hasn't been updated yet) answers it
+	// with the same automatic failure reply it'd send for any other
+	// unrecognized request, which is indistinguishable from declining, so
+	// it's always safe to send.
+	chanTransportCompressReq = "teleport-transport-compress"
 )
 
 const (
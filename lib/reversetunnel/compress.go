@@ -0,0 +1,73 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+package reversetunnel
+
+import (
+	"compress/gzip"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// compressedChannel wraps an ssh.Channel, transparently gzip-compressing
+// everything written to it and decompressing everything read from it. It's
+// only ever used once both ends of a teleport-transport channel have
+// negotiated compression via chanTransportCompressReq, so either side can
+// assume the other is doing the same.
+//
+// The underlying readers/writers are created lazily, on first use: eagerly
+// creating a gzip.Reader here would block reading the other side's header
+// before either goroutine had a chance to start proxying.
+type compressedChannel struct {
+	ssh.Channel
+	gzr *gzip.Reader
+	gzw *gzip.Writer
+}
+
+func newCompressedChannel(ch ssh.Channel) *compressedChannel {
+	return &compressedChannel{Channel: ch}
+}
+
+func (c *compressedChannel) Read(p []byte) (int, error) {
+	if c.gzr == nil {
+		gzr, err := gzip.NewReader(c.Channel)
+		if err != nil {
+			return 0, err
+		}
+		c.gzr = gzr
+	}
+	return c.gzr.Read(p)
+}
+
+// Write compresses p and flushes it immediately, since this channel carries
+// live proxied traffic and can't wait for a deflate buffer to fill up.
+func (c *compressedChannel) Write(p []byte) (int, error) {
+	if c.gzw == nil {
+		c.gzw = gzip.NewWriter(c.Channel)
+	}
+	n, err := c.gzw.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, c.gzw.Flush()
+}
+
+func (c *compressedChannel) Close() error {
+	if c.gzw != nil {
+		c.gzw.Close()
+	}
+	return c.Channel.Close()
+}
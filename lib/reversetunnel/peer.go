@@ -0,0 +1,249 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+package reversetunnel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// chanPeerTransport is the channel type one proxy in an HA pool uses to ask
+// a sibling proxy to dial a site on its behalf. It's a fallback for a
+// session that lands on a proxy which has no live reverse tunnel connection
+// for the target site because the site's agent(s) happened to tunnel in
+// through a different proxy in the pool.
+const chanPeerTransport = "teleport-peer-transport"
+
+// peerDialRequest is the JSON payload sent as ssh.NewChannel extra data on
+// chanPeerTransport requests.
+type peerDialRequest struct {
+	Cluster string `json:"cluster"`
+	Network string `json:"network"`
+	Addr    string `json:"addr"`
+}
+
+// dialViaPeer asks every proxy registered in the cluster to dial addr in
+// cluster on our behalf, returning the first successful connection. This is
+// a single-hop fallback: a peer services the request directly against its
+// own local tunnel connections and never forwards it any further, so this
+// never recurses.
+func (s *server) dialViaPeer(cluster, network, addr string) (net.Conn, error) {
+	if len(s.hostSigners) == 0 {
+		return nil, trace.NotFound("no peer proxies available: peering is not configured")
+	}
+	proxies, err := s.localAuth.GetProxies()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	proxies = s.orderProxiesByAffinity(cluster, addr, proxies)
+	var lastErr error
+	for _, proxy := range proxies {
+		if proxy.GetTunnelAddr() == "" {
+			continue
+		}
+		conn, err := s.dialPeerProxy(proxy, cluster, network, addr)
+		if err != nil {
+			log.Debugf("[TUNNEL] peer proxy %v could not dial %v/%v: %v", proxy.GetName(), cluster, addr, err)
+			lastErr = err
+			continue
+		}
+		return conn, nil
+	}
+	if lastErr == nil {
+		lastErr = trace.NotFound("no peer proxy is tunneled to cluster %v", cluster)
+	}
+	return nil, lastErr
+}
+
+// orderProxiesByAffinity reorders proxies so that any in the same region
+// (services.RegionLabel) as the node at addr are tried before the rest,
+// breaking ties within a region by a quick TCP dial latency probe to each
+// candidate's tunnel port. This only changes the order candidates are
+// tried in; dialViaPeer still falls through to every proxy on failure, so
+// affinity is a performance preference, not a correctness requirement.
+func (s *server) orderProxiesByAffinity(cluster, addr string, proxies []services.Server) []services.Server {
+	region := s.nodeRegion(cluster, addr)
+	if region == "" {
+		return proxies
+	}
+	var local, other []services.Server
+	for _, proxy := range proxies {
+		if proxy.GetAllLabels()[services.RegionLabel] == region {
+			local = append(local, proxy)
+		} else {
+			other = append(other, proxy)
+		}
+	}
+	sortByProbedLatency(local)
+	return append(local, other...)
+}
+
+// nodeRegion returns the region label of the node being dialed at addr, if
+// it's a node in this auth server's own cluster. Returns "" if the node
+// can't be found or has no region label, in which case affinity is
+// skipped; trusted-cluster nodes aren't visible here, so routing to them
+// is never affected.
+func (s *server) nodeRegion(cluster, addr string) string {
+	domainName, err := s.localAuth.GetDomainName()
+	if err != nil || cluster != domainName {
+		return ""
+	}
+	nodes, err := s.localAuth.GetNodes(defaults.Namespace)
+	if err != nil {
+		return ""
+	}
+	for _, node := range nodes {
+		if node.GetAddr() == addr {
+			return node.GetAllLabels()[services.RegionLabel]
+		}
+	}
+	return ""
+}
+
+// sortByProbedLatency orders proxies in place by the round-trip time of a
+// plain TCP dial to their tunnel port, a cheap stand-in for network
+// latency. A proxy that can't be reached at all, or has no tunnel address
+// to probe, sorts last; dialViaPeer will simply fail fast on it in turn.
+func sortByProbedLatency(proxies []services.Server) {
+	if len(proxies) < 2 {
+		return
+	}
+	latency := make(map[string]time.Duration, len(proxies))
+	for _, proxy := range proxies {
+		latency[proxy.GetName()] = probeLatency(proxy.GetTunnelAddr())
+	}
+	sort.SliceStable(proxies, func(i, j int) bool {
+		return latency[proxies[i].GetName()] < latency[proxies[j].GetName()]
+	})
+}
+
+// probeLatency measures how long a TCP handshake takes to addr.
+func probeLatency(addr string) time.Duration {
+	if addr == "" {
+		return time.Hour
+	}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err != nil {
+		return time.Hour
+	}
+	conn.Close()
+	return time.Since(start)
+}
+
+// dialPeerProxy connects to a sibling proxy's reverse tunnel port using this
+// proxy's own host certificate, and asks it to dial addr in cluster on our
+// behalf over an authenticated inter-proxy channel.
+func (s *server) dialPeerProxy(proxy services.Server, cluster, network, addr string) (net.Conn, error) {
+	tunnelAddr := proxy.GetTunnelAddr()
+	client, err := ssh.Dial("tcp", tunnelAddr, &ssh.ClientConfig{
+		User:            proxy.GetName(),
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(s.hostSigners...)},
+		HostKeyCallback: s.checkPeerHostSignature,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err, "dialing peer proxy %v at %v", proxy.GetName(), tunnelAddr)
+	}
+	payload, err := json.Marshal(peerDialRequest{Cluster: cluster, Network: network, Addr: addr})
+	if err != nil {
+		client.Close()
+		return nil, trace.Wrap(err)
+	}
+	ch, reqC, err := client.OpenChannel(chanPeerTransport, payload)
+	if err != nil {
+		client.Close()
+		return nil, trace.Wrap(err)
+	}
+	go ssh.DiscardRequests(reqC)
+	return utils.NewChConn(client, ch), nil
+}
+
+// checkPeerHostSignature verifies that a sibling proxy's host certificate is
+// signed by this cluster's own host CA, the same trust check used for
+// site-to-site reverse tunnel connections.
+func (s *server) checkPeerHostSignature(hostport string, remote net.Addr, key ssh.PublicKey) error {
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return trace.BadParameter("expected certificate")
+	}
+	domainName, err := s.localAuth.GetDomainName()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(s.checkTrustedKey(services.HostCA, domainName, cert.SignatureKey))
+}
+
+// handlePeerTransport services an inbound chanPeerTransport request from a
+// sibling proxy. It's only honored from proxies of this same cluster: the
+// SSH connection is already authenticated by keyAuth, but that accepts any
+// trusted host (including a connected trusted cluster's proxies), so this
+// additionally checks the authenticated domain matches our own before
+// dialing anything on the requester's behalf.
+func (s *server) handlePeerTransport(sconn *ssh.ServerConn, nch ssh.NewChannel) {
+	if sconn.Permissions.Extensions[extCertType] != extCertTypeHost {
+		nch.Reject(ssh.Prohibited, "peer transport requires host authentication")
+		return
+	}
+	domainName, err := s.localAuth.GetDomainName()
+	if err != nil || sconn.Permissions.Extensions[extAuthority] != domainName {
+		nch.Reject(ssh.Prohibited, "peer transport is only available within the same cluster")
+		return
+	}
+	var req peerDialRequest
+	if err := json.Unmarshal(nch.ExtraData(), &req); err != nil {
+		nch.Reject(ssh.ConnectionFailed, "invalid peer dial request")
+		return
+	}
+	site, err := s.GetSite(req.Cluster)
+	if err != nil {
+		nch.Reject(ssh.ConnectionFailed, fmt.Sprintf("unknown cluster %v", req.Cluster))
+		return
+	}
+	conn, err := site.Dial(nil, &utils.NetAddr{Addr: req.Addr, AddrNetwork: req.Network})
+	if err != nil {
+		nch.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, reqC, err := nch.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqC)
+	go func() {
+		defer ch.Close()
+		defer conn.Close()
+		io.Copy(ch, conn)
+	}()
+	go func() {
+		defer ch.Close()
+		defer conn.Close()
+		io.Copy(conn, ch)
+	}()
+}
@@ -231,7 +231,15 @@ func (s *remoteSite) Dial(from, to net.Addr) (conn net.Conn, err error) {
 			}
 			return nil, trace.Errorf(strings.TrimSpace(string(errMessage)))
 		}
-		return utils.NewChConn(remoteConn.sshConn, ch), nil
+
+		// ask the agent to gzip-compress the proxied stream; an agent that
+		// doesn't recognize the request declines it the same way it would
+		// decline any other unknown request, so this is always safe to try
+		var proxiedCh ssh.Channel = ch
+		if compressed, _ := ch.SendRequest(chanTransportCompressReq, true, nil); compressed {
+			proxiedCh = newCompressedChannel(ch)
+		}
+		return utils.NewChConn(remoteConn.sshConn, proxiedCh), nil
 	}
 	// loop through existing TCP/IP connections (reverse tunnels) and try
 	// to establish an inbound connection-over-ssh-channel to the remote
@@ -243,6 +251,15 @@ func (s *remoteSite) Dial(from, to net.Addr) (conn net.Conn, err error) {
 		}
 		s.log.Errorf("[TUNNEL] Dial(addr=%v) failed: %v", addr, err)
 	}
+	// we have no connected tunnel of our own for this site, but a sibling
+	// proxy in our own HA pool might: ask around before giving up. this only
+	// covers session/transport dialing, not dialAccessPoint(), to keep the
+	// peering surface as small as possible.
+	if peerConn, peerErr := s.srv.dialViaPeer(s.domainName, to.Network(), addr); peerErr == nil {
+		return peerConn, nil
+	} else if err != nil {
+		s.log.Warningf("[TUNNEL] peer dial for %v also failed: %v", s.domainName, peerErr)
+	}
 	// didn't connect and no error? this means we didn't have any connected
 	// tunnels to try
 	if err == nil {
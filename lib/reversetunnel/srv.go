@@ -50,10 +50,19 @@ type server struct {
 	hostCertChecker ssh.CertChecker
 	userCertChecker ssh.CertChecker
 
+	// hostSigners are this proxy's own host certificates, used to
+	// authenticate to sibling proxies when peering a session dial
+	hostSigners []ssh.Signer
+
 	// srv is the "base class" i.e. the underlying SSH server
 	srv     *sshutils.Server
 	limiter *limiter.Limiter
 
+	// listener, if set, is an already-open listener (e.g. one imported via
+	// systemd socket activation) that the server accepts connections on
+	// instead of binding addr itself
+	listener net.Listener
+
 	// remoteSites is the list of conencted remote clusters
 	remoteSites []*remoteSite
 
@@ -89,6 +98,15 @@ func SetLimiter(limiter *limiter.Limiter) ServerOption {
 	}
 }
 
+// SetListener makes the server accept connections on an already-open
+// listener instead of binding addr itself.
+func SetListener(l net.Listener) ServerOption {
+	return func(s *server) error {
+		s.listener = l
+		return nil
+	}
+}
+
 // NewServer creates and returns a reverse tunnel server which is fully
 // initialized but hasn't been started yet
 func NewServer(addr utils.NetAddr, hostSigners []ssh.Signer,
@@ -99,6 +117,7 @@ func NewServer(addr utils.NetAddr, hostSigners []ssh.Signer,
 		remoteSites:    []*remoteSite{},
 		localAuth:      authAPI,
 		newAccessPoint: fn,
+		hostSigners:    hostSigners,
 	}
 	var err error
 	srv.limiter, err = limiter.NewLimiter(limiter.LimiterConfig{})
@@ -112,6 +131,10 @@ func NewServer(addr utils.NetAddr, hostSigners []ssh.Signer,
 		}
 	}
 
+	sshServerOpts := []sshutils.ServerOption{sshutils.SetLimiter(srv.limiter)}
+	if srv.listener != nil {
+		sshServerOpts = append(sshServerOpts, sshutils.SetListener(srv.listener))
+	}
 	s, err := sshutils.NewServer(
 		teleport.ComponentReverseTunnel,
 		addr,
@@ -120,7 +143,7 @@ func NewServer(addr utils.NetAddr, hostSigners []ssh.Signer,
 		sshutils.AuthMethods{
 			PublicKey: srv.keyAuth,
 		},
-		sshutils.SetLimiter(srv.limiter),
+		sshServerOpts...,
 	)
 	if err != nil {
 		return nil, err
@@ -150,6 +173,10 @@ func (s *server) HandleNewChan(conn net.Conn, sconn *ssh.ServerConn, nch ssh.New
 		"reverse tunnel server")
 
 	ct := nch.ChannelType()
+	if ct == chanPeerTransport {
+		s.handlePeerTransport(sconn, nch)
+		return
+	}
 	if ct != chanHeartbeat {
 		msg := fmt.Sprintf("reversetunnel received unknown channel request %v from %v",
 			nch.ChannelType(), sconn)
@@ -0,0 +1,506 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// acmeAccountKeyPath is the file name (relative to the data dir) of the
+// persistent RSA key used to identify this proxy to the ACME CA.
+const acmeAccountKeyPath = "acme_account_key.pem"
+
+// initACME obtains (or renews, if the cached certificate is close to
+// expiry) a TLS certificate for cfg.Proxy.PublicAddr via the ACME protocol
+// (RFC 8555) using an HTTP-01 challenge, and points cfg.Proxy.TLSCert and
+// cfg.Proxy.TLSKey at the resulting files. It only implements HTTP-01;
+// TLS-ALPN-01 is left as future work since HTTP-01 covers the common case
+// of a proxy with a public address reachable on port 80.
+func initACME(cfg *Config) error {
+	host, _, err := net.SplitHostPort(cfg.Proxy.PublicAddr.Addr)
+	if err != nil {
+		// PublicAddr may have been supplied without a port.
+		host = cfg.Proxy.PublicAddr.Addr
+	}
+	if host == "" {
+		return trace.BadParameter("acme requires proxy_service.public_addr to be set")
+	}
+
+	certPath := filepath.Join(cfg.DataDir, defaults.ACMECertPath)
+	keyPath := filepath.Join(cfg.DataDir, defaults.ACMEKeyPath)
+
+	if certNeedsRenewal, err := certExpiresSoon(certPath, host); err == nil && !certNeedsRenewal {
+		log.Infof("[PROXY] using cached ACME certificate for %v", host)
+		cfg.Proxy.TLSCert = certPath
+		cfg.Proxy.TLSKey = keyPath
+		return nil
+	}
+
+	directoryURI := cfg.Proxy.ACME.URI
+	if directoryURI == "" {
+		directoryURI = defaults.ACMEDirectoryURI
+	}
+
+	accountKey, err := loadOrCreateACMEAccountKey(filepath.Join(cfg.DataDir, acmeAccountKeyPath))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	client := &acmeClient{
+		directoryURI: directoryURI,
+		accountKey:   accountKey,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+
+	certPEM, keyPEM, err := client.obtainCertificate(host, cfg.Proxy.ACME.Email)
+	if err != nil {
+		return trace.Wrap(err, "failed to obtain ACME certificate for %v", host)
+	}
+
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := ioutil.WriteFile(certPath, certPEM, 0644); err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Infof("[PROXY] obtained ACME certificate for %v", host)
+	cfg.Proxy.TLSCert = certPath
+	cfg.Proxy.TLSKey = keyPath
+	return nil
+}
+
+// certExpiresSoon returns true if the certificate cached at certPath is
+// missing, does not cover host, or expires within defaults.ACMERenewBeforeExpiry.
+func certExpiresSoon(certPath string, host string) (bool, error) {
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return true, trace.Wrap(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return true, trace.BadParameter("invalid certificate PEM in %v", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true, trace.Wrap(err)
+	}
+	if cert.VerifyHostname(host) != nil {
+		return true, nil
+	}
+	return time.Until(cert.NotAfter) < defaults.ACMERenewBeforeExpiry, nil
+}
+
+func loadOrCreateACMEAccountKey(path string) (*rsa.PrivateKey, error) {
+	if keyPEM, err := ioutil.ReadFile(path); err == nil {
+		block, _ := pem.Decode(keyPEM)
+		if block == nil {
+			return nil, trace.BadParameter("invalid ACME account key PEM in %v", path)
+		}
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return key, nil
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(path, keyPEM, 0600); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return key, nil
+}
+
+// acmeClient is a minimal ACME v2 (RFC 8555) client sufficient to obtain a
+// certificate via an HTTP-01 challenge. It intentionally implements only
+// the subset of the protocol Teleport needs, not a general purpose ACME
+// library.
+type acmeClient struct {
+	directoryURI string
+	accountKey   *rsa.PrivateKey
+	httpClient   *http.Client
+
+	directory  acmeDirectory
+	accountURL string
+	nonce      string
+}
+
+type acmeDirectory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+type acmeOrder struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+}
+
+type acmeAuthorization struct {
+	Status     string          `json:"status"`
+	Identifier acmeIdentifier  `json:"identifier"`
+	Challenges []acmeChallenge `json:"challenges"`
+}
+
+type acmeIdentifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type acmeChallenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+// obtainCertificate runs the full ACME v2 order->authorize->finalize flow
+// for host and returns the PEM-encoded certificate chain and private key.
+func (c *acmeClient) obtainCertificate(host, email string) (certPEM, keyPEM []byte, err error) {
+	if err := c.fetchDirectory(); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	if err := c.fetchNonce(); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	if err := c.registerAccount(email); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	order, orderURL, err := c.createOrder(host)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	for _, authzURL := range order.Authorizations {
+		if err := c.completeHTTP01Authorization(authzURL); err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+	}
+
+	certKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: host},
+		DNSNames: []string{host},
+	}, certKey)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	if err := c.finalizeOrder(order, orderURL, csr); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	certPEM, err = c.downloadCertificate(orderURL)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(certKey)})
+	return certPEM, keyPEM, nil
+}
+
+func (c *acmeClient) fetchDirectory() error {
+	resp, err := c.httpClient.Get(c.directoryURI)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("acme directory returned status %v", resp.StatusCode)
+	}
+	return trace.Wrap(json.NewDecoder(resp.Body).Decode(&c.directory))
+}
+
+func (c *acmeClient) fetchNonce() error {
+	resp, err := c.httpClient.Head(c.directory.NewNonce)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	c.nonce = resp.Header.Get("Replay-Nonce")
+	if c.nonce == "" {
+		return trace.BadParameter("acme server did not return a nonce")
+	}
+	return nil
+}
+
+// post signs payload as a JWS request per RFC 8555 and posts it to url,
+// updating c.nonce from the response for the next request.
+func (c *acmeClient) post(url string, payload interface{}, useJWK bool) (*http.Response, error) {
+	body, err := c.signJWS(url, payload, useJWK)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := c.httpClient.Post(url, "application/jose+json", strings.NewReader(string(body)))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if nonce := resp.Header.Get("Replay-Nonce"); nonce != "" {
+		c.nonce = nonce
+	}
+	return resp, nil
+}
+
+func (c *acmeClient) registerAccount(email string) error {
+	payload := map[string]interface{}{
+		"termsOfServiceAgreed": true,
+	}
+	if email != "" {
+		payload["contact"] = []string{"mailto:" + email}
+	}
+	resp, err := c.post(c.directory.NewAccount, payload, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("acme account registration failed with status %v", resp.StatusCode)
+	}
+	c.accountURL = resp.Header.Get("Location")
+	if c.accountURL == "" {
+		return trace.BadParameter("acme server did not return an account URL")
+	}
+	return nil
+}
+
+func (c *acmeClient) createOrder(host string) (*acmeOrder, string, error) {
+	payload := map[string]interface{}{
+		"identifiers": []acmeIdentifier{{Type: "dns", Value: host}},
+	}
+	resp, err := c.post(c.directory.NewOrder, payload, false)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, "", trace.BadParameter("acme order creation failed with status %v", resp.StatusCode)
+	}
+	var order acmeOrder
+	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	return &order, resp.Header.Get("Location"), nil
+}
+
+// completeHTTP01Authorization serves the key authorization for the
+// authorization's http-01 challenge on port 80 and waits for the CA to
+// validate it.
+func (c *acmeClient) completeHTTP01Authorization(authzURL string) error {
+	resp, err := c.post(authzURL, "", false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	var authz acmeAuthorization
+	if err := json.NewDecoder(resp.Body).Decode(&authz); err != nil {
+		return trace.Wrap(err)
+	}
+	if authz.Status == "valid" {
+		return nil
+	}
+
+	var challenge *acmeChallenge
+	for i := range authz.Challenges {
+		if authz.Challenges[i].Type == "http-01" {
+			challenge = &authz.Challenges[i]
+			break
+		}
+	}
+	if challenge == nil {
+		return trace.BadParameter("acme authorization for %v has no http-01 challenge", authz.Identifier.Value)
+	}
+
+	keyAuth := challenge.Token + "." + c.jwkThumbprint()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/acme-challenge/"+challenge.Token, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, keyAuth)
+	})
+	listener, err := net.Listen("tcp", ":http")
+	if err != nil {
+		return trace.Wrap(err, "unable to bind port 80 to serve the acme http-01 challenge")
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if _, err := c.post(challenge.URL, map[string]interface{}{}, false); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return trace.Wrap(c.pollUntil(authzURL, "valid"))
+}
+
+// pollUntil polls url every second (up to a minute) until its "status"
+// field reaches wantStatus.
+func (c *acmeClient) pollUntil(url, wantStatus string) error {
+	for i := 0; i < 60; i++ {
+		resp, err := c.post(url, "", false)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var status struct {
+			Status string `json:"status"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if status.Status == wantStatus {
+			return nil
+		}
+		if status.Status == "invalid" {
+			return trace.BadParameter("acme resource %v became invalid", url)
+		}
+		time.Sleep(time.Second)
+	}
+	return trace.LimitExceeded("timed out waiting for %v to become %v", url, wantStatus)
+}
+
+func (c *acmeClient) finalizeOrder(order *acmeOrder, orderURL string, csr []byte) error {
+	resp, err := c.post(order.Finalize, map[string]interface{}{
+		"csr": base64.RawURLEncoding.EncodeToString(csr),
+	}, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resp.Body.Close()
+	return trace.Wrap(c.pollUntil(orderURL, "valid"))
+}
+
+func (c *acmeClient) downloadCertificate(orderURL string) ([]byte, error) {
+	resp, err := c.post(orderURL, "", false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var order acmeOrder
+	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		resp.Body.Close()
+		return nil, trace.Wrap(err)
+	}
+	resp.Body.Close()
+
+	certResp, err := c.post(order.Certificate, "", false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer certResp.Body.Close()
+	certPEM, err := ioutil.ReadAll(certResp.Body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return certPEM, nil
+}
+
+// jwkFields returns the RSA public key encoded as an RFC 7638 JWK, with
+// fields in the fixed order the thumbprint algorithm requires.
+func (c *acmeClient) jwkFields() (n, e string) {
+	pub := c.accountKey.PublicKey
+	n = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+	e = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	return n, e
+}
+
+func (c *acmeClient) jwk() map[string]string {
+	n, e := c.jwkFields()
+	return map[string]string{"e": e, "kty": "RSA", "n": n}
+}
+
+// jwkThumbprint returns the base64url SHA-256 thumbprint of the account
+// key's JWK, per RFC 7638, used to build key authorizations.
+func (c *acmeClient) jwkThumbprint() string {
+	n, e := c.jwkFields()
+	canonical := fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, e, n)
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// signJWS builds an RFC 8555 flattened JWS request, signing payload (an
+// empty string for POST-as-GET requests) with the account key.
+func (c *acmeClient) signJWS(url string, payload interface{}, useJWK bool) ([]byte, error) {
+	var payloadBytes []byte
+	if s, ok := payload.(string); ok && s == "" {
+		payloadBytes = nil
+	} else {
+		var err error
+		payloadBytes, err = json.Marshal(payload)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	protected := map[string]interface{}{
+		"alg":   "RS256",
+		"nonce": c.nonce,
+		"url":   url,
+	}
+	if useJWK || c.accountURL == "" {
+		protected["jwk"] = c.jwk()
+	} else {
+		protected["kid"] = c.accountURL
+	}
+	protectedBytes, err := json.Marshal(protected)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	protected64 := base64.RawURLEncoding.EncodeToString(protectedBytes)
+	payload64 := base64.RawURLEncoding.EncodeToString(payloadBytes)
+
+	digest := sha256.Sum256([]byte(protected64 + "." + payload64))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.accountKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	jws := map[string]string{
+		"protected": protected64,
+		"payload":   payload64,
+		"signature": base64.RawURLEncoding.EncodeToString(signature),
+	}
+	return json.Marshal(jws)
+}
@@ -128,6 +128,23 @@ type Config struct {
 	// MACAlgorithms is a list of message authentication codes (MAC) that
 	// the server supports. If omitted the defaults will be used.
 	MACAlgorithms []string
+
+	// CipherSuites is a list of TLS cipher suites that the proxy web
+	// listener supports. If omitted, the defaults will be used.
+	CipherSuites []uint16
+
+	// FIPS means Teleport was started in FIPS 140-2 compliant mode. Ciphers,
+	// KEXAlgorithms, MACAlgorithms, and CipherSuites are restricted to the
+	// FIPS-approved subset; see lib/config.applyFIPSDefaults.
+	FIPS bool
+
+	// Offline means this process must not make any outbound network calls
+	// other than to the addresses explicitly configured for its own
+	// cluster (e.g. AuthServers, trusted clusters), for operation on an
+	// air-gapped or classified network. Features that inherently require
+	// reaching an external service, like ACME, refuse to start instead of
+	// silently trying and failing once network access is actually needed.
+	Offline bool
 }
 
 // ApplyToken assigns a given token to all internal services but only if token
@@ -227,6 +244,53 @@ type ProxyConfig struct {
 
 	// PublicAddr is the public address the Teleport UI can be accessed at.
 	PublicAddr utils.NetAddr
+
+	// ACME configures automatic TLS certificate acquisition and renewal for
+	// the web proxy via the ACME protocol (e.g. Let's Encrypt).
+	ACME ACMEConfig
+
+	// VirtualHosts lists additional public hostnames the web proxy accepts
+	// over the same WebAddr listener, each served with its own certificate
+	// and (optionally) a default SSO connector, selected via TLS SNI. This
+	// lets one proxy pool front several public hostnames, e.g. for separate
+	// brands or environments.
+	VirtualHosts []VirtualHost
+}
+
+// VirtualHost configures an additional public hostname the web proxy
+// accepts via SNI, alongside the primary PublicAddr/TLSCert/TLSKey.
+type VirtualHost struct {
+	// PublicAddr is the public hostname clients use to reach the proxy
+	// through this virtual host, e.g. "acme.example.com".
+	PublicAddr string
+
+	// TLSKey is the path to the PEM encoded private key served for this
+	// hostname.
+	TLSKey string
+
+	// TLSCert is the path to the PEM encoded certificate served for this
+	// hostname.
+	TLSCert string
+
+	// ConnectorName, if set, is the OIDC or SAML connector clients of this
+	// hostname authenticate against by default, instead of the cluster's
+	// global auth preference ConnectorName.
+	ConnectorName string
+}
+
+// ACMEConfig configures automatic TLS certificate management via ACME.
+type ACMEConfig struct {
+	// Enabled turns on ACME certificate acquisition for the web proxy.
+	// PublicAddr must be set to the public hostname to request a cert for.
+	Enabled bool
+
+	// Email is the contact address supplied to the ACME CA when creating an
+	// account, used for expiry and revocation notices.
+	Email string
+
+	// URI is the ACME directory URL to use. Defaults to Let's Encrypt's
+	// production endpoint if unset.
+	URI string
 }
 
 // AuthConfig is a configuration of the auth server
@@ -266,9 +330,25 @@ type AuthConfig struct {
 	// NoAudit, when set to true, disables session recording and event audit
 	NoAudit bool
 
+	// AuditEventsURI selects the storage backend for audit events and
+	// session recordings, e.g. "file:///var/lib/teleport/log". The scheme
+	// is looked up in events.RegisterAuditLog, so third parties can plug in
+	// a custom backend (e.g. Elasticsearch, BigQuery) without patching this
+	// package. Defaults to a "file://" URI under DataDir/log.
+	AuditEventsURI string
+
+	// CompressSessions, when true, gzip-compresses session recordings
+	// stored by the default local file audit log backend.
+	CompressSessions bool
+
 	// Preference defines the authentication preference (type and second factor) for
 	// the auth server.
 	Preference services.AuthPreference
+
+	// CAKeyAlgo is the key algorithm (defaults.CAKeyAlgoRSA/ECDSA/ED25519)
+	// used to generate the user and host CAs on first start. If empty,
+	// defaults.CAKeyAlgoRSA is used.
+	CAKeyAlgo string
 }
 
 // SSHConfig configures SSH server node role
@@ -317,7 +397,7 @@ func ApplyDefaults(cfg *Config) {
 	cfg.Auth.DynamicConfig = false
 	cfg.Auth.StorageConfig.Type = boltbk.GetName()
 	cfg.Auth.StorageConfig.Params = backend.Params{"path": cfg.DataDir}
-	defaults.ConfigureLimiter(&cfg.Auth.Limiter)
+	limiter.ConfigureLimiter(&cfg.Auth.Limiter)
 	// set new style default auth preferences
 	ap := &services.AuthPreferenceV2{}
 	ap.CheckAndSetDefaults()
@@ -328,11 +408,11 @@ func ApplyDefaults(cfg *Config) {
 	cfg.Proxy.SSHAddr = *defaults.ProxyListenAddr()
 	cfg.Proxy.WebAddr = *defaults.ProxyWebListenAddr()
 	cfg.Proxy.ReverseTunnelListenAddr = *defaults.ReverseTunnellListenAddr()
-	defaults.ConfigureLimiter(&cfg.Proxy.Limiter)
+	limiter.ConfigureLimiter(&cfg.Proxy.Limiter)
 
 	// defaults for the SSH service:
 	cfg.SSH.Enabled = true
 	cfg.SSH.Addr = *defaults.SSHServerListenAddr()
 	cfg.SSH.Shell = defaults.DefaultShell
-	defaults.ConfigureLimiter(&cfg.SSH.Limiter)
+	limiter.ConfigureLimiter(&cfg.SSH.Limiter)
 }
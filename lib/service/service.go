@@ -19,6 +19,8 @@ limitations under the License.
 package service
 
 import (
+	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -37,12 +39,16 @@ import (
 	"github.com/gravitational/teleport/lib/backend/boltbk"
 	"github.com/gravitational/teleport/lib/backend/dir"
 	"github.com/gravitational/teleport/lib/backend/dynamo"
+	"github.com/gravitational/teleport/lib/backend/encryptedbk"
 	"github.com/gravitational/teleport/lib/backend/etcdbk"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
+	_ "github.com/gravitational/teleport/lib/events/elasticsearch" // registers the "elasticsearch" audit_events_uri scheme
 	"github.com/gravitational/teleport/lib/limiter"
+	"github.com/gravitational/teleport/lib/notifications"
 	"github.com/gravitational/teleport/lib/reversetunnel"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/services/local"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/srv"
 	"github.com/gravitational/teleport/lib/state"
@@ -104,6 +110,26 @@ type TeleportProcess struct {
 
 	// identities of this process (credentials to auth sever, basically)
 	Identities map[teleport.Role]*auth.Identity
+
+	// importedDescriptors holds listeners passed to this process via
+	// systemd socket activation, keyed by the name each one was given; see
+	// utils.ImportListeners and listenerFor
+	importedDescriptors map[string]net.Listener
+}
+
+// listenerFor resolves addr to a net.Listener ready to be started on.
+// Addresses using the "fd" scheme (e.g. "fd://web") are resolved to a
+// listener imported via systemd socket activation instead of being bound --
+// this lets a unit file pre-bind a privileged port and hand it to teleport
+// without the process ever needing to run as root. Any other network
+// (including "unix") is bound directly by the caller, so this only needs to
+// be consulted for "fd" addresses.
+func (process *TeleportProcess) listenerFor(addr utils.NetAddr) (net.Listener, error) {
+	listener, ok := process.importedDescriptors[addr.Addr]
+	if !ok {
+		return nil, trace.BadParameter("no socket-activated listener named %q was passed to this process (check LISTEN_FDNAMES / FileDescriptorName=)", addr.Addr)
+	}
+	return listener, nil
 }
 
 func (process *TeleportProcess) GetAuthServer() *auth.AuthServer {
@@ -236,11 +262,17 @@ func NewTeleport(cfg *Config) (*TeleportProcess, error) {
 		}
 	}
 
+	importedDescriptors, err := utils.ImportListeners()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	process := &TeleportProcess{
-		Clock:      clockwork.NewRealClock(),
-		Supervisor: NewSupervisor(),
-		Config:     cfg,
-		Identities: make(map[teleport.Role]*auth.Identity),
+		Clock:               clockwork.NewRealClock(),
+		Supervisor:          NewSupervisor(),
+		Config:              cfg,
+		Identities:          make(map[teleport.Role]*auth.Identity),
+		importedDescriptors: importedDescriptors,
 	}
 
 	serviceStarted := false
@@ -288,6 +320,28 @@ func (process *TeleportProcess) getLocalAuth() *auth.AuthServer {
 	return process.localAuth
 }
 
+// getOrCreateSessionRecordingKey returns the cluster's session recording
+// encryption key, generating and persisting a new one on first use so it
+// is shared by every auth server in the cluster.
+func getOrCreateSessionRecordingKey(b backend.Backend) ([]byte, error) {
+	clusterConfig := local.NewClusterConfigurationService(b)
+	key, err := clusterConfig.GetSessionRecordingKey()
+	if err == nil {
+		return key, nil
+	}
+	if !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	key = make([]byte, events.EncryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := clusterConfig.UpsertSessionRecordingKey(key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return key, nil
+}
+
 // initAuthService can be called to initialize auth server service
 func (process *TeleportProcess) initAuthService(authority auth.Authority) error {
 	var (
@@ -305,16 +359,54 @@ func (process *TeleportProcess) initAuthService(authority auth.Authority) error
 	// create the audit log, which will be consuming (and recording) all events
 	// and record sessions
 	var auditLog events.IAuditLog
-	if cfg.Auth.NoAudit {
+	switch {
+	case cfg.Auth.NoAudit:
 		auditLog = &events.DiscardAuditLog{}
 		log.Warn("the audit and session recording are turned off")
-	} else {
-		auditLog, err = events.NewAuditLog(filepath.Join(cfg.DataDir, "log"))
+	case cfg.Auth.AuditEventsURI != "":
+		// a non-default backend was configured, e.g. a third party events/
+		// session storage plugin registered under its own URI scheme
+		auditLog, err = events.NewAuditLogFromURI(cfg.Auth.AuditEventsURI)
 		if err != nil {
 			return trace.Wrap(err)
 		}
+	default:
+		recordingKey, err := getOrCreateSessionRecordingKey(b)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		auditLog, err = events.NewAuditLogWithConfig(events.AuditLogConfig{
+			DataDir:          filepath.Join(cfg.DataDir, "log"),
+			EncryptionKey:    recordingKey,
+			CompressSessions: cfg.Auth.CompressSessions,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	// wrap the audit log so events that match the cluster's
+	// NotificationConfig fire webhooks (e.g. to Slack or PagerDuty)
+	clusterConfig := local.NewClusterConfigurationService(b)
+	auditLog, err = notifications.New(notifications.Config{
+		Server:    auditLog,
+		GetConfig: clusterConfig.GetNotificationConfig,
+		SessionURL: func(sid session.ID) string {
+			if cfg.Proxy.PublicAddr.IsEmpty() {
+				return ""
+			}
+			return fmt.Sprintf("https://%v/web/cluster/sessions/%v", cfg.Proxy.PublicAddr.Addr, sid)
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
 	}
 
+	// run every registered detection hook (see events.RegisterHook) against
+	// each emitted event, raising an events.AlertEvent for anything a hook
+	// flags as suspicious
+	auditLog = events.NewHookedAuditLog(auditLog)
+
 	// first, create the AuthServer
 	authServer, identity, err := auth.Init(auth.InitConfig{
 		Backend:         b,
@@ -336,6 +428,7 @@ func (process *TeleportProcess) initAuthService(authority auth.Authority) error
 		AuthPreference:  cfg.Auth.Preference,
 		OIDCConnectors:  cfg.OIDCConnectors,
 		DeveloperMode:   cfg.DeveloperMode,
+		CAKeyAlgo:       cfg.Auth.CAKeyAlgo,
 	}, cfg.Auth.DynamicConfig)
 	if err != nil {
 		return trace.Wrap(err)
@@ -360,6 +453,7 @@ func (process *TeleportProcess) initAuthService(authority auth.Authority) error
 		AuditLog:       auditLog,
 	}
 
+	cfg.Auth.Limiter.AuditLog = auditLog
 	limiter, err := limiter.NewLimiter(cfg.Auth.Limiter)
 	if err != nil {
 		return trace.Wrap(err)
@@ -370,11 +464,19 @@ func (process *TeleportProcess) initAuthService(authority auth.Authority) error
 	var authTunnel *auth.AuthTunnel
 	process.RegisterFunc(func() error {
 		utils.Consolef(cfg.Console, "[AUTH]  Auth service is starting on %v", cfg.Auth.SSHAddr.Addr)
+		authTunnelOpts := []auth.ServerOption{auth.SetLimiter(limiter)}
+		if cfg.Auth.SSHAddr.AddrNetwork == "fd" {
+			listener, err := process.listenerFor(cfg.Auth.SSHAddr)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			authTunnelOpts = append(authTunnelOpts, auth.SetListener(listener))
+		}
 		authTunnel, err = auth.NewTunnel(
 			cfg.Auth.SSHAddr,
 			identity.KeySigner,
 			apiConf,
-			auth.SetLimiter(limiter),
+			authTunnelOpts...,
 		)
 		if err != nil {
 			utils.Consolef(cfg.Console, "[AUTH] Error: %v", err)
@@ -425,7 +527,7 @@ func (process *TeleportProcess) initAuthService(authority auth.Authority) error
 			if err != nil {
 				return trace.Wrap(err)
 			}
-			srv.SetAddr(fmt.Sprintf("%v:%v", process.Config.AdvertiseIP.String(), port))
+			srv.SetAddr(net.JoinHostPort(process.Config.AdvertiseIP.String(), port))
 		} else {
 			// advertise-ip is not set, while the CA is listening on 0.0.0.0? lets try
 			// to guess the 'advertise ip' then:
@@ -453,9 +555,45 @@ func (process *TeleportProcess) initAuthService(authority auth.Authority) error
 		return nil
 	})
 
+	// periodically sync Teleport roles from Okta group membership, per the
+	// cluster's OktaConfig (a no-op until one is set)
+	oktaSyncCtx, cancelOktaSync := context.WithCancel(context.Background())
+	process.RegisterFunc(func() error {
+		authServer.RunOktaSync(oktaSyncCtx)
+		return nil
+	})
+
+	// periodically warn about provisioning tokens nearing expiry, per the
+	// cluster's EmailPlugin (a no-op until one is set)
+	emailExpiryCtx, cancelEmailExpiry := context.WithCancel(context.Background())
+	process.RegisterFunc(func() error {
+		authServer.RunEmailExpiryCheck(emailExpiryCtx)
+		return nil
+	})
+
+	// periodically activate access requests whose scheduled start has
+	// arrived, and revoke access requests whose TTL has elapsed
+	accessRequestSweepCtx, cancelAccessRequestSweep := context.WithCancel(context.Background())
+	process.RegisterFunc(func() error {
+		authServer.RunAccessRequestSweep(accessRequestSweepCtx)
+		return nil
+	})
+
+	// periodically close review campaigns whose deadline has arrived,
+	// revoking the roles their reviewers voted to revoke
+	reviewCampaignSweepCtx, cancelReviewCampaignSweep := context.WithCancel(context.Background())
+	process.RegisterFunc(func() error {
+		authServer.RunReviewCampaignSweep(reviewCampaignSweepCtx)
+		return nil
+	})
+
 	// execute this when process is asked to exit:
 	process.onExit(func(payload interface{}) {
 		askedToExit = true
+		cancelOktaSync()
+		cancelEmailExpiry()
+		cancelAccessRequestSweep()
+		cancelReviewCampaignSweep()
 		authTunnel.Close()
 		log.Infof("[AUTH] auth service exited")
 	})
@@ -515,6 +653,7 @@ func (process *TeleportProcess) initSSH() error {
 
 		cfg := process.Config
 
+		cfg.SSH.Limiter.AuditLog = conn.Client
 		limiter, err := limiter.NewLimiter(cfg.SSH.Limiter)
 		if err != nil {
 			return trace.Wrap(err)
@@ -536,16 +675,18 @@ func (process *TeleportProcess) initSSH() error {
 			return trace.Wrap(err)
 		}
 
-		s, err = srv.New(cfg.SSH.Addr,
-			cfg.Hostname,
-			[]ssh.Signer{conn.Identity.KeySigner},
-			authClient,
-			cfg.DataDir,
-			cfg.AdvertiseIP,
-			cfg.Proxy.PublicAddr,
+		bufferedAuditLog, err := state.NewBufferedAuditLog(state.BufferedAuditLogConfig{
+			Server:     conn.Client,
+			JournalDir: cfg.DataDir,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+
+		sshServerOpts := []srv.ServerOption{
 			srv.SetLimiter(limiter),
 			srv.SetShell(cfg.SSH.Shell),
-			srv.SetAuditLog(conn.Client),
+			srv.SetAuditLog(bufferedAuditLog),
 			srv.SetSessionServer(conn.Client),
 			srv.SetLabels(cfg.SSH.Labels, cfg.SSH.CmdLabels),
 			srv.SetNamespace(namespace),
@@ -553,6 +694,23 @@ func (process *TeleportProcess) initSSH() error {
 			srv.SetCiphers(cfg.Ciphers),
 			srv.SetKEXAlgorithms(cfg.KEXAlgorithms),
 			srv.SetMACAlgorithms(cfg.MACAlgorithms),
+		}
+		if cfg.SSH.Addr.AddrNetwork == "fd" {
+			listener, err := process.listenerFor(cfg.SSH.Addr)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			sshServerOpts = append(sshServerOpts, srv.SetListener(listener))
+		}
+
+		s, err = srv.New(cfg.SSH.Addr,
+			cfg.Hostname,
+			[]ssh.Signer{conn.Identity.KeySigner},
+			authClient,
+			cfg.DataDir,
+			cfg.AdvertiseIP,
+			cfg.Proxy.PublicAddr,
+			sshServerOpts...,
 		)
 		if err != nil {
 			return trace.Wrap(err)
@@ -637,15 +795,24 @@ func (process *TeleportProcess) RegisterWithAuthServer(token string, role telepo
 
 // initProxy gets called if teleport runs with 'proxy' role enabled.
 // this means it will do two things:
-//    1. serve a web UI
-//    2. proxy SSH connections to nodes running with 'node' role
-//    3. take care of reverse tunnels
+//  1. serve a web UI
+//  2. proxy SSH connections to nodes running with 'node' role
+//  3. take care of reverse tunnels
 func (process *TeleportProcess) initProxy() error {
-	// if no TLS key was provided for the web UI, generate a self signed cert
-	if process.Config.Proxy.TLSKey == "" && !process.Config.Proxy.DisableWebService {
-		err := initSelfSignedHTTPSCert(process.Config)
-		if err != nil {
-			return trace.Wrap(err)
+	if !process.Config.Proxy.DisableWebService {
+		switch {
+		case process.Config.Proxy.ACME.Enabled:
+			// obtain (or renew) a certificate from the configured ACME CA
+			// for the proxy's public address.
+			if err := initACME(process.Config); err != nil {
+				return trace.Wrap(err)
+			}
+		case process.Config.Proxy.TLSKey == "":
+			// if no TLS key was provided for the web UI, generate a self
+			// signed cert
+			if err := initSelfSignedHTTPSCert(process.Config); err != nil {
+				return trace.Wrap(err)
+			}
 		}
 	}
 	myRole := teleport.RoleProxy
@@ -673,6 +840,7 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 	)
 	cfg := process.Config
 
+	cfg.Proxy.Limiter.AuditLog = conn.Client
 	proxyLimiter, err := limiter.NewLimiter(cfg.Proxy.Limiter)
 	if err != nil {
 		return trace.Wrap(err)
@@ -689,19 +857,54 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 		return trace.Wrap(err)
 	}
 
+	reverseTunnelOpts := []reversetunnel.ServerOption{
+		reversetunnel.SetLimiter(reverseTunnelLimiter),
+		reversetunnel.DirectSite(conn.Identity.Cert.Extensions[utils.CertExtensionAuthority],
+			conn.Client),
+	}
+	if cfg.Proxy.ReverseTunnelListenAddr.AddrNetwork == "fd" {
+		listener, err := process.listenerFor(cfg.Proxy.ReverseTunnelListenAddr)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		reverseTunnelOpts = append(reverseTunnelOpts, reversetunnel.SetListener(listener))
+	}
 	tsrv, err := reversetunnel.NewServer(
 		cfg.Proxy.ReverseTunnelListenAddr,
 		[]ssh.Signer{conn.Identity.KeySigner},
 		authClient,
 		process.newLocalCache,
-		reversetunnel.SetLimiter(reverseTunnelLimiter),
-		reversetunnel.DirectSite(conn.Identity.Cert.Extensions[utils.CertExtensionAuthority],
-			conn.Client),
+		reverseTunnelOpts...,
 	)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
+	bufferedAuditLog, err := state.NewBufferedAuditLog(state.BufferedAuditLogConfig{
+		Server:     conn.Client,
+		JournalDir: cfg.DataDir,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	proxySSHOpts := []srv.ServerOption{
+		srv.SetLimiter(proxyLimiter),
+		srv.SetProxyMode(tsrv),
+		srv.SetTunnelAddr(cfg.Proxy.ReverseTunnelListenAddr),
+		srv.SetSessionServer(conn.Client),
+		srv.SetAuditLog(bufferedAuditLog),
+		srv.SetCiphers(cfg.Ciphers),
+		srv.SetKEXAlgorithms(cfg.KEXAlgorithms),
+		srv.SetMACAlgorithms(cfg.MACAlgorithms),
+	}
+	if cfg.Proxy.SSHAddr.AddrNetwork == "fd" {
+		listener, err := process.listenerFor(cfg.Proxy.SSHAddr)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		proxySSHOpts = append(proxySSHOpts, srv.SetListener(listener))
+	}
 	SSHProxy, err := srv.New(cfg.Proxy.SSHAddr,
 		cfg.Hostname,
 		[]ssh.Signer{conn.Identity.KeySigner},
@@ -709,13 +912,7 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 		cfg.DataDir,
 		nil,
 		cfg.Proxy.PublicAddr,
-		srv.SetLimiter(proxyLimiter),
-		srv.SetProxyMode(tsrv),
-		srv.SetSessionServer(conn.Client),
-		srv.SetAuditLog(conn.Client),
-		srv.SetCiphers(cfg.Ciphers),
-		srv.SetKEXAlgorithms(cfg.KEXAlgorithms),
-		srv.SetMACAlgorithms(cfg.MACAlgorithms),
+		proxySSHOpts...,
 	)
 	if err != nil {
 		return trace.Wrap(err)
@@ -756,15 +953,22 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 	if !process.Config.Proxy.DisableWebService {
 		process.RegisterFunc(func() error {
 			utils.Consolef(cfg.Console, "[PROXY] Web proxy service is starting on %v", cfg.Proxy.WebAddr.Addr)
+			virtualHostConnectors := make(map[string]string)
+			for _, vhost := range cfg.Proxy.VirtualHosts {
+				if vhost.ConnectorName != "" {
+					virtualHostConnectors[vhost.PublicAddr] = vhost.ConnectorName
+				}
+			}
 			webHandler, err := web.NewHandler(
 				web.Config{
-					Proxy:        tsrv,
-					AuthServers:  cfg.AuthServers[0],
-					DomainName:   cfg.Hostname,
-					ProxyClient:  conn.Client,
-					DisableUI:    process.Config.Proxy.DisableWebInterface,
-					ProxySSHAddr: cfg.Proxy.SSHAddr,
-					ProxyWebAddr: cfg.Proxy.WebAddr,
+					Proxy:                 tsrv,
+					AuthServers:           cfg.AuthServers[0],
+					DomainName:            cfg.Hostname,
+					ProxyClient:           conn.Client,
+					DisableUI:             process.Config.Proxy.DisableWebInterface,
+					ProxySSHAddr:          cfg.Proxy.SSHAddr,
+					ProxyWebAddr:          cfg.Proxy.WebAddr,
+					VirtualHostConnectors: virtualHostConnectors,
 				})
 			if err != nil {
 				utils.Consolef(cfg.Console, "[PROXY] starting the web server: %v", err)
@@ -776,13 +980,44 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 			process.BroadcastEvent(Event{Name: ProxyWebServerEvent, Payload: webHandler})
 
 			log.Infof("[PROXY] init TLS listeners")
-			webListener, err = utils.ListenTLS(
-				cfg.Proxy.WebAddr.Addr,
-				cfg.Proxy.TLSCert,
-				cfg.Proxy.TLSKey)
+			var webBaseListener net.Listener
+			if cfg.Proxy.WebAddr.AddrNetwork == "fd" {
+				webBaseListener, err = process.listenerFor(cfg.Proxy.WebAddr)
+			} else {
+				webBaseListener, err = net.Listen(cfg.Proxy.WebAddr.AddrNetwork, cfg.Proxy.WebAddr.Addr)
+			}
 			if err != nil {
 				return trace.Wrap(err)
 			}
+			if len(cfg.Proxy.VirtualHosts) == 0 {
+				webListener, err = utils.ListenTLS(
+					webBaseListener,
+					cfg.Proxy.TLSCert,
+					cfg.Proxy.TLSKey,
+					cfg.CipherSuites)
+			} else {
+				virtualHosts := make(map[string]utils.KeyPairFiles, len(cfg.Proxy.VirtualHosts))
+				for _, vhost := range cfg.Proxy.VirtualHosts {
+					virtualHosts[vhost.PublicAddr] = utils.KeyPairFiles{
+						CertFile: vhost.TLSCert,
+						KeyFile:  vhost.TLSKey,
+					}
+				}
+				webListener, err = utils.ListenTLSSNI(
+					webBaseListener,
+					cfg.Proxy.TLSCert,
+					cfg.Proxy.TLSKey,
+					cfg.CipherSuites,
+					virtualHosts)
+			}
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			// route connections that ALPN-negotiate the SSH tunneling
+			// protocol to the SSH proxy instead of the web handler, so
+			// tsh can reach this proxy over 443 from networks that only
+			// allow outbound HTTPS
+			webListener = utils.NewALPNListener(webListener, SSHProxy.HandleConnection)
 			if err = http.Serve(webListener, proxyLimiter); err != nil {
 				if askedToExit {
 					log.Infof("[PROXY] web server exited")
@@ -856,6 +1091,22 @@ func (process *TeleportProcess) initAuthStorage() (bk backend.Backend, err error
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+
+	// the local (bolt or filesystem) backends store everything, including
+	// CA private keys, password hashes and OTP secrets, as a plain file on
+	// this server's disk. If a key_file is configured, encrypt values at
+	// rest so a stolen copy of that file isn't enough to read them. This
+	// isn't offered for etcd or DynamoDB, which already run behind their
+	// own access control and (for DynamoDB) encryption-at-rest.
+	switch bc.Type {
+	case boltbk.GetName(), dir.GetName():
+		if keyFile := bc.Params.GetString("key_file"); keyFile != "" {
+			bk, err = encryptedbk.New(bk, keyFile)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+	}
 	return bk, nil
 }
 
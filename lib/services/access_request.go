@@ -0,0 +1,168 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// AccessRequestState is the lifecycle state of an AccessRequest.
+type AccessRequestState string
+
+const (
+	// AccessRequestPending is the state of a request awaiting a decision.
+	AccessRequestPending AccessRequestState = "pending"
+	// AccessRequestApproved is the state of a request that was granted.
+	AccessRequestApproved AccessRequestState = "approved"
+	// AccessRequestDenied is the state of a request that was rejected.
+	AccessRequestDenied AccessRequestState = "denied"
+	// AccessRequestExpired is the state of a request whose granted roles
+	// were revoked because its TTL elapsed.
+	AccessRequestExpired AccessRequestState = "expired"
+)
+
+// AccessRequest is a user's request to be granted additional roles,
+// together with a human or plugin's decision on it. Unlike the resources
+// in resource.go, it isn't a versioned, schema-validated Resource: it's
+// short-lived request/response state managed entirely by the auth server,
+// the same way OIDCAuthRequest is.
+type AccessRequest struct {
+	// ID uniquely identifies the request.
+	ID string `json:"id"`
+	// User is the Teleport user asking for the roles.
+	User string `json:"user"`
+	// Roles are the roles being requested.
+	Roles []string `json:"roles"`
+	// Reason is an optional note from the requester explaining why.
+	Reason string `json:"reason,omitempty"`
+	// State is the request's current lifecycle state.
+	State AccessRequestState `json:"state"`
+	// Created is when the request was made.
+	Created time.Time `json:"created"`
+
+	// Resolver identifies who or what resolved the request: a Teleport
+	// username, or a plugin identifier such as "slack".
+	Resolver string `json:"resolver,omitempty"`
+	// ResolveReason is an optional note left by the resolver, e.g. a
+	// Slack approver's comment on the button they pressed.
+	ResolveReason string `json:"resolve_reason,omitempty"`
+
+	// Approvals lists the users who have approved the request so far.
+	// Most requests are fulfilled by a single approval; a role granting
+	// RequestApproverCount > 1 (see RoleOptions) keeps the request in the
+	// pending state, accumulating approvals here, until enough distinct
+	// members of RequestApproverRole have signed off.
+	Approvals []string `json:"approvals,omitempty"`
+
+	// Start is when the requested roles should take effect. The zero
+	// value means as soon as the request is approved.
+	Start time.Time `json:"start,omitempty"`
+	// TTL is how long the requested roles stay in effect once granted.
+	// The zero value means the grant doesn't expire on its own.
+	TTL Duration `json:"ttl,omitempty"`
+	// Activated is true once the requested roles have actually been
+	// added to User's role list. For a request with a future Start, this
+	// lags behind approval until RunAccessRequestSweep catches up to it.
+	Activated bool `json:"activated,omitempty"`
+	// Expires is when an Activated grant's TTL runs out and its roles
+	// are revoked. The zero value means it doesn't expire.
+	Expires time.Time `json:"expires,omitempty"`
+
+	// SlackChannel and SlackThreadTS identify the Slack message posted
+	// for this request (if the Slack plugin is enabled), so the approve/
+	// deny decision and any further activity can be threaded under it.
+	SlackChannel  string `json:"slack_channel,omitempty"`
+	SlackThreadTS string `json:"slack_thread_ts,omitempty"`
+}
+
+// Check returns nil if all parameters are valid, err otherwise.
+func (r *AccessRequest) Check() error {
+	if r.ID == "" {
+		return trace.BadParameter("AccessRequest: missing id")
+	}
+	if r.User == "" {
+		return trace.BadParameter("AccessRequest: missing user")
+	}
+	if len(r.Roles) == 0 {
+		return trace.BadParameter("AccessRequest: missing roles")
+	}
+	return nil
+}
+
+// IsResolved returns true once a decision on the request has been made:
+// it was approved, denied, or (having previously been approved) its
+// granted roles have since expired.
+func (r *AccessRequest) IsResolved() bool {
+	return r.State == AccessRequestApproved || r.State == AccessRequestDenied || r.State == AccessRequestExpired
+}
+
+// MatchesReason returns true if reason plausibly corresponds to this
+// request. It's the validation hook a node enforcing a role's
+// RequestReason option can use to check a session's --reason against the
+// access requests already on file for that user, rather than trusting
+// whatever string the client happened to send. Nodes don't currently have
+// their own view of the AccessRequests store (it's reached through the
+// auth server's API, not the AccessPoint interface used by SSH servers),
+// so wiring this up end to end is left to whoever integrates a specific
+// ticketing system; this method only pins down what "matches" means once
+// they do.
+func (r *AccessRequest) MatchesReason(reason string) bool {
+	return r.Reason != "" && r.Reason == reason
+}
+
+// HasApproval returns true if user has already approved the request.
+func (r *AccessRequest) HasApproval(user string) bool {
+	for _, u := range r.Approvals {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessRequests manages the lifecycle of AccessRequest objects.
+type AccessRequests interface {
+	// CreateAccessRequest creates a new AccessRequest in the pending
+	// state.
+	CreateAccessRequest(req AccessRequest) error
+	// GetAccessRequest returns the AccessRequest named by id.
+	GetAccessRequest(id string) (*AccessRequest, error)
+	// GetAccessRequests returns every AccessRequest, most recent first.
+	GetAccessRequests() ([]AccessRequest, error)
+	// SetAccessRequestState resolves the AccessRequest named by id,
+	// recording who or what resolved it and why.
+	SetAccessRequestState(id string, state AccessRequestState, resolver, reason string) error
+	// AddAccessRequestApproval records that approver has approved the
+	// AccessRequest named by id, without resolving it. Used for requests
+	// that need more than one approval before being fulfilled.
+	AddAccessRequestApproval(id, approver string) error
+	// ActivateAccessRequest marks the AccessRequest named by id as
+	// Activated (its roles have been granted) and records when that
+	// grant expires, the zero Time if it doesn't.
+	ActivateAccessRequest(id string, expires time.Time) error
+	// ExpireAccessRequest marks the AccessRequest named by id as expired,
+	// once its Activated grant's TTL has elapsed.
+	ExpireAccessRequest(id string) error
+	// SetAccessRequestSlackThread records the Slack message posted for
+	// the AccessRequest named by id, so later activity can be threaded
+	// under it.
+	SetAccessRequestSlackThread(id, channel, threadTS string) error
+	// DeleteAccessRequest removes the AccessRequest named by id.
+	DeleteAccessRequest(id string) error
+}
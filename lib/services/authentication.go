@@ -54,6 +54,31 @@ type AuthPreference interface {
 	// SetU2F sets the U2F configuration settings.
 	SetU2F(*U2F)
 
+	// GetMessageOfTheDay gets the message of the day, a legal banner shown
+	// before login on tsh and the web UI.
+	GetMessageOfTheDay() string
+	// SetMessageOfTheDay sets the message of the day.
+	SetMessageOfTheDay(string)
+
+	// GetAllowLocalAuth gets whether local username/password/2FA login is
+	// permitted alongside an oidc or saml connector. It has no effect when
+	// Type is local. Defaults to true so break-glass admin accounts keep
+	// working if the configured identity provider is unreachable.
+	GetAllowLocalAuth() bool
+	// SetAllowLocalAuth sets whether local username/password/2FA login is
+	// permitted alongside an oidc or saml connector.
+	SetAllowLocalAuth(bool)
+
+	// GetCertificateFormat gets the default certificate extension format
+	// used for user certificates issued by this cluster, e.g.
+	// teleport.CompatibilityOldSSH to drop Teleport's certificate
+	// extensions for interoperability with stock OpenSSH sshd. Callers may
+	// still request a different format per-certificate; this is only the
+	// cluster-wide default.
+	GetCertificateFormat() string
+	// SetCertificateFormat sets the default certificate extension format.
+	SetCertificateFormat(string)
+
 	// CheckAndSetDefaults sets and default values and then
 	// verifies the constraints for AuthPreference.
 	CheckAndSetDefaults() error
@@ -135,6 +160,42 @@ func (c *AuthPreferenceV2) SetU2F(u2f *U2F) {
 	c.Spec.U2F = u2f
 }
 
+// GetMessageOfTheDay gets the message of the day, a legal banner shown
+// before login on tsh and the web UI.
+func (c *AuthPreferenceV2) GetMessageOfTheDay() string {
+	return c.Spec.MessageOfTheDay
+}
+
+// SetMessageOfTheDay sets the message of the day.
+func (c *AuthPreferenceV2) SetMessageOfTheDay(message string) {
+	c.Spec.MessageOfTheDay = message
+}
+
+// GetAllowLocalAuth gets whether local username/password/2FA login is
+// permitted alongside an oidc or saml connector.
+func (c *AuthPreferenceV2) GetAllowLocalAuth() bool {
+	if c.Spec.AllowLocalAuth == nil {
+		return true
+	}
+	return *c.Spec.AllowLocalAuth
+}
+
+// SetAllowLocalAuth sets whether local username/password/2FA login is
+// permitted alongside an oidc or saml connector.
+func (c *AuthPreferenceV2) SetAllowLocalAuth(allow bool) {
+	c.Spec.AllowLocalAuth = &allow
+}
+
+// GetCertificateFormat gets the default certificate extension format.
+func (c *AuthPreferenceV2) GetCertificateFormat() string {
+	return c.Spec.CertificateFormat
+}
+
+// SetCertificateFormat sets the default certificate extension format.
+func (c *AuthPreferenceV2) SetCertificateFormat(s string) {
+	c.Spec.CertificateFormat = s
+}
+
 // CheckAndSetDefaults verifies the constraints for AuthPreference.
 func (c *AuthPreferenceV2) CheckAndSetDefaults() error {
 	// if nothing is passed in, set defaults
@@ -144,6 +205,13 @@ func (c *AuthPreferenceV2) CheckAndSetDefaults() error {
 	if c.Spec.SecondFactor == "" {
 		c.Spec.SecondFactor = teleport.OTP
 	}
+	if c.Spec.AllowLocalAuth == nil {
+		allowLocalAuth := true
+		c.Spec.AllowLocalAuth = &allowLocalAuth
+	}
+	if c.Spec.CertificateFormat == "" {
+		c.Spec.CertificateFormat = teleport.CompatibilityNone
+	}
 
 	// make sure type makes sense
 	if c.Spec.Type != teleport.Local && c.Spec.Type != teleport.OIDC && c.Spec.Type != teleport.SAML {
@@ -153,6 +221,10 @@ func (c *AuthPreferenceV2) CheckAndSetDefaults() error {
 	if c.Spec.SecondFactor != teleport.OFF && c.Spec.SecondFactor != teleport.OTP && c.Spec.SecondFactor != teleport.U2F {
 		return trace.BadParameter("second factor type %q not supported", c.Spec.SecondFactor)
 	}
+	// make sure certificate format makes sense
+	if _, err := utils.CheckCompatibilityFlag(c.Spec.CertificateFormat); err != nil {
+		return trace.Wrap(err)
+	}
 
 	return nil
 }
@@ -176,6 +248,22 @@ type AuthPreferenceSpecV2 struct {
 
 	// U2F are the settings for the U2F device.
 	U2F *U2F `json:"u2f,omitempty"`
+
+	// MessageOfTheDay is a legal banner shown before login on tsh and the
+	// web UI, e.g. to satisfy consent-to-monitoring requirements.
+	MessageOfTheDay string `json:"message_of_the_day,omitempty"`
+
+	// AllowLocalAuth controls whether local username/password/2FA login
+	// remains available alongside an oidc or saml connector. It has no
+	// effect when Type is local. Defaults to true when unset.
+	AllowLocalAuth *bool `json:"allow_local_auth,omitempty"`
+
+	// CertificateFormat is the default certificate extension format used
+	// for user certificates issued by this cluster. Defaults to
+	// teleport.CompatibilityNone; set to teleport.CompatibilityOldSSH to
+	// interoperate with stock OpenSSH sshd versions that don't understand
+	// Teleport's certificate extensions.
+	CertificateFormat string `json:"cert_format,omitempty"`
 }
 
 // U2F defines settings for U2F device.
@@ -214,6 +302,15 @@ const AuthPreferenceSpecSchemaTemplate = `{
 				}
 			}
 		}
+	},
+	"message_of_the_day": {
+		"type": "string"
+	},
+	"allow_local_auth": {
+		"type": "boolean"
+	},
+	"cert_format": {
+		"type": "string"
 	}%v
   }
 }`
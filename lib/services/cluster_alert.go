@@ -0,0 +1,111 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// AlertSeverity is how urgently a ClusterAlert should be brought to an
+// operator's attention.
+type AlertSeverity string
+
+const (
+	// AlertSeverityInfo is a routine notice, e.g. a planned maintenance
+	// window.
+	AlertSeverityInfo AlertSeverity = "info"
+	// AlertSeverityWarning calls out something that should be addressed
+	// soon but isn't yet an emergency.
+	AlertSeverityWarning AlertSeverity = "warning"
+	// AlertSeverityCritical is a security advisory or other issue that
+	// needs immediate attention.
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+// ClusterAlert is an admin-authored notice -- a planned maintenance
+// window or a security advisory -- surfaced to clients such as tsh login
+// and the web UI until it expires or every user has acknowledged it.
+// Like AccessRequest, it isn't a versioned, schema-validated Resource:
+// it's short-lived, operator-managed state with no need for the full
+// Resource interface's namespacing/labels machinery.
+type ClusterAlert struct {
+	// ID uniquely identifies the alert.
+	ID string `json:"id"`
+	// Severity is how urgently the alert should be brought to an
+	// operator's attention.
+	Severity AlertSeverity `json:"severity"`
+	// Message is the text shown to clients.
+	Message string `json:"message"`
+	// Created is when the alert was made.
+	Created time.Time `json:"created"`
+	// Expires is when the alert stops being shown. The zero value means
+	// it never expires on its own and must be acknowledged or deleted.
+	Expires time.Time `json:"expires,omitempty"`
+
+	// AcknowledgedBy lists the users who have acknowledged this alert.
+	// An acknowledged alert is no longer shown to that user, but remains
+	// visible to everyone else until it expires or they acknowledge it
+	// too.
+	AcknowledgedBy []string `json:"acknowledged_by,omitempty"`
+}
+
+// Check returns nil if all parameters are valid, err otherwise.
+func (a *ClusterAlert) Check() error {
+	if a.ID == "" {
+		return trace.BadParameter("ClusterAlert: missing id")
+	}
+	if a.Message == "" {
+		return trace.BadParameter("ClusterAlert: missing message")
+	}
+	switch a.Severity {
+	case AlertSeverityInfo, AlertSeverityWarning, AlertSeverityCritical:
+	default:
+		return trace.BadParameter("ClusterAlert: unknown severity %q", a.Severity)
+	}
+	return nil
+}
+
+// IsExpired returns true if the alert's Expires time has passed.
+func (a *ClusterAlert) IsExpired(now time.Time) bool {
+	return !a.Expires.IsZero() && !now.Before(a.Expires)
+}
+
+// IsAcknowledgedBy returns true if user has already acknowledged the
+// alert.
+func (a *ClusterAlert) IsAcknowledgedBy(user string) bool {
+	for _, u := range a.AcknowledgedBy {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// ClusterAlerts manages the lifecycle of ClusterAlert objects.
+type ClusterAlerts interface {
+	// CreateClusterAlert creates a new ClusterAlert.
+	CreateClusterAlert(alert ClusterAlert) error
+	// GetClusterAlerts returns every ClusterAlert, most recent first.
+	GetClusterAlerts() ([]ClusterAlert, error)
+	// AcknowledgeClusterAlert records that user has acknowledged the
+	// ClusterAlert named by id.
+	AcknowledgeClusterAlert(id, user string) error
+	// DeleteClusterAlert removes the ClusterAlert named by id.
+	DeleteClusterAlert(id string) error
+}
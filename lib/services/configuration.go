@@ -34,4 +34,37 @@ type ClusterConfiguration interface {
 	GetAuthPreference() (AuthPreference, error)
 	// SetAuthPreference sets services.AuthPreference from the backend.
 	SetAuthPreference(AuthPreference) error
+
+	// GetSessionRecordingKey returns the cluster-managed AES-256 key used
+	// to encrypt session recordings and audit chunks at rest.
+	GetSessionRecordingKey() ([]byte, error)
+	// UpsertSessionRecordingKey sets the cluster-managed session recording
+	// encryption key. Rotating it makes recordings written under the
+	// previous key unreadable, as this backend does not keep key history.
+	UpsertSessionRecordingKey(key []byte) error
+
+	// GetNotificationConfig gets services.NotificationConfig from the backend.
+	GetNotificationConfig() (NotificationConfig, error)
+	// SetNotificationConfig sets services.NotificationConfig on the backend.
+	SetNotificationConfig(NotificationConfig) error
+
+	// GetLDAPConnector gets services.LDAPConnector from the backend.
+	GetLDAPConnector() (LDAPConnector, error)
+	// SetLDAPConnector sets services.LDAPConnector on the backend.
+	SetLDAPConnector(LDAPConnector) error
+
+	// GetOktaConfig gets services.OktaConfig from the backend.
+	GetOktaConfig() (OktaConfig, error)
+	// SetOktaConfig sets services.OktaConfig on the backend.
+	SetOktaConfig(OktaConfig) error
+
+	// GetSlackPlugin gets services.SlackPlugin from the backend.
+	GetSlackPlugin() (SlackPlugin, error)
+	// SetSlackPlugin sets services.SlackPlugin on the backend.
+	SetSlackPlugin(SlackPlugin) error
+
+	// GetEmailPlugin gets services.EmailPlugin from the backend.
+	GetEmailPlugin() (EmailPlugin, error)
+	// SetEmailPlugin sets services.EmailPlugin on the backend.
+	SetEmailPlugin(EmailPlugin) error
 }
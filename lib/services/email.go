@@ -0,0 +1,334 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// EmailPlugin configures an SMTP-based notifier: it emails access request
+// creation/resolution and upcoming provisioning token expirations to the
+// addresses EmailRoutes maps requested roles to. Like SlackPlugin, there's
+// only ever one of these per cluster.
+//
+// This snapshot has no multi-phase CA rotation state machine, so unlike a
+// current-generation Teleport email plugin, this one has nothing to hook
+// for CA rotation phase notifications.
+type EmailPlugin interface {
+	// Resource provides common resource properties.
+	Resource
+
+	// GetEnabled returns whether email delivery is turned on.
+	GetEnabled() bool
+	// SetEnabled turns email delivery on or off.
+	SetEnabled(bool)
+
+	// GetSMTPHost returns the SMTP server's hostname.
+	GetSMTPHost() string
+	// SetSMTPHost sets the SMTP server's hostname.
+	SetSMTPHost(string)
+	// GetSMTPPort returns the SMTP server's port.
+	GetSMTPPort() int
+	// SetSMTPPort sets the SMTP server's port.
+	SetSMTPPort(int)
+	// GetUsername returns the SMTP auth username.
+	GetUsername() string
+	// SetUsername sets the SMTP auth username.
+	SetUsername(string)
+	// GetPassword returns the SMTP auth password.
+	GetPassword() string
+	// SetPassword sets the SMTP auth password.
+	SetPassword(string)
+	// GetFrom returns the From address used on sent mail.
+	GetFrom() string
+	// SetFrom sets the From address used on sent mail.
+	SetFrom(string)
+
+	// GetExpiryWarning returns how long before a provisioning token expires
+	// to send a warning email.
+	GetExpiryWarning() time.Duration
+	// SetExpiryWarning sets how long before a provisioning token expires
+	// to send a warning email.
+	SetExpiryWarning(time.Duration)
+
+	// GetRoutes returns the rules mapping requested roles to recipients.
+	GetRoutes() []EmailRoute
+	// SetRoutes sets the rules mapping requested roles to recipients.
+	SetRoutes([]EmailRoute)
+
+	// CheckAndSetDefaults checks and sets default values for missing
+	// fields.
+	CheckAndSetDefaults() error
+}
+
+// EmailRoute maps a set of roles to the recipients notified about access
+// requests naming any of them. An EmailRoute with no Roles matches every
+// request, and is used for cluster-wide notifications like provisioning
+// token expiration warnings.
+type EmailRoute struct {
+	// Roles are the requested roles this route applies to. Empty matches
+	// any request.
+	Roles []string `json:"roles,omitempty"`
+	// Addresses are the recipient email addresses.
+	Addresses []string `json:"addresses"`
+}
+
+// NewEmailPlugin returns a new EmailPlugin from spec.
+func NewEmailPlugin(spec EmailPluginSpecV2) (EmailPlugin, error) {
+	c := EmailPluginV2{
+		Kind:    KindEmailPlugin,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      MetaNameEmailPlugin,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := c.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &c, nil
+}
+
+// EmailPluginV2 implements EmailPlugin.
+type EmailPluginV2 struct {
+	// Kind is a resource kind - always resource.
+	Kind string `json:"kind"`
+	// Version is a resource version.
+	Version string `json:"version"`
+	// Metadata is metadata about the resource.
+	Metadata Metadata `json:"metadata"`
+	// Spec is the specification of the resource.
+	Spec EmailPluginSpecV2 `json:"spec"`
+}
+
+// EmailPluginSpecV2 is the actual data we care about for EmailPlugin.
+type EmailPluginSpecV2 struct {
+	Enabled       bool         `json:"enabled"`
+	SMTPHost      string       `json:"smtp_host"`
+	SMTPPort      int          `json:"smtp_port"`
+	Username      string       `json:"username"`
+	Password      string       `json:"password"`
+	From          string       `json:"from"`
+	ExpiryWarning Duration     `json:"expiry_warning"`
+	Routes        []EmailRoute `json:"routes"`
+}
+
+// GetName returns the name of the EmailPlugin resource.
+func (c *EmailPluginV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the EmailPlugin resource.
+func (c *EmailPluginV2) SetName(e string) {
+	c.Metadata.Name = e
+}
+
+// Expiry returns object expiry setting.
+func (c *EmailPluginV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets expiry time for the object.
+func (c *EmailPluginV2) SetExpiry(expires time.Time) {
+	c.Metadata.SetExpiry(expires)
+}
+
+// SetTTL sets Expires header using realtime clock.
+func (c *EmailPluginV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata.
+func (c *EmailPluginV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetEnabled returns whether email delivery is turned on.
+func (c *EmailPluginV2) GetEnabled() bool { return c.Spec.Enabled }
+
+// SetEnabled turns email delivery on or off.
+func (c *EmailPluginV2) SetEnabled(e bool) { c.Spec.Enabled = e }
+
+// GetSMTPHost returns the SMTP server's hostname.
+func (c *EmailPluginV2) GetSMTPHost() string { return c.Spec.SMTPHost }
+
+// SetSMTPHost sets the SMTP server's hostname.
+func (c *EmailPluginV2) SetSMTPHost(h string) { c.Spec.SMTPHost = h }
+
+// GetSMTPPort returns the SMTP server's port.
+func (c *EmailPluginV2) GetSMTPPort() int { return c.Spec.SMTPPort }
+
+// SetSMTPPort sets the SMTP server's port.
+func (c *EmailPluginV2) SetSMTPPort(p int) { c.Spec.SMTPPort = p }
+
+// GetUsername returns the SMTP auth username.
+func (c *EmailPluginV2) GetUsername() string { return c.Spec.Username }
+
+// SetUsername sets the SMTP auth username.
+func (c *EmailPluginV2) SetUsername(u string) { c.Spec.Username = u }
+
+// GetPassword returns the SMTP auth password.
+func (c *EmailPluginV2) GetPassword() string { return c.Spec.Password }
+
+// SetPassword sets the SMTP auth password.
+func (c *EmailPluginV2) SetPassword(p string) { c.Spec.Password = p }
+
+// GetFrom returns the From address used on sent mail.
+func (c *EmailPluginV2) GetFrom() string { return c.Spec.From }
+
+// SetFrom sets the From address used on sent mail.
+func (c *EmailPluginV2) SetFrom(f string) { c.Spec.From = f }
+
+// GetExpiryWarning returns how long before a provisioning token expires to
+// send a warning email.
+func (c *EmailPluginV2) GetExpiryWarning() time.Duration { return c.Spec.ExpiryWarning.Duration }
+
+// SetExpiryWarning sets how long before a provisioning token expires to
+// send a warning email.
+func (c *EmailPluginV2) SetExpiryWarning(d time.Duration) { c.Spec.ExpiryWarning = NewDuration(d) }
+
+// GetRoutes returns the rules mapping requested roles to recipients.
+func (c *EmailPluginV2) GetRoutes() []EmailRoute { return c.Spec.Routes }
+
+// SetRoutes sets the rules mapping requested roles to recipients.
+func (c *EmailPluginV2) SetRoutes(r []EmailRoute) { c.Spec.Routes = r }
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults.
+func (c *EmailPluginV2) CheckAndSetDefaults() error {
+	if err := c.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Spec.SMTPHost == "" {
+		return trace.BadParameter("EmailPlugin: missing smtp_host")
+	}
+	if c.Spec.SMTPPort == 0 {
+		c.Spec.SMTPPort = defaults.EmailSMTPPort
+	}
+	if c.Spec.From == "" {
+		return trace.BadParameter("EmailPlugin: missing from")
+	}
+	for _, r := range c.Spec.Routes {
+		if len(r.Addresses) == 0 {
+			return trace.BadParameter("EmailPlugin: route is missing addresses")
+		}
+	}
+	if c.Spec.ExpiryWarning.Duration == 0 {
+		c.Spec.ExpiryWarning = NewDuration(defaults.EmailExpiryWarning)
+	}
+	return nil
+}
+
+// String represents a human readable version of the email plugin config.
+func (c *EmailPluginV2) String() string {
+	return fmt.Sprintf("EmailPlugin(smtp_host=%v, enabled=%v)", c.Spec.SMTPHost, c.Spec.Enabled)
+}
+
+// EmailPluginSpecSchemaTemplate is a template for EmailPlugin schema.
+const EmailPluginSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["smtp_host", "from"],
+  "properties": {
+    "enabled": {"type": "boolean"},
+    "smtp_host": {"type": "string"},
+    "smtp_port": {"type": "number"},
+    "username": {"type": "string"},
+    "password": {"type": "string"},
+    "from": {"type": "string"},
+    "expiry_warning": {"type": "string"},
+    "routes": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "roles": {"type": "array", "items": {"type": "string"}},
+          "addresses": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }%v
+  }
+}`
+
+// GetEmailPluginSchema returns the schema with optionally injected schema
+// for extensions.
+func GetEmailPluginSchema(extensionSchema string) string {
+	var schema string
+	if extensionSchema == "" {
+		schema = fmt.Sprintf(EmailPluginSpecSchemaTemplate, "")
+	} else {
+		schema = fmt.Sprintf(EmailPluginSpecSchemaTemplate, ","+extensionSchema)
+	}
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, schema, DefaultDefinitions)
+}
+
+// EmailPluginMarshaler implements marshal/unmarshal of EmailPlugin
+// implementations, mostly adds support for extended versions.
+type EmailPluginMarshaler interface {
+	Marshal(c EmailPlugin, opts ...MarshalOption) ([]byte, error)
+	Unmarshal(bytes []byte) (EmailPlugin, error)
+}
+
+var emailPluginMarshaler EmailPluginMarshaler = &TeleportEmailPluginMarshaler{}
+
+// SetEmailPluginMarshaler sets the marshaler.
+func SetEmailPluginMarshaler(m EmailPluginMarshaler) {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	emailPluginMarshaler = m
+}
+
+// GetEmailPluginMarshaler gets the marshaler.
+func GetEmailPluginMarshaler() EmailPluginMarshaler {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	return emailPluginMarshaler
+}
+
+// TeleportEmailPluginMarshaler is used to marshal and unmarshal
+// EmailPlugin.
+type TeleportEmailPluginMarshaler struct{}
+
+// Unmarshal unmarshals EmailPlugin from JSON.
+func (t *TeleportEmailPluginMarshaler) Unmarshal(bytes []byte) (EmailPlugin, error) {
+	var c EmailPluginV2
+
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	err := utils.UnmarshalWithSchema(GetEmailPluginSchema(""), &c, bytes)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+	utils.UTC(&c.Metadata.Expires)
+	return &c, nil
+}
+
+// Marshal marshals EmailPlugin to JSON.
+func (t *TeleportEmailPluginMarshaler) Marshal(c EmailPlugin, opts ...MarshalOption) ([]byte, error) {
+	return json.Marshal(c)
+}
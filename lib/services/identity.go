@@ -287,6 +287,11 @@ type OIDCAuthRequest struct {
 
 	// Compatibility specifies OpenSSH compatibility flags.
 	Compatibility string `json:"compatibility,omitempty"`
+
+	// SSOTestFlow, if set, marks this as a `tctl sso test` dry run: the
+	// connector's claims-to-roles and trait mappings are computed and
+	// reported back, but no user or session is created or persisted.
+	SSOTestFlow bool `json:"sso_test_flow,omitempty"`
 }
 
 // Check returns nil if all parameters are great, err otherwise
@@ -346,6 +351,28 @@ type SAMLAuthRequest struct {
 
 	// Compatibility specifies OpenSSH compatibility flags.
 	Compatibility string `json:"compatibility,omitempty"`
+
+	// SSOTestFlow, if set, marks this as a `tctl sso test` dry run: the
+	// connector's attribute-to-roles and trait mappings are computed and
+	// reported back, but no user or session is created or persisted.
+	SSOTestFlow bool `json:"sso_test_flow,omitempty"`
+}
+
+// SSOTestFlowResult is returned in place of a certificate when an OIDC or
+// SAML auth request has SSOTestFlow set. It reports the raw claims or
+// assertions received from the identity provider along with the roles and
+// traits the connector's mappings produced from them, so `tctl sso test`
+// can be used to debug a connector before it is rolled out.
+type SSOTestFlowResult struct {
+	// Claims contains the raw claims (OIDC) or assertions (SAML) received
+	// from the identity provider.
+	Claims map[string]interface{} `json:"claims"`
+	// MappedRoles is the list of roles the connector's mapping rules
+	// produced from Claims.
+	MappedRoles []string `json:"mapped_roles"`
+	// Traits is the set of traits the connector's trait mapping rules
+	// produced from Claims.
+	Traits map[string][]string `json:"traits"`
 }
 
 // Check returns nil if all parameters are great, err otherwise
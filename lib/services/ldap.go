@@ -0,0 +1,358 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// LDAPConnector specifies how local logins are authenticated against an
+// LDAP/Active Directory server, for organizations that have a directory
+// but no SAML/OIDC identity provider. Unlike OIDCConnector/SAMLConnector,
+// there's only ever one of these per cluster: it replaces the bcrypt
+// password check local login normally does, it doesn't add another choice
+// on the login screen.
+type LDAPConnector interface {
+	// Resource provides common resource properties.
+	Resource
+
+	// GetAddr returns the "host:port" of the LDAP server.
+	GetAddr() string
+	// SetAddr sets the "host:port" of the LDAP server.
+	SetAddr(string)
+
+	// GetInsecureSkipVerify returns whether to skip TLS certificate
+	// verification when connecting to the LDAP server.
+	GetInsecureSkipVerify() bool
+	// SetInsecureSkipVerify sets whether to skip TLS certificate
+	// verification when connecting to the LDAP server.
+	SetInsecureSkipVerify(bool)
+
+	// GetBindDN returns the DN the service account binds as to search the
+	// directory, e.g. "cn=teleport,ou=svc,dc=example,dc=com".
+	GetBindDN() string
+	// SetBindDN sets the service account bind DN.
+	SetBindDN(string)
+	// GetBindPassword returns the service account's password.
+	GetBindPassword() string
+	// SetBindPassword sets the service account's password.
+	SetBindPassword(string)
+
+	// GetUserSearchBaseDN returns the DN to search for users under.
+	GetUserSearchBaseDN() string
+	// SetUserSearchBaseDN sets the DN to search for users under.
+	SetUserSearchBaseDN(string)
+	// GetUserSearchFilter returns the filter used to find a user by name,
+	// e.g. "(sAMAccountName=%s)". %s is replaced with the login username.
+	GetUserSearchFilter() string
+	// SetUserSearchFilter sets the user search filter.
+	SetUserSearchFilter(string)
+
+	// GetGroupSearchBaseDN returns the DN to search for groups under.
+	GetGroupSearchBaseDN() string
+	// SetGroupSearchBaseDN sets the DN to search for groups under.
+	SetGroupSearchBaseDN(string)
+	// GetGroupSearchFilter returns the filter used to find the groups a
+	// user belongs to, e.g. "(member=%s)". %s is replaced with the user's
+	// DN.
+	GetGroupSearchFilter() string
+	// SetGroupSearchFilter sets the group search filter.
+	SetGroupSearchFilter(string)
+	// GetGroupAttribute returns the attribute holding a group's name,
+	// e.g. "cn".
+	GetGroupAttribute() string
+	// SetGroupAttribute sets the group name attribute.
+	SetGroupAttribute(string)
+
+	// GetGroupsToRoles returns the mapping of AD/LDAP group names to
+	// Teleport role names.
+	GetGroupsToRoles() []LDAPGroupToRole
+	// SetGroupsToRoles sets the mapping of AD/LDAP group names to
+	// Teleport role names.
+	SetGroupsToRoles([]LDAPGroupToRole)
+
+	// CheckAndSetDefaults checks and sets default values for missing
+	// fields.
+	CheckAndSetDefaults() error
+}
+
+// LDAPGroupToRole maps a single LDAP/AD group to the Teleport roles a
+// member of that group should have.
+type LDAPGroupToRole struct {
+	// Group is the LDAP/AD group name, as found in the attribute named by
+	// LDAPConnector.GetGroupAttribute().
+	Group string `json:"group"`
+	// Roles are the Teleport roles granted to members of Group.
+	Roles []string `json:"roles"`
+}
+
+// NewLDAPConnector returns a new LDAPConnector from spec.
+func NewLDAPConnector(spec LDAPConnectorSpecV2) (LDAPConnector, error) {
+	c := LDAPConnectorV2{
+		Kind:    KindLDAPConnector,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      MetaNameLDAPConnector,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := c.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &c, nil
+}
+
+// LDAPConnectorV2 implements LDAPConnector.
+type LDAPConnectorV2 struct {
+	// Kind is a resource kind - always resource.
+	Kind string `json:"kind"`
+	// Version is a resource version.
+	Version string `json:"version"`
+	// Metadata is metadata about the resource.
+	Metadata Metadata `json:"metadata"`
+	// Spec is the specification of the resource.
+	Spec LDAPConnectorSpecV2 `json:"spec"`
+}
+
+// LDAPConnectorSpecV2 is the actual data we care about for LDAPConnector.
+type LDAPConnectorSpecV2 struct {
+	Addr               string            `json:"addr"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify,omitempty"`
+	BindDN             string            `json:"bind_dn"`
+	BindPassword       string            `json:"bind_password"`
+	UserSearchBaseDN   string            `json:"user_search_base_dn"`
+	UserSearchFilter   string            `json:"user_search_filter"`
+	GroupSearchBaseDN  string            `json:"group_search_base_dn"`
+	GroupSearchFilter  string            `json:"group_search_filter"`
+	GroupAttribute     string            `json:"group_attribute"`
+	GroupsToRoles      []LDAPGroupToRole `json:"groups_to_roles"`
+}
+
+// GetName returns the name of the LDAPConnector resource.
+func (c *LDAPConnectorV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the LDAPConnector resource.
+func (c *LDAPConnectorV2) SetName(e string) {
+	c.Metadata.Name = e
+}
+
+// Expiry returns object expiry setting.
+func (c *LDAPConnectorV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets expiry time for the object.
+func (c *LDAPConnectorV2) SetExpiry(expires time.Time) {
+	c.Metadata.SetExpiry(expires)
+}
+
+// SetTTL sets Expires header using realtime clock.
+func (c *LDAPConnectorV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata.
+func (c *LDAPConnectorV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetAddr returns the "host:port" of the LDAP server.
+func (c *LDAPConnectorV2) GetAddr() string { return c.Spec.Addr }
+
+// SetAddr sets the "host:port" of the LDAP server.
+func (c *LDAPConnectorV2) SetAddr(addr string) { c.Spec.Addr = addr }
+
+// GetInsecureSkipVerify returns whether to skip TLS certificate
+// verification.
+func (c *LDAPConnectorV2) GetInsecureSkipVerify() bool { return c.Spec.InsecureSkipVerify }
+
+// SetInsecureSkipVerify sets whether to skip TLS certificate verification.
+func (c *LDAPConnectorV2) SetInsecureSkipVerify(b bool) { c.Spec.InsecureSkipVerify = b }
+
+// GetBindDN returns the service account bind DN.
+func (c *LDAPConnectorV2) GetBindDN() string { return c.Spec.BindDN }
+
+// SetBindDN sets the service account bind DN.
+func (c *LDAPConnectorV2) SetBindDN(dn string) { c.Spec.BindDN = dn }
+
+// GetBindPassword returns the service account's password.
+func (c *LDAPConnectorV2) GetBindPassword() string { return c.Spec.BindPassword }
+
+// SetBindPassword sets the service account's password.
+func (c *LDAPConnectorV2) SetBindPassword(p string) { c.Spec.BindPassword = p }
+
+// GetUserSearchBaseDN returns the DN to search for users under.
+func (c *LDAPConnectorV2) GetUserSearchBaseDN() string { return c.Spec.UserSearchBaseDN }
+
+// SetUserSearchBaseDN sets the DN to search for users under.
+func (c *LDAPConnectorV2) SetUserSearchBaseDN(dn string) { c.Spec.UserSearchBaseDN = dn }
+
+// GetUserSearchFilter returns the filter used to find a user by name.
+func (c *LDAPConnectorV2) GetUserSearchFilter() string { return c.Spec.UserSearchFilter }
+
+// SetUserSearchFilter sets the user search filter.
+func (c *LDAPConnectorV2) SetUserSearchFilter(f string) { c.Spec.UserSearchFilter = f }
+
+// GetGroupSearchBaseDN returns the DN to search for groups under.
+func (c *LDAPConnectorV2) GetGroupSearchBaseDN() string { return c.Spec.GroupSearchBaseDN }
+
+// SetGroupSearchBaseDN sets the DN to search for groups under.
+func (c *LDAPConnectorV2) SetGroupSearchBaseDN(dn string) { c.Spec.GroupSearchBaseDN = dn }
+
+// GetGroupSearchFilter returns the filter used to find a user's groups.
+func (c *LDAPConnectorV2) GetGroupSearchFilter() string { return c.Spec.GroupSearchFilter }
+
+// SetGroupSearchFilter sets the group search filter.
+func (c *LDAPConnectorV2) SetGroupSearchFilter(f string) { c.Spec.GroupSearchFilter = f }
+
+// GetGroupAttribute returns the attribute holding a group's name.
+func (c *LDAPConnectorV2) GetGroupAttribute() string { return c.Spec.GroupAttribute }
+
+// SetGroupAttribute sets the group name attribute.
+func (c *LDAPConnectorV2) SetGroupAttribute(a string) { c.Spec.GroupAttribute = a }
+
+// GetGroupsToRoles returns the mapping of LDAP/AD groups to Teleport
+// roles.
+func (c *LDAPConnectorV2) GetGroupsToRoles() []LDAPGroupToRole { return c.Spec.GroupsToRoles }
+
+// SetGroupsToRoles sets the mapping of LDAP/AD groups to Teleport roles.
+func (c *LDAPConnectorV2) SetGroupsToRoles(m []LDAPGroupToRole) { c.Spec.GroupsToRoles = m }
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults.
+func (c *LDAPConnectorV2) CheckAndSetDefaults() error {
+	if err := c.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Spec.Addr == "" {
+		return trace.BadParameter("LDAPConnector: missing addr")
+	}
+	if c.Spec.UserSearchBaseDN == "" {
+		return trace.BadParameter("LDAPConnector: missing user_search_base_dn")
+	}
+	if c.Spec.UserSearchFilter == "" {
+		c.Spec.UserSearchFilter = "(uid=%s)"
+	}
+	if c.Spec.GroupAttribute == "" {
+		c.Spec.GroupAttribute = "cn"
+	}
+	return nil
+}
+
+// String represents a human readable version of the LDAP connector.
+func (c *LDAPConnectorV2) String() string {
+	return fmt.Sprintf("LDAPConnector(addr=%v, user_search_base_dn=%v)", c.Spec.Addr, c.Spec.UserSearchBaseDN)
+}
+
+// LDAPConnectorSpecSchemaTemplate is a template for LDAPConnector schema.
+const LDAPConnectorSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["addr", "user_search_base_dn"],
+  "properties": {
+    "addr": {"type": "string"},
+    "insecure_skip_verify": {"type": "boolean"},
+    "bind_dn": {"type": "string"},
+    "bind_password": {"type": "string"},
+    "user_search_base_dn": {"type": "string"},
+    "user_search_filter": {"type": "string"},
+    "group_search_base_dn": {"type": "string"},
+    "group_search_filter": {"type": "string"},
+    "group_attribute": {"type": "string"},
+    "groups_to_roles": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "group": {"type": "string"},
+          "roles": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }%v
+  }
+}`
+
+// GetLDAPConnectorSchema returns the schema with optionally injected schema
+// for extensions.
+func GetLDAPConnectorSchema(extensionSchema string) string {
+	var schema string
+	if extensionSchema == "" {
+		schema = fmt.Sprintf(LDAPConnectorSpecSchemaTemplate, "")
+	} else {
+		schema = fmt.Sprintf(LDAPConnectorSpecSchemaTemplate, ","+extensionSchema)
+	}
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, schema, DefaultDefinitions)
+}
+
+// LDAPConnectorMarshaler implements marshal/unmarshal of LDAPConnector
+// implementations, mostly adds support for extended versions.
+type LDAPConnectorMarshaler interface {
+	Marshal(c LDAPConnector, opts ...MarshalOption) ([]byte, error)
+	Unmarshal(bytes []byte) (LDAPConnector, error)
+}
+
+var ldapConnectorMarshaler LDAPConnectorMarshaler = &TeleportLDAPConnectorMarshaler{}
+
+// SetLDAPConnectorMarshaler sets the marshaler.
+func SetLDAPConnectorMarshaler(m LDAPConnectorMarshaler) {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	ldapConnectorMarshaler = m
+}
+
+// GetLDAPConnectorMarshaler gets the marshaler.
+func GetLDAPConnectorMarshaler() LDAPConnectorMarshaler {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	return ldapConnectorMarshaler
+}
+
+// TeleportLDAPConnectorMarshaler is used to marshal and unmarshal
+// LDAPConnector.
+type TeleportLDAPConnectorMarshaler struct{}
+
+// Unmarshal unmarshals LDAPConnector from JSON.
+func (t *TeleportLDAPConnectorMarshaler) Unmarshal(bytes []byte) (LDAPConnector, error) {
+	var c LDAPConnectorV2
+
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	err := utils.UnmarshalWithSchema(GetLDAPConnectorSchema(""), &c, bytes)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+	utils.UTC(&c.Metadata.Expires)
+	return &c, nil
+}
+
+// Marshal marshals LDAPConnector to JSON.
+func (t *TeleportLDAPConnectorMarshaler) Marshal(c LDAPConnector, opts ...MarshalOption) ([]byte, error) {
+	return json.Marshal(c)
+}
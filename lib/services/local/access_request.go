@@ -0,0 +1,208 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// accessRequestsPath is the backend bucket AccessRequests are stored under.
+var accessRequestsPath = []string{"access_requests"}
+
+// AccessRequestService is responsible for managing AccessRequest objects.
+type AccessRequestService struct {
+	backend.Backend
+}
+
+// NewAccessRequestService returns a new AccessRequestService.
+func NewAccessRequestService(backend backend.Backend) *AccessRequestService {
+	return &AccessRequestService{
+		Backend: backend,
+	}
+}
+
+// CreateAccessRequest creates a new AccessRequest in the pending state.
+func (s *AccessRequestService) CreateAccessRequest(req services.AccessRequest) error {
+	req.State = services.AccessRequestPending
+	if err := req.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.CreateVal(accessRequestsPath, req.ID, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetAccessRequest returns the AccessRequest named by id.
+func (s *AccessRequestService) GetAccessRequest(id string) (*services.AccessRequest, error) {
+	data, err := s.GetVal(accessRequestsPath, id)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("access request %q not found", id)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var req services.AccessRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &req, nil
+}
+
+// GetAccessRequests returns every AccessRequest, most recently created
+// first.
+func (s *AccessRequestService) GetAccessRequests() ([]services.AccessRequest, error) {
+	ids, err := s.GetKeys(accessRequestsPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	requests := make([]services.AccessRequest, 0, len(ids))
+	for _, id := range ids {
+		req, err := s.GetAccessRequest(id)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return nil, trace.Wrap(err)
+			}
+			continue
+		}
+		requests = append(requests, *req)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].Created.After(requests[j].Created) })
+	return requests, nil
+}
+
+// SetAccessRequestState resolves the AccessRequest named by id.
+func (s *AccessRequestService) SetAccessRequestState(id string, state services.AccessRequestState, resolver, reason string) error {
+	req, err := s.GetAccessRequest(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.State = state
+	req.Resolver = resolver
+	req.ResolveReason = reason
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(accessRequestsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// SetAccessRequestSlackThread records the Slack message posted for the
+// AccessRequest named by id.
+func (s *AccessRequestService) SetAccessRequestSlackThread(id, channel, threadTS string) error {
+	req, err := s.GetAccessRequest(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.SlackChannel = channel
+	req.SlackThreadTS = threadTS
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(accessRequestsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// AddAccessRequestApproval records that approver has approved the
+// AccessRequest named by id, without resolving it.
+func (s *AccessRequestService) AddAccessRequestApproval(id, approver string) error {
+	req, err := s.GetAccessRequest(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if req.HasApproval(approver) {
+		return trace.AlreadyExists("%v has already approved access request %v", approver, id)
+	}
+	req.Approvals = append(req.Approvals, approver)
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(accessRequestsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// ActivateAccessRequest marks the AccessRequest named by id as Activated
+// and records when its grant expires.
+func (s *AccessRequestService) ActivateAccessRequest(id string, expires time.Time) error {
+	req, err := s.GetAccessRequest(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.Activated = true
+	req.Expires = expires
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(accessRequestsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// ExpireAccessRequest marks the AccessRequest named by id as expired.
+func (s *AccessRequestService) ExpireAccessRequest(id string) error {
+	req, err := s.GetAccessRequest(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req.State = services.AccessRequestExpired
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(accessRequestsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteAccessRequest removes the AccessRequest named by id.
+func (s *AccessRequestService) DeleteAccessRequest(id string) error {
+	return trace.Wrap(s.DeleteKey(accessRequestsPath, id))
+}
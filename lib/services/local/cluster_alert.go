@@ -0,0 +1,124 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// clusterAlertsPath is the backend bucket ClusterAlerts are stored under.
+var clusterAlertsPath = []string{"cluster_alerts"}
+
+// ClusterAlertService is responsible for managing ClusterAlert objects.
+type ClusterAlertService struct {
+	backend.Backend
+}
+
+// NewClusterAlertService returns a new ClusterAlertService.
+func NewClusterAlertService(backend backend.Backend) *ClusterAlertService {
+	return &ClusterAlertService{
+		Backend: backend,
+	}
+}
+
+// CreateClusterAlert creates a new ClusterAlert.
+func (s *ClusterAlertService) CreateClusterAlert(alert services.ClusterAlert) error {
+	if err := alert.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.CreateVal(clusterAlertsPath, alert.ID, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetClusterAlert returns the ClusterAlert named by id.
+func (s *ClusterAlertService) GetClusterAlert(id string) (*services.ClusterAlert, error) {
+	data, err := s.GetVal(clusterAlertsPath, id)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("cluster alert %q not found", id)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var alert services.ClusterAlert
+	if err := json.Unmarshal(data, &alert); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &alert, nil
+}
+
+// GetClusterAlerts returns every ClusterAlert, most recently created
+// first.
+func (s *ClusterAlertService) GetClusterAlerts() ([]services.ClusterAlert, error) {
+	ids, err := s.GetKeys(clusterAlertsPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	alerts := make([]services.ClusterAlert, 0, len(ids))
+	for _, id := range ids {
+		alert, err := s.GetClusterAlert(id)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return nil, trace.Wrap(err)
+			}
+			continue
+		}
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Created.After(alerts[j].Created) })
+	return alerts, nil
+}
+
+// AcknowledgeClusterAlert records that user has acknowledged the
+// ClusterAlert named by id.
+func (s *ClusterAlertService) AcknowledgeClusterAlert(id, user string) error {
+	alert, err := s.GetClusterAlert(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if alert.IsAcknowledgedBy(user) {
+		return nil
+	}
+	alert.AcknowledgedBy = append(alert.AcknowledgedBy, user)
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(clusterAlertsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteClusterAlert removes the ClusterAlert named by id.
+func (s *ClusterAlertService) DeleteClusterAlert(id string) error {
+	return trace.Wrap(s.DeleteKey(clusterAlertsPath, id))
+}
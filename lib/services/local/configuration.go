@@ -119,3 +119,174 @@ func (s *ClusterConfigurationService) SetAuthPreference(preferences services.Aut
 
 	return nil
 }
+
+// GetSessionRecordingKey returns the cluster-managed AES-256 key used to
+// encrypt session recordings and audit chunks at rest.
+func (s *ClusterConfigurationService) GetSessionRecordingKey() ([]byte, error) {
+	key, err := s.GetVal([]string{"cluster_configuration"}, "session_recording_key")
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("session recording key not found")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return key, nil
+}
+
+// UpsertSessionRecordingKey sets the cluster-managed session recording
+// encryption key.
+func (s *ClusterConfigurationService) UpsertSessionRecordingKey(key []byte) error {
+	err := s.UpsertVal([]string{"cluster_configuration"}, "session_recording_key", key, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetNotificationConfig gets the webhook notification configuration from
+// the backend.
+func (s *ClusterConfigurationService) GetNotificationConfig() (services.NotificationConfig, error) {
+	data, err := s.GetVal([]string{"cluster_configuration"}, "notification_config")
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("notification config not found")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return services.GetNotificationConfigMarshaler().Unmarshal(data)
+}
+
+// SetNotificationConfig sets the webhook notification configuration in the
+// backend.
+func (s *ClusterConfigurationService) SetNotificationConfig(c services.NotificationConfig) error {
+	data, err := services.GetNotificationConfigMarshaler().Marshal(c)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = s.UpsertVal([]string{"cluster_configuration"}, "notification_config", []byte(data), backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetLDAPConnector gets the LDAP connector settings from the backend.
+func (s *ClusterConfigurationService) GetLDAPConnector() (services.LDAPConnector, error) {
+	data, err := s.GetVal([]string{"cluster_configuration"}, "ldap_connector")
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("LDAP connector not found")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return services.GetLDAPConnectorMarshaler().Unmarshal(data)
+}
+
+// SetLDAPConnector sets the LDAP connector settings in the backend.
+func (s *ClusterConfigurationService) SetLDAPConnector(c services.LDAPConnector) error {
+	data, err := services.GetLDAPConnectorMarshaler().Marshal(c)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = s.UpsertVal([]string{"cluster_configuration"}, "ldap_connector", []byte(data), backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetOktaConfig gets the Okta sync service settings from the backend.
+func (s *ClusterConfigurationService) GetOktaConfig() (services.OktaConfig, error) {
+	data, err := s.GetVal([]string{"cluster_configuration"}, "okta_config")
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("Okta config not found")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return services.GetOktaConfigMarshaler().Unmarshal(data)
+}
+
+// SetOktaConfig sets the Okta sync service settings in the backend.
+func (s *ClusterConfigurationService) SetOktaConfig(c services.OktaConfig) error {
+	data, err := services.GetOktaConfigMarshaler().Marshal(c)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = s.UpsertVal([]string{"cluster_configuration"}, "okta_config", []byte(data), backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetSlackPlugin gets the Slack access request plugin settings from the
+// backend.
+func (s *ClusterConfigurationService) GetSlackPlugin() (services.SlackPlugin, error) {
+	data, err := s.GetVal([]string{"cluster_configuration"}, "slack_plugin")
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("Slack plugin not found")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return services.GetSlackPluginMarshaler().Unmarshal(data)
+}
+
+// SetSlackPlugin sets the Slack access request plugin settings in the
+// backend.
+func (s *ClusterConfigurationService) SetSlackPlugin(c services.SlackPlugin) error {
+	data, err := services.GetSlackPluginMarshaler().Marshal(c)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = s.UpsertVal([]string{"cluster_configuration"}, "slack_plugin", []byte(data), backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// GetEmailPlugin gets the email notification plugin settings from the
+// backend.
+func (s *ClusterConfigurationService) GetEmailPlugin() (services.EmailPlugin, error) {
+	data, err := s.GetVal([]string{"cluster_configuration"}, "email_plugin")
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("email plugin not found")
+		}
+		return nil, trace.Wrap(err)
+	}
+
+	return services.GetEmailPluginMarshaler().Unmarshal(data)
+}
+
+// SetEmailPlugin sets the email notification plugin settings in the
+// backend.
+func (s *ClusterConfigurationService) SetEmailPlugin(c services.EmailPlugin) error {
+	data, err := services.GetEmailPluginMarshaler().Marshal(c)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = s.UpsertVal([]string{"cluster_configuration"}, "email_plugin", []byte(data), backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
@@ -19,6 +19,7 @@ package local
 import (
 	"encoding/json"
 	"sort"
+	"time"
 
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/services"
@@ -149,6 +150,24 @@ func (s *PresenceService) upsertServer(prefix string, server services.Server) er
 	return trace.Wrap(err)
 }
 
+// keepAliveServer extends the TTL of an already registered server without
+// re-marshaling and re-sending its spec, which is all a heartbeat that
+// hasn't changed needs. The backend has no partial-write primitive, so this
+// still costs a full UpsertVal, but it saves the marshal on the caller's
+// side and gives backends room to special-case an unchanged-spec write
+// (e.g. a TTL-only update) in the future.
+func (s *PresenceService) keepAliveServer(bucket []string, name string, expires time.Time) error {
+	if name == "" {
+		return trace.BadParameter("missing server name")
+	}
+	data, err := s.GetVal(bucket, name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ttl := backend.TTL(s.Clock(), expires)
+	return trace.Wrap(s.UpsertVal(bucket, name, data, ttl))
+}
+
 // DeleteAllNodes deletes all nodes in a namespace
 func (s *PresenceService) DeleteAllNodes(namespace string) error {
 	return s.DeleteBucket([]string{namespacesPrefix, namespace}, nodesPrefix)
@@ -183,12 +202,37 @@ func (s *PresenceService) GetNodes(namespace string) ([]services.Server, error)
 	return servers, nil
 }
 
+// DeleteNode deletes node
+func (s *PresenceService) DeleteNode(namespace string, name string) error {
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	return s.DeleteKey([]string{namespacesPrefix, namespace, nodesPrefix}, name)
+}
+
 // UpsertNode registers node presence, permanently if ttl is 0 or
 // for the specified duration with second resolution if it's >= 1 second
+//
+// A node that doesn't set its own State (the common case -- heartbeats send
+// the same spec every time) keeps whatever state an administrator last set
+// for it, defaulting to ServerStatePending the first time this node name is
+// ever seen. This is how a brand new, self-registered node lands in pending
+// and stays there across every subsequent heartbeat until tctl/the web UI
+// approves it.
 func (s *PresenceService) UpsertNode(server services.Server) error {
 	if server.GetNamespace() == "" {
 		return trace.BadParameter("missing node namespace")
 	}
+	if server.GetState() == "" {
+		if existing, err := s.getNode(server.GetNamespace(), server.GetName()); err == nil {
+			server.SetState(existing.GetState())
+		} else {
+			server.SetState(services.ServerStatePending)
+		}
+	}
 	data, err := services.GetServerMarshaler().MarshalServer(server)
 	if err != nil {
 		return trace.Wrap(err)
@@ -198,6 +242,54 @@ func (s *PresenceService) UpsertNode(server services.Server) error {
 	return trace.Wrap(err)
 }
 
+// getNode fetches a single node by name without listing the whole namespace.
+func (s *PresenceService) getNode(namespace, name string) (services.Server, error) {
+	data, err := s.GetVal([]string{namespacesPrefix, namespace, nodesPrefix}, name)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("node %v is not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+	return services.GetServerMarshaler().UnmarshalServer(data, services.KindNode)
+}
+
+// ApproveNode marks a pending or quarantined node as approved, allowing it
+// to receive sessions again.
+func (s *PresenceService) ApproveNode(namespace, name string) error {
+	return s.setNodeState(namespace, name, services.ServerStateApproved)
+}
+
+// QuarantineNode marks a node as quarantined, immediately blocking new
+// sessions to it until it's approved again.
+func (s *PresenceService) QuarantineNode(namespace, name string) error {
+	return s.setNodeState(namespace, name, services.ServerStateQuarantined)
+}
+
+func (s *PresenceService) setNodeState(namespace, name, state string) error {
+	if namespace == "" {
+		return trace.BadParameter("missing parameter namespace")
+	}
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	server, err := s.getNode(namespace, name)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	server.SetState(state)
+	return trace.Wrap(s.UpsertNode(server))
+}
+
+// KeepAliveNode extends the TTL of a node that already registered its full
+// spec via UpsertNode, without resending that spec
+func (s *PresenceService) KeepAliveNode(namespace, name string, expires time.Time) error {
+	if namespace == "" {
+		return trace.BadParameter("missing node namespace")
+	}
+	return s.keepAliveServer([]string{namespacesPrefix, namespace, nodesPrefix}, name, expires)
+}
+
 // GetAuthServers returns a list of registered servers
 func (s *PresenceService) GetAuthServers() ([]services.Server, error) {
 	return s.getServers(services.KindAuthServer, authServersPrefix)
@@ -215,6 +307,12 @@ func (s *PresenceService) UpsertProxy(server services.Server) error {
 	return s.upsertServer(proxiesPrefix, server)
 }
 
+// KeepAliveProxy extends the TTL of a proxy that already registered its
+// full spec via UpsertProxy, without resending that spec
+func (s *PresenceService) KeepAliveProxy(name string, expires time.Time) error {
+	return s.keepAliveServer([]string{proxiesPrefix}, name, expires)
+}
+
 // GetProxies returns a list of registered proxies
 func (s *PresenceService) GetProxies() ([]services.Server, error) {
 	return s.getServers(services.KindProxy, proxiesPrefix)
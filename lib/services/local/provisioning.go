@@ -20,7 +20,6 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
@@ -39,28 +38,58 @@ func NewProvisioningService(backend backend.Backend) *ProvisioningService {
 	return &ProvisioningService{backend}
 }
 
-// UpsertToken adds provisioning tokens for the auth server
-func (s *ProvisioningService) UpsertToken(token string, roles teleport.Roles, ttl time.Duration) error {
+// UpsertToken adds a provisioning token, or updates an existing one
+func (s *ProvisioningService) UpsertToken(p services.ProvisionToken) error {
+	ttl := p.Expires.Sub(time.Now().UTC())
 	if ttl < time.Second {
 		ttl = defaults.ProvisioningTokenTTL
+		p.Expires = time.Now().UTC().Add(ttl)
 	}
-	t := services.ProvisionToken{
-		Roles:   roles,
-		Expires: time.Now().UTC().Add(ttl),
-		Token:   token,
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now().UTC()
 	}
-	out, err := json.Marshal(t)
+	// preserve the original creation metadata when a token is rotated
+	// rather than newly created
+	if existing, err := s.GetToken(p.Token); err == nil {
+		p.CreatedAt = existing.CreatedAt
+		p.Uses = existing.Uses
+		if p.CreatedBy == "" {
+			p.CreatedBy = existing.CreatedBy
+		}
+	}
+	out, err := json.Marshal(p)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	err = s.backend.UpsertVal([]string{"tokens"}, token, out, ttl)
+	err = s.backend.UpsertVal([]string{"tokens"}, p.Token, out, ttl)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
+// IncrementTokenUsage records that token has been used once more to join a
+// node, without otherwise disturbing its roles, creator, or expiry.
+func (s *ProvisioningService) IncrementTokenUsage(token string) error {
+	t, err := s.GetToken(token)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	t.Uses++
+	ttl := t.Expires.Sub(time.Now().UTC())
+	if ttl <= 0 {
+		// about to expire (or already has); leave it for the normal
+		// expiry/cleanup path instead of resurrecting it with a new TTL
+		return nil
+	}
+	out, err := json.Marshal(t)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(s.backend.UpsertVal([]string{"tokens"}, token, out, ttl))
+}
+
 // GetToken finds and returns token by id
 func (s *ProvisioningService) GetToken(token string) (*services.ProvisionToken, error) {
 	out, err := s.backend.GetVal([]string{"tokens"}, token)
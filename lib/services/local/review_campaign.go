@@ -0,0 +1,152 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// reviewCampaignsPath is the backend bucket ReviewCampaigns are stored
+// under.
+var reviewCampaignsPath = []string{"review_campaigns"}
+
+// ReviewCampaignService is responsible for managing ReviewCampaign objects.
+type ReviewCampaignService struct {
+	backend.Backend
+}
+
+// NewReviewCampaignService returns a new ReviewCampaignService.
+func NewReviewCampaignService(backend backend.Backend) *ReviewCampaignService {
+	return &ReviewCampaignService{
+		Backend: backend,
+	}
+}
+
+// CreateReviewCampaign creates a new ReviewCampaign in the active state.
+func (s *ReviewCampaignService) CreateReviewCampaign(campaign services.ReviewCampaign) error {
+	campaign.State = services.ReviewCampaignActive
+	if err := campaign.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := json.Marshal(campaign)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.CreateVal(reviewCampaignsPath, campaign.ID, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetReviewCampaign returns the ReviewCampaign named by id.
+func (s *ReviewCampaignService) GetReviewCampaign(id string) (*services.ReviewCampaign, error) {
+	data, err := s.GetVal(reviewCampaignsPath, id)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("review campaign %q not found", id)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var campaign services.ReviewCampaign
+	if err := json.Unmarshal(data, &campaign); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &campaign, nil
+}
+
+// GetReviewCampaigns returns every ReviewCampaign, most recently created
+// first.
+func (s *ReviewCampaignService) GetReviewCampaigns() ([]services.ReviewCampaign, error) {
+	ids, err := s.GetKeys(reviewCampaignsPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	campaigns := make([]services.ReviewCampaign, 0, len(ids))
+	for _, id := range ids {
+		campaign, err := s.GetReviewCampaign(id)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return nil, trace.Wrap(err)
+			}
+			continue
+		}
+		campaigns = append(campaigns, *campaign)
+	}
+	sort.Slice(campaigns, func(i, j int) bool { return campaigns[i].Created.After(campaigns[j].Created) })
+	return campaigns, nil
+}
+
+// RecordReviewVote records reviewer's decision on user's grant in the
+// ReviewCampaign named by id, replacing any previous decision the same
+// reviewer made about the same user.
+func (s *ReviewCampaignService) RecordReviewVote(id string, vote services.ReviewVote) error {
+	campaign, err := s.GetReviewCampaign(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	votes := make([]services.ReviewVote, 0, len(campaign.Votes)+1)
+	for _, v := range campaign.Votes {
+		if v.User == vote.User && v.Reviewer == vote.Reviewer {
+			continue
+		}
+		votes = append(votes, v)
+	}
+	campaign.Votes = append(votes, vote)
+
+	data, err := json.Marshal(campaign)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(reviewCampaignsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// CloseReviewCampaign marks the ReviewCampaign named by id as closed and
+// records which grants were actually revoked.
+func (s *ReviewCampaignService) CloseReviewCampaign(id string, revoked []services.ReviewGrant) error {
+	campaign, err := s.GetReviewCampaign(id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	campaign.State = services.ReviewCampaignClosed
+	campaign.Revoked = revoked
+
+	data, err := json.Marshal(campaign)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(reviewCampaignsPath, id, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteReviewCampaign removes the ReviewCampaign named by id.
+func (s *ReviewCampaignService) DeleteReviewCampaign(id string) error {
+	return trace.Wrap(s.DeleteKey(reviewCampaignsPath, id))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"encoding/json"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// trustedHostKeysPath is the backend bucket TrustedHostKeys are stored
+// under.
+var trustedHostKeysPath = []string{"trusted_host_keys"}
+
+// TrustedHostKeyService is responsible for managing TrustedHostKey
+// objects.
+type TrustedHostKeyService struct {
+	backend.Backend
+}
+
+// NewTrustedHostKeyService returns a new TrustedHostKeyService.
+func NewTrustedHostKeyService(backend backend.Backend) *TrustedHostKeyService {
+	return &TrustedHostKeyService{
+		Backend: backend,
+	}
+}
+
+// UpsertTrustedHostKey creates or replaces the TrustedHostKey for
+// key.Hostname.
+func (s *TrustedHostKeyService) UpsertTrustedHostKey(key services.TrustedHostKey) error {
+	if err := key.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = s.UpsertVal(trustedHostKeysPath, key.Hostname, data, backend.Forever)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetTrustedHostKey returns the TrustedHostKey for hostname.
+func (s *TrustedHostKeyService) GetTrustedHostKey(hostname string) (*services.TrustedHostKey, error) {
+	data, err := s.GetVal(trustedHostKeysPath, hostname)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("trusted host key for %q not found", hostname)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var key services.TrustedHostKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &key, nil
+}
+
+// GetTrustedHostKeys returns every registered TrustedHostKey.
+func (s *TrustedHostKeyService) GetTrustedHostKeys() ([]services.TrustedHostKey, error) {
+	hostnames, err := s.GetKeys(trustedHostKeysPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	keys := make([]services.TrustedHostKey, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		key, err := s.GetTrustedHostKey(hostname)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return nil, trace.Wrap(err)
+			}
+			continue
+		}
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+// DeleteTrustedHostKey removes the TrustedHostKey for hostname.
+func (s *TrustedHostKeyService) DeleteTrustedHostKey(hostname string) error {
+	return trace.Wrap(s.DeleteKey(trustedHostKeysPath, hostname))
+}
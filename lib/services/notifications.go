@@ -0,0 +1,291 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// NotificationConfig defines webhooks fired on selected security events
+// (e.g. a new trusted cluster, a CA rotation, a root login, a lock being
+// created). This is a configuration resource, never create more than one
+// instance of it.
+type NotificationConfig interface {
+	// Resource provides common resource properties.
+	Resource
+
+	// GetEnabled returns whether webhook delivery is turned on.
+	GetEnabled() bool
+	// SetEnabled turns webhook delivery on or off.
+	SetEnabled(bool)
+
+	// GetEvents returns the audit event types that trigger a webhook, e.g.
+	// "trusted_cluster.create" or "auth".
+	GetEvents() []string
+	// SetEvents sets the audit event types that trigger a webhook.
+	SetEvents([]string)
+
+	// GetWebhooks returns the webhook destinations events are sent to.
+	GetWebhooks() []Webhook
+	// SetWebhooks sets the webhook destinations events are sent to.
+	SetWebhooks([]Webhook)
+
+	// CheckAndSetDefaults checks and set default values for missing fields.
+	CheckAndSetDefaults() error
+}
+
+// Webhook is a single notification destination: a URL to POST a rendered
+// payload to whenever a configured event fires.
+type Webhook struct {
+	// Name identifies this webhook among others in the same
+	// NotificationConfig, e.g. for logging.
+	Name string `json:"name"`
+	// URL is the endpoint the rendered Template is POSTed to, e.g. a Slack
+	// incoming webhook URL or a PagerDuty Events API v2 endpoint.
+	URL string `json:"url"`
+	// Template is a text/template used to render the request body. It's
+	// executed with a map holding "EventType", "Fields" (the raw audit
+	// event fields) and "Time".
+	//
+	// If empty, a generic JSON payload of the event is sent, which is
+	// enough for a plain webhook receiver but usually not what Slack or
+	// PagerDuty expect -- set this to shape the payload for the target.
+	Template string `json:"template,omitempty"`
+}
+
+// NewNotificationConfig is a convenience wrapper to create a
+// NotificationConfig resource.
+func NewNotificationConfig(spec NotificationConfigSpecV2) (NotificationConfig, error) {
+	nc := NotificationConfigV2{
+		Kind:    KindNotificationConfig,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      MetaNameNotificationConfig,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := nc.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &nc, nil
+}
+
+// NotificationConfigV2 implements the NotificationConfig interface.
+type NotificationConfigV2 struct {
+	// Kind is a resource kind - always resource.
+	Kind string `json:"kind"`
+
+	// Version is a resource version.
+	Version string `json:"version"`
+
+	// Metadata is metadata about the resource.
+	Metadata Metadata `json:"metadata"`
+
+	// Spec is the specification of the resource.
+	Spec NotificationConfigSpecV2 `json:"spec"`
+}
+
+// NotificationConfigSpecV2 is the actual data we care about for
+// NotificationConfig.
+type NotificationConfigSpecV2 struct {
+	// Enabled turns webhook delivery on or off.
+	Enabled bool `json:"enabled"`
+	// Events is the list of audit event types that trigger a webhook.
+	Events []string `json:"events"`
+	// Webhooks is the list of webhook destinations events are sent to.
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// GetName returns the name of the NotificationConfig resource.
+func (c *NotificationConfigV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the NotificationConfig resource.
+func (c *NotificationConfigV2) SetName(e string) {
+	c.Metadata.Name = e
+}
+
+// Expiry returns object expiry setting
+func (c *NotificationConfigV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets expiry time for the object
+func (c *NotificationConfigV2) SetExpiry(expires time.Time) {
+	c.Metadata.SetExpiry(expires)
+}
+
+// SetTTL sets Expires header using realtime clock
+func (c *NotificationConfigV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata
+func (c *NotificationConfigV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetEnabled returns whether webhook delivery is turned on.
+func (c *NotificationConfigV2) GetEnabled() bool {
+	return c.Spec.Enabled
+}
+
+// SetEnabled turns webhook delivery on or off.
+func (c *NotificationConfigV2) SetEnabled(enabled bool) {
+	c.Spec.Enabled = enabled
+}
+
+// GetEvents returns the audit event types that trigger a webhook.
+func (c *NotificationConfigV2) GetEvents() []string {
+	return c.Spec.Events
+}
+
+// SetEvents sets the audit event types that trigger a webhook.
+func (c *NotificationConfigV2) SetEvents(e []string) {
+	c.Spec.Events = e
+}
+
+// GetWebhooks returns the webhook destinations events are sent to.
+func (c *NotificationConfigV2) GetWebhooks() []Webhook {
+	return c.Spec.Webhooks
+}
+
+// SetWebhooks sets the webhook destinations events are sent to.
+func (c *NotificationConfigV2) SetWebhooks(w []Webhook) {
+	c.Spec.Webhooks = w
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults.
+func (c *NotificationConfigV2) CheckAndSetDefaults() error {
+	// make sure we have defaults for all metadata fields
+	if err := c.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, w := range c.Spec.Webhooks {
+		if w.URL == "" {
+			return trace.BadParameter("notification webhook %q is missing a url", w.Name)
+		}
+	}
+	return nil
+}
+
+// String represents a human readable version of the notification config.
+func (c *NotificationConfigV2) String() string {
+	return fmt.Sprintf("NotificationConfig(enabled=%v, events=%v, webhooks=%v)", c.Spec.Enabled, c.Spec.Events, len(c.Spec.Webhooks))
+}
+
+// NotificationConfigSpecSchemaTemplate is a template for NotificationConfig
+// schema.
+const NotificationConfigSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "enabled": {
+      "type": "boolean"
+    },
+    "events": {
+      "type": "array",
+      "items": {
+        "type": "string"
+      }
+    },
+    "webhooks": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string"},
+          "url": {"type": "string"},
+          "template": {"type": "string"}
+        }
+      }
+    }%v
+  }
+}`
+
+// GetNotificationConfigSchema returns the schema with optionally injected
+// schema for extensions.
+func GetNotificationConfigSchema(extensionSchema string) string {
+	var schema string
+	if extensionSchema == "" {
+		schema = fmt.Sprintf(NotificationConfigSpecSchemaTemplate, "")
+	} else {
+		schema = fmt.Sprintf(NotificationConfigSpecSchemaTemplate, ","+extensionSchema)
+	}
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, schema, DefaultDefinitions)
+}
+
+// NotificationConfigMarshaler implements marshal/unmarshal of
+// NotificationConfig implementations, mostly adds support for extended
+// versions.
+type NotificationConfigMarshaler interface {
+	Marshal(c NotificationConfig, opts ...MarshalOption) ([]byte, error)
+	Unmarshal(bytes []byte) (NotificationConfig, error)
+}
+
+var notificationConfigMarshaler NotificationConfigMarshaler = &TeleportNotificationConfigMarshaler{}
+
+// SetNotificationConfigMarshaler sets the marshaler.
+func SetNotificationConfigMarshaler(m NotificationConfigMarshaler) {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	notificationConfigMarshaler = m
+}
+
+// GetNotificationConfigMarshaler gets the marshaler.
+func GetNotificationConfigMarshaler() NotificationConfigMarshaler {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	return notificationConfigMarshaler
+}
+
+// TeleportNotificationConfigMarshaler is used to marshal and unmarshal
+// NotificationConfig.
+type TeleportNotificationConfigMarshaler struct{}
+
+// Unmarshal unmarshals NotificationConfig from JSON.
+func (t *TeleportNotificationConfigMarshaler) Unmarshal(bytes []byte) (NotificationConfig, error) {
+	var nc NotificationConfigV2
+
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	err := utils.UnmarshalWithSchema(GetNotificationConfigSchema(""), &nc, bytes)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+	utils.UTC(&nc.Metadata.Expires)
+	return &nc, nil
+}
+
+// Marshal marshals NotificationConfig to JSON.
+func (t *TeleportNotificationConfigMarshaler) Marshal(c NotificationConfig, opts ...MarshalOption) ([]byte, error) {
+	return json.Marshal(c)
+}
@@ -59,6 +59,9 @@ type OIDCConnector interface {
 	GetScope() []string
 	// ClaimsToRoles specifies dynamic mapping from claims to roles
 	GetClaimsToRoles() []ClaimMapping
+	// GetTraitMappings returns the rules used to rename claims into the
+	// trait names expected by role templates
+	GetTraitMappings() []TraitMapping
 	// GetClaims returns list of claims expected by mappings
 	GetClaims() []string
 	// MapClaims maps claims to roles
@@ -83,6 +86,9 @@ type OIDCConnector interface {
 	SetScope([]string)
 	// SetClaimsToRoles sets dynamic mapping from claims to roles
 	SetClaimsToRoles([]ClaimMapping)
+	// SetTraitMappings sets the rules used to rename claims into the
+	// trait names expected by role templates
+	SetTraitMappings([]TraitMapping)
 	// SetDisplay sets friendly name for this provider.
 	SetDisplay(string)
 }
@@ -286,6 +292,12 @@ func (o *OIDCConnectorV2) SetClaimsToRoles(claims []ClaimMapping) {
 	o.Spec.ClaimsToRoles = claims
 }
 
+// SetTraitMappings sets the rules used to rename claims into the
+// trait names expected by role templates
+func (o *OIDCConnectorV2) SetTraitMappings(mappings []TraitMapping) {
+	o.Spec.TraitMappings = mappings
+}
+
 // SetClientID sets id for authentication client (in our case it's our Auth server)
 func (o *OIDCConnectorV2) SetClientID(clintID string) {
 	o.Spec.ClientID = clintID
@@ -347,6 +359,12 @@ func (o *OIDCConnectorV2) GetClaimsToRoles() []ClaimMapping {
 	return o.Spec.ClaimsToRoles
 }
 
+// GetTraitMappings returns the rules used to rename claims into the
+// trait names expected by role templates
+func (o *OIDCConnectorV2) GetTraitMappings() []TraitMapping {
+	return o.Spec.TraitMappings
+}
+
 // GetClaims returns list of claims expected by mappings
 func (o *OIDCConnectorV2) GetClaims() []string {
 	var out []string
@@ -540,6 +558,10 @@ type OIDCConnectorSpecV2 struct {
 	Scope []string `json:"scope,omitempty"`
 	// ClaimsToRoles specifies dynamic mapping from claims to roles
 	ClaimsToRoles []ClaimMapping `json:"claims_to_roles,omitempty"`
+	// TraitMappings is a list of rules that rename claims into the trait
+	// names expected by role templates, applied in order after claims are
+	// converted to traits
+	TraitMappings []TraitMapping `json:"trait_mappings,omitempty"`
 }
 
 // OIDCConnectorSpecV2Schema is a JSON Schema for OIDC Connector
@@ -564,9 +586,13 @@ var OIDCConnectorSpecV2Schema = fmt.Sprintf(`{
     "claims_to_roles": {
       "type": "array",
       "items": %v
+    },
+    "trait_mappings": {
+      "type": "array",
+      "items": %v
     }
   }
-}`, ClaimMappingSchema)
+}`, ClaimMappingSchema, TraitMappingSchema)
 
 // GetClaimNames returns a list of claim names from the claim values
 func GetClaimNames(claims jose.Claims) []string {
@@ -0,0 +1,306 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// OktaConfig configures periodic sync of Okta groups into Teleport
+// roles/traits, so a change to who's in an Okta group is reflected in
+// Teleport within one sync period rather than only on that user's next
+// login. Like LDAPConnector, there's only ever one of these per cluster.
+type OktaConfig interface {
+	// Resource provides common resource properties.
+	Resource
+
+	// GetEnabled returns whether the sync service is running.
+	GetEnabled() bool
+	// SetEnabled sets whether the sync service is running.
+	SetEnabled(bool)
+
+	// GetOrgURL returns the Okta org's base URL, e.g.
+	// "https://example.okta.com".
+	GetOrgURL() string
+	// SetOrgURL sets the Okta org's base URL.
+	SetOrgURL(string)
+	// GetAPIToken returns the Okta API token used to authenticate.
+	GetAPIToken() string
+	// SetAPIToken sets the Okta API token.
+	SetAPIToken(string)
+
+	// GetSyncInterval returns how often to sync.
+	GetSyncInterval() time.Duration
+	// SetSyncInterval sets how often to sync.
+	SetSyncInterval(time.Duration)
+
+	// GetGroupsToRoles returns the mapping of Okta group names to
+	// Teleport role names.
+	GetGroupsToRoles() []OktaGroupToRole
+	// SetGroupsToRoles sets the mapping of Okta group names to Teleport
+	// role names.
+	SetGroupsToRoles([]OktaGroupToRole)
+
+	// CheckAndSetDefaults checks and sets default values for missing
+	// fields.
+	CheckAndSetDefaults() error
+}
+
+// OktaGroupToRole maps a single Okta group to the Teleport roles a member
+// of that group should have.
+type OktaGroupToRole struct {
+	// Group is the Okta group name.
+	Group string `json:"group"`
+	// Roles are the Teleport roles granted to members of Group.
+	Roles []string `json:"roles"`
+}
+
+// NewOktaConfig returns a new OktaConfig from spec.
+func NewOktaConfig(spec OktaConfigSpecV2) (OktaConfig, error) {
+	c := OktaConfigV2{
+		Kind:    KindOktaConfig,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      MetaNameOktaConfig,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := c.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &c, nil
+}
+
+// OktaConfigV2 implements OktaConfig.
+type OktaConfigV2 struct {
+	// Kind is a resource kind - always resource.
+	Kind string `json:"kind"`
+	// Version is a resource version.
+	Version string `json:"version"`
+	// Metadata is metadata about the resource.
+	Metadata Metadata `json:"metadata"`
+	// Spec is the specification of the resource.
+	Spec OktaConfigSpecV2 `json:"spec"`
+}
+
+// OktaConfigSpecV2 is the actual data we care about for OktaConfig.
+type OktaConfigSpecV2 struct {
+	Enabled       bool              `json:"enabled"`
+	OrgURL        string            `json:"org_url"`
+	APIToken      string            `json:"api_token"`
+	SyncInterval  Duration          `json:"sync_interval"`
+	GroupsToRoles []OktaGroupToRole `json:"groups_to_roles"`
+}
+
+// GetName returns the name of the OktaConfig resource.
+func (c *OktaConfigV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the OktaConfig resource.
+func (c *OktaConfigV2) SetName(e string) {
+	c.Metadata.Name = e
+}
+
+// Expiry returns object expiry setting.
+func (c *OktaConfigV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets expiry time for the object.
+func (c *OktaConfigV2) SetExpiry(expires time.Time) {
+	c.Metadata.SetExpiry(expires)
+}
+
+// SetTTL sets Expires header using realtime clock.
+func (c *OktaConfigV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata.
+func (c *OktaConfigV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetEnabled returns whether the sync service is running.
+func (c *OktaConfigV2) GetEnabled() bool { return c.Spec.Enabled }
+
+// SetEnabled sets whether the sync service is running.
+func (c *OktaConfigV2) SetEnabled(e bool) { c.Spec.Enabled = e }
+
+// GetOrgURL returns the Okta org's base URL.
+func (c *OktaConfigV2) GetOrgURL() string { return c.Spec.OrgURL }
+
+// SetOrgURL sets the Okta org's base URL.
+func (c *OktaConfigV2) SetOrgURL(u string) { c.Spec.OrgURL = u }
+
+// GetAPIToken returns the Okta API token.
+func (c *OktaConfigV2) GetAPIToken() string { return c.Spec.APIToken }
+
+// SetAPIToken sets the Okta API token.
+func (c *OktaConfigV2) SetAPIToken(t string) { c.Spec.APIToken = t }
+
+// GetSyncInterval returns how often to sync.
+func (c *OktaConfigV2) GetSyncInterval() time.Duration { return c.Spec.SyncInterval.Duration }
+
+// SetSyncInterval sets how often to sync.
+func (c *OktaConfigV2) SetSyncInterval(d time.Duration) { c.Spec.SyncInterval = NewDuration(d) }
+
+// GetGroupsToRoles returns the mapping of Okta groups to Teleport roles.
+func (c *OktaConfigV2) GetGroupsToRoles() []OktaGroupToRole { return c.Spec.GroupsToRoles }
+
+// SetGroupsToRoles sets the mapping of Okta groups to Teleport roles.
+func (c *OktaConfigV2) SetGroupsToRoles(m []OktaGroupToRole) { c.Spec.GroupsToRoles = m }
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults.
+func (c *OktaConfigV2) CheckAndSetDefaults() error {
+	if err := c.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Spec.OrgURL == "" {
+		return trace.BadParameter("OktaConfig: missing org_url")
+	}
+	if c.Spec.APIToken == "" {
+		return trace.BadParameter("OktaConfig: missing api_token")
+	}
+	if c.Spec.SyncInterval.Duration == 0 {
+		c.Spec.SyncInterval = NewDuration(defaults.OktaSyncInterval)
+	}
+	return nil
+}
+
+// String represents a human readable version of the Okta config.
+func (c *OktaConfigV2) String() string {
+	return fmt.Sprintf("OktaConfig(org_url=%v, enabled=%v)", c.Spec.OrgURL, c.Spec.Enabled)
+}
+
+// OktaConfigSpecSchemaTemplate is a template for OktaConfig schema.
+const OktaConfigSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["org_url", "api_token"],
+  "properties": {
+    "enabled": {"type": "boolean"},
+    "org_url": {"type": "string"},
+    "api_token": {"type": "string"},
+    "sync_interval": {"type": "string"},
+    "groups_to_roles": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "group": {"type": "string"},
+          "roles": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }%v
+  }
+}`
+
+// GetOktaConfigSchema returns the schema with optionally injected schema
+// for extensions.
+func GetOktaConfigSchema(extensionSchema string) string {
+	var schema string
+	if extensionSchema == "" {
+		schema = fmt.Sprintf(OktaConfigSpecSchemaTemplate, "")
+	} else {
+		schema = fmt.Sprintf(OktaConfigSpecSchemaTemplate, ","+extensionSchema)
+	}
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, schema, DefaultDefinitions)
+}
+
+// OktaConfigMarshaler implements marshal/unmarshal of OktaConfig
+// implementations, mostly adds support for extended versions.
+type OktaConfigMarshaler interface {
+	Marshal(c OktaConfig, opts ...MarshalOption) ([]byte, error)
+	Unmarshal(bytes []byte) (OktaConfig, error)
+}
+
+var oktaConfigMarshaler OktaConfigMarshaler = &TeleportOktaConfigMarshaler{}
+
+// SetOktaConfigMarshaler sets the marshaler.
+func SetOktaConfigMarshaler(m OktaConfigMarshaler) {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	oktaConfigMarshaler = m
+}
+
+// GetOktaConfigMarshaler gets the marshaler.
+func GetOktaConfigMarshaler() OktaConfigMarshaler {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	return oktaConfigMarshaler
+}
+
+// OktaSyncReport summarizes what a run of the Okta group sync did (or, for
+// a dry run, would do) to reconcile Teleport users' roles with their Okta
+// group membership.
+type OktaSyncReport struct {
+	// DryRun is true if the changes below were computed but not applied.
+	DryRun bool `json:"dry_run"`
+	// Changes is one entry per user whose roles don't yet match what their
+	// Okta group membership implies.
+	Changes []OktaUserRoleDrift `json:"changes"`
+}
+
+// OktaUserRoleDrift describes a single user's role drift from their Okta
+// group membership.
+type OktaUserRoleDrift struct {
+	// User is the Teleport/Okta login.
+	User string `json:"user"`
+	// New is true if User doesn't exist in Teleport yet.
+	New bool `json:"new,omitempty"`
+	// OldRoles are the user's roles before the sync.
+	OldRoles []string `json:"old_roles"`
+	// NewRoles are the roles implied by the user's current Okta group
+	// membership.
+	NewRoles []string `json:"new_roles"`
+}
+
+// TeleportOktaConfigMarshaler is used to marshal and unmarshal OktaConfig.
+type TeleportOktaConfigMarshaler struct{}
+
+// Unmarshal unmarshals OktaConfig from JSON.
+func (t *TeleportOktaConfigMarshaler) Unmarshal(bytes []byte) (OktaConfig, error) {
+	var c OktaConfigV2
+
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	err := utils.UnmarshalWithSchema(GetOktaConfigSchema(""), &c, bytes)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+	utils.UTC(&c.Metadata.Expires)
+	return &c, nil
+}
+
+// Marshal marshals OktaConfig to JSON.
+func (t *TeleportOktaConfigMarshaler) Marshal(c OktaConfig, opts ...MarshalOption) ([]byte, error) {
+	return json.Marshal(c)
+}
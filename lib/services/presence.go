@@ -36,10 +36,25 @@ type Presence interface {
 	// DeleteAllNodes deletes all nodes in a namespace
 	DeleteAllNodes(namespace string) error
 
+	// DeleteNode deletes node by name
+	DeleteNode(namespace string, name string) error
+
 	// UpsertNode registers node presence, permanently if ttl is 0 or
 	// for the specified duration with second resolution if it's >= 1 second
 	UpsertNode(server Server) error
 
+	// KeepAliveNode extends the TTL of a node that already registered its
+	// full spec via UpsertNode, without resending that spec
+	KeepAliveNode(namespace, name string, expires time.Time) error
+
+	// ApproveNode marks a pending or quarantined node as approved, allowing
+	// it to receive sessions again.
+	ApproveNode(namespace, name string) error
+
+	// QuarantineNode marks a node as quarantined, immediately blocking new
+	// sessions to it until it's approved again.
+	QuarantineNode(namespace, name string) error
+
 	// GetAuthServers returns a list of registered servers
 	GetAuthServers() ([]Server, error)
 
@@ -51,6 +66,10 @@ type Presence interface {
 	// for the specified duration with second resolution if it's >= 1 second
 	UpsertProxy(server Server) error
 
+	// KeepAliveProxy extends the TTL of a proxy that already registered its
+	// full spec via UpsertProxy, without resending that spec
+	KeepAliveProxy(name string, expires time.Time) error
+
 	// GetProxies returns a list of registered proxies
 	GetProxies() ([]Server, error)
 
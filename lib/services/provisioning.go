@@ -18,15 +18,22 @@ package services
 
 import (
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/gravitational/teleport"
+
+	"github.com/gravitational/trace"
 )
 
 // Provisioner governs adding new nodes to the cluster
 type Provisioner interface {
-	// UpsertToken adds provisioning tokens for the auth server
-	UpsertToken(token string, roles teleport.Roles, ttl time.Duration) error
+	// UpsertToken adds a provisioning token, or updates an existing one.
+	// Rotating an existing token (upserting the same p.Token again)
+	// preserves its CreatedAt and Uses; CreatedBy is preserved unless
+	// p.CreatedBy is non-empty.
+	UpsertToken(p ProvisionToken) error
 
 	// GetToken finds and returns token by id
 	GetToken(token string) (*ProvisionToken, error)
@@ -36,6 +43,11 @@ type Provisioner interface {
 
 	// GetTokens returns all non-expired tokens
 	GetTokens() ([]ProvisionToken, error)
+
+	// IncrementTokenUsage records that token has been used once more to
+	// join a node, without otherwise disturbing its roles, creator, or
+	// expiry.
+	IncrementTokenUsage(token string) error
 }
 
 // ProvisionToken stores metadata about some provisioning token
@@ -43,6 +55,23 @@ type ProvisionToken struct {
 	Roles   teleport.Roles `json:"roles"`
 	Expires time.Time      `json:"expires"`
 	Token   string         `json:"token"`
+
+	// CreatedAt is when the token was first generated.
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	// CreatedBy is the Teleport user who requested the token, if known.
+	CreatedBy string `json:"created_by,omitempty"`
+	// Uses counts how many times the token has been used to join a node.
+	Uses int `json:"uses,omitempty"`
+
+	// OneTimeUse, if set, makes the token unusable after its first
+	// successful join, regardless of its TTL. This limits the blast
+	// radius of a token that leaks (e.g. in a CI log) to a single node.
+	OneTimeUse bool `json:"one_time_use,omitempty"`
+	// AllowedHosts, if non-empty, restricts which nodes may join with
+	// this token. Each entry is either a CIDR (matched against the
+	// joining node's address) or an exact node name. A join that matches
+	// none of the entries is rejected.
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
 }
 
 // String returns the human readable representation of a provisioning token.
@@ -51,6 +80,37 @@ func (p ProvisionToken) String() string {
 	if p.Expires.Unix() != 0 {
 		expires = p.Expires.String()
 	}
-	return fmt.Sprintf("ProvisionToken(Token=%v,Roles=%v,Expires=%v)",
-		p.Token, p.Roles, expires)
+	createdBy := p.CreatedBy
+	if createdBy == "" {
+		createdBy = "unknown"
+	}
+	return fmt.Sprintf("ProvisionToken(Token=%v,Roles=%v,Expires=%v,CreatedBy=%v,Uses=%v)",
+		p.Token, p.Roles, expires, createdBy, p.Uses)
+}
+
+// CheckBinding verifies that a node named nodeName joining from remoteAddr
+// is allowed to use this token, per its AllowedHosts restriction. An empty
+// AllowedHosts list places no restriction on the join.
+func (p ProvisionToken) CheckBinding(nodeName string, remoteAddr string) error {
+	if len(p.AllowedHosts) == 0 {
+		return nil
+	}
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	for _, allowed := range p.AllowedHosts {
+		if _, cidr, err := net.ParseCIDR(allowed); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return nil
+			}
+			continue
+		}
+		if strings.EqualFold(allowed, nodeName) {
+			return nil
+		}
+	}
+	return trace.AccessDenied("node %q [%v] is not permitted to use this token, allowed hosts: %v",
+		nodeName, remoteAddr, p.AllowedHosts)
 }
@@ -124,6 +124,89 @@ const (
 	// KindTrustedCluster is a resource that contains trusted cluster configuration.
 	KindTrustedCluster = "trusted_cluster"
 
+	// KindSessionRecordingConfig is a type of configuration resource that
+	// contains the cluster-managed session recording encryption key.
+	KindSessionRecordingConfig = "session_recording_config"
+
+	// KindNotificationConfig is a type of configuration resource that
+	// contains the webhooks fired on selected security events.
+	KindNotificationConfig = "notification_config"
+
+	// MetaNameNotificationConfig is the name of the configuration resource
+	// for notifications.
+	MetaNameNotificationConfig = "notification-config"
+
+	// KindLDAPConnector is a type of configuration resource that contains
+	// the settings for authenticating local logins against an LDAP/Active
+	// Directory server.
+	KindLDAPConnector = "ldap"
+
+	// MetaNameLDAPConnector is the name of the configuration resource for
+	// the LDAP connector.
+	MetaNameLDAPConnector = "ldap"
+
+	// KindOktaConfig is a type of configuration resource that contains the
+	// settings for the periodic Okta group-to-role sync service.
+	KindOktaConfig = "okta_config"
+
+	// MetaNameOktaConfig is the name of the configuration resource for the
+	// Okta sync service.
+	MetaNameOktaConfig = "okta-config"
+
+	// KindSlackPlugin is a type of configuration resource that contains
+	// the settings for the Slack access request plugin.
+	KindSlackPlugin = "slack_plugin"
+
+	// MetaNameSlackPlugin is the name of the configuration resource for
+	// the Slack access request plugin.
+	MetaNameSlackPlugin = "slack-plugin"
+
+	// KindAccessRequest is a type of resource representing a user's
+	// request for additional roles.
+	KindAccessRequest = "access_request"
+
+	// KindReviewCampaign is a type of resource representing a periodic
+	// access review campaign.
+	KindReviewCampaign = "review_campaign"
+
+	// KindClusterAlert is a type of resource representing an
+	// admin-authored notice (planned maintenance, a security advisory)
+	// surfaced to clients such as tsh login and the web UI.
+	KindClusterAlert = "cluster_alert"
+
+	// KindTrustedHostKey is a type of resource representing an
+	// operator-vouched-for OpenSSH host key, trusted alongside the
+	// cluster's own host CA during a migration.
+	KindTrustedHostKey = "trusted_host_key"
+
+	// KindEmailPlugin is a type of configuration resource that contains
+	// the settings for the SMTP-based email notification plugin.
+	KindEmailPlugin = "email_plugin"
+
+	// MetaNameEmailPlugin is the name of the configuration resource for
+	// the email notification plugin.
+	MetaNameEmailPlugin = "email-plugin"
+
+	// RegionLabel is a well-known node/proxy label naming the region
+	// (datacenter, cloud region, etc.) the agent is running in. Proxies
+	// use it to prefer routing sessions through a sibling proxy in the
+	// same region as the target node, to avoid unnecessary cross-region
+	// hops in a globally distributed cluster.
+	RegionLabel = "region"
+
+	// OriginLabel marks a resource as having been created by some
+	// automated process, identifying which one. It's a common metadata
+	// label rather than a per-kind schema field so any resource kind can
+	// carry it without a version bump.
+	OriginLabel = "origin"
+
+	// OriginConfigFile marks a resource as created by reconciling the
+	// auth server's static config file (e.g. roles or OIDC connectors
+	// declared under "auth_service"). Resources with this label are
+	// pruned automatically when removed from the config file, since the
+	// file, not the backend, is their source of truth.
+	OriginConfigFile = "config-file"
+
 	// V3 is the third version of resources.
 	V3 = "v3"
 
@@ -368,6 +451,10 @@ func ParseShortcut(in string) (string, error) {
 		return KindTrustedCluster, nil
 	case "cluster_authentication_preferences", "cap":
 		return KindClusterAuthPreference, nil
+	case "tokens":
+		return KindToken, nil
+	case "all":
+		return "all", nil
 	}
 	return "", trace.BadParameter("unsupported resource: %v", in)
 }
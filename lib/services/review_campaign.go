@@ -0,0 +1,184 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// ReviewCampaignState is the lifecycle state of a ReviewCampaign.
+type ReviewCampaignState string
+
+const (
+	// ReviewCampaignActive is the state of a campaign still collecting
+	// reviewer decisions.
+	ReviewCampaignActive ReviewCampaignState = "active"
+	// ReviewCampaignClosed is the state of a campaign whose Deadline has
+	// passed and whose decisions have been applied.
+	ReviewCampaignClosed ReviewCampaignState = "closed"
+)
+
+// ReviewDecision is a reviewer's disposition on whether a user should keep
+// the role grants a ReviewCampaign snapshotted.
+type ReviewDecision string
+
+const (
+	// ReviewKeep means the reviewer judges the grant still justified.
+	ReviewKeep ReviewDecision = "keep"
+	// ReviewRevoke means the reviewer judges the grant should be removed.
+	ReviewRevoke ReviewDecision = "revoke"
+)
+
+// ReviewGrant is one user's role assignment as it stood when the
+// ReviewCampaign was created, awaiting a reviewer's decision.
+type ReviewGrant struct {
+	// User is the Teleport user whose grant is under review.
+	User string `json:"user"`
+	// Roles are the roles User held when the campaign was created.
+	Roles []string `json:"roles"`
+}
+
+// ReviewVote is a single reviewer's decision on a ReviewGrant.
+type ReviewVote struct {
+	// User is the Teleport user the decision is about.
+	User string `json:"user"`
+	// Reviewer is who made the decision.
+	Reviewer string `json:"reviewer"`
+	// Decision is what the reviewer decided.
+	Decision ReviewDecision `json:"decision"`
+	// Reason is an optional note explaining the decision.
+	Reason string `json:"reason,omitempty"`
+	// Created is when the decision was recorded.
+	Created time.Time `json:"created"`
+}
+
+// ReviewCampaign is a periodic access review: a snapshot of every user's
+// role grants at the time it was created, a list of reviewers responsible
+// for deciding whether each grant is still justified, and the votes they
+// cast. Once Deadline passes, RunReviewCampaignSweep closes the campaign
+// and removes the roles any user's grant was voted ReviewRevoke on without
+// a countermanding ReviewKeep.
+type ReviewCampaign struct {
+	// ID uniquely identifies the campaign.
+	ID string `json:"id"`
+	// Name is a human-readable label for the campaign, e.g. "Q3 2026
+	// production access review".
+	Name string `json:"name"`
+	// Reviewers are the Teleport usernames allowed to cast votes on this
+	// campaign's grants.
+	Reviewers []string `json:"reviewers"`
+	// Grants is the snapshot of role assignments under review.
+	Grants []ReviewGrant `json:"grants"`
+	// Votes are the decisions reviewers have cast so far.
+	Votes []ReviewVote `json:"votes,omitempty"`
+	// State is the campaign's current lifecycle state.
+	State ReviewCampaignState `json:"state"`
+	// Created is when the campaign was started.
+	Created time.Time `json:"created"`
+	// Deadline is when the campaign closes and its decisions take effect.
+	Deadline time.Time `json:"deadline"`
+	// Revoked lists the (user, role) pairs RunReviewCampaignSweep actually
+	// removed when the campaign closed, for the audit trail -- a role a
+	// grant listed but that was already removed by other means by the
+	// time the campaign closed isn't included.
+	Revoked []ReviewGrant `json:"revoked,omitempty"`
+}
+
+// Check returns nil if all parameters are valid, err otherwise.
+func (c *ReviewCampaign) Check() error {
+	if c.ID == "" {
+		return trace.BadParameter("ReviewCampaign: missing id")
+	}
+	if c.Name == "" {
+		return trace.BadParameter("ReviewCampaign: missing name")
+	}
+	if len(c.Reviewers) == 0 {
+		return trace.BadParameter("ReviewCampaign: missing reviewers")
+	}
+	return nil
+}
+
+// IsReviewer returns true if user is allowed to cast votes on c.
+func (c *ReviewCampaign) IsReviewer(user string) bool {
+	for _, r := range c.Reviewers {
+		if r == user {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGrant returns true if user has a grant under review in c.
+func (c *ReviewCampaign) HasGrant(user string) bool {
+	for _, g := range c.Grants {
+		if g.User == user {
+			return true
+		}
+	}
+	return false
+}
+
+// VotesFor returns every vote cast on user's grant.
+func (c *ReviewCampaign) VotesFor(user string) []ReviewVote {
+	var out []ReviewVote
+	for _, v := range c.Votes {
+		if v.User == user {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ShouldRevoke returns true if user's grant should be revoked when c
+// closes: at least one reviewer voted ReviewRevoke and none voted
+// ReviewKeep. A grant with no votes at all is left alone -- an auditor who
+// never weighed in shouldn't cause a revocation by default.
+func (c *ReviewCampaign) ShouldRevoke(user string) bool {
+	var sawRevoke bool
+	for _, v := range c.VotesFor(user) {
+		if v.Decision == ReviewKeep {
+			return false
+		}
+		if v.Decision == ReviewRevoke {
+			sawRevoke = true
+		}
+	}
+	return sawRevoke
+}
+
+// ReviewCampaigns manages the lifecycle of ReviewCampaign objects.
+type ReviewCampaigns interface {
+	// CreateReviewCampaign creates a new ReviewCampaign in the active
+	// state.
+	CreateReviewCampaign(campaign ReviewCampaign) error
+	// GetReviewCampaign returns the ReviewCampaign named by id.
+	GetReviewCampaign(id string) (*ReviewCampaign, error)
+	// GetReviewCampaigns returns every ReviewCampaign, most recently
+	// created first.
+	GetReviewCampaigns() ([]ReviewCampaign, error)
+	// RecordReviewVote records reviewer's decision on user's grant in the
+	// ReviewCampaign named by id, replacing any previous decision the same
+	// reviewer made about the same user.
+	RecordReviewVote(id string, vote ReviewVote) error
+	// CloseReviewCampaign marks the ReviewCampaign named by id as closed
+	// and records which grants were actually revoked.
+	CloseReviewCampaign(id string, revoked []ReviewGrant) error
+	// DeleteReviewCampaign removes the ReviewCampaign named by id.
+	DeleteReviewCampaign(id string) error
+}
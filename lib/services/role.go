@@ -19,11 +19,13 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/teleport/lib/utils/parse"
 
@@ -42,6 +44,7 @@ var DefaultUserRules = map[string][]string{
 	KindSAML:           RO(),
 	KindSession:        RO(),
 	KindTrustedCluster: RW(),
+	KindClusterAlert:   RO(),
 }
 
 // DefaultImplicitRules provides access to the default set of implicit rules
@@ -199,8 +202,70 @@ const (
 
 	// MaxSessionTTL defines how long a SSH session can last for.
 	MaxSessionTTL = "max_session_ttl"
+
+	// RequestApproverRole names the role whose members must approve access
+	// requests granting this role, for roles sensitive enough to require
+	// dual authorization. Unset means requests granting this role can be
+	// resolved by anyone permitted to update access requests.
+	RequestApproverRole = "request_approver_role"
+
+	// RequestApproverCount is how many distinct members of
+	// RequestApproverRole must approve an access request granting this
+	// role before it's fulfilled. Defaults to 1 when RequestApproverRole
+	// is set but this is left unspecified.
+	RequestApproverCount = "request_approver_count"
+
+	// RecordSession overrides the cluster-wide session recording default
+	// for sessions started by this role. Unset defers to the cluster
+	// default. If roles disagree, an explicit false always wins over an
+	// explicit true, so a role added to bar recording (e.g. for a
+	// jurisdiction where recording certain employees is prohibited)
+	// can't be defeated by combining it with a role that allows it.
+	RecordSession = "record_session"
+
+	// RecordSessionNotify controls whether the user is shown a notice at
+	// the start of their session saying whether it's being recorded.
+	// Unset defaults to true; ties are broken the same way
+	// RecordSession's are.
+	RecordSessionNotify = "record_session_notify"
+
+	// RequestReason is set to "required" on roles that can only be used
+	// to start a session if the user supplies an incident/ticket
+	// reference (e.g. via 'tsh ssh --reason'), tying just-in-time node
+	// access back to whatever paged them. Any other value, or leaving it
+	// unset, makes the reason optional.
+	RequestReason = "request_reason"
+
+	// SessionAccessScope narrows what a role's grant of session resource
+	// access actually covers. Unset (the default) grants access to every
+	// session within the role's allowed namespaces. Set to
+	// SessionAccessScopeOwn, it only extends that grant to sessions the
+	// user actually participated in, so e.g. an auditor role can be
+	// limited to replaying their own sessions without being all-or-nothing
+	// about session read access. As with other options, the least
+	// restrictive role in the set wins: combining a scoped role with an
+	// unscoped one grants the unscoped (wider) access.
+	SessionAccessScope = "session_access_scope"
+
+	// KillProcessGroup controls whether every process in a session's
+	// process group is terminated when the session ends, rather than just
+	// the directly-spawned shell or command. Unset defaults to false,
+	// matching historical behavior, so a role doesn't have to opt in to
+	// keep nohup'd background jobs alive across reconnects. If any role
+	// in the set enables it, the whole group is killed -- same
+	// "most cautious setting wins" tie-break RecordSession uses, since
+	// this is a security/hygiene control, not a convenience one.
+	KillProcessGroup = "kill_process_group"
 )
 
+// RequestReasonRequired is the RequestReason value that makes supplying a
+// reason mandatory to start a session.
+const RequestReasonRequired = "required"
+
+// SessionAccessScopeOwn is the SessionAccessScope value that limits a
+// role's session access grant to sessions the user participated in.
+const SessionAccessScopeOwn = "own"
+
 const (
 	// Allow is the set of conditions that allow access.
 	Allow RoleConditionType = true
@@ -237,6 +302,38 @@ type Role interface {
 	// SetLogins sets *nix system logins for allow or deny condition.
 	SetLogins(RoleConditionType, []string)
 
+	// GetSudoers gets the raw sudoers file lines this role grants for the
+	// allow or deny condition. Only the allow condition is meaningful --
+	// see RoleConditions.Sudoers.
+	GetSudoers(RoleConditionType) []string
+	// SetSudoers sets the raw sudoers file lines this role grants for the
+	// allow or deny condition.
+	SetSudoers(RoleConditionType, []string)
+
+	// GetSELinuxLabel gets the SELinux security context sessions should be
+	// confined to for the allow or deny condition. Only the allow condition
+	// is meaningful -- see RoleConditions.SELinuxLabel.
+	GetSELinuxLabel(RoleConditionType) string
+	// SetSELinuxLabel sets the SELinux security context sessions should be
+	// confined to for the allow or deny condition.
+	SetSELinuxLabel(RoleConditionType, string)
+
+	// GetAppArmorProfile gets the AppArmor profile sessions should be
+	// confined to for the allow or deny condition. Only the allow condition
+	// is meaningful -- see RoleConditions.AppArmorProfile.
+	GetAppArmorProfile(RoleConditionType) string
+	// SetAppArmorProfile sets the AppArmor profile sessions should be
+	// confined to for the allow or deny condition.
+	SetAppArmorProfile(RoleConditionType, string)
+
+	// GetCgroupLimits gets the cgroup resource limits sessions should be
+	// placed under for the allow or deny condition. Only the allow
+	// condition is meaningful -- see RoleConditions.CgroupLimits.
+	GetCgroupLimits(RoleConditionType) CgroupLimits
+	// SetCgroupLimits sets the cgroup resource limits sessions should be
+	// placed under for the allow or deny condition.
+	SetCgroupLimits(RoleConditionType, CgroupLimits)
+
 	// GetNamespaces gets a list of namespaces this role is allowed or denied access to.
 	GetNamespaces(RoleConditionType) []string
 	// GetNamespaces sets a list of namespaces this role is allowed or denied access to.
@@ -247,6 +344,11 @@ type Role interface {
 	// SetNodeLabels sets the map of node labels this role is allowed or denied access to.
 	SetNodeLabels(RoleConditionType, map[string]string)
 
+	// GetClusterLabels gets the map of trusted cluster labels this role is allowed or denied access to.
+	GetClusterLabels(RoleConditionType) map[string]string
+	// SetClusterLabels sets the map of trusted cluster labels this role is allowed or denied access to.
+	SetClusterLabels(RoleConditionType, map[string]string)
+
 	// GetRules gets all allow or deny rules.
 	GetRules(rct RoleConditionType) map[string][]string
 	// SetRules sets an allow or deny rule.
@@ -266,6 +368,16 @@ type RoleV3 struct {
 	// rawObject is the raw object stored in the backend without any
 	// conversions applied, used in migrations.
 	rawObject interface{}
+
+	// allowMatcher and denyMatcher cache this role's compiled label
+	// matchers and login sets for the allow/deny conditions, built once by
+	// compileMatchers -- normally called by NewRoleSet, so every RoleSet
+	// FetchRoles returns already has them -- instead of being re-parsed on
+	// every CheckAccessToServer call a busy proxy makes while listing
+	// nodes. Once set, they're never mutated again, so reading them
+	// concurrently across goroutines sharing the same RoleSet is safe.
+	allowMatcher *roleMatcher
+	denyMatcher  *roleMatcher
 }
 
 // Equals returns true if the roles are equal. Roles are equal if options,
@@ -341,6 +453,15 @@ func (r *RoleV3) GetRawObject() interface{} {
 	return r.rawObject
 }
 
+// compileMatchers precomputes r's allow and deny matchers from its current
+// logins and label selectors. Called by NewRoleSet once a role's traits
+// have been applied and it won't change again for the lifetime of the
+// RoleSet it's part of.
+func (r *RoleV3) compileMatchers() {
+	r.allowMatcher = newRoleMatcher(r, Allow)
+	r.denyMatcher = newRoleMatcher(r, Deny)
+}
+
 // SetExpiry sets expiry time for the object.
 func (r *RoleV3) SetExpiry(expires time.Time) {
 	r.Metadata.SetExpiry(expires)
@@ -400,6 +521,76 @@ func (r *RoleV3) SetLogins(rct RoleConditionType, logins []string) {
 	}
 }
 
+// GetSudoers gets sudoers file lines for allow or deny condition.
+func (r *RoleV3) GetSudoers(rct RoleConditionType) []string {
+	if rct == Allow {
+		return r.Spec.Allow.Sudoers
+	}
+	return r.Spec.Deny.Sudoers
+}
+
+// SetSudoers sets sudoers file lines for allow or deny condition.
+func (r *RoleV3) SetSudoers(rct RoleConditionType, sudoers []string) {
+	scopy := utils.CopyStrings(sudoers)
+
+	if rct == Allow {
+		r.Spec.Allow.Sudoers = scopy
+	} else {
+		r.Spec.Deny.Sudoers = scopy
+	}
+}
+
+// GetSELinuxLabel gets the SELinux security context for allow or deny condition.
+func (r *RoleV3) GetSELinuxLabel(rct RoleConditionType) string {
+	if rct == Allow {
+		return r.Spec.Allow.SELinuxLabel
+	}
+	return r.Spec.Deny.SELinuxLabel
+}
+
+// SetSELinuxLabel sets the SELinux security context for allow or deny condition.
+func (r *RoleV3) SetSELinuxLabel(rct RoleConditionType, label string) {
+	if rct == Allow {
+		r.Spec.Allow.SELinuxLabel = label
+	} else {
+		r.Spec.Deny.SELinuxLabel = label
+	}
+}
+
+// GetAppArmorProfile gets the AppArmor profile for allow or deny condition.
+func (r *RoleV3) GetAppArmorProfile(rct RoleConditionType) string {
+	if rct == Allow {
+		return r.Spec.Allow.AppArmorProfile
+	}
+	return r.Spec.Deny.AppArmorProfile
+}
+
+// SetAppArmorProfile sets the AppArmor profile for allow or deny condition.
+func (r *RoleV3) SetAppArmorProfile(rct RoleConditionType, profile string) {
+	if rct == Allow {
+		r.Spec.Allow.AppArmorProfile = profile
+	} else {
+		r.Spec.Deny.AppArmorProfile = profile
+	}
+}
+
+// GetCgroupLimits gets cgroup resource limits for allow or deny condition.
+func (r *RoleV3) GetCgroupLimits(rct RoleConditionType) CgroupLimits {
+	if rct == Allow {
+		return r.Spec.Allow.CgroupLimits
+	}
+	return r.Spec.Deny.CgroupLimits
+}
+
+// SetCgroupLimits sets cgroup resource limits for allow or deny condition.
+func (r *RoleV3) SetCgroupLimits(rct RoleConditionType, limits CgroupLimits) {
+	if rct == Allow {
+		r.Spec.Allow.CgroupLimits = limits
+	} else {
+		r.Spec.Deny.CgroupLimits = limits
+	}
+}
+
 // GetNamespaces gets a list of namespaces this role is allowed or denied access to.
 func (r *RoleV3) GetNamespaces(rct RoleConditionType) []string {
 	if rct == Allow {
@@ -438,6 +629,25 @@ func (r *RoleV3) SetNodeLabels(rct RoleConditionType, labels map[string]string)
 	}
 }
 
+// GetClusterLabels gets the map of trusted cluster labels this role is allowed or denied access to.
+func (r *RoleV3) GetClusterLabels(rct RoleConditionType) map[string]string {
+	if rct == Allow {
+		return r.Spec.Allow.ClusterLabels
+	}
+	return r.Spec.Deny.ClusterLabels
+}
+
+// SetClusterLabels sets the map of trusted cluster labels this role is allowed or denied access to.
+func (r *RoleV3) SetClusterLabels(rct RoleConditionType, labels map[string]string) {
+	lcopy := utils.CopyStringMap(labels)
+
+	if rct == Allow {
+		r.Spec.Allow.ClusterLabels = lcopy
+	} else {
+		r.Spec.Deny.ClusterLabels = lcopy
+	}
+}
+
 // GetRules gets all allow or deny rules.
 func (r *RoleV3) GetRules(rct RoleConditionType) map[string][]string {
 	if rct == Allow {
@@ -477,6 +687,9 @@ func (r *RoleV3) CheckAndSetDefaults() error {
 	if r.Spec.Allow.NodeLabels == nil {
 		r.Spec.Allow.NodeLabels = map[string]string{Wildcard: Wildcard}
 	}
+	if r.Spec.Allow.ClusterLabels == nil {
+		r.Spec.Allow.ClusterLabels = map[string]string{Wildcard: Wildcard}
+	}
 	if r.Spec.Allow.Rules == nil {
 		r.Spec.Allow.Rules = utils.CopyStringMapSlices(DefaultUserRules)
 	}
@@ -630,20 +843,84 @@ func (o RoleOptions) GetDuration(key string) (Duration, error) {
 	return value, nil
 }
 
+// GetInt returns the option as an int or returns an error. JSON numbers
+// decode into the map as float64, so that's accepted alongside int.
+func (o RoleOptions) GetInt(key string) (int, error) {
+	valueI, ok := o[key]
+	if !ok {
+		return 0, trace.NotFound("key %q not found in options", key)
+	}
+
+	switch value := valueI.(type) {
+	case int:
+		return value, nil
+	case float64:
+		return int(value), nil
+	default:
+		return 0, trace.BadParameter("type %T for key %q is not an int", valueI, key)
+	}
+}
+
 // Equals checks if all the key/values in the RoleOptions map match.
 func (o RoleOptions) Equals(other RoleOptions) bool {
 	return utils.InterfaceMapsEqual(o, other)
 }
 
+// CgroupLimits caps the host resources a session may use. A zero field
+// means "no limit" for that resource, so the empty CgroupLimits{} is a
+// valid "don't confine resource usage" value.
+type CgroupLimits struct {
+	// CPUPercent caps CPU usage as a percentage of one CPU core, e.g. 50
+	// allows up to half a core's worth of CPU time.
+	CPUPercent int `json:"cpu_percent,omitempty" yaml:"cpu_percent,omitempty"`
+	// MemoryBytes caps resident memory usage.
+	MemoryBytes int64 `json:"memory_bytes,omitempty" yaml:"memory_bytes,omitempty"`
+	// MaxPIDs caps the number of processes/threads the session can create.
+	MaxPIDs int64 `json:"max_pids,omitempty" yaml:"max_pids,omitempty"`
+}
+
+// IsEmpty returns true if c has no limit set for any resource.
+func (c CgroupLimits) IsEmpty() bool {
+	return c.CPUPercent == 0 && c.MemoryBytes == 0 && c.MaxPIDs == 0
+}
+
 // RoleConditions is a set of conditions that must all match to be allowed or
 // denied access.
 type RoleConditions struct {
 	// Logins is a list of *nix system logins.
 	Logins []string `json:"logins,omitempty" yaml:"logins,omitempty"`
+	// Sudoers is a list of raw sudoers file lines granted to this role's
+	// logins. Each line may contain the placeholder "{{login}}", which is
+	// replaced with the actual OS login being used for the session (as
+	// resolved from Logins/traits) when the lines are written out to the
+	// node at session start. Only meaningful on the allow side: unlike
+	// Logins, there's no established meaning for denying a free-form
+	// sudoers line, so Deny.Sudoers is ignored.
+	Sudoers []string `json:"sudoers,omitempty" yaml:"sudoers,omitempty"`
+	// SELinuxLabel is the SELinux security context sessions with this
+	// role's logins are confined to, e.g. "staff_u:staff_r:staff_t:s0". It
+	// is applied when the session's command is executed (see
+	// lib/srv/confinement.go) via "runcon". Only meaningful on the allow
+	// side, same as Sudoers: Deny.SELinuxLabel is ignored.
+	SELinuxLabel string `json:"selinux_label,omitempty" yaml:"selinux_label,omitempty"`
+	// AppArmorProfile is the name of the AppArmor profile sessions with
+	// this role's logins are confined to. It is applied when the
+	// session's command is executed (see lib/srv/confinement.go) via
+	// "aa-exec -p". Only meaningful on the allow side, same as Sudoers:
+	// Deny.AppArmorProfile is ignored.
+	AppArmorProfile string `json:"apparmor_profile,omitempty" yaml:"apparmor_profile,omitempty"`
+	// CgroupLimits caps the host resources sessions with this role's
+	// logins may use, enforced via a dedicated cgroup (see
+	// lib/srv/cgroup.go). Only meaningful on the allow side, same as
+	// Sudoers: Deny.CgroupLimits is ignored.
+	CgroupLimits CgroupLimits `json:"cgroup_limits,omitempty" yaml:"cgroup_limits,omitempty"`
 	// Namespaces is a list of namespaces (used to partition a cluster).
 	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
 	// NodeLabels is a map of node labels (used to dynamically grant access to nodes).
 	NodeLabels map[string]string `json:"node_labels,omitempty" yaml:"node_labels,omitempty"`
+	// ClusterLabels is a map of trusted cluster labels (used to dynamically
+	// grant visibility into, and access through, trusted clusters).
+	ClusterLabels map[string]string `json:"cluster_labels,omitempty" yaml:"cluster_labels,omitempty"`
 
 	// Rules is a list of rules and their access levels. Rules are a high level
 	// construct used for access control.
@@ -656,12 +933,27 @@ func (r *RoleConditions) Equals(o RoleConditions) bool {
 	if !utils.StringSlicesEqual(r.Logins, o.Logins) {
 		return false
 	}
+	if !utils.StringSlicesEqual(r.Sudoers, o.Sudoers) {
+		return false
+	}
+	if r.SELinuxLabel != o.SELinuxLabel {
+		return false
+	}
+	if r.AppArmorProfile != o.AppArmorProfile {
+		return false
+	}
+	if r.CgroupLimits != o.CgroupLimits {
+		return false
+	}
 	if !utils.StringSlicesEqual(r.Namespaces, o.Namespaces) {
 		return false
 	}
 	if !utils.StringMapsEqual(r.NodeLabels, o.NodeLabels) {
 		return false
 	}
+	if !utils.StringMapsEqual(r.ClusterLabels, o.ClusterLabels) {
+		return false
+	}
 	if !utils.StringMapSlicesEqual(r.Rules, o.Rules) {
 		return false
 	}
@@ -950,6 +1242,10 @@ type AccessChecker interface {
 	// CheckAccessToServer checks access to server.
 	CheckAccessToServer(login string, server Server) error
 
+	// CheckAccessToCluster checks whether a trusted cluster is visible to,
+	// and reachable by, this role set, based on the cluster's labels.
+	CheckAccessToCluster(cluster TrustedCluster) error
+
 	// CheckAccessToRule checks access to a rule within a namespace.
 	CheckAccessToRule(namespace string, rule string, verb string) error
 
@@ -966,6 +1262,13 @@ type AccessChecker interface {
 
 	// CanForwardAgents returns true if this role set offers capability to forward agents
 	CanForwardAgents() bool
+
+	// CanViewSession returns true if username may view/replay sess, given
+	// the roles in this set that grant read access to the session
+	// resource within sess's namespace. Callers are expected to have
+	// already confirmed that access via CheckAccessToRule; this narrows it
+	// further for roles that set SessionAccessScope.
+	CanViewSession(sess session.Session, username string) bool
 }
 
 // FromSpec returns new RoleSet created from spec
@@ -1028,9 +1331,18 @@ func FetchRoles(roleNames []string, access RoleGetter, traits map[string][]strin
 	return NewRoleSet(roles...), nil
 }
 
-// NewRoleSet returns new RoleSet based on the roles
+// NewRoleSet returns new RoleSet based on the roles. Every *RoleV3 in the
+// resulting set has its allow/deny matchers precompiled, so the set as a
+// whole is safe to share and check against concurrently -- see
+// RoleV3.compileMatchers.
 func NewRoleSet(roles ...Role) RoleSet {
-	return append(roles, NewImplicitRole())
+	roles = append(roles, NewImplicitRole())
+	for _, role := range roles {
+		if r3, ok := role.(*RoleV3); ok {
+			r3.compileMatchers()
+		}
+	}
+	return roles
 }
 
 // RoleSet is a set of roles that implements access control functionality
@@ -1070,6 +1382,126 @@ func MatchLogin(logins []string, login string) bool {
 	return false
 }
 
+// loginSet is a precompiled form of a login list, giving CheckAccessToServer
+// an O(1) membership test instead of the linear scan MatchLogin does.
+type loginSet map[string]bool
+
+func newLoginSet(logins []string) loginSet {
+	s := make(loginSet, len(logins))
+	for _, l := range logins {
+		s[l] = true
+	}
+	return s
+}
+
+func (s loginSet) contains(login string) bool {
+	return s[login]
+}
+
+// compiledLabelMatcher is a precompiled form of a label selector (as set by
+// SetNodeLabels/SetClusterLabels). A selector value of the form "^...$" is
+// treated as a regular expression and compiled once here, instead of being
+// parsed again on every MatchLabels call; every other value keeps using
+// the plain equality/wildcard rule MatchLabels has always had.
+type compiledLabelMatcher struct {
+	wildcard bool
+	exact    map[string]string
+	regexes  map[string]*regexp.Regexp
+}
+
+func compileLabelMatcher(selector map[string]string) compiledLabelMatcher {
+	var m compiledLabelMatcher
+	if selector[Wildcard] == Wildcard {
+		m.wildcard = true
+		return m
+	}
+	for key, val := range selector {
+		if re, ok := compileLabelRegexp(val); ok {
+			if m.regexes == nil {
+				m.regexes = make(map[string]*regexp.Regexp)
+			}
+			m.regexes[key] = re
+			continue
+		}
+		if m.exact == nil {
+			m.exact = make(map[string]string)
+		}
+		m.exact[key] = val
+	}
+	return m
+}
+
+// compileLabelRegexp compiles val as a regular expression if it's wrapped
+// in "^...$", the convention that marks a label selector value as a
+// pattern rather than a literal string to match exactly.
+func compileLabelRegexp(val string) (*regexp.Regexp, bool) {
+	if len(val) < 2 || val[0] != '^' || val[len(val)-1] != '$' {
+		return nil, false
+	}
+	re, err := regexp.Compile(val)
+	if err != nil {
+		return nil, false
+	}
+	return re, true
+}
+
+// matches reports whether target satisfies the selector m was compiled
+// from, mirroring MatchLabels' semantics.
+func (m compiledLabelMatcher) matches(target map[string]string) bool {
+	if m.wildcard {
+		return true
+	}
+	if len(m.exact) == 0 && len(m.regexes) == 0 {
+		return false
+	}
+	for key, val := range m.exact {
+		targetVal, ok := target[key]
+		if !ok || (val != targetVal && val != Wildcard) {
+			return false
+		}
+	}
+	for key, re := range m.regexes {
+		targetVal, ok := target[key]
+		if !ok || !re.MatchString(targetVal) {
+			return false
+		}
+	}
+	return true
+}
+
+// roleMatcher bundles a role's compiled login set and label matchers for
+// one RoleConditionType (allow or deny), see RoleV3.compileMatchers.
+type roleMatcher struct {
+	logins        loginSet
+	nodeLabels    compiledLabelMatcher
+	clusterLabels compiledLabelMatcher
+}
+
+func newRoleMatcher(role Role, rct RoleConditionType) *roleMatcher {
+	return &roleMatcher{
+		logins:        newLoginSet(role.GetLogins(rct)),
+		nodeLabels:    compileLabelMatcher(role.GetNodeLabels(rct)),
+		clusterLabels: compileLabelMatcher(role.GetClusterLabels(rct)),
+	}
+}
+
+// roleMatcherFor returns role's precompiled matcher for rct. Every role in
+// a RoleSet built by NewRoleSet already has one; this falls back to
+// compiling one on the spot for a role that somehow didn't go through it
+// (or isn't a *RoleV3 at all), so correctness never depends on which path
+// a role was constructed by, only the speed of the lookup does.
+func roleMatcherFor(role Role, rct RoleConditionType) *roleMatcher {
+	if r3, ok := role.(*RoleV3); ok {
+		if rct == Allow && r3.allowMatcher != nil {
+			return r3.allowMatcher
+		}
+		if rct == Deny && r3.denyMatcher != nil {
+			return r3.denyMatcher
+		}
+	}
+	return newRoleMatcher(role, rct)
+}
+
 // MatchNamespace returns true if given list of namespace matches
 // target namespace, wildcard matches everything
 func MatchNamespace(selector []string, namespace string) bool {
@@ -1081,22 +1513,15 @@ func MatchNamespace(selector []string, namespace string) bool {
 	return false
 }
 
-// MatchLabels matches selector against target
+// MatchLabels matches selector against target. A selector value of the
+// form "^...$" is matched as a regular expression instead of a literal
+// string.
 func MatchLabels(selector map[string]string, target map[string]string) bool {
 	// empty selector matches nothing
 	if len(selector) == 0 {
 		return false
 	}
-	// *: * matches everything even empty target set
-	if selector[Wildcard] == Wildcard {
-		return true
-	}
-	for key, val := range selector {
-		if targetVal, ok := target[key]; !ok || (val != targetVal && val != Wildcard) {
-			return false
-		}
-	}
-	return true
+	return compileLabelMatcher(selector).matches(target)
 }
 
 // AdjustSessionTTL will reduce the requested ttl to lowest max allowed TTL
@@ -1155,9 +1580,10 @@ func (set RoleSet) CheckAccessToServer(login string, s Server) error {
 	// check deny rules first: a single matching namespace, label, or login from
 	// the deny role set prohibits access.
 	for _, role := range set {
+		denyMatcher := roleMatcherFor(role, Deny)
 		matchNamespace := MatchNamespace(role.GetNamespaces(Deny), s.GetNamespace())
-		matchLabels := MatchLabels(role.GetNodeLabels(Deny), s.GetAllLabels())
-		matchLogin := MatchLogin(role.GetLogins(Deny), login)
+		matchLabels := denyMatcher.nodeLabels.matches(s.GetAllLabels())
+		matchLogin := denyMatcher.logins.contains(login)
 		if matchNamespace || matchLabels || matchLogin {
 			errorMessage := fmt.Sprintf("role %v denied access to node %v: deny rule matched; match(namespace=%v, label=%v, login=%v)",
 				role.GetName(), s.GetHostname(), matchNamespace, matchLabels, matchLogin)
@@ -1169,9 +1595,10 @@ func (set RoleSet) CheckAccessToServer(login string, s Server) error {
 	// check allow rules: namespace, label, and login have to all match in
 	// one role in the role set to be granted access.
 	for _, role := range set {
+		allowMatcher := roleMatcherFor(role, Allow)
 		matchNamespace := MatchNamespace(role.GetNamespaces(Allow), s.GetNamespace())
-		matchLabels := MatchLabels(role.GetNodeLabels(Allow), s.GetAllLabels())
-		matchLogin := MatchLogin(role.GetLogins(Allow), login)
+		matchLabels := allowMatcher.nodeLabels.matches(s.GetAllLabels())
+		matchLogin := allowMatcher.logins.contains(login)
 		if matchNamespace && matchLabels && matchLogin {
 			return nil
 		}
@@ -1186,6 +1613,28 @@ func (set RoleSet) CheckAccessToServer(login string, s Server) error {
 	return trace.AccessDenied(errorMessage)
 }
 
+// CheckAccessToCluster checks if a role set has access to a trusted cluster,
+// based on ClusterLabels. Deny rules are checked first, then allow rules,
+// mirroring CheckAccessToServer.
+func (set RoleSet) CheckAccessToCluster(cluster TrustedCluster) error {
+	labels := cluster.GetMetadata().Labels
+
+	for _, role := range set {
+		if roleMatcherFor(role, Deny).clusterLabels.matches(labels) {
+			return trace.AccessDenied("role %v denied access to trusted cluster %v: deny rule matched",
+				role.GetName(), cluster.GetName())
+		}
+	}
+
+	for _, role := range set {
+		if roleMatcherFor(role, Allow).clusterLabels.matches(labels) {
+			return nil
+		}
+	}
+
+	return trace.AccessDenied("access to trusted cluster %v is denied to %v", cluster.GetName(), set)
+}
+
 // CanForwardAgents returns true if role set allows forwarding agents.
 func (set RoleSet) CanForwardAgents() bool {
 	for _, role := range set {
@@ -1200,6 +1649,164 @@ func (set RoleSet) CanForwardAgents() bool {
 	return false
 }
 
+// RecordSession decides whether sessions started by this role set should
+// be recorded, per each role's RecordSession option. clusterDefault
+// applies if no role in the set opts in or out explicitly. A role with
+// RecordSession=false always wins over one with RecordSession=true.
+func (set RoleSet) RecordSession(clusterDefault bool) bool {
+	sawTrue := false
+	for _, role := range set {
+		record, err := role.GetOptions().GetBoolean(RecordSession)
+		if err != nil {
+			continue
+		}
+		if !record {
+			return false
+		}
+		sawTrue = true
+	}
+	if sawTrue {
+		return true
+	}
+	return clusterDefault
+}
+
+// NotifySessionRecording decides whether the user should be shown a
+// notice about the session recording decision RecordSession made, per
+// each role's RecordSessionNotify option. Defaults to true; a role with
+// RecordSessionNotify=false always wins over one with it set to true.
+func (set RoleSet) NotifySessionRecording() bool {
+	for _, role := range set {
+		notify, err := role.GetOptions().GetBoolean(RecordSessionNotify)
+		if err == nil && !notify {
+			return false
+		}
+	}
+	return true
+}
+
+// ShouldKillProcessGroup returns true if any role in the set wants a
+// session's entire process group terminated when the session ends (per the
+// role's KillProcessGroup option), rather than just the directly-spawned
+// shell or command left to exit on its own.
+func (set RoleSet) ShouldKillProcessGroup() bool {
+	for _, role := range set {
+		kill, err := role.GetOptions().GetBoolean(KillProcessGroup)
+		if err == nil && kill {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireSessionReason returns true if any role in the set requires a
+// reason to be supplied before a session can be started, per the role's
+// RequestReason option.
+func (set RoleSet) RequireSessionReason() bool {
+	for _, role := range set {
+		reason, err := role.GetOptions().GetString(RequestReason)
+		if err == nil && reason == RequestReasonRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// SudoersLines returns every Allow.Sudoers line granted by any role in the
+// set, with the "{{login}}" placeholder in each line substituted for
+// login. Lines are deduplicated (after substitution) so a user with
+// several roles granting the same rule doesn't get it written out
+// repeatedly.
+func (set RoleSet) SudoersLines(login string) []string {
+	var lines []string
+	seen := make(map[string]bool)
+	for _, role := range set {
+		for _, line := range role.GetSudoers(Allow) {
+			rendered := strings.Replace(line, "{{login}}", login, -1)
+			if seen[rendered] {
+				continue
+			}
+			seen[rendered] = true
+			lines = append(lines, rendered)
+		}
+	}
+	return lines
+}
+
+// SELinuxLabel returns the SELinux security context that should confine
+// this session, or "" if no role in the set grants one. If roles disagree,
+// the first non-empty Allow.SELinuxLabel found wins; granting conflicting
+// labels across a user's roles is a misconfiguration, so callers should
+// treat mixing them as undefined rather than relying on this order.
+func (set RoleSet) SELinuxLabel() string {
+	for _, role := range set {
+		if label := role.GetSELinuxLabel(Allow); label != "" {
+			return label
+		}
+	}
+	return ""
+}
+
+// AppArmorProfile returns the AppArmor profile that should confine this
+// session, or "" if no role in the set grants one. Conflicting
+// Allow.AppArmorProfile values across roles resolve the same way
+// SELinuxLabel's do: first non-empty wins, and granting more than one is a
+// misconfiguration to avoid rather than rely on.
+func (set RoleSet) AppArmorProfile() string {
+	for _, role := range set {
+		if profile := role.GetAppArmorProfile(Allow); profile != "" {
+			return profile
+		}
+	}
+	return ""
+}
+
+// CgroupLimits returns the resource limits that should confine this
+// session's cgroup. Unlike SELinuxLabel/AppArmorProfile, where granting
+// more than one value is a misconfiguration, roles are expected to be
+// layered for resource limits: for each resource, the most restrictive
+// (smallest) non-zero limit granted by any role wins, and a resource with
+// no limit granted by any role in the set stays unlimited.
+func (set RoleSet) CgroupLimits() CgroupLimits {
+	var out CgroupLimits
+	for _, role := range set {
+		limits := role.GetCgroupLimits(Allow)
+		out.CPUPercent = minNonZero(out.CPUPercent, limits.CPUPercent)
+		out.MemoryBytes = minNonZero64(out.MemoryBytes, limits.MemoryBytes)
+		out.MaxPIDs = minNonZero64(out.MaxPIDs, limits.MaxPIDs)
+	}
+	return out
+}
+
+// minNonZero returns the smaller of a and b, treating 0 as "unset" rather
+// than as the smallest possible value.
+func minNonZero(a, b int) int {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	case a < b:
+		return a
+	default:
+		return b
+	}
+}
+
+// minNonZero64 is minNonZero for int64.
+func minNonZero64(a, b int64) int64 {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	case a < b:
+		return a
+	default:
+		return b
+	}
+}
+
 // CheckAgentForward checks if the role can request to forward the SSH agent
 // for this user.
 func (set RoleSet) CheckAgentForward(login string) error {
@@ -1253,6 +1860,31 @@ func (set RoleSet) CheckAccessToRule(namespace string, resource string, verb str
 	return trace.AccessDenied("%v access to %v in namespace %v is denied for %v: no allow rule matched", verb, resource, namespace, set)
 }
 
+// CanViewSession returns true if username may view/replay sess, given the
+// roles in this set that grant read access to the session resource within
+// sess's namespace. A role that doesn't restrict SessionAccessScope grants
+// access to any such session regardless of who was in it; a role with
+// SessionAccessScope set to SessionAccessScopeOwn only extends its grant to
+// sessions username actually participated in. The widest-granting
+// matching role wins, consistent with CheckAccessToRule's allow semantics.
+func (set RoleSet) CanViewSession(sess session.Session, username string) bool {
+	for _, role := range set {
+		matchNamespace := MatchNamespace(role.GetNamespaces(Allow), ProcessNamespace(sess.Namespace))
+		matchRule := MatchRule(role.GetRules(Allow), KindSession, VerbRead)
+		if !matchNamespace || !matchRule {
+			continue
+		}
+		scope, err := role.GetOptions().GetString(SessionAccessScope)
+		if err != nil || scope != SessionAccessScopeOwn {
+			return true
+		}
+		if sess.HasParty(username) {
+			return true
+		}
+	}
+	return false
+}
+
 // ProcessNamespace sets default namespace in case if namespace is empty
 func ProcessNamespace(namespace string) string {
 	if namespace == "" {
@@ -55,6 +55,12 @@ type SAMLConnector interface {
 	GetAttributesToRoles() []AttributeMapping
 	// SetAttributesToRoles sets attributes to roles mapping
 	SetAttributesToRoles(mapping []AttributeMapping)
+	// GetTraitMappings returns the rules used to rename attributes into the
+	// trait names expected by role templates
+	GetTraitMappings() []TraitMapping
+	// SetTraitMappings sets the rules used to rename attributes into the
+	// trait names expected by role templates
+	SetTraitMappings(mappings []TraitMapping)
 	// GetAttributes returns list of attributes expected by mappings
 	GetAttributes() []string
 	// MapAttributes maps attributes to roles
@@ -398,6 +404,18 @@ func (o *SAMLConnectorV2) SetAttributesToRoles(mapping []AttributeMapping) {
 	o.Spec.AttributesToRoles = mapping
 }
 
+// GetTraitMappings returns the rules used to rename attributes into the
+// trait names expected by role templates
+func (o *SAMLConnectorV2) GetTraitMappings() []TraitMapping {
+	return o.Spec.TraitMappings
+}
+
+// SetTraitMappings sets the rules used to rename attributes into the
+// trait names expected by role templates
+func (o *SAMLConnectorV2) SetTraitMappings(mappings []TraitMapping) {
+	o.Spec.TraitMappings = mappings
+}
+
 // SetProvider sets the identity provider.
 func (o *SAMLConnectorV2) SetProvider(identityProvider string) {
 	o.Spec.Provider = identityProvider
@@ -722,6 +740,10 @@ type SAMLConnectorSpecV2 struct {
 	EntityDescriptorURL string `json:"entity_descriptor_url"`
 	// AttriburesToRoles is a list of mappings of attribute statements to roles
 	AttributesToRoles []AttributeMapping `json:"attributes_to_roles"`
+	// TraitMappings is a list of rules that rename attribute statements into
+	// the trait names expected by role templates, applied in order after
+	// attributes are converted to traits
+	TraitMappings []TraitMapping `json:"trait_mappings,omitempty"`
 	// SigningKeyPair is x509 key pair used to sign AuthnRequest
 	SigningKeyPair *SigningKeyPair `json:"signing_key_pair,omitempty"`
 	// Provider is the external identity provider.
@@ -748,9 +770,13 @@ var SAMLConnectorSpecV2Schema = fmt.Sprintf(`{
       "type": "array",
       "items": %v
     },
+    "trait_mappings": {
+      "type": "array",
+      "items": %v
+    },
     "signing_key_pair": %v
   }
-}`, AttributeMappingSchema, SigningKeyPairSchema)
+}`, AttributeMappingSchema, TraitMappingSchema, SigningKeyPairSchema)
 
 // GetAttributeNames returns a list of claim names from the claim values
 func GetAttributeNames(attributes map[string]types.Attribute) []string {
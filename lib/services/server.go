@@ -13,6 +13,24 @@ import (
 	"github.com/jonboulle/clockwork"
 )
 
+const (
+	// ServerStatePending marks a node that has self-registered (e.g. by
+	// using a generic, non-administrator-issued provisioning token) but that
+	// an administrator has not yet reviewed. A pending node is visible in
+	// node listings but cannot receive sessions.
+	ServerStatePending = "pending"
+	// ServerStateApproved marks a node an administrator has cleared to
+	// receive sessions. The empty state is treated the same as approved,
+	// for backwards compatibility with nodes registered before this field
+	// existed.
+	ServerStateApproved = "approved"
+	// ServerStateQuarantined marks a node an administrator has flagged as
+	// unauthorized or compromised. Like pending, a quarantined node cannot
+	// receive sessions, but the two are tracked separately so the reason a
+	// node is blocked stays visible in `tctl nodes ls`.
+	ServerStateQuarantined = "quarantined"
+)
+
 // Server represents a Node, Proxy or Auth server in a Teleport cluster
 type Server interface {
 	// Resource provides common resource headers
@@ -31,12 +49,36 @@ type Server interface {
 	GetCmdLabels() map[string]CommandLabel
 	// GetPublicAddr is an optional field that returns the public address this cluster can be reached at.
 	GetPublicAddr() string
+	// GetTunnelAddr is an optional field, set by proxies, that returns the
+	// address other proxies in the cluster can reach this proxy's reverse
+	// tunnel server at, for peer-to-peer session dialing.
+	GetTunnelAddr() string
 	// String returns string representation of the server
 	String() string
 	// SetAddr sets server address
 	SetAddr(addr string)
 	// SetPublicAddr sets the public address this cluster can be reached at.
 	SetPublicAddr(string)
+	// SetTunnelAddr sets the address other proxies can reach this proxy's
+	// reverse tunnel server at.
+	SetTunnelAddr(string)
+	// GetState returns the node's lifecycle state, one of ServerStatePending,
+	// ServerStateApproved or ServerStateQuarantined.
+	GetState() string
+	// SetState sets the node's lifecycle state.
+	SetState(state string)
+	// GetTeleportVersion returns the teleport version this server last
+	// announced itself with, e.g. "2.2.3". Empty for servers that
+	// registered before this was tracked.
+	GetTeleportVersion() string
+	// GetOS returns the operating system this server last reported running
+	// on, e.g. "linux". Empty for servers that registered before this was
+	// tracked.
+	GetOS() string
+	// GetStartTime returns the time this server's process started, so
+	// callers can derive its uptime. Zero for servers that registered
+	// before this was tracked.
+	GetStartTime() time.Time
 	// SetNamespace sets server namespace
 	SetNamespace(namespace string)
 	// V1 returns V1 version for backwards compatibility
@@ -133,6 +175,12 @@ func (s *ServerV2) SetPublicAddr(addr string) {
 	s.Spec.PublicAddr = addr
 }
 
+// SetTunnelAddr sets the address other proxies can reach this proxy's
+// reverse tunnel server at.
+func (s *ServerV2) SetTunnelAddr(addr string) {
+	s.Spec.TunnelAddr = addr
+}
+
 // GetName returns server name
 func (s *ServerV2) GetName() string {
 	return s.Metadata.Name
@@ -153,11 +201,45 @@ func (s *ServerV2) GetPublicAddr() string {
 	return s.Spec.PublicAddr
 }
 
+// GetTunnelAddr is an optional field, set by proxies, that returns the
+// address other proxies in the cluster can reach this proxy's reverse
+// tunnel server at, for peer-to-peer session dialing.
+func (s *ServerV2) GetTunnelAddr() string {
+	return s.Spec.TunnelAddr
+}
+
 // GetHostname returns server hostname
 func (s *ServerV2) GetHostname() string {
 	return s.Spec.Hostname
 }
 
+// GetTeleportVersion returns the teleport version this server last
+// announced itself with.
+func (s *ServerV2) GetTeleportVersion() string {
+	return s.Spec.TeleportVersion
+}
+
+// GetOS returns the operating system this server last reported running on.
+func (s *ServerV2) GetOS() string {
+	return s.Spec.OS
+}
+
+// GetStartTime returns the time this server's process started.
+func (s *ServerV2) GetStartTime() time.Time {
+	return s.Spec.StartTime
+}
+
+// GetState returns the node's lifecycle state, one of ServerStatePending,
+// ServerStateApproved or ServerStateQuarantined.
+func (s *ServerV2) GetState() string {
+	return s.Spec.State
+}
+
+// SetState sets the node's lifecycle state.
+func (s *ServerV2) SetState(state string) {
+	s.Spec.State = state
+}
+
 // GetLabels returns server's static label key pairs
 func (s *ServerV2) GetLabels() map[string]string {
 	return s.Metadata.Labels
@@ -233,10 +315,27 @@ type ServerSpecV2 struct {
 	Addr string `json:"addr"`
 	// PublicAddr is the public address this cluster can be reached at.
 	PublicAddr string `json:"public_addr,omitempty"`
+	// TunnelAddr is set by proxies to the address other proxies in the
+	// cluster can reach their reverse tunnel server at, so a session that
+	// lands on a proxy without a live tunnel for the target site can be
+	// forwarded to a sibling proxy that has one.
+	TunnelAddr string `json:"tunnel_addr,omitempty"`
 	// Hostname is server hostname
 	Hostname string `json:"hostname"`
 	// CmdLabels is server dynamic labels
 	CmdLabels map[string]CommandLabelV2 `json:"cmd_labels,omitempty"`
+	// State is the node's lifecycle state: one of ServerStatePending,
+	// ServerStateApproved or ServerStateQuarantined. Empty is treated the
+	// same as ServerStateApproved.
+	State string `json:"state,omitempty"`
+	// TeleportVersion is the teleport version this server last announced
+	// itself with, e.g. "2.2.3".
+	TeleportVersion string `json:"teleport_version,omitempty"`
+	// OS is the operating system this server last reported running on,
+	// e.g. "linux".
+	OS string `json:"os,omitempty"`
+	// StartTime is the time this server's process started.
+	StartTime time.Time `json:"start_time,omitempty"`
 }
 
 // ServerSpecV2Schema is JSON schema for server
@@ -246,7 +345,12 @@ const ServerSpecV2Schema = `{
   "properties": {
     "addr": {"type": "string"},
     "public_addr": {"type": "string"},
+    "tunnel_addr": {"type": "string"},
     "hostname": {"type": "string"},
+    "state": {"type": "string"},
+    "teleport_version": {"type": "string"},
+    "os": {"type": "string"},
+    "start_time": {"type": "string"},
     "labels": {
       "type": "object",
       "patternProperties": {
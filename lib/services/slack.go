@@ -0,0 +1,249 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// SlackPlugin configures the Slack access request plugin: it posts a
+// notification to a channel whenever a user creates an AccessRequest, and
+// threads the resolution under it once someone approves or denies the
+// request (via tctl or the API). Like LDAPConnector and OktaConfig,
+// there's only ever one of these per cluster.
+type SlackPlugin interface {
+	// Resource provides common resource properties.
+	Resource
+
+	// GetEnabled returns whether the plugin is active.
+	GetEnabled() bool
+	// SetEnabled sets whether the plugin is active.
+	SetEnabled(bool)
+
+	// GetBotToken returns the Slack bot token ("xoxb-...") used to post
+	// and update messages.
+	GetBotToken() string
+	// SetBotToken sets the Slack bot token.
+	SetBotToken(string)
+	// GetSigningSecret returns the Slack app's signing secret, used to
+	// verify that interaction callbacks really came from Slack.
+	GetSigningSecret() string
+	// SetSigningSecret sets the Slack app's signing secret.
+	SetSigningSecret(string)
+	// GetChannel returns the channel ID approval messages are posted to.
+	GetChannel() string
+	// SetChannel sets the channel ID approval messages are posted to.
+	SetChannel(string)
+
+	// CheckAndSetDefaults checks and sets default values for missing
+	// fields.
+	CheckAndSetDefaults() error
+}
+
+// NewSlackPlugin returns a new SlackPlugin from spec.
+func NewSlackPlugin(spec SlackPluginSpecV2) (SlackPlugin, error) {
+	c := SlackPluginV2{
+		Kind:    KindSlackPlugin,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      MetaNameSlackPlugin,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := c.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &c, nil
+}
+
+// SlackPluginV2 implements SlackPlugin.
+type SlackPluginV2 struct {
+	// Kind is a resource kind - always resource.
+	Kind string `json:"kind"`
+	// Version is a resource version.
+	Version string `json:"version"`
+	// Metadata is metadata about the resource.
+	Metadata Metadata `json:"metadata"`
+	// Spec is the specification of the resource.
+	Spec SlackPluginSpecV2 `json:"spec"`
+}
+
+// SlackPluginSpecV2 is the actual data we care about for SlackPlugin.
+type SlackPluginSpecV2 struct {
+	Enabled       bool   `json:"enabled"`
+	BotToken      string `json:"bot_token"`
+	SigningSecret string `json:"signing_secret"`
+	Channel       string `json:"channel"`
+}
+
+// GetName returns the name of the SlackPlugin resource.
+func (c *SlackPluginV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the SlackPlugin resource.
+func (c *SlackPluginV2) SetName(e string) {
+	c.Metadata.Name = e
+}
+
+// Expiry returns object expiry setting.
+func (c *SlackPluginV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets expiry time for the object.
+func (c *SlackPluginV2) SetExpiry(expires time.Time) {
+	c.Metadata.SetExpiry(expires)
+}
+
+// SetTTL sets Expires header using realtime clock.
+func (c *SlackPluginV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata.
+func (c *SlackPluginV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetEnabled returns whether the plugin is active.
+func (c *SlackPluginV2) GetEnabled() bool { return c.Spec.Enabled }
+
+// SetEnabled sets whether the plugin is active.
+func (c *SlackPluginV2) SetEnabled(e bool) { c.Spec.Enabled = e }
+
+// GetBotToken returns the Slack bot token.
+func (c *SlackPluginV2) GetBotToken() string { return c.Spec.BotToken }
+
+// SetBotToken sets the Slack bot token.
+func (c *SlackPluginV2) SetBotToken(t string) { c.Spec.BotToken = t }
+
+// GetSigningSecret returns the Slack app's signing secret.
+func (c *SlackPluginV2) GetSigningSecret() string { return c.Spec.SigningSecret }
+
+// SetSigningSecret sets the Slack app's signing secret.
+func (c *SlackPluginV2) SetSigningSecret(s string) { c.Spec.SigningSecret = s }
+
+// GetChannel returns the channel ID approval messages are posted to.
+func (c *SlackPluginV2) GetChannel() string { return c.Spec.Channel }
+
+// SetChannel sets the channel ID approval messages are posted to.
+func (c *SlackPluginV2) SetChannel(ch string) { c.Spec.Channel = ch }
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults.
+func (c *SlackPluginV2) CheckAndSetDefaults() error {
+	if err := c.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Spec.BotToken == "" {
+		return trace.BadParameter("SlackPlugin: missing bot_token")
+	}
+	if c.Spec.SigningSecret == "" {
+		return trace.BadParameter("SlackPlugin: missing signing_secret")
+	}
+	if c.Spec.Channel == "" {
+		return trace.BadParameter("SlackPlugin: missing channel")
+	}
+	return nil
+}
+
+// String represents a human readable version of the Slack plugin config.
+func (c *SlackPluginV2) String() string {
+	return fmt.Sprintf("SlackPlugin(channel=%v, enabled=%v)", c.Spec.Channel, c.Spec.Enabled)
+}
+
+// SlackPluginSpecSchemaTemplate is a template for SlackPlugin schema.
+const SlackPluginSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["bot_token", "signing_secret", "channel"],
+  "properties": {
+    "enabled": {"type": "boolean"},
+    "bot_token": {"type": "string"},
+    "signing_secret": {"type": "string"},
+    "channel": {"type": "string"}%v
+  }
+}`
+
+// GetSlackPluginSchema returns the schema with optionally injected schema
+// for extensions.
+func GetSlackPluginSchema(extensionSchema string) string {
+	var schema string
+	if extensionSchema == "" {
+		schema = fmt.Sprintf(SlackPluginSpecSchemaTemplate, "")
+	} else {
+		schema = fmt.Sprintf(SlackPluginSpecSchemaTemplate, ","+extensionSchema)
+	}
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, schema, DefaultDefinitions)
+}
+
+// SlackPluginMarshaler implements marshal/unmarshal of SlackPlugin
+// implementations, mostly adds support for extended versions.
+type SlackPluginMarshaler interface {
+	Marshal(c SlackPlugin, opts ...MarshalOption) ([]byte, error)
+	Unmarshal(bytes []byte) (SlackPlugin, error)
+}
+
+var slackPluginMarshaler SlackPluginMarshaler = &TeleportSlackPluginMarshaler{}
+
+// SetSlackPluginMarshaler sets the marshaler.
+func SetSlackPluginMarshaler(m SlackPluginMarshaler) {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	slackPluginMarshaler = m
+}
+
+// GetSlackPluginMarshaler gets the marshaler.
+func GetSlackPluginMarshaler() SlackPluginMarshaler {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	return slackPluginMarshaler
+}
+
+// TeleportSlackPluginMarshaler is used to marshal and unmarshal
+// SlackPlugin.
+type TeleportSlackPluginMarshaler struct{}
+
+// Unmarshal unmarshals SlackPlugin from JSON.
+func (t *TeleportSlackPluginMarshaler) Unmarshal(bytes []byte) (SlackPlugin, error) {
+	var c SlackPluginV2
+
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	err := utils.UnmarshalWithSchema(GetSlackPluginSchema(""), &c, bytes)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+	utils.UTC(&c.Metadata.Expires)
+	return &c, nil
+}
+
+// Marshal marshals SlackPlugin to JSON.
+func (t *TeleportSlackPluginMarshaler) Marshal(c SlackPlugin, opts ...MarshalOption) ([]byte, error) {
+	return json.Marshal(c)
+}
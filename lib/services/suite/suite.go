@@ -355,7 +355,10 @@ func (s *ServicesTestSuite) TokenCRUD(c *C) {
 	_, err := s.ProvisioningS.GetToken("token")
 	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%#v", err))
 
-	c.Assert(s.ProvisioningS.UpsertToken("token", teleport.Roles{teleport.RoleAuth, teleport.RoleNode}, 0), IsNil)
+	c.Assert(s.ProvisioningS.UpsertToken(services.ProvisionToken{
+		Token: "token",
+		Roles: teleport.Roles{teleport.RoleAuth, teleport.RoleNode},
+	}), IsNil)
 
 	token, err := s.ProvisioningS.GetToken("token")
 	c.Assert(err, IsNil)
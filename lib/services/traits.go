@@ -0,0 +1,74 @@
+/*
+Copyright 2015 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+// TraitMapping renames an external trait (e.g. an OIDC claim or SAML
+// attribute) into the trait name expected by role templates such as
+// {{external.logins}}.
+type TraitMapping struct {
+	// Trait is the name of the incoming trait to read.
+	Trait string `json:"trait"`
+	// Value, if set, restricts this mapping to only the values of Trait
+	// that equal it; an empty Value copies every value of Trait.
+	Value string `json:"value,omitempty"`
+	// To is the name of the resulting trait. Defaults to Trait when empty,
+	// in which case the mapping is a pure filter rather than a rename.
+	To string `json:"to,omitempty"`
+}
+
+// TraitMappingSchema is the JSON schema for TraitMapping
+var TraitMappingSchema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["trait"],
+  "properties": {
+    "trait": {"type": "string"},
+    "value": {"type": "string"},
+    "to": {"type": "string"}
+  }
+}`
+
+// ApplyTraitMappings runs traits through the trait transformation pipeline
+// described by mappings, returning a new map that additionally contains
+// the resulting renamed traits. The input map is never modified, and is
+// returned unchanged when no mappings are configured.
+func ApplyTraitMappings(traits map[string][]string, mappings []TraitMapping) map[string][]string {
+	if len(mappings) == 0 {
+		return traits
+	}
+	out := make(map[string][]string, len(traits))
+	for k, v := range traits {
+		out[k] = v
+	}
+	for _, mapping := range mappings {
+		values, ok := traits[mapping.Trait]
+		if !ok {
+			continue
+		}
+		to := mapping.To
+		if to == "" {
+			to = mapping.Trait
+		}
+		for _, value := range values {
+			if mapping.Value != "" && value != mapping.Value {
+				continue
+			}
+			out[to] = append(out[to], value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/gravitational/trace"
+)
+
+// TrustedHostKey is a raw OpenSSH host public key an operator has vouched
+// for out of band (typically by pulling it off the box itself), so tsh
+// trusts it without prompting even though it wasn't issued by the
+// cluster's host CA. It exists to let a fleet be converted to Teleport
+// gradually: hosts still running plain sshd stay reachable and trusted
+// while their Teleport nodes are rolled out one at a time.
+//
+// Unlike the resources in resource.go, it isn't a versioned, schema-
+// validated Resource: it's a small, flat piece of state managed entirely
+// by the auth server, the same way AccessRequest is.
+type TrustedHostKey struct {
+	// Hostname is the host this key is trusted for, exactly as a client
+	// names it when connecting (it need not be a registered Teleport node
+	// name -- that's the point).
+	Hostname string `json:"hostname"`
+	// AuthorizedKeys holds one or more of the host's own public keys, in
+	// OpenSSH authorized_keys wire format, imported unchanged from e.g.
+	// /etc/ssh/ssh_host_rsa_key.pub.
+	AuthorizedKeys [][]byte `json:"authorized_keys"`
+}
+
+// Check returns nil if k is valid, err otherwise.
+func (k *TrustedHostKey) Check() error {
+	if k.Hostname == "" {
+		return trace.BadParameter("TrustedHostKey: missing hostname")
+	}
+	if len(k.AuthorizedKeys) == 0 {
+		return trace.BadParameter("TrustedHostKey: missing authorized_keys")
+	}
+	return nil
+}
+
+// TrustedHostKeys manages the lifecycle of TrustedHostKey objects.
+type TrustedHostKeys interface {
+	// UpsertTrustedHostKey creates or replaces the TrustedHostKey for
+	// key.Hostname.
+	UpsertTrustedHostKey(key TrustedHostKey) error
+	// GetTrustedHostKey returns the TrustedHostKey for hostname.
+	GetTrustedHostKey(hostname string) (*TrustedHostKey, error)
+	// GetTrustedHostKeys returns every registered TrustedHostKey.
+	GetTrustedHostKeys() ([]TrustedHostKey, error)
+	// DeleteTrustedHostKey removes the TrustedHostKey for hostname.
+	DeleteTrustedHostKey(hostname string) error
+}
@@ -50,6 +50,17 @@ type User interface {
 	GetTraits() map[string][]string
 	// GetTraits sets the trait map for this user used to populate role variables.
 	SetTraits(map[string][]string)
+	// GetLocalAuthOnly returns true if this user is pinned to local
+	// username/password/2FA login regardless of the cluster's configured
+	// auth Type, e.g. a break-glass admin who must be able to sign in even
+	// if the configured identity provider is unreachable.
+	GetLocalAuthOnly() bool
+	// SetLocalAuthOnly sets whether this user is pinned to local login.
+	SetLocalAuthOnly(bool)
+	// SetAccountExpiry sets when this account stops being usable, without
+	// putting the user record itself on a backend TTL. Use this instead of
+	// the generic Resource.SetExpiry for account expiry.
+	SetAccountExpiry(time.Time)
 }
 
 // NewUser creates new empty user
@@ -191,11 +202,24 @@ func (u *UserV2) GetMetadata() Metadata {
 	return u.Metadata
 }
 
-// SetExpiry sets expiry time for the object
+// SetExpiry sets expiry time for the object. This is the generic resource
+// TTL: once it elapses, the backend deletes the user outright. It must not
+// be used for account expiry -- see SetAccountExpiry.
 func (u *UserV2) SetExpiry(expires time.Time) {
 	u.Metadata.SetExpiry(expires)
 }
 
+// SetAccountExpiry sets the time after which the account stops being usable
+// (new logins are denied and existing sessions are disconnected), without
+// making the backend delete the user record itself. This is deliberately
+// kept separate from SetExpiry/Metadata.Expires, which drives backend TTL:
+// reusing that field here would make the user record vanish from the
+// backend the moment it "expires", so GetUser would 404 before
+// withUserLock or disconnectExpiredParties ever got to look at it.
+func (u *UserV2) SetAccountExpiry(expires time.Time) {
+	u.Spec.Expires = expires
+}
+
 // SetTTL sets Expires header using realtime clock
 func (u *UserV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
 	u.Metadata.SetTTL(clock, ttl)
@@ -228,6 +252,17 @@ func (u *UserV2) SetTraits(traits map[string][]string) {
 	u.Spec.Traits = traits
 }
 
+// GetLocalAuthOnly returns true if this user is pinned to local login
+// regardless of the cluster's configured auth Type.
+func (u *UserV2) GetLocalAuthOnly() bool {
+	return u.Spec.LocalAuthOnly
+}
+
+// SetLocalAuthOnly sets whether this user is pinned to local login.
+func (u *UserV2) SetLocalAuthOnly(localAuthOnly bool) {
+	u.Spec.LocalAuthOnly = localAuthOnly
+}
+
 // UserSpecV2 is a specification for V2 user
 type UserSpecV2 struct {
 	// OIDCIdentities lists associated OpenID Connect identities
@@ -249,11 +284,20 @@ type UserSpecV2 struct {
 	// Status is a login status of the user
 	Status LoginStatus `json:"status"`
 
-	// Expires if set sets TTL on the user
+	// Expires, if set, is when the account stops being usable: new logins
+	// are denied and existing sessions are disconnected. Unlike
+	// Metadata.Expires, setting this does not put the user record itself on
+	// a backend TTL.
 	Expires time.Time `json:"expires"`
 
 	// CreatedBy holds information about agent or person created this usre
 	CreatedBy CreatedBy `json:"created_by"`
+
+	// LocalAuthOnly, if true, pins this user to local username/password/2FA
+	// login regardless of the cluster's configured auth Type. It is intended
+	// for break-glass admin accounts that must remain reachable if the
+	// configured OIDC or SAML identity provider is down.
+	LocalAuthOnly bool `json:"local_auth_only,omitempty"`
 }
 
 // V1 converts UserV2 to UserV1 format
@@ -299,7 +343,8 @@ const UserSpecV2SchemaTemplate = `{
       "items": %v
     },
     "status": %v,
-    "created_by": %v%v
+    "created_by": %v,
+    "local_auth_only": {"type": "boolean"}%v
   }
 }`
 
@@ -344,7 +389,9 @@ func (u *UserV2) Equals(other User) bool {
 	return true
 }
 
-// Expiry returns expiry time for temporary users
+// Expiry returns the account's expiry time: Metadata.Expires for temporary
+// users whose record itself is on a backend TTL, or Spec.Expires (set via
+// SetAccountExpiry) for accounts that expire without being deleted.
 func (u *UserV2) Expiry() time.Time {
 	if !u.Metadata.Expires.IsZero() {
 		return u.Metadata.Expires
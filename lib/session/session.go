@@ -131,6 +131,17 @@ func (s *Session) RemoveParty(pid ID) bool {
 	return false
 }
 
+// HasParty returns true if username was (or still is) a party to this
+// session.
+func (s *Session) HasParty(username string) bool {
+	for i := range s.Parties {
+		if s.Parties[i].User == username {
+			return true
+		}
+	}
+	return false
+}
+
 // Party is a participant a user or a script executing some action
 // in the context of the session
 type Party struct {
@@ -144,6 +155,10 @@ type Party struct {
 	ServerID string `json:"server_id"`
 	// LastActive is a last time this party was active
 	LastActive time.Time `json:"last_active"`
+	// CanWrite is false if the session owner has muted this party: its
+	// input is discarded on the node rather than forwarded to the
+	// session, though it still receives output as an observer.
+	CanWrite bool `json:"can_write"`
 }
 
 // String returns debug friendly representation
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// capSetuid and capSetgid are the Linux capability bit numbers for
+	// CAP_SETUID and CAP_SETGID, see capabilities(7). Holding both, as
+	// either root or via ambient/file capabilities, is what lets this
+	// process switch to another OS user's UID/GID to honor a "login as"
+	// request.
+	capSetuid = 7
+	capSetgid = 6
+)
+
+// canSwitchUsers reports whether this process holds CAP_SETUID and
+// CAP_SETGID in its effective capability set, which is what
+// syscall.Credential needs to succeed. Root always holds both; a
+// non-root process can too if it was launched with
+// "AmbientCapabilities=CAP_SETUID CAP_SETGID" in its systemd unit, or
+// the binary was granted them with "setcap cap_setuid,cap_setgid+ep".
+//
+// It reads /proc/self/status rather than linking a capability library,
+// since this tree vendors none and adding one is out of scope here.
+func canSwitchUsers() bool {
+	if os.Geteuid() == 0 {
+		return true
+	}
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		// can't introspect capabilities on this platform/sandbox; assume
+		// the caller knows what it's doing and let the later setuid
+		// syscall itself fail loudly if it doesn't
+		return true
+	}
+	defer f.Close()
+
+	mask, ok := findCapEff(f)
+	if !ok {
+		return true
+	}
+	return mask&(1<<capSetuid) != 0 && mask&(1<<capSetgid) != 0
+}
+
+// findCapEff scans r, formatted like /proc/<pid>/status, for the "CapEff:"
+// line and returns its hex bitmask.
+func findCapEff(r io.Reader) (uint64, bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+		hex := strings.TrimSpace(strings.TrimPrefix(line, "CapEff:"))
+		mask, err := strconv.ParseUint(hex, 16, 64)
+		if err != nil {
+			return 0, false
+		}
+		return mask, true
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"strings"
+
+	"gopkg.in/check.v1"
+)
+
+type CapsSuite struct{}
+
+var _ = check.Suite(&CapsSuite{})
+
+func (s *CapsSuite) TestFindCapEff(c *check.C) {
+	status := "Name:\tteleport\n" +
+		"State:\tS (sleeping)\n" +
+		"CapEff:\t00000000800000c0\n" +
+		"CapBnd:\t0000003fffffffff\n"
+	mask, ok := findCapEff(strings.NewReader(status))
+	c.Assert(ok, check.Equals, true)
+	c.Assert(mask&(1<<capSetuid) != 0, check.Equals, true)
+	c.Assert(mask&(1<<capSetgid) != 0, check.Equals, true)
+
+	// a process with no setuid/setgid bits set
+	status = "Name:\tteleport\nCapEff:\t0000000000000000\n"
+	mask, ok = findCapEff(strings.NewReader(status))
+	c.Assert(ok, check.Equals, true)
+	c.Assert(mask&(1<<capSetuid) != 0, check.Equals, false)
+
+	// no CapEff line at all
+	_, ok = findCapEff(strings.NewReader("Name:\tteleport\n"))
+	c.Assert(ok, check.Equals, false)
+}
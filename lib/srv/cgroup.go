@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gravitational/teleport/lib/services"
+	rsession "github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// cgroupRoot is the cgroup v1 hierarchy mountpoint this node uses to cap
+// session resource usage. It matches the layout every mainstream distro
+// mounts at boot (one directory per controller under it).
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cfsPeriodUs is the CPU controller's cfs_period_us, the window cfs_quota_us
+// is measured against. 100ms is the kernel's own default.
+const cfsPeriodUs = 100000
+
+// cfsQuotaUs works out to the quota (in the same microsecond units as
+// cfsPeriodUs) a cgroup needs to be capped at cpuPercent of one CPU core.
+func cfsQuotaUs(cpuPercent int) int64 {
+	return int64(cfsPeriodUs) * int64(cpuPercent) / 100
+}
+
+// createCgroup places pid into a dedicated cgroup capped at the resource
+// limits roles grants, named after sid so it's easy to correlate with the
+// session in an incident. It returns the cgroup's path (also the value
+// recorded as events.SessionCgroupID) and "" if roles grants no limits, so
+// sessions that don't need confining don't get a cgroup at all.
+func createCgroup(pid int, roles services.RoleSet, sid rsession.ID) (string, error) {
+	limits := roles.CgroupLimits()
+	if limits.IsEmpty() {
+		return "", nil
+	}
+
+	name := fmt.Sprintf("teleport-%v", sid)
+	var written []string
+	for controller, files := range cgroupFiles(limits) {
+		dir := filepath.Join(cgroupRoot, controller, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			removeCgroupDirs(written)
+			return "", trace.ConvertSystemError(err)
+		}
+		written = append(written, dir)
+		for file, value := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+				removeCgroupDirs(written)
+				return "", trace.ConvertSystemError(err)
+			}
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+			removeCgroupDirs(written)
+			return "", trace.ConvertSystemError(err)
+		}
+	}
+	return name, nil
+}
+
+// cgroupControllers lists every controller createCgroup/removeCgroup know
+// how to manage, so removeCgroup can clean up without needing to know which
+// limits the session was actually given.
+var cgroupControllers = []string{"cpu", "memory", "pids"}
+
+// cgroupFiles maps each controller this session needs to the files (and
+// their contents) that enforce limits' caps under cgroup v1.
+func cgroupFiles(limits services.CgroupLimits) map[string]map[string]string {
+	controllers := make(map[string]map[string]string)
+	if limits.CPUPercent != 0 {
+		controllers["cpu"] = map[string]string{
+			"cpu.cfs_period_us": strconv.Itoa(cfsPeriodUs),
+			"cpu.cfs_quota_us":  strconv.FormatInt(cfsQuotaUs(limits.CPUPercent), 10),
+		}
+	}
+	if limits.MemoryBytes != 0 {
+		controllers["memory"] = map[string]string{
+			"memory.limit_in_bytes": strconv.FormatInt(limits.MemoryBytes, 10),
+		}
+	}
+	if limits.MaxPIDs != 0 {
+		controllers["pids"] = map[string]string{
+			"pids.max": strconv.FormatInt(limits.MaxPIDs, 10),
+		}
+	}
+	return controllers
+}
+
+// removeCgroup deletes the cgroup directories createCgroup made for name.
+// name being "" (no cgroup was created for this session) is a no-op.
+func removeCgroup(name string) {
+	if name == "" {
+		return
+	}
+	var dirs []string
+	for _, controller := range cgroupControllers {
+		dirs = append(dirs, filepath.Join(cgroupRoot, controller, name))
+	}
+	removeCgroupDirs(dirs)
+}
+
+// removeCgroupDirs is a best-effort cleanup helper for createCgroup's error
+// paths, removing whichever controller directories it had already created
+// before hitting an error.
+func removeCgroupDirs(dirs []string) {
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
+			log.Warningf("failed to remove cgroup %v: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package srv
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// maxCommandLineBufBytes bounds how much of an unterminated line
+// commandExtractor keeps around, so a session that never emits a newline
+// (a curses UI, a binary transfer) can't grow it without bound.
+const maxCommandLineBufBytes = 4096
+
+// ansiEscapeSeq strips ANSI CSI escape sequences (cursor movement, color)
+// that would otherwise sit between a colored prompt and the command text.
+var ansiEscapeSeq = regexp.MustCompile("\x1b\\[[0-9;]*[a-zA-Z]")
+
+// shellPromptLine is the heuristic that recognizes a shell prompt followed
+// by a typed command: a line ending in one of the classic prompt characters
+// ('$', '#', '%', '>') and exactly one space, followed by the command text.
+// It's necessarily approximate -- PS1 is user-configurable and this can
+// both miss unusual prompts and, more rarely, misfire on command output
+// that happens to look like one. It's meant to make "who ran rm -rf on
+// prod" searches practical, not to be a complete terminal emulator.
+var shellPromptLine = regexp.MustCompile(`[$#%>] (\S.*)$`)
+
+// commandExtractor heuristically pulls command lines typed into an
+// interactive session's terminal out of the raw stream of bytes echoed back
+// to the client, so they can be recorded as searchable audit events instead
+// of only being replayable from the raw session recording.
+type commandExtractor struct {
+	buf []byte
+}
+
+// feed appends data to the extractor's line buffer and returns any command
+// lines found in newly completed lines.
+func (c *commandExtractor) feed(data []byte) []string {
+	c.buf = append(c.buf, data...)
+
+	var commands []string
+	for {
+		idx := bytes.IndexAny(c.buf, "\r\n")
+		if idx < 0 {
+			break
+		}
+		if cmd, ok := extractCommand(c.buf[:idx]); ok {
+			commands = append(commands, cmd)
+		}
+		c.buf = c.buf[idx+1:]
+	}
+	if over := len(c.buf) - maxCommandLineBufBytes; over > 0 {
+		c.buf = c.buf[over:]
+	}
+	return commands
+}
+
+// extractCommand applies the shellPromptLine heuristic to a single line of
+// terminal output.
+func extractCommand(line []byte) (string, bool) {
+	clean := ansiEscapeSeq.ReplaceAll(line, nil)
+	m := shellPromptLine.FindSubmatch(clean)
+	if m == nil {
+		return "", false
+	}
+	cmd := strings.TrimSpace(string(m[1]))
+	if cmd == "" {
+		return "", false
+	}
+	return cmd, true
+}
@@ -187,7 +187,7 @@ func prepareCommand(ctx *ctx) (*exec.Cmd, error) {
 		if len(proxies) > 0 {
 			proxyHost = proxies[0].GetPublicAddr()
 			if proxyHost == "" {
-				proxyHost = fmt.Sprintf("%v:%v", proxies[0].GetHostname(), defaults.HTTPListenPort)
+				proxyHost = net.JoinHostPort(proxies[0].GetHostname(), strconv.Itoa(defaults.HTTPListenPort))
 				log.Debugf("public_address not set for proxy, returning proxyHost: %q", proxyHost)
 			}
 		}
@@ -241,6 +241,21 @@ func prepareCommand(ctx *ctx) (*exec.Cmd, error) {
 		if len(groups) == 0 {
 			groups = append(groups, uint32(gid))
 		}
+		// switching to another OS user's UID/GID needs CAP_SETUID and
+		// CAP_SETGID -- normally held by running as root, but a node can
+		// run unprivileged and still honor logins for its own OS user if
+		// it's granted those two capabilities instead (see canSwitchUsers
+		// for how, e.g. systemd's AmbientCapabilities= or file capabilities).
+		// Fail clearly here rather than letting the exec itself die with an
+		// opaque EPERM.
+		if !canSwitchUsers() {
+			return nil, trace.AccessDenied(
+				"this node is running as %q and cannot switch to login %q: "+
+					"grant it CAP_SETUID and CAP_SETGID (e.g. via systemd's "+
+					"AmbientCapabilities= or `setcap cap_setuid,cap_setgid+ep`), "+
+					"run it as root, or only request logins matching the OS user it runs as",
+				me.Username, osUserName)
+		}
 		c.SysProcAttr.Credential = &syscall.Credential{
 			Uid:    uint32(uid),
 			Gid:    uint32(gid),
@@ -286,6 +301,16 @@ func prepareCommand(ctx *ctx) (*exec.Cmd, error) {
 		}
 		c.Env = append(c.Env, userEnvs...)
 	}
+
+	// confine the session to whatever SELinux label or AppArmor profile
+	// ctx.teleportUser's roles grant, if any
+	roles, err := ctx.srv.fetchRoleSet(ctx.teleportUser, ctx.clusterName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := confineCommand(c, roles); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return c, nil
 }
 
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// processGroupMembers returns the PIDs of every process currently in
+// process group pgid, found by scanning /proc since Linux exposes no
+// syscall for it. Processes that exit mid-scan are silently skipped
+// rather than treated as an error.
+func processGroupMembers(pgid int) []int {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var members []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if statPgid(pid) == pgid {
+			members = append(members, pid)
+		}
+	}
+	return members
+}
+
+// statPgid returns pid's process group ID, or -1 if it can't be read
+// (e.g. the process has already exited). It parses /proc/<pid>/stat,
+// whose 5th field is pgrp -- skipping past the 2nd field (comm) by
+// finding its closing paren, since comm itself may contain spaces.
+func statPgid(pid int) int {
+	data, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return -1
+	}
+	afterComm := strings.LastIndex(string(data), ")")
+	if afterComm < 0 {
+		return -1
+	}
+	fields := strings.Fields(string(data)[afterComm+1:])
+	// fields[0]=state, fields[1]=ppid, fields[2]=pgrp
+	if len(fields) < 3 {
+		return -1
+	}
+	pgid, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return -1
+	}
+	return pgid
+}
+
+// reapSessionProcesses terminates every process left running in pgid --
+// the session's leader process group -- and returns the PIDs it killed.
+// It's called instead of killing just the session's directly-spawned
+// shell, so a background job the user started with e.g. "nohup ... &"
+// doesn't silently outlive the session as an unaccounted-for orphan.
+func reapSessionProcesses(pgid int) []int {
+	members := processGroupMembers(pgid)
+	if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		log.Warningf("failed to kill process group %v: %v", pgid, err)
+	}
+	return members
+}
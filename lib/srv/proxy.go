@@ -250,9 +250,18 @@ func (t *proxySubsys) proxyToHost(
 	ips, _ := net.LookupHost(t.host)
 	log.Debugf("proxy connecting to host=%v port=%v, exact port=%v\n", t.host, t.port, specifiedPort)
 
-	// enumerate and try to find a server with self-registered with a matching name/IP:
+	// enumerate and try to find a server self-registered with a matching
+	// UUID, or, failing that, an unambiguous name/IP match. A UUID match is
+	// checked first and always wins outright, since it's unambiguous by
+	// construction even if some other node's hostname happens to collide
+	// with it.
 	var server services.Server
+	var byHostname []services.Server
 	for i := range servers {
+		if t.host == servers[i].GetName() {
+			server = servers[i]
+			break
+		}
 		ip, port, err := net.SplitHostPort(servers[i].GetAddr())
 		if err != nil {
 			log.Error(err)
@@ -261,14 +270,37 @@ func (t *proxySubsys) proxyToHost(
 
 		if t.host == ip || t.host == servers[i].GetHostname() || utils.SliceContainsStr(ips, ip) {
 			if !specifiedPort || t.port == port {
-				server = servers[i]
-				break
+				byHostname = append(byHostname, servers[i])
 			}
 		}
 	}
+	if server == nil {
+		switch len(byHostname) {
+		case 0:
+		case 1:
+			server = byHostname[0]
+		default:
+			// several cloned/duplicate hosts share this name: don't guess,
+			// tell the caller exactly which nodes matched so they can
+			// specify one by UUID instead
+			names := make([]string, 0, len(byHostname))
+			for _, s := range byHostname {
+				names = append(names, fmt.Sprintf("%v (%v)", s.GetName(), s.LabelsString()))
+			}
+			return trace.BadParameter(
+				"hostname %q is ambiguous, %d nodes are registered with it: %v; specify one by UUID instead",
+				t.host, len(byHostname), strings.Join(names, ", "))
+		}
+	}
 
 	var serverAddr string
 	if server != nil {
+		switch server.GetState() {
+		case services.ServerStatePending:
+			return trace.AccessDenied("node %q is pending administrator approval and cannot receive sessions yet", server.GetHostname())
+		case services.ServerStateQuarantined:
+			return trace.AccessDenied("node %q is quarantined and cannot receive sessions", server.GetHostname())
+		}
 		serverAddr = server.GetAddr()
 	} else {
 		if !specifiedPort {
@@ -19,7 +19,9 @@ package srv
 import (
 	"fmt"
 	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gravitational/teleport"
@@ -45,6 +47,17 @@ const (
 	// we should tolerate before giving up trying to sync the
 	// term size
 	maxTermSyncErrorCount = 5
+
+	// latencyProbeReqType is an SSH channel request name with no registered
+	// handler on either end. A compliant client automatically answers it
+	// with a failure reply as soon as it's received, so sending one and
+	// timing the reply gives a round-trip latency sample without requiring
+	// any client-side support.
+	latencyProbeReqType = "teleport-latency-probe"
+
+	// latencyProbeInterval is how often each party's channel is probed for
+	// round-trip latency over the life of a session.
+	latencyProbeInterval = 30 * time.Second
 )
 
 var (
@@ -54,11 +67,32 @@ var (
 			Help: "Number of active sessions",
 		},
 	)
+	sessionBytesIn = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "server_session_bytes_in_total",
+			Help: "Total number of bytes received from clients across all sessions",
+		},
+	)
+	sessionBytesOut = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "server_session_bytes_out_total",
+			Help: "Total number of bytes sent to clients across all sessions",
+		},
+	)
+	sessionRoundTripLatency = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "server_session_round_trip_latency_seconds",
+			Help: "Round trip latency sampled periodically over the life of a session",
+		},
+	)
 )
 
 func init() {
 	// Metrics have to be registered to be exposed:
 	prometheus.MustRegister(serverSessions)
+	prometheus.MustRegister(sessionBytesIn)
+	prometheus.MustRegister(sessionBytesOut)
+	prometheus.MustRegister(sessionRoundTripLatency)
 }
 
 // sessionRegistry holds a map of all active sessions on a given
@@ -84,23 +118,68 @@ func (r *sessionRegistry) Close() {
 	log.Debugf("sessionRegistry.Close()")
 }
 
+// disconnectExpiredParties looks up the Teleport user behind every party in
+// every active session and disconnects any whose account has since expired.
+func (r *sessionRegistry) disconnectExpiredParties() {
+	r.Lock()
+	parties := make([]*party, 0)
+	for _, sess := range r.sessions {
+		sess.Lock()
+		for _, p := range sess.parties {
+			parties = append(parties, p)
+		}
+		sess.Unlock()
+	}
+	r.Unlock()
+
+	for _, p := range parties {
+		user, err := r.srv.authService.GetUser(p.user)
+		if err != nil {
+			log.Warningf("failed to check expiry for user %v: %v", p.user, err)
+			continue
+		}
+		expires := user.Expiry()
+		if expires.IsZero() || r.srv.clock.Now().UTC().Before(expires) {
+			continue
+		}
+		p.ctx.Infof("disconnecting %v: account has expired", p.user)
+		p.ctx.conn.Close()
+		if err := p.Close(); err != nil {
+			p.ctx.Error(err)
+		}
+	}
+}
+
 // joinShell either joins an existing session or starts a new shell
 func (s *sessionRegistry) openSession(ch ssh.Channel, req *ssh.Request, ctx *ctx) error {
 	if ctx.session != nil {
 		// emit "joined session" event:
 		s.srv.EmitAuditEvent(events.SessionJoinEvent, events.EventFields{
-			events.SessionEventID:  string(ctx.session.id),
-			events.EventNamespace:  s.srv.getNamespace(),
-			events.EventLogin:      ctx.login,
-			events.EventUser:       ctx.teleportUser,
-			events.LocalAddr:       ctx.conn.LocalAddr().String(),
-			events.RemoteAddr:      ctx.conn.RemoteAddr().String(),
-			events.SessionServerID: ctx.srv.ID(),
+			events.SessionEventID:      string(ctx.session.id),
+			events.EventNamespace:      s.srv.getNamespace(),
+			events.EventLogin:          ctx.login,
+			events.EventUser:           ctx.teleportUser,
+			events.LocalAddr:           ctx.conn.LocalAddr().String(),
+			events.RemoteAddr:          ctx.conn.RemoteAddr().String(),
+			events.SessionServerID:     ctx.srv.ID(),
+			events.SessionServerLabels: ctx.srv.getInfo().GetAllLabels(),
 		})
 		ctx.Infof("[SESSION] joining session: %v", ctx.session.id)
 		_, err := ctx.session.join(ch, req, ctx)
 		return trace.Wrap(err)
 	}
+	// if this user's roles require a reason for starting a session (e.g.
+	// an incident/ticket reference for just-in-time node access), make
+	// sure the client actually supplied one before we create the session
+	roles, err := ctx.srv.fetchRoleSet(ctx.teleportUser, ctx.clusterName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	reason, _ := ctx.getEnv(sshutils.SessionReasonEnvVar)
+	if roles.RequireSessionReason() && reason == "" {
+		return trace.BadParameter("a --reason is required to start a session as %v", ctx.teleportUser)
+	}
+
 	// session not found? need to create one. start by getting/generating an ID for it
 	sid, found := ctx.getEnv(sshutils.SessionEnvVar)
 	if !found {
@@ -165,11 +244,19 @@ func (s *sessionRegistry) leaveSession(party *party) error {
 		s.Unlock()
 
 		// send an event indicating that this session has ended
-		s.srv.EmitAuditEvent(events.SessionEndEvent, events.EventFields{
-			events.SessionEventID: string(sess.id),
-			events.EventUser:      party.user,
-			events.EventNamespace: s.srv.getNamespace(),
-		})
+		endFields := events.EventFields{
+			events.SessionEventID:  string(sess.id),
+			events.EventUser:       party.user,
+			events.EventNamespace:  s.srv.getNamespace(),
+			events.SessionBytesIn:  atomic.LoadUint64(&sess.bytesIn),
+			events.SessionBytesOut: atomic.LoadUint64(&sess.bytesOut),
+		}
+		if p50, p90, p99, ok := sess.latencyPercentiles(); ok {
+			endFields[events.SessionLatencyP50] = p50
+			endFields[events.SessionLatencyP90] = p90
+			endFields[events.SessionLatencyP99] = p99
+		}
+		s.srv.EmitAuditEvent(events.SessionEndEvent, endFields)
 		if err := sess.Close(); err != nil {
 			log.Error(err)
 		}
@@ -241,6 +328,39 @@ func (s *sessionRegistry) notifyWinChange(params rsession.TerminalParams, ctx *c
 	return nil
 }
 
+// setPartyCanWrite is called when an SSH server receives a request from a
+// session's owner to grant or revoke a participant's write access on the
+// fly. Only the session owner may do this.
+func (s *sessionRegistry) setPartyCanWrite(partyID rsession.ID, canWrite bool, ctx *ctx) error {
+	if ctx.session == nil {
+		log.Debugf("setPartyCanWrite(): no session found!")
+		return nil
+	}
+	sess := ctx.session
+	if ctx.teleportUser != sess.owner {
+		return trace.AccessDenied("only the session owner can change a participant's write access")
+	}
+
+	sess.Lock()
+	party, ok := sess.parties[partyID]
+	sess.Unlock()
+	if !ok {
+		return trace.NotFound("party %v not found", partyID)
+	}
+	party.setCanWrite(canWrite)
+
+	sid := sess.id
+	s.srv.EmitAuditEvent(events.SessionModerateEvent, events.EventFields{
+		events.EventNamespace:          s.srv.getNamespace(),
+		events.SessionEventID:          sid,
+		events.EventLogin:              ctx.login,
+		events.EventUser:               ctx.teleportUser,
+		events.SessionModerateParty:    string(partyID),
+		events.SessionModerateCanWrite: canWrite,
+	})
+	return nil
+}
+
 func (s *sessionRegistry) broadcastResult(sid rsession.ID, r execResult) error {
 	s.Lock()
 	defer s.Unlock()
@@ -304,7 +424,21 @@ type session struct {
 	// login stores the login of the initial session creator
 	login string
 
+	// owner is the Teleport user who created this session. Only they can
+	// grant or revoke other parties' write access via setPartyCanWrite.
+	owner string
+
 	closeOnce sync.Once
+
+	// bytesIn and bytesOut count the bytes read from and written to
+	// clients over the life of the session. They're accessed atomically
+	// since parties update them outside of the session's own lock.
+	bytesIn  uint64
+	bytesOut uint64
+
+	// latencies holds round-trip latency samples collected by probing
+	// parties' channels, used to report percentiles when the session ends.
+	latencies []time.Duration
 }
 
 // newSession creates a new session with a given ID within a given context.
@@ -358,6 +492,7 @@ func newSession(id rsession.ID, r *sessionRegistry, context *ctx) (*session, err
 		parties:   make(map[rsession.ID]*party),
 		writer:    newMultiWriter(),
 		login:     context.login,
+		owner:     context.teleportUser,
 		closeC:    make(chan bool),
 		lingerTTL: defaults.SessionRefreshPeriod * 10,
 	}
@@ -455,6 +590,9 @@ type sessionRecorder struct {
 	sid rsession.ID
 	// namespace is session namespace
 	namespace string
+	// commands heuristically extracts typed command lines out of the
+	// terminal stream, so they show up as searchable audit events
+	commands commandExtractor
 }
 
 func newSessionRecorder(alog events.IAuditLog, namespace string, sid rsession.ID) (*sessionRecorder, error) {
@@ -501,6 +639,15 @@ func (r *sessionRecorder) Write(data []byte) (int, error) {
 	}); err != nil {
 		log.Error(trace.DebugReport(err))
 	}
+	for _, cmd := range r.commands.feed(data) {
+		if err := r.alog.EmitAuditEvent(events.SessionCommandEvent, events.EventFields{
+			events.SessionEventID:     string(r.sid),
+			events.EventNamespace:     r.namespace,
+			events.SessionCommandLine: cmd,
+		}); err != nil {
+			log.Warningf("failed to emit session command event: %v", err)
+		}
+	}
 	return len(data), nil
 }
 
@@ -540,27 +687,72 @@ func (s *session) start(ch ssh.Channel, ctx *ctx) error {
 		return trace.Wrap(err)
 	}
 
+	// roles are needed below to decide recording, sudoers and cgroup
+	// confinement, so fetch them once up front
+	roles, err := ctx.srv.fetchRoleSet(ctx.teleportUser, ctx.clusterName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// place the session's process into a dedicated cgroup capped at
+	// whatever resource limits the user's roles grant, so a runaway
+	// command can't take down the rest of the host
+	cgroupID, err := createCgroup(cmd.Process.Pid, roles, s.id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
 	// emit "new session created" event:
-	s.registry.srv.EmitAuditEvent(events.SessionStartEvent, events.EventFields{
-		events.EventNamespace:  ctx.srv.getNamespace(),
-		events.SessionEventID:  string(s.id),
-		events.SessionServerID: ctx.srv.ID(),
-		events.EventLogin:      ctx.login,
-		events.EventUser:       ctx.teleportUser,
-		events.LocalAddr:       ctx.conn.LocalAddr().String(),
-		events.RemoteAddr:      ctx.conn.RemoteAddr().String(),
-		events.TerminalSize:    s.term.params.Serialize(),
-	})
+	startFields := events.EventFields{
+		events.EventNamespace:      ctx.srv.getNamespace(),
+		events.SessionEventID:      string(s.id),
+		events.SessionServerID:     ctx.srv.ID(),
+		events.SessionServerLabels: ctx.srv.getInfo().GetAllLabels(),
+		events.EventLogin:          ctx.login,
+		events.EventUser:           ctx.teleportUser,
+		events.LocalAddr:           ctx.conn.LocalAddr().String(),
+		events.RemoteAddr:          ctx.conn.RemoteAddr().String(),
+		events.TerminalSize:        s.term.params.Serialize(),
+		events.ClientVersion:       string(ctx.conn.ClientVersion()),
+	}
+	if reason, found := ctx.getEnv(sshutils.SessionReasonEnvVar); found {
+		startFields[events.SessionReason] = reason
+	}
+	if cgroupID != "" {
+		startFields[events.SessionCgroupID] = cgroupID
+	}
+	s.registry.srv.EmitAuditEvent(events.SessionStartEvent, startFields)
 
-	// start recording this session
+	// start recording this session, unless the user's roles opt it out
+	// (or in) of the cluster-wide default -- see
+	// services.RoleSet.RecordSession
 	auditLog := s.registry.srv.alog
-	if auditLog != nil {
+	recordSession := roles.RecordSession(auditLog != nil)
+	if recordSession && auditLog != nil {
 		recorder, err := newSessionRecorder(auditLog, ctx.srv.getNamespace(), s.id)
 		if err != nil {
 			return trace.Wrap(err)
 		}
 		s.writer.addWriter("session-recorder", recorder, true)
 	}
+	if roles.NotifySessionRecording() {
+		notice := "This session is not being recorded.\r\n"
+		if recordSession {
+			notice = "This session is being recorded.\r\n"
+		}
+		s.writer.Write([]byte(notice))
+	}
+	if reason, found := startFields[events.SessionReason]; found {
+		s.writer.Write([]byte(fmt.Sprintf("Session reason: %v\r\n", reason)))
+	}
+
+	// distribute any sudoers lines the user's roles grant to the node for
+	// the lifetime of this session, so sudo privilege here is governed by
+	// Teleport roles rather than the host's own sudoers config
+	sudoersPath, err := writeSudoers(ctx.login, roles, s.id)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 
 	// start asynchronous loop of synchronizing session state with
 	// the session server (terminal size and activity)
@@ -571,7 +763,7 @@ func (s *session) start(ch ssh.Channel, ctx *ctx) error {
 	go func() {
 		// notify terminal about a copy process going on
 		defer s.term.Add(-1)
-		io.Copy(s.writer, s.term.pty)
+		io.Copy(&countingWriter{Writer: s.writer, total: &s.bytesOut}, s.term.pty)
 		log.Infof("session.io.copy() stopped")
 	}()
 
@@ -593,12 +785,30 @@ func (s *session) start(ch ssh.Channel, ctx *ctx) error {
 	// wait for the session to end before the shell, kill the shell
 	go func() {
 		<-s.closeC
-		if cmd.Process != nil {
+		removeSudoers(sudoersPath)
+		removeCgroup(cgroupID)
+		if cmd.Process == nil {
+			return
+		}
+		if !roles.ShouldKillProcessGroup() {
 			if err := cmd.Process.Kill(); err != nil {
 				if err.Error() != "os: process already finished" {
 					log.Error(trace.DebugReport(err))
 				}
 			}
+			return
+		}
+		// the shell is its own process group leader (see terminal.run's
+		// Setsid), so reaping its group catches any background job left
+		// behind (e.g. a "nohup ... &") instead of leaving it to outlive
+		// the session as an unaccounted-for orphan
+		killed := reapSessionProcesses(cmd.Process.Pid)
+		if len(killed) > 1 {
+			s.registry.srv.EmitAuditEvent(events.SessionLeftoverProcessesEvent, events.EventFields{
+				events.SessionEventID:      string(s.id),
+				events.EventNamespace:      s.registry.srv.getNamespace(),
+				events.SessionLeftoverPIDs: killed,
+			})
 		}
 	}()
 	return nil
@@ -614,9 +824,41 @@ func (s *session) String() string {
 	return fmt.Sprintf("session(id=%v, parties=%v)", s.id, len(s.parties))
 }
 
+// recordLatency appends a round-trip latency sample collected from one of
+// this session's parties, and observes it in the global latency histogram.
+func (s *session) recordLatency(d time.Duration) {
+	sessionRoundTripLatency.Observe(d.Seconds())
+	s.Lock()
+	defer s.Unlock()
+	s.latencies = append(s.latencies, d)
+}
+
+// minLatencySamples is the fewest round-trip samples needed before
+// percentiles are considered meaningful enough to report.
+const minLatencySamples = 3
+
+// latencyPercentiles returns the p50/p90/p99 round-trip latency observed
+// over the life of the session, in milliseconds. ok is false if too few
+// samples were collected.
+func (s *session) latencyPercentiles() (p50, p90, p99 int64, ok bool) {
+	s.Lock()
+	samples := append([]time.Duration{}, s.latencies...)
+	s.Unlock()
+
+	if len(samples) < minLatencySamples {
+		return 0, 0, 0, false
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	percentile := func(p float64) int64 {
+		i := int(p * float64(len(samples)-1))
+		return samples[i].Nanoseconds() / int64(time.Millisecond)
+	}
+	return percentile(0.5), percentile(0.9), percentile(0.99), true
+}
+
 // removeParty removes the party from two places:
-//   1. from in-memory dictionary inside of this session
-//   2. from sessin server's storage
+//  1. from in-memory dictionary inside of this session
+//  2. from sessin server's storage
 func (s *session) removeParty(p *party) error {
 	p.ctx.Infof("session.removeParty(%v)", p)
 
@@ -670,7 +912,9 @@ func (s *session) getNamespace() string {
 
 // pollAndSync is a loop inside a goroutite which keeps synchronizing the terminal
 // size to what's in the session (so all connected parties have the same terminal size)
-// it also updates 'active' field on the session.
+// it also updates 'active' field on the session, and each party's LastActive/CanWrite,
+// so that polling viewers (e.g. the session list API) can tell who's currently typing
+// and who the owner has muted.
 func (s *session) pollAndSync() {
 	log.Debugf("[session.registry] start pollAndSync()\b")
 	defer log.Debugf("[session.registry] end pollAndSync()\n")
@@ -688,11 +932,12 @@ func (s *session) pollAndSync() {
 			return trace.Wrap(err)
 		}
 		var active = true
+		parties := s.currentParties()
 		sessionServer.UpdateSession(rsession.UpdateRequest{
 			Namespace: ns,
 			ID:        sess.ID,
 			Active:    &active,
-			Parties:   nil,
+			Parties:   &parties,
 		})
 		winSize, err := s.term.getWinsize()
 		if err != nil {
@@ -730,6 +975,25 @@ func (s *session) pollAndSync() {
 	}
 }
 
+// currentParties takes a snapshot of every party currently attached to the
+// session, for persisting to session storage.
+func (s *session) currentParties() []rsession.Party {
+	s.Lock()
+	defer s.Unlock()
+	parties := make([]rsession.Party, 0, len(s.parties))
+	for _, p := range s.parties {
+		parties = append(parties, rsession.Party{
+			ID:         p.id,
+			User:       p.user,
+			ServerID:   p.serverID,
+			RemoteAddr: p.site,
+			LastActive: p.getLastActive(),
+			CanWrite:   p.getCanWrite(),
+		})
+	}
+	return parties
+}
+
 // addParty is called when a new party joins the session.
 func (s *session) addParty(p *party) error {
 	if s.login != p.login {
@@ -772,6 +1036,7 @@ func (s *session) addParty(p *party) error {
 			ServerID:   p.serverID,
 			RemoteAddr: p.site,
 			LastActive: p.getLastActive(),
+			CanWrite:   p.getCanWrite(),
 		})
 		db.UpdateSession(rsession.UpdateRequest{
 			ID:        dbSession.ID,
@@ -785,6 +1050,9 @@ func (s *session) addParty(p *party) error {
 
 	p.ctx.Infof("[SESSION] new party joined: %v", p.String())
 
+	// periodically probe this party's channel to sample round-trip latency
+	go p.pollLatency()
+
 	// this goroutine keeps pumping party's input into the session
 	go func() {
 		defer s.term.Add(-1)
@@ -805,6 +1073,21 @@ func (s *session) join(ch ssh.Channel, req *ssh.Request, ctx *ctx) (*party, erro
 	return p, nil
 }
 
+// countingWriter wraps an io.Writer, atomically accumulating the number of
+// bytes written through it into total and into the global sessionBytesOut
+// counter.
+type countingWriter struct {
+	io.Writer
+	total *uint64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	atomic.AddUint64(w.total, uint64(n))
+	sessionBytesOut.Add(float64(n))
+	return n, err
+}
+
 func newMultiWriter() *multiWriter {
 	return &multiWriter{writers: make(map[string]writerWrapper)}
 }
@@ -891,6 +1174,7 @@ func newParty(s *session, ch ssh.Channel, ctx *ctx) *party {
 		sconn:     ctx.conn,
 		termSizeC: make(chan []byte, 5),
 		closeC:    make(chan bool),
+		canWrite:  true,
 	}
 }
 
@@ -910,6 +1194,11 @@ type party struct {
 	termSizeC  chan []byte
 	lastActive time.Time
 	closeOnce  sync.Once
+
+	// canWrite is false if the session owner has muted this party: its
+	// input is discarded rather than forwarded into the session, though it
+	// keeps receiving output as an observer.
+	canWrite bool
 }
 
 func (p *party) onWindowChanged(params *rsession.TerminalParams) {
@@ -938,9 +1227,59 @@ func (p *party) getLastActive() time.Time {
 	return p.lastActive
 }
 
+// pollLatency periodically sends an unanswered-by-design SSH channel
+// request down this party's channel and times the failure reply a
+// compliant client sends back automatically, recording the round trip as
+// a latency sample for the session. It runs until the party's channel is
+// closed.
+func (p *party) pollLatency() {
+	ticker := time.NewTicker(latencyProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closeC:
+			return
+		case <-ticker.C:
+		}
+		start := time.Now()
+		if _, err := p.ch.SendRequest(latencyProbeReqType, true, nil); err != nil {
+			continue
+		}
+		p.s.recordLatency(time.Since(start))
+	}
+}
+
 func (p *party) Read(bytes []byte) (int, error) {
-	p.updateActivity()
-	return p.ch.Read(bytes)
+	// a muted party must never report bytes read without an error: io.Copy
+	// treats a (0, nil) return as no progress and will eventually give up
+	// with io.ErrNoProgress. Loop instead, draining the channel so it
+	// stays healthy and p.lastActive keeps reflecting the party's typing
+	// activity, until either they're unmuted or the channel errors.
+	for {
+		p.updateActivity()
+		n, err := p.ch.Read(bytes)
+		if err != nil {
+			return n, err
+		}
+		if !p.getCanWrite() {
+			continue
+		}
+		atomic.AddUint64(&p.s.bytesIn, uint64(n))
+		sessionBytesIn.Add(float64(n))
+		return n, nil
+	}
+}
+
+func (p *party) getCanWrite() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.canWrite
+}
+
+func (p *party) setCanWrite(canWrite bool) {
+	p.Lock()
+	defer p.Unlock()
+	p.canWrite = canWrite
 }
 
 func (p *party) Write(bytes []byte) (int, error) {
@@ -27,6 +27,8 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -78,6 +80,11 @@ type Server struct {
 	advertiseIP     net.IP
 	proxyPublicAddr utils.NetAddr
 
+	// tunnelAddr, when set in proxy mode, is advertised to the rest of the
+	// cluster as this proxy's reverse tunnel address, so a sibling proxy can
+	// peer with it when it needs to reach a site tunneled through this one
+	tunnelAddr utils.NetAddr
+
 	// server UUID gets generated once on the first start and never changes
 	// usually stored in a file inside the data dir
 	uuid string
@@ -95,6 +102,10 @@ type Server struct {
 	// clock is a system clock
 	clock clockwork.Clock
 
+	// startTime is when this process started, reported to the auth server
+	// on every heartbeat so operators can see each agent's uptime
+	startTime time.Time
+
 	// permitUserEnvironment controls if this server will read ~/.tsh/environment
 	// before creating a new session.
 	permitUserEnvironment bool
@@ -110,6 +121,11 @@ type Server struct {
 	// macAlgorithms is a list of message authentication codes (MAC) that
 	// the server supports. If omitted the defaults will be used.
 	macAlgorithms []string
+
+	// listener, if set, is an already-open listener (e.g. one imported via
+	// systemd socket activation) that the server accepts connections on
+	// instead of binding addr itself
+	listener net.Listener
 }
 
 // ServerOption is a functional option passed to the server
@@ -127,7 +143,9 @@ func (s *Server) Start() error {
 	if len(s.cmdLabels) > 0 {
 		s.updateLabels()
 	}
+	sweepOrphanedSudoers()
 	go s.heartbeatPresence()
+	go s.expireSessions()
 	return s.srv.Start()
 }
 
@@ -136,6 +154,13 @@ func (s *Server) Wait() {
 	s.srv.Wait()
 }
 
+// HandleConnection processes conn as if it had been accepted by this
+// server's own listener. It's used to hand this server SSH connections
+// that actually arrived over the proxy's ALPN-routed HTTPS listener.
+func (s *Server) HandleConnection(conn net.Conn) {
+	s.srv.HandleConnection(conn)
+}
+
 // SetShell sets default shell that will be executed for interactive
 // sessions
 func SetShell(shell string) ServerOption {
@@ -162,6 +187,15 @@ func SetProxyMode(tsrv reversetunnel.Server) ServerOption {
 	}
 }
 
+// SetTunnelAddr sets the address this proxy's reverse tunnel server can be
+// reached at by sibling proxies, for peer-to-peer session dialing
+func SetTunnelAddr(tunnelAddr utils.NetAddr) ServerOption {
+	return func(s *Server) error {
+		s.tunnelAddr = tunnelAddr
+		return nil
+	}
+}
+
 // SetLabels sets dynamic and static labels that server will report to the
 // auth servers
 func SetLabels(labels map[string]string,
@@ -233,6 +267,15 @@ func SetMACAlgorithms(macAlgorithms []string) ServerOption {
 	}
 }
 
+// SetListener makes the server accept connections on an already-open
+// listener instead of binding addr itself.
+func SetListener(l net.Listener) ServerOption {
+	return func(s *Server) error {
+		s.listener = l
+		return nil
+	}
+}
+
 // New returns an unstarted server
 func New(addr utils.NetAddr,
 	hostname string,
@@ -259,6 +302,7 @@ func New(addr utils.NetAddr,
 		uuid:            uuid,
 		closer:          utils.NewCloseBroadcaster(),
 		clock:           clockwork.NewRealClock(),
+		startTime:       time.Now().UTC(),
 	}
 	s.limiter, err = limiter.NewLimiter(limiter.LimiterConfig{})
 	if err != nil {
@@ -280,15 +324,21 @@ func New(addr utils.NetAddr,
 	}
 
 	s.reg = newSessionRegistry(s)
-	srv, err := sshutils.NewServer(
-		component,
-		addr, s, signers,
-		sshutils.AuthMethods{PublicKey: s.keyAuth},
+	sshServerOpts := []sshutils.ServerOption{
 		sshutils.SetLimiter(s.limiter),
 		sshutils.SetRequestHandler(s),
 		sshutils.SetCiphers(s.ciphers),
 		sshutils.SetKEXAlgorithms(s.kexAlgorithms),
-		sshutils.SetMACAlgorithms(s.macAlgorithms))
+		sshutils.SetMACAlgorithms(s.macAlgorithms),
+	}
+	if s.listener != nil {
+		sshServerOpts = append(sshServerOpts, sshutils.SetListener(s.listener))
+	}
+	srv, err := sshutils.NewServer(
+		component,
+		addr, s, signers,
+		sshutils.AuthMethods{PublicKey: s.keyAuth},
+		sshServerOpts...)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -362,34 +412,74 @@ func (s *Server) getInfo() services.Server {
 			Labels:    s.labels,
 		},
 		Spec: services.ServerSpecV2{
-			CmdLabels: services.LabelsToV2(s.getCommandLabels()),
-			Addr:      s.AdvertiseAddr(),
-			Hostname:  s.hostname,
+			CmdLabels:       services.LabelsToV2(s.getCommandLabels()),
+			Addr:            s.AdvertiseAddr(),
+			Hostname:        s.hostname,
+			TeleportVersion: teleport.Version,
+			OS:              runtime.GOOS,
+			StartTime:       s.startTime,
 		},
 	}
 }
 
+// stampedInfo returns getInfo() with any TTL-independent, proxy-only fields
+// applied, so it's safe to compare across heartbeats to decide whether the
+// full spec needs to be resent.
+func (s *Server) stampedInfo() services.Server {
+	srv := s.getInfo()
+	if s.proxyMode {
+		srv.SetPublicAddr(s.proxyPublicAddr.String())
+		if !s.tunnelAddr.IsEmpty() {
+			srv.SetTunnelAddr(s.tunnelAddr.String())
+		}
+	}
+	return srv
+}
+
 // registerServer attempts to register server in the cluster
 func (s *Server) registerServer() error {
-	srv := s.getInfo()
+	srv := s.stampedInfo()
 	srv.SetTTL(s.clock, defaults.ServerHeartbeatTTL)
 	if !s.proxyMode {
 		return trace.Wrap(s.authService.UpsertNode(srv))
 	}
-	srv.SetPublicAddr(s.proxyPublicAddr.String())
 	return trace.Wrap(s.authService.UpsertProxy(srv))
 }
 
+// keepAlivePresence extends the TTL of the spec this server last announced,
+// without resending it.
+func (s *Server) keepAlivePresence() error {
+	expires := s.clock.Now().UTC().Add(defaults.ServerHeartbeatTTL)
+	if !s.proxyMode {
+		return trace.Wrap(s.authService.KeepAliveNode(s.getNamespace(), s.ID(), expires))
+	}
+	return trace.Wrap(s.authService.KeepAliveProxy(s.ID(), expires))
+}
+
 // heartbeatPresence periodically calls into the auth server to let everyone
-// know we're up & alive
+// know we're up & alive. The full spec is only resent when something about
+// it changed since the last heartbeat (e.g. dynamic labels updated);
+// otherwise a lightweight keepalive extends its TTL, so a cluster with
+// thousands of otherwise-static nodes isn't rewriting their full resource
+// into the backend every few seconds.
 func (s *Server) heartbeatPresence() {
 	sleepTime := defaults.ServerHeartbeatTTL/2 + utils.RandomDuration(defaults.ServerHeartbeatTTL/10)
 	ticker := time.NewTicker(sleepTime)
 	defer ticker.Stop()
 
+	var lastAnnounced services.Server
 	for {
-		if err := s.registerServer(); err != nil {
-			log.Warningf("failed to announce %v presence: %v", s.ID(), err)
+		current := s.stampedInfo()
+		if lastAnnounced != nil && reflect.DeepEqual(current, lastAnnounced) {
+			if err := s.keepAlivePresence(); err != nil {
+				log.Warningf("failed to keep %v presence alive: %v", s.ID(), err)
+			}
+		} else {
+			if err := s.registerServer(); err != nil {
+				log.Warningf("failed to announce %v presence: %v", s.ID(), err)
+			} else {
+				lastAnnounced = current
+			}
 		}
 		select {
 		case <-ticker.C:
@@ -403,6 +493,24 @@ func (s *Server) heartbeatPresence() {
 	}
 }
 
+// expireSessions periodically re-checks the Teleport user account behind
+// every session this server is currently hosting, disconnecting any party
+// whose account has expired since the session started. This is how a
+// contractor's access actually gets cut at the end of their engagement even
+// if they're mid-session when the clock runs out.
+func (s *Server) expireSessions() {
+	ticker := time.NewTicker(defaults.ExpiredUserCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.reg.disconnectExpiredParties()
+		case <-s.closer.C:
+			return
+		}
+	}
+}
+
 func (s *Server) updateLabels() {
 	for name, label := range s.cmdLabels {
 		go s.periodicUpdateLabel(name, label.Clone())
@@ -529,9 +637,24 @@ func (s *Server) checkPermissionToLogin(cert *ssh.Certificate, teleportUser, osU
 		}
 	}
 
-	if err := roles.CheckAccessToServer(osUser, s.getInfo()); err != nil {
+	info := s.getInfo()
+	accessErr := roles.CheckAccessToServer(osUser, info)
+
+	if _, isCanary := info.GetAllLabels()[teleport.CanaryLabel]; isCanary {
+		// fires regardless of accessErr -- there's no legitimate reason to
+		// ever touch a canary node, so a denied attempt is just as
+		// significant a signal as one that RBAC happens to allow.
+		s.EmitAuditEvent(events.CanaryAccessEvent, events.EventFields{
+			events.EventUser:            teleportUser,
+			events.EventLogin:           osUser,
+			events.CanaryAccessServerID: info.GetName(),
+			events.CanaryAccessGranted:  accessErr == nil,
+		})
+	}
+
+	if accessErr != nil {
 		return "", trace.AccessDenied("user %s@%s is not authorized to login as %v@%s: %v",
-			teleportUser, ca.GetClusterName(), osUser, domainName, err)
+			teleportUser, ca.GetClusterName(), osUser, domainName, accessErr)
 	}
 
 	return domainName, nil
@@ -948,6 +1071,8 @@ func (s *Server) dispatch(ch ssh.Channel, req *ssh.Request, ctx *ctx) error {
 		return s.handleSubsystem(ch, req, ctx)
 	case sshutils.WindowChangeReq:
 		return s.handleWinChange(ch, req, ctx)
+	case sshutils.ModerateSessionReq:
+		return s.handleModerateSession(ch, req, ctx)
 	case sshutils.AgentReq:
 		// This happens when SSH client has agent forwarding enabled, in this case
 		// client sends a special request, in return SSH server opens new channel
@@ -1040,6 +1165,17 @@ func (s *Server) handleWinChange(ch ssh.Channel, req *ssh.Request, ctx *ctx) err
 	return trace.Wrap(s.reg.notifyWinChange(*params, ctx))
 }
 
+// handleModerateSession gets called when a session owner sends a request to
+// grant or revoke a participant's write access on the fly.
+func (s *Server) handleModerateSession(ch ssh.Channel, req *ssh.Request, ctx *ctx) error {
+	var params sshutils.ModerateSessionReqParams
+	if err := ssh.Unmarshal(req.Payload, &params); err != nil {
+		ctx.Error(err)
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(s.reg.setPartyCanWrite(rsession.ID(params.PartyID), params.CanWrite, ctx))
+}
+
 func (s *Server) handleSubsystem(ch ssh.Channel, req *ssh.Request, ctx *ctx) error {
 	sb, err := parseSubsystemRequest(s, req)
 	if err != nil {
@@ -184,6 +184,7 @@ func (s *SrvSuite) SetUpTest(c *C) {
 
 	c.Assert(s.srv.Start(), IsNil)
 	c.Assert(s.srv.registerServer(), IsNil)
+	c.Assert(s.a.ApproveNode(s.srv.getNamespace(), s.srv.ID()), IsNil)
 
 	// set up an agent server and a client that uses agent for forwarding
 	keyring := agent.NewKeyring()
@@ -567,6 +568,7 @@ func (s *SrvSuite) TestProxyReverseTunnel(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(srv2.Start(), IsNil)
 	c.Assert(srv2.registerServer(), IsNil)
+	c.Assert(s.a.ApproveNode(srv2.getNamespace(), srv2.ID()), IsNil)
 	defer srv2.Close()
 
 	srv2.registerServer()
@@ -796,6 +798,59 @@ func (s *SrvSuite) TestClientDisconnect(c *C) {
 	c.Assert(clt.Close(), IsNil)
 }
 
+// TestDisconnectExpiredParties verifies that disconnectExpiredParties
+// disconnects a live session once the Teleport user behind it has an
+// expired account, without the user record itself getting evicted from
+// the backend first.
+func (s *SrvSuite) TestDisconnectExpiredParties(c *C) {
+	se, err := s.clt.NewSession()
+	c.Assert(err, IsNil)
+	defer se.Close()
+	c.Assert(se.Shell(), IsNil)
+
+	// wait for the party to register in the session registry
+	var hasParty bool
+	for i := 0; i < 10; i++ {
+		s.srv.reg.Lock()
+		for _, sess := range s.srv.reg.sessions {
+			sess.Lock()
+			hasParty = len(sess.parties) > 0
+			sess.Unlock()
+		}
+		s.srv.reg.Unlock()
+		if hasParty {
+			break
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	c.Assert(hasParty, Equals, true)
+
+	user, err := s.a.GetUser(s.user)
+	c.Assert(err, IsNil)
+	user.SetAccountExpiry(time.Now().UTC().Add(-time.Hour))
+	c.Assert(s.a.UpsertUser(user), IsNil)
+
+	// the user record must still be there -- account expiry must not put
+	// it on a backend TTL -- or disconnectExpiredParties would never get
+	// a chance to look at it.
+	_, err = s.a.GetUser(s.user)
+	c.Assert(err, IsNil)
+
+	s.srv.reg.disconnectExpiredParties()
+
+	errC := make(chan error, 1)
+	go func() {
+		_, err := se.Output("echo hello")
+		errC <- err
+	}()
+	select {
+	case err := <-errC:
+		c.Assert(err, NotNil)
+	case <-time.After(5 * time.Second):
+		c.Fatal("session was not disconnected after account expiry")
+	}
+}
+
 func (s *SrvSuite) TestLimiter(c *C) {
 	limiter, err := limiter.NewLimiter(
 		limiter.LimiterConfig{
@@ -0,0 +1,113 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/services"
+	rsession "github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// sudoersDir is where sudo itself looks for additional policy files, see
+// sudoers(5)'s "#includedir" directive.
+const sudoersDir = "/etc/sudoers.d"
+
+// sudoersFilePattern is the glob every snippet writeSudoers creates matches,
+// used by sweepOrphanedSudoers to find them again.
+const sudoersFilePattern = "teleport-*"
+
+// writeSudoers renders the sudoers lines roles grants login and writes them
+// to a file under sudoersDir scoped to this one session, so host sudo
+// policy tracks Teleport roles instead of whatever's checked into config
+// management. It returns "" (and no error) if roles grants login no
+// sudoers lines, so nothing is written for sessions that don't need it.
+func writeSudoers(login string, roles services.RoleSet, sid rsession.ID) (string, error) {
+	lines := roles.SudoersLines(login)
+	if len(lines) == 0 {
+		return "", nil
+	}
+	contents := []byte(strings.Join(lines, "\n") + "\n")
+	if err := validateSudoers(contents); err != nil {
+		return "", trace.Wrap(err)
+	}
+	// sudo refuses to read policy files that are writable by anyone but
+	// root, so these are written 0440 as sudoers(5) recommends.
+	path := filepath.Join(sudoersDir, fmt.Sprintf("teleport-%v", sid))
+	if err := ioutil.WriteFile(path, contents, 0440); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return path, nil
+}
+
+// validateSudoers rejects contents unless it parses as a well-formed
+// sudoers(5) file, by shelling out to "visudo -cf -" the same way an
+// administrator would check a snippet by hand before dropping it into
+// sudoersDir. A malformed snippet there breaks sudo for every user on the
+// node, not just the one Teleport wrote it for, so this fails closed: a
+// missing visudo is treated the same as a validation failure.
+func validateSudoers(contents []byte) error {
+	visudo, err := exec.LookPath("visudo")
+	if err != nil {
+		return trace.NotFound("cannot validate generated sudoers snippet: \"visudo\" is not installed on this node")
+	}
+	cmd := exec.Command(visudo, "-cf", "-")
+	cmd.Stdin = bytes.NewReader(contents)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return trace.BadParameter("generated sudoers snippet failed validation: %v", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// removeSudoers deletes a sudoers snippet written by writeSudoers. path
+// being "" (nothing was written for this session) is a no-op.
+func removeSudoers(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Warningf("failed to remove sudoers snippet %v: %v", path, err)
+	}
+}
+
+// sweepOrphanedSudoers removes every snippet writeSudoers left behind under
+// sudoersDir. It's meant to run once at node startup, before this process
+// has had a chance to write a snippet of its own: at that point every
+// teleport-* file under sudoersDir must belong to a session whose node
+// process exited (e.g. crashed) before removeSudoers ran for it.
+func sweepOrphanedSudoers() {
+	matches, err := filepath.Glob(filepath.Join(sudoersDir, sudoersFilePattern))
+	if err != nil {
+		log.Warningf("failed to sweep orphaned sudoers snippets: %v", err)
+		return
+	}
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warningf("failed to remove orphaned sudoers snippet %v: %v", path, err)
+		}
+	}
+}
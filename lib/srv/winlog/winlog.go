@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package winlog writes Teleport audit events into the Windows Event Log,
+// so they show up alongside every other security-relevant log on a
+// Windows host instead of only existing inside Teleport's own audit log.
+//
+// This is a first, intentionally small slice of porting lib/srv's node
+// service to Windows -- the rest (spawning a session's shell via ConPTY
+// instead of a pty(7)+SysProcAttr.Credential, and the Win32 equivalent of
+// this package's POSIX UID/GID-switching and cgroup confinement) is
+// substantial platform-specific work that doesn't fit in the same change
+// and isn't attempted here. Everything in lib/srv outside this package
+// remains Linux/BSD-only.
+package winlog
+
+import "github.com/gravitational/trace"
+
+// Severity is the Windows Event Log level a Sink.Report call is tagged
+// with, mapping onto the three levels Event Viewer itself distinguishes.
+type Severity int
+
+const (
+	// SeverityInfo corresponds to EVENTLOG_INFORMATION_TYPE.
+	SeverityInfo Severity = iota
+	// SeverityWarning corresponds to EVENTLOG_WARNING_TYPE.
+	SeverityWarning
+	// SeverityError corresponds to EVENTLOG_ERROR_TYPE.
+	SeverityError
+)
+
+// Sink writes lines to the Windows Event Log under a single event source.
+type Sink interface {
+	// Report writes message to the event log at the given severity.
+	Report(severity Severity, message string) error
+	// Close releases the underlying event source handle. Safe to call
+	// more than once.
+	Close() error
+}
+
+// errUnsupported is returned by NewSink on every platform but Windows.
+var errUnsupported = trace.BadParameter("winlog is only supported on Windows")
@@ -46,6 +46,17 @@ type PTYReqParams struct {
 	Modes string
 }
 
+// ModerateSessionReqParams specifies parameters for a request to grant or
+// revoke a participant's write access to a shared session, sent by the
+// session owner on their own already-open session channel.
+type ModerateSessionReqParams struct {
+	// PartyID identifies the participant whose write access is being
+	// changed.
+	PartyID string
+	// CanWrite is the write access to set for PartyID.
+	CanWrite bool
+}
+
 // Check validates PTY parameters.
 func (p *PTYReqParams) Check() error {
 	if p.W > maxSize || p.W < minSize {
@@ -74,6 +85,11 @@ func (p *PTYReqParams) CheckAndSetDefaults() error {
 const (
 	// SessionEnvVar is environment variable for SSH session
 	SessionEnvVar = "TELEPORT_SESSION"
+	// SessionReasonEnvVar carries an optional incident/ticket reference
+	// supplied by the client (e.g. 'tsh ssh --reason') through to the
+	// node, so it can enforce a role's RequestReason option and record
+	// the reason on the session.start event.
+	SessionReasonEnvVar = "TELEPORT_SESSION_REASON"
 	// SetEnvReq sets environment requests
 	SetEnvReq = "env"
 	// WindowChangeReq is a request to change window
@@ -82,6 +98,9 @@ const (
 	PTYReq = "pty-req"
 	// AgentReq is ssh agent requesst
 	AgentReq = "auth-agent-req@openssh.com"
+	// ModerateSessionReq is a request from a session's owner to grant or
+	// revoke a participant's write access on the fly.
+	ModerateSessionReq = "moderate-session@teleport"
 )
 
 const (
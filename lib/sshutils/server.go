@@ -94,6 +94,16 @@ func SetLimiter(limiter *limiter.Limiter) ServerOption {
 	}
 }
 
+// SetListener makes the server accept connections on an already-open
+// listener, e.g. one imported via systemd socket activation, instead of
+// binding its own when started.
+func SetListener(l net.Listener) ServerOption {
+	return func(s *Server) error {
+		s.listener = l
+		return nil
+	}
+}
+
 func NewServer(
 	component string,
 	a utils.NetAddr,
@@ -185,12 +195,14 @@ func (s *Server) Addr() string {
 
 func (s *Server) Start() error {
 	s.askedToClose = false
-	socket, err := net.Listen(s.addr.AddrNetwork, s.addr.Addr)
-	if err != nil {
-		return err
+	if s.listener == nil {
+		socket, err := net.Listen(s.addr.AddrNetwork, s.addr.Addr)
+		if err != nil {
+			return err
+		}
+		s.listener = socket
 	}
-	s.listener = socket
-	log.Infof("[SSH:%s] listening socket: %v", s.component, socket.Addr())
+	log.Infof("[SSH:%s] listening socket: %v", s.component, s.listener.Addr())
 	go s.acceptConnections()
 	return nil
 }
@@ -236,6 +248,14 @@ func (s *Server) acceptConnections() {
 	}
 }
 
+// HandleConnection processes conn exactly as if it had just been accepted
+// by this server's own listener. It's exported so a connection accepted
+// elsewhere — for example by an ALPN-demultiplexing TLS listener sharing
+// the proxy's HTTPS port — can still be handed off to this server.
+func (s *Server) HandleConnection(conn net.Conn) {
+	s.handleConnection(conn)
+}
+
 // handleConnection is called every time an SSH server accepts a new
 // connection from a client.
 //
@@ -0,0 +1,191 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package state
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBufferedEventsQueueLen bounds how many audit events
+	// BufferedAuditLog holds in memory awaiting delivery. Once it's full,
+	// EmitAuditEvent stops waiting for room and journals the event to disk
+	// directly instead -- the backpressure limit that keeps a stalled audit
+	// log server from growing a node/proxy's memory without bound.
+	DefaultBufferedEventsQueueLen = 1000
+	// DefaultReplayInterval is how often the on-disk journal is retried
+	// while no new events are being emitted.
+	DefaultReplayInterval = 10 * time.Second
+)
+
+// BufferedAuditLogConfig configures BufferedAuditLog.
+type BufferedAuditLogConfig struct {
+	// Server is the upstream audit log server events are forwarded to.
+	Server events.IAuditLog
+	// QueueLen bounds the number of events buffered in memory. Defaults to
+	// DefaultBufferedEventsQueueLen.
+	QueueLen int
+	// ReplayInterval is how often the journal is retried while idle.
+	// Defaults to DefaultReplayInterval.
+	ReplayInterval time.Duration
+	// JournalDir is the directory the on-disk event journal is kept in.
+	// Defaults to os.TempDir().
+	JournalDir string
+	// MaxJournaledEvents bounds how many events the on-disk journal keeps.
+	// Defaults to DefaultMaxJournaledEvents.
+	MaxJournaledEvents int
+}
+
+// CheckAndSetDefaults checks parameters and sets default values
+func (c *BufferedAuditLogConfig) CheckAndSetDefaults() error {
+	if c.Server == nil {
+		return trace.BadParameter("missing parameter Server")
+	}
+	if c.QueueLen == 0 {
+		c.QueueLen = DefaultBufferedEventsQueueLen
+	}
+	if c.ReplayInterval == 0 {
+		c.ReplayInterval = DefaultReplayInterval
+	}
+	if c.JournalDir == "" {
+		c.JournalDir = os.TempDir()
+	}
+	return nil
+}
+
+// BufferedAuditLog wraps an events.IAuditLog and makes EmitAuditEvent
+// resilient to the server being briefly unreachable. It's meant for the
+// audit log a node or proxy attaches to its whole SSH server (auth
+// attempts, port forwarding, and other events not tied to a single
+// session's CachingAuditLog): EmitAuditEvent hands the event to a
+// background goroutine and returns immediately, so a slow or unreachable
+// auth server never blocks the session or command that triggered it. Any
+// event that can't be delivered is journaled to disk, bounded by
+// MaxJournaledEvents, and replayed, in order, once the connection recovers.
+//
+// Everything else (session chunks, searches, etc.) is forwarded straight
+// through to Server, unbuffered -- EmitAuditEvent is the only call sites
+// like Server.EmitAuditEvent in lib/srv make without any retry of their
+// own, so it's the only one that needs this.
+type BufferedAuditLog struct {
+	BufferedAuditLogConfig
+	journal *eventJournal
+	queue   chan journaledEvent
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewBufferedAuditLog creates a BufferedAuditLog and starts its delivery
+// goroutine.
+func NewBufferedAuditLog(cfg BufferedAuditLogConfig) (*BufferedAuditLog, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	b := &BufferedAuditLog{
+		BufferedAuditLogConfig: cfg,
+		journal: &eventJournal{
+			Path:      filepath.Join(cfg.JournalDir, "server.events.journal"),
+			MaxEvents: cfg.MaxJournaledEvents,
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	b.queue = make(chan journaledEvent, cfg.QueueLen)
+	go b.run()
+	return b, nil
+}
+
+func (b *BufferedAuditLog) run() {
+	ticker := time.NewTicker(b.ReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.journal.replay(b.Server.EmitAuditEvent)
+		case je := <-b.queue:
+			b.journal.replay(b.Server.EmitAuditEvent)
+			if err := b.Server.EmitAuditEvent(je.EventType, je.Fields); err != nil {
+				if journalErr := b.journal.append(je.EventType, je.Fields); journalErr != nil {
+					log.Warningf("failed to journal audit event %v for later replay: %v", je.EventType, journalErr)
+				}
+			}
+		}
+	}
+}
+
+// EmitAuditEvent hands the event off to the background delivery goroutine
+// and returns immediately. If the in-memory queue is already full, the
+// event is journaled to disk directly rather than blocking the caller.
+func (b *BufferedAuditLog) EmitAuditEvent(eventType string, fields events.EventFields) error {
+	select {
+	case b.queue <- journaledEvent{EventType: eventType, Fields: fields}:
+		return nil
+	default:
+		log.Warningf("audit event queue full, journaling %v event directly", eventType)
+		return trace.Wrap(b.journal.append(eventType, fields))
+	}
+}
+
+// PostSessionSlice is part of events.IAuditLog implementation
+func (b *BufferedAuditLog) PostSessionSlice(slice events.SessionSlice) error {
+	return b.Server.PostSessionSlice(slice)
+}
+
+// PostSessionChunk is part of events.IAuditLog implementation
+func (b *BufferedAuditLog) PostSessionChunk(namespace string, sid session.ID, reader io.Reader) error {
+	return b.Server.PostSessionChunk(namespace, sid, reader)
+}
+
+// GetSessionChunk is part of events.IAuditLog implementation
+func (b *BufferedAuditLog) GetSessionChunk(namespace string, sid session.ID, offsetBytes, maxBytes int) ([]byte, error) {
+	return b.Server.GetSessionChunk(namespace, sid, offsetBytes, maxBytes)
+}
+
+// GetSessionEvents is part of events.IAuditLog implementation
+func (b *BufferedAuditLog) GetSessionEvents(namespace string, sid session.ID, after int) ([]events.EventFields, error) {
+	return b.Server.GetSessionEvents(namespace, sid, after)
+}
+
+// SearchEvents is part of events.IAuditLog implementation
+func (b *BufferedAuditLog) SearchEvents(fromUTC, toUTC time.Time, query string) ([]events.EventFields, error) {
+	return b.Server.SearchEvents(fromUTC, toUTC, query)
+}
+
+// PurgeSessionData is part of events.IAuditLog implementation
+func (b *BufferedAuditLog) PurgeSessionData(namespace string, sid session.ID) error {
+	return b.Server.PurgeSessionData(namespace, sid)
+}
+
+// Close stops the delivery goroutine and closes the upstream server.
+func (b *BufferedAuditLog) Close() error {
+	b.cancel()
+	return b.Server.Close()
+}
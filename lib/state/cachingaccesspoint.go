@@ -414,18 +414,52 @@ func (cs *CachingAuthClient) GetUsers() (users []services.User, err error) {
 	return users, err
 }
 
+// GetUser is a part of auth.AccessPoint implementation
+func (cs *CachingAuthClient) GetUser(name string) (user services.User, err error) {
+	err = cs.try(func() error {
+		user, err = cs.ap.GetUser(name)
+		return err
+	})
+	if err != nil {
+		if trace.IsConnectionProblem(err) {
+			return cs.identity.GetUser(name)
+		}
+		return user, err
+	}
+	cs.setTTL(user)
+	if err := cs.identity.UpsertUser(user); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return user, err
+}
+
 // UpsertNode is part of auth.AccessPoint implementation
 func (cs *CachingAuthClient) UpsertNode(s services.Server) error {
 	cs.setTTL(s)
 	return cs.ap.UpsertNode(s)
 }
 
+// KeepAliveNode is part of auth.AccessPoint implementation
+func (cs *CachingAuthClient) KeepAliveNode(namespace, name string, expires time.Time) error {
+	return cs.ap.KeepAliveNode(namespace, name, expires)
+}
+
+// DeleteNode is part of auth.AccessPoint implementation
+func (cs *CachingAuthClient) DeleteNode(namespace string, name string) error {
+	return cs.ap.DeleteNode(namespace, name)
+}
+
 // UpsertProxy is part of auth.AccessPoint implementation
 func (cs *CachingAuthClient) UpsertProxy(s services.Server) error {
 	cs.setTTL(s)
 	return cs.ap.UpsertProxy(s)
 }
 
+// KeepAliveProxy is part of auth.AccessPoint implementation
+func (cs *CachingAuthClient) KeepAliveProxy(name string, expires time.Time) error {
+	return cs.ap.KeepAliveProxy(name, expires)
+}
+
 // try calls a given function f and checks for errors. If f() fails, the current
 // time is recorded. Future calls to f will be ingored until sufficient time passes
 // since th last error
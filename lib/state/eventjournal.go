@@ -0,0 +1,124 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/events"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMaxJournaledEvents bounds how many audit events an eventJournal
+// keeps on disk while the audit log server is unreachable. Once the limit
+// is reached, the oldest journaled events are dropped to make room for new
+// ones, so a long outage can't grow the journal file without bound.
+const DefaultMaxJournaledEvents = 10000
+
+// journaledEvent is one line of an on-disk event journal.
+type journaledEvent struct {
+	EventType string             `json:"event_type"`
+	Fields    events.EventFields `json:"fields"`
+}
+
+// eventJournal persists audit events that couldn't be forwarded to the
+// audit log server, so they can be replayed, in order, once it's reachable
+// again. It's shared by CachingAuditLog (per-session events) and
+// BufferedAuditLog (server-wide events).
+type eventJournal struct {
+	// Path is the file events are journaled to.
+	Path string
+	// MaxEvents bounds how many events the journal keeps; defaults to
+	// DefaultMaxJournaledEvents.
+	MaxEvents int
+}
+
+func (j *eventJournal) maxEvents() int {
+	if j.MaxEvents <= 0 {
+		return DefaultMaxJournaledEvents
+	}
+	return j.MaxEvents
+}
+
+// append adds an event to the journal, dropping the oldest journaled
+// event(s) if it's already at capacity.
+func (j *eventJournal) append(eventType string, fields events.EventFields) error {
+	lines, err := j.readLines()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := json.Marshal(journaledEvent{EventType: eventType, Fields: fields})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	lines = append(lines, string(data))
+	if over := len(lines) - j.maxEvents(); over > 0 {
+		log.Warningf("audit event journal %v is full, dropping %v oldest event(s)", j.Path, over)
+		lines = lines[over:]
+	}
+	return trace.Wrap(ioutil.WriteFile(j.Path, []byte(strings.Join(lines, "\n")+"\n"), 0600))
+}
+
+// replay forwards journaled events, in order, via send. As soon as send
+// fails, that event and every one after it (order matters for an audit
+// trail) are left in the journal for the next attempt.
+func (j *eventJournal) replay(send func(eventType string, fields events.EventFields) error) {
+	lines, err := j.readLines()
+	if err != nil || len(lines) == 0 {
+		return
+	}
+	var remaining []string
+	for i, line := range lines {
+		var je journaledEvent
+		if err := json.Unmarshal([]byte(line), &je); err != nil {
+			log.Warningf("dropping unreadable journaled audit event: %v", err)
+			continue
+		}
+		if err := send(je.EventType, je.Fields); err != nil {
+			remaining = lines[i:]
+			break
+		}
+	}
+	if len(remaining) == 0 {
+		os.Remove(j.Path)
+		return
+	}
+	if err := ioutil.WriteFile(j.Path, []byte(strings.Join(remaining, "\n")+"\n"), 0600); err != nil {
+		log.Warningf("failed to rewrite audit event journal %v: %v", j.Path, err)
+	}
+}
+
+func (j *eventJournal) readLines() ([]string, error) {
+	data, err := ioutil.ReadFile(j.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	trimmed := strings.TrimRight(string(data), "\n")
+	if trimmed == "" {
+		return nil, nil
+	}
+	return strings.Split(trimmed, "\n"), nil
+}
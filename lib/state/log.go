@@ -19,8 +19,12 @@ package state
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/gravitational/teleport/lib/events"
@@ -133,6 +137,11 @@ type CachingAuditLogConfig struct {
 	BackoffInitialInterval time.Duration
 	// BackoffMaxInterval is maximum interval for backoff
 	BackoffMaxInterval time.Duration
+	// SpoolDir is where session chunks that could not be delivered to the
+	// audit log server are spooled to disk as a last resort, so a node
+	// crash (or the auth server being unreachable at shutdown) doesn't
+	// silently discard the tail of a long session's recording.
+	SpoolDir string
 }
 
 // CheckAndSetDefaults checks and sets defaults
@@ -176,6 +185,9 @@ func (c *CachingAuditLogConfig) CheckAndSetDefaults() error {
 	if c.BackoffMaxInterval == 0 {
 		c.BackoffMaxInterval = DefaultBackoffMaxInterval
 	}
+	if c.SpoolDir == "" {
+		c.SpoolDir = os.TempDir()
+	}
 	return nil
 }
 
@@ -203,6 +215,7 @@ type CachingAuditLog struct {
 	chunks        []*events.SessionChunk
 	bytes         int64
 	throttleStart time.Time
+	eventJournal  *eventJournal
 }
 
 func (ll *CachingAuditLog) add(chunks []*events.SessionChunk) {
@@ -229,6 +242,7 @@ func NewCachingAuditLog(cfg CachingAuditLogConfig) (*CachingAuditLog, error) {
 		CachingAuditLogConfig: cfg,
 		cancel:                cancel,
 		ctx:                   ctx,
+		eventJournal:          &eventJournal{Path: filepath.Join(cfg.SpoolDir, fmt.Sprintf("%v-%v.events.journal", cfg.Namespace, cfg.SessionID))},
 	}
 	ll.queue = make(chan []*events.SessionChunk, ll.QueueLen)
 	go ll.run()
@@ -310,6 +324,7 @@ func (ll *CachingAuditLog) flush(opts flushOpts) {
 	}
 	log.Warningf("lost connection: %v", err)
 	if opts.noRetry {
+		ll.spoolLostSlice(slice)
 		return
 	}
 	ticker := backoff.NewTicker(ll.newExponentialBackoff())
@@ -317,6 +332,7 @@ func (ll *CachingAuditLog) flush(opts flushOpts) {
 	for {
 		select {
 		case <-ll.ctx.Done():
+			ll.spoolLostSlice(slice)
 			return
 		case <-ticker.C:
 			err := ll.postSlice(slice)
@@ -329,6 +345,47 @@ func (ll *CachingAuditLog) flush(opts flushOpts) {
 	}
 }
 
+// spoolPath returns the local file used to spool chunks for this session
+// that could not be delivered to the audit log server.
+func (ll *CachingAuditLog) spoolPath() string {
+	return filepath.Join(ll.SpoolDir, fmt.Sprintf("%v-%v.chunks.spool", ll.Namespace, ll.SessionID))
+}
+
+// spoolLostSlice appends a slice of chunks that could not be delivered to
+// the audit log server to a local spool file, so the tail of a session's
+// recording can be recovered from disk instead of being silently dropped.
+// Best-effort: a failure to spool is only logged, never returned, since
+// this is already the last-resort path after delivery has failed.
+func (ll *CachingAuditLog) spoolLostSlice(slice events.SessionSlice) {
+	if len(slice.Chunks) == 0 {
+		return
+	}
+	f, err := os.OpenFile(ll.spoolPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Warningf("failed to spool %v undelivered session chunks to disk: %v", len(slice.Chunks), err)
+		return
+	}
+	defer f.Close()
+	for _, chunk := range slice.Chunks {
+		data, err := chunk.Marshal()
+		if err != nil {
+			log.Warningf("failed to marshal session chunk for spooling: %v", err)
+			continue
+		}
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+		if _, err := f.Write(size[:]); err != nil {
+			log.Warningf("failed to spool session chunk to %v: %v", ll.spoolPath(), err)
+			return
+		}
+		if _, err := f.Write(data); err != nil {
+			log.Warningf("failed to spool session chunk to %v: %v", ll.spoolPath(), err)
+			return
+		}
+	}
+	log.Warningf("spooled %v undelivered session chunks to %v for manual recovery", len(slice.Chunks), ll.spoolPath())
+}
+
 func (ll *CachingAuditLog) postSlice(slice events.SessionSlice) error {
 	start := time.Now()
 	err := ll.Server.PostSessionSlice(slice)
@@ -382,8 +439,26 @@ func (ll *CachingAuditLog) Close() error {
 	return nil
 }
 
+// EmitAuditEvent forwards an audit event to the audit log server. Before
+// doing so, it opportunistically replays any events journaled to disk
+// during a previous outage, so this session's audit trail arrives in
+// order once connectivity is restored. If the server can't be reached
+// right now, the event is journaled instead of being dropped -- the
+// caller (the SSH server) only logs EmitAuditEvent's error today, so
+// journaling here is what actually makes the event survive the outage.
 func (ll *CachingAuditLog) EmitAuditEvent(eventType string, fields events.EventFields) error {
-	return ll.Server.EmitAuditEvent(eventType, fields)
+	ll.eventJournal.replay(ll.Server.EmitAuditEvent)
+	err := ll.Server.EmitAuditEvent(eventType, fields)
+	if err == nil {
+		return nil
+	}
+	if !trace.IsConnectionProblem(err) {
+		return trace.Wrap(err)
+	}
+	if journalErr := ll.eventJournal.append(eventType, fields); journalErr != nil {
+		log.Warningf("failed to journal audit event %v for later replay: %v", eventType, journalErr)
+	}
+	return trace.Wrap(err)
 }
 
 func (ll *CachingAuditLog) PostSessionChunk(namespace string, sid session.ID, reader io.Reader) error {
@@ -413,3 +488,7 @@ func (ll *CachingAuditLog) GetSessionEvents(string, session.ID, int) ([]events.E
 func (ll *CachingAuditLog) SearchEvents(time.Time, time.Time, string) ([]events.EventFields, error) {
 	return nil, errNotSupported
 }
+
+func (ll *CachingAuditLog) PurgeSessionData(namespace string, sid session.ID) error {
+	return ll.Server.PurgeSessionData(namespace, sid)
+}
@@ -113,7 +113,9 @@ func ParseAddr(a string) (*NetAddr, error) {
 		if err != nil {
 			return nil, trace.BadParameter("invalid network address: '%v', expecting host:port", a)
 		}
-		return &NetAddr{Addr: fmt.Sprintf("%v:%v", host, port), AddrNetwork: "tcp"}, nil
+		// net.JoinHostPort (rather than a plain "host:port") re-brackets
+		// an IPv6 host literal, so this round-trips addresses like "[::1]:22"
+		return &NetAddr{Addr: net.JoinHostPort(host, port), AddrNetwork: "tcp"}, nil
 	}
 	u, err := url.Parse(a)
 	if err != nil {
@@ -124,6 +126,11 @@ func ParseAddr(a string) (*NetAddr, error) {
 		return &NetAddr{Addr: u.Host, AddrNetwork: u.Scheme, Path: u.Path}, nil
 	case "unix":
 		return &NetAddr{Addr: u.Path, AddrNetwork: u.Scheme}, nil
+	case "fd":
+		// fd://<name> refers to a listener imported via systemd socket
+		// activation (see utils.ImportListeners), named after the
+		// FileDescriptorName= its socket unit declared
+		return &NetAddr{Addr: u.Host, AddrNetwork: u.Scheme}, nil
 	default:
 		return nil, trace.BadParameter("'%v': unsupported scheme: '%v'", a, u.Scheme)
 	}
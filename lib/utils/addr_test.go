@@ -75,6 +75,29 @@ func (s *AddrTestSuite) TestParseDefaults(c *C) {
 	c.Assert(addr.IsEmpty(), Equals, false)
 }
 
+func (s *AddrTestSuite) TestParseIPv6(c *C) {
+	// a bare "host:port" IPv6 address must round-trip with its brackets
+	// intact, not come out looking like three colon-separated fields
+	addr, err := ParseAddr("[::1]:25")
+	c.Assert(err, IsNil)
+	c.Assert(addr, NotNil)
+	c.Assert(addr.Addr, Equals, "[::1]:25")
+	c.Assert(addr.FullAddress(), Equals, "tcp://[::1]:25")
+
+	addr, err = ParseAddr("tcp://[::1]:25")
+	c.Assert(err, IsNil)
+	c.Assert(addr, NotNil)
+	c.Assert(addr.Addr, Equals, "[::1]:25")
+}
+
+func (s *AddrTestSuite) TestParseFD(c *C) {
+	addr, err := ParseAddr("fd://web")
+	c.Assert(err, IsNil)
+	c.Assert(addr, NotNil)
+	c.Assert(addr.AddrNetwork, Equals, "fd")
+	c.Assert(addr.Addr, Equals, "web")
+}
+
 func (s *AddrTestSuite) TestReplaceLocalhost(c *C) {
 	var result string
 	result = ReplaceLocalhost("10.10.1.1", "192.168.1.100:399")
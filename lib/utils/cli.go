@@ -24,6 +24,7 @@ import (
 	"log/syslog"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/gravitational/teleport"
 
@@ -93,6 +94,9 @@ func FatalError(err error) {
 
 // UserMessageFromError returns user friendly error message from error
 func UserMessageFromError(err error) string {
+	if strings.Contains(err.Error(), VersionSkewErrorPrefix) {
+		return fmt.Sprintf("This version of the client is incompatible with the Teleport cluster it's connecting to:\n%v\n\nPlease install a client version within one minor release of the cluster and try again.", err)
+	}
 	// untrusted cert?
 	switch innerError := trace.Unwrap(err).(interface{}).(type) {
 	case x509.HostnameError:
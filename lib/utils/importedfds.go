@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// EnvListenPID is set by systemd to the PID of the process the sockets
+	// were opened for. It guards against a forked child inheriting and
+	// misinterpreting file descriptors that were never meant for it.
+	EnvListenPID = "LISTEN_PID"
+	// EnvListenFDs is set by systemd to the number of sockets passed down to
+	// this process via socket activation, starting at file descriptor 3.
+	EnvListenFDs = "LISTEN_FDS"
+	// EnvListenFDNames is set by systemd to a colon-separated list of names,
+	// one per imported file descriptor, taken from the corresponding socket
+	// unit's FileDescriptorName=. Descriptors without a name default to
+	// their positional index.
+	EnvListenFDNames = "LISTEN_FDNAMES"
+
+	// listenFDsStart is the first file descriptor systemd hands off to a
+	// socket-activated process; see sd_listen_fds(3).
+	listenFDsStart = 3
+)
+
+// ImportListeners returns the listeners passed to this process by systemd
+// socket activation (see systemd.socket(5)), keyed by the name each one was
+// given via FileDescriptorName= (or its positional index, if unnamed). It
+// returns an empty map if this process was not socket-activated, so callers
+// can unconditionally look up a name and fall back to binding their own
+// listener when it's absent.
+func ImportListeners() (map[string]net.Listener, error) {
+	listeners := make(map[string]net.Listener)
+	if os.Getenv(EnvListenPID) != strconv.Itoa(os.Getpid()) {
+		return listeners, nil
+	}
+	count, err := strconv.Atoi(os.Getenv(EnvListenFDs))
+	if err != nil || count <= 0 {
+		return listeners, nil
+	}
+	names := strings.Split(os.Getenv(EnvListenFDNames), ":")
+	for i := 0; i < count; i++ {
+		fd := listenFDsStart + i
+		name := strconv.Itoa(i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to import socket-activated listener %q (fd %v)", name, fd)
+		}
+		listeners[name] = listener
+	}
+	return listeners, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"os"
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+type ImportedFDsTestSuite struct {
+}
+
+var _ = Suite(&ImportedFDsTestSuite{})
+
+func (s *ImportedFDsTestSuite) unsetEnv() {
+	os.Unsetenv(EnvListenPID)
+	os.Unsetenv(EnvListenFDs)
+	os.Unsetenv(EnvListenFDNames)
+}
+
+func (s *ImportedFDsTestSuite) TestNotSocketActivated(c *C) {
+	defer s.unsetEnv()
+	s.unsetEnv()
+
+	listeners, err := ImportListeners()
+	c.Assert(err, IsNil)
+	c.Assert(listeners, HasLen, 0)
+}
+
+func (s *ImportedFDsTestSuite) TestWrongPID(c *C) {
+	defer s.unsetEnv()
+	// LISTEN_PID not matching our own PID means these descriptors were
+	// meant for a different process (e.g. a forked child inherited them by
+	// mistake), so they must be ignored
+	os.Setenv(EnvListenPID, strconv.Itoa(os.Getpid()+1))
+	os.Setenv(EnvListenFDs, "1")
+
+	listeners, err := ImportListeners()
+	c.Assert(err, IsNil)
+	c.Assert(listeners, HasLen, 0)
+}
@@ -18,10 +18,12 @@ package proxy
 import (
 	"bufio"
 	"context"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -67,6 +69,30 @@ func (d directDial) Dial(network string, addr string, config *ssh.ClientConfig)
 	return DialWithDeadline(network, addr, config)
 }
 
+type socks5Dial struct {
+	proxyHost string
+}
+
+// Dial first connects to a SOCKS5 proxy, then uses the connection to
+// establish a new SSH connection.
+func (d socks5Dial) Dial(network string, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	pconn, err := dialSOCKS5Proxy(d.proxyHost, addr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if config.Timeout > 0 {
+		pconn.SetReadDeadline(time.Now().Add(config.Timeout))
+	}
+	c, chans, reqs, err := ssh.NewClientConn(pconn, addr, config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if config.Timeout > 0 {
+		pconn.SetReadDeadline(time.Time{})
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
 type proxyDial struct {
 	proxyHost string
 }
@@ -93,11 +119,21 @@ func (d proxyDial) Dial(network string, addr string, config *ssh.ClientConfig) (
 	return ssh.NewClient(c, chans, reqs), nil
 }
 
-// DialerFromEnvironment returns a Dial function. If the https_proxy or http_proxy
-// environment variable are set, it returns a function that will dial through
-// said proxy server. If neither variable is set, it will connect to the SSH
-// server directly.
+// DialerFromEnvironment returns a Dial function. If the all_proxy
+// environment variable names a SOCKS5 proxy, it returns a function that
+// dials through it. Otherwise, if the https_proxy or http_proxy
+// environment variable are set, it returns a function that will dial
+// through said HTTP CONNECT proxy server. If none of those variables are
+// set, it will connect to the SSH server directly.
 func DialerFromEnvironment() Dialer {
+	// SOCKS5 takes priority: it's only ever set deliberately (there's no
+	// "socks5_proxy" equivalent of plain http_proxy's bare host:port), so
+	// a caller that set it wants it used.
+	if proxyAddr := getSOCKS5ProxyAddress(); proxyAddr != "" {
+		log.Debugf("[SOCKS5 PROXY] Found proxy %q in environment, returning SOCKS5 dialer.", proxyAddr)
+		return socks5Dial{proxyHost: proxyAddr}
+	}
+
 	// try and get proxy addr from the environment
 	proxyAddr := getProxyAddress()
 
@@ -111,6 +147,22 @@ func DialerFromEnvironment() Dialer {
 	return proxyDial{proxyHost: proxyAddr}
 }
 
+// DialProxyFromEnvironment connects to addr, routing the connection through
+// a corporate proxy configured in the environment (SOCKS5 via all_proxy,
+// or HTTP CONNECT via https_proxy/http_proxy) if one is set, or dialing
+// addr directly otherwise. It's the net.Conn-level equivalent of
+// DialerFromEnvironment, for callers that build their own SSH (or other)
+// protocol on top of the connection rather than dialing SSH themselves.
+func DialProxyFromEnvironment(addr string) (net.Conn, error) {
+	if proxyAddr := getSOCKS5ProxyAddress(); proxyAddr != "" {
+		return dialSOCKS5Proxy(proxyAddr, addr)
+	}
+	if proxyAddr := getProxyAddress(); proxyAddr != "" {
+		return dialProxy(proxyAddr, addr)
+	}
+	return net.Dial("tcp", addr)
+}
+
 func dialProxy(proxyAddr string, addr string) (net.Conn, error) {
 	ctx := context.Background()
 
@@ -149,6 +201,154 @@ func dialProxy(proxyAddr string, addr string) (net.Conn, error) {
 	return conn, nil
 }
 
+// socks5NoAuth and friends are the handful of SOCKS5 (RFC 1928) protocol
+// constants this client needs.
+const (
+	socks5Version        = 0x05
+	socks5NoAuth         = 0x00
+	socks5Connect        = 0x01
+	socks5AddrIPv4       = 0x01
+	socks5AddrDomainName = 0x03
+	socks5AddrIPv6       = 0x04
+	socks5StatusSucceded = 0x00
+)
+
+// dialSOCKS5Proxy connects to proxyAddr and asks it, via the SOCKS5
+// protocol, to relay a further TCP connection to addr. Only the "no
+// authentication required" method is supported, which covers the common
+// case of a corporate SOCKS5 proxy reachable without credentials; proxies
+// that require a username/password aren't supported.
+func dialSOCKS5Proxy(proxyAddr string, addr string) (net.Conn, error) {
+	conn, err := net.Dial("tcp", proxyAddr)
+	if err != nil {
+		log.Warnf("[SOCKS5 PROXY] Unable to dial to proxy: %v: %v", proxyAddr, err)
+		return nil, trace.ConvertSystemError(err)
+	}
+
+	if _, err := conn.Write([]byte{socks5Version, 1, socks5NoAuth}); err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+	method := make([]byte, 2)
+	if _, err := io.ReadFull(conn, method); err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+	if method[0] != socks5Version || method[1] != socks5NoAuth {
+		conn.Close()
+		return nil, trace.BadParameter("SOCKS5 proxy %v requires authentication, which is not supported", proxyAddr)
+	}
+
+	req, err := socks5ConnectRequest(addr)
+	if err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+
+	if err := socks5ReadReply(conn); err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+
+	return conn, nil
+}
+
+// socks5ConnectRequest builds a SOCKS5 CONNECT request asking the proxy to
+// relay a connection to addr.
+func socks5ConnectRequest(addr string) ([]byte, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, trace.BadParameter("invalid port %q in address %q", portStr, addr)
+	}
+
+	req := []byte{socks5Version, socks5Connect, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, socks5AddrIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, socks5AddrIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, trace.BadParameter("hostname %q is too long for SOCKS5", host)
+		}
+		req = append(req, socks5AddrDomainName, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+	return req, nil
+}
+
+// socks5ReadReply reads and validates a SOCKS5 CONNECT reply from conn.
+func socks5ReadReply(conn net.Conn) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return trace.Wrap(err)
+	}
+	if header[1] != socks5StatusSucceded {
+		return trace.BadParameter("SOCKS5 proxy refused connection, status %v", header[1])
+	}
+
+	// BND.ADDR, whose length depends on ATYP; this client has no use for
+	// it, but it still has to be drained off the connection.
+	var addrLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		addrLen = net.IPv4len
+	case socks5AddrIPv6:
+		addrLen = net.IPv6len
+	case socks5AddrDomainName:
+		lenByte := make([]byte, 1)
+		if _, err := io.ReadFull(conn, lenByte); err != nil {
+			return trace.Wrap(err)
+		}
+		addrLen = int(lenByte[0])
+	default:
+		return trace.BadParameter("SOCKS5 proxy returned unknown address type %v", header[3])
+	}
+	// +2 for BND.PORT
+	if _, err := io.ReadFull(conn, make([]byte, addrLen+2)); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// getSOCKS5ProxyAddress returns the host:port of the SOCKS5 proxy named by
+// the all_proxy/ALL_PROXY environment variable, or "" if neither is set to
+// a socks5:// (or socks5h://) URL.
+func getSOCKS5ProxyAddress() string {
+	envs := []string{
+		teleport.AllProxy,
+		strings.ToLower(teleport.AllProxy),
+	}
+
+	for _, v := range envs {
+		addr := os.Getenv(v)
+		if addr == "" {
+			continue
+		}
+		proxyurl, err := url.Parse(addr)
+		if err != nil || !strings.HasPrefix(proxyurl.Scheme, "socks5") {
+			log.Debugf("[SOCKS5 PROXY] Unable to parse environment variable %q: %q.", v, addr)
+			continue
+		}
+		log.Debugf("[SOCKS5 PROXY] Successfully parsed environment variable %q: %q to %q", v, addr, proxyurl.Host)
+		return proxyurl.Host
+	}
+
+	return ""
+}
+
 func getProxyAddress() string {
 	envs := []string{
 		teleport.HTTPSProxy,
@@ -17,6 +17,8 @@ package proxy
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"testing"
 
@@ -83,8 +85,79 @@ func (s *ProxySuite) TestGetProxyAddress(c *check.C) {
 	}
 }
 
+func (s *ProxySuite) TestGetSOCKS5ProxyAddress(c *check.C) {
+	var tests = []struct {
+		inEnvName    string
+		inEnvValue   string
+		outProxyAddr string
+	}{
+		// 0 - valid socks5:// URL
+		{
+			"all_proxy",
+			"socks5://proxy:1080",
+			"proxy:1080",
+		},
+		// 1 - valid socks5h:// URL
+		{
+			"ALL_PROXY",
+			"socks5h://proxy:1080",
+			"proxy:1080",
+		},
+		// 2 - not a SOCKS5 URL, ignored
+		{
+			"all_proxy",
+			"http://proxy:1080",
+			"",
+		},
+	}
+
+	for i, tt := range tests {
+		comment := check.Commentf("Test %v", i)
+
+		unsetEnv()
+		os.Setenv(tt.inEnvName, tt.inEnvValue)
+		p := getSOCKS5ProxyAddress()
+		unsetEnv()
+
+		c.Assert(p, check.Equals, tt.outProxyAddr, comment)
+	}
+}
+
+func (s *ProxySuite) TestDialSOCKS5Proxy(c *check.C) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, check.IsNil)
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// method selection: version, nmethods, no-auth
+		buf := make([]byte, 3)
+		io.ReadFull(conn, buf)
+		conn.Write([]byte{socks5Version, socks5NoAuth})
+
+		// CONNECT request: version, cmd, rsv, atyp, domain len, domain, port
+		header := make([]byte, 4)
+		io.ReadFull(conn, header)
+		domainLen := make([]byte, 1)
+		io.ReadFull(conn, domainLen)
+		io.ReadFull(conn, make([]byte, int(domainLen[0])+2))
+
+		// reply: success, bind addr 0.0.0.0:0
+		conn.Write([]byte{socks5Version, socks5StatusSucceded, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+	}()
+
+	conn, err := dialSOCKS5Proxy(ln.Addr().String(), "example.com:22")
+	c.Assert(err, check.IsNil)
+	conn.Close()
+}
+
 func unsetEnv() {
-	for _, envname := range []string{"http_proxy", "https_proxy", "HTTP_PROXY", "HTTPS_PROXY"} {
+	for _, envname := range []string{"http_proxy", "https_proxy", "HTTP_PROXY", "HTTPS_PROXY", "all_proxy", "ALL_PROXY"} {
 		os.Unsetenv(envname)
 	}
 }
@@ -1,3 +1,5 @@
+// +build cgo
+
 /*
 Copyright 2015 Gravitational, Inc.
 
@@ -40,10 +42,6 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	DefaultShell = "/bin/sh"
-)
-
 // GetLoginShell determines the login shell for a given username
 func GetLoginShell(username string) (string, error) {
 	// see if the username is valid
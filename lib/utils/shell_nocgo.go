@@ -0,0 +1,73 @@
+// +build !cgo
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetLoginShell determines the login shell for a given username.
+//
+// This is the cgo-free counterpart to shell.go's getpwnam_r-based
+// implementation, used whenever CGO_ENABLED=0 -- most commonly when
+// cross-compiling for a platform/architecture without a C toolchain handy,
+// e.g. an arm/arm64 build for an IoT gateway. It follows the same approach
+// the standard library's os/user falls back to in that situation (see
+// lookup_unix.go and its godoc), parsing /etc/passwd directly instead of
+// calling into libc.
+func GetLoginShell(username string) (string, error) {
+	if _, err := user.Lookup(username); err != nil {
+		return "", trace.Wrap(err)
+	}
+
+	f, err := os.Open("/etc/passwd")
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(line, ":")
+		if len(fields) < 7 || fields[0] != username {
+			continue
+		}
+		shell := strings.TrimSpace(fields[6])
+		if shell == "" {
+			log.Warnf("no shell specified for %s. using default=%s", username, DefaultShell)
+			shell = DefaultShell
+		}
+		return shell, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return "", trace.Errorf("cannot determine shell for %s", username)
+}
@@ -0,0 +1,32 @@
+// +build !cgo
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import log "github.com/sirupsen/logrus"
+
+// ResetInterruptSignalHandler is a best-effort no-op on a cgo-free build
+// (e.g. a cross-compiled arm/arm64 binary with no C toolchain available):
+// resetting a signal's disposition back to SIG_DFL needs a raw sigaction(2)
+// call this tree doesn't have a portable, vendored way to make without cgo.
+// If sysvinit (or anything else) started this process with SIGINT ignored,
+// a cgo-free build stays unable to Ctrl-C out of it; use a cgo-enabled
+// build if that matters on your platform.
+func ResetInterruptSignalHandler() {
+	log.Debug("ResetInterruptSignalHandler is a no-op on this cgo-free build")
+}
@@ -32,19 +32,112 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// ListenTLS sets up TLS listener for the http handler, starts listening
-// on a TCP socket and returns the socket which is ready to be used
-// for http.Serve
-func ListenTLS(address string, certFile, keyFile string) (net.Listener, error) {
-	tlsConfig, err := CreateTLSConfiguration(certFile, keyFile)
+// ALPNAuthProtocol is the ALPN protocol name a TLS client offers to tell
+// the proxy it wants to tunnel the SSH protocol over this connection
+// instead of HTTPS. It lets tsh reach a cluster from networks that only
+// allow outbound traffic on the HTTPS port.
+const ALPNAuthProtocol = "teleport-proxy-ssh"
+
+// ListenTLS wraps listener with TLS, ready to be used for http.Serve.
+// cipherSuites overrides the default TLS cipher suite list if non-empty.
+// listener is typically a freshly-bound TCP socket, but may be a Unix
+// socket or one imported via systemd socket activation (see
+// ImportListeners) -- TLS is indifferent to the transport underneath it.
+func ListenTLS(listener net.Listener, certFile, keyFile string, cipherSuites []uint16) (net.Listener, error) {
+	tlsConfig, err := CreateTLSConfiguration(certFile, keyFile, cipherSuites)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
+// ListenTLSSNI is like ListenTLS, but additionally serves distinct
+// certificates for the given virtual hostnames based on the TLS SNI server
+// name, falling back to certFile/keyFile for any other name. It's used by
+// the proxy to serve several public hostnames with distinct certificates
+// off a single listener.
+func ListenTLSSNI(listener net.Listener, certFile, keyFile string, cipherSuites []uint16, virtualHosts map[string]KeyPairFiles) (net.Listener, error) {
+	tlsConfig, err := CreateTLSConfiguration(certFile, keyFile, cipherSuites)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return tls.Listen("tcp", address, tlsConfig)
+	if len(virtualHosts) > 0 {
+		certs, err := loadNamedCertificates(virtualHosts)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		defaultCert := tlsConfig.Certificates[0]
+		tlsConfig.Certificates = nil
+		tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if cert, ok := certs[hello.ServerName]; ok {
+				return cert, nil
+			}
+			return &defaultCert, nil
+		}
+	}
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
+// KeyPairFiles is a pair of paths to a PEM-encoded TLS certificate and its
+// matching private key.
+type KeyPairFiles struct {
+	CertFile string
+	KeyFile  string
+}
+
+func loadNamedCertificates(virtualHosts map[string]KeyPairFiles) (map[string]*tls.Certificate, error) {
+	certs := make(map[string]*tls.Certificate, len(virtualHosts))
+	for hostname, files := range virtualHosts {
+		cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			return nil, trace.Wrap(err, "loading certificate for virtual host %q", hostname)
+		}
+		certs[hostname] = &cert
+	}
+	return certs, nil
 }
 
-// CreateTLSConfiguration sets up default TLS configuration
-func CreateTLSConfiguration(certFile, keyFile string) (*tls.Config, error) {
+// defaultCipherSuites is the TLS cipher suite list used when the config file
+// doesn't specify one.
+var defaultCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+}
+
+// cipherSuiteMapping maps the human readable cipher suite names accepted in
+// the config file to their crypto/tls values.
+var cipherSuiteMapping = map[string]uint16{
+	"tls-ecdhe-ecdsa-with-aes-128-gcm-sha256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"tls-ecdhe-rsa-with-aes-128-gcm-sha256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"tls-ecdhe-ecdsa-with-aes-256-gcm-sha384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"tls-ecdhe-rsa-with-aes-256-gcm-sha384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"tls-ecdhe-rsa-with-aes-256-cbc-sha":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"tls-ecdhe-rsa-with-aes-128-cbc-sha":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"tls-ecdhe-ecdsa-with-aes-256-cbc-sha":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"tls-ecdhe-ecdsa-with-aes-128-cbc-sha":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"tls-rsa-with-aes-256-cbc-sha":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"tls-rsa-with-aes-128-cbc-sha":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+}
+
+// CipherSuiteMapping returns the TLS cipher suite ID for the given config
+// file name, and whether it was recognized.
+func CipherSuiteMapping(name string) (uint16, bool) {
+	id, ok := cipherSuiteMapping[name]
+	return id, ok
+}
+
+// CreateTLSConfiguration sets up default TLS configuration. cipherSuites
+// overrides the default cipher suite list if non-empty.
+func CreateTLSConfiguration(certFile, keyFile string, cipherSuites []uint16) (*tls.Config, error) {
 	config := &tls.Config{}
 
 	if _, err := os.Stat(certFile); err != nil {
@@ -62,28 +155,65 @@ func CreateTLSConfiguration(certFile, keyFile string) (*tls.Config, error) {
 
 	config.Certificates = []tls.Certificate{cert}
 
-	config.CipherSuites = []uint16{
-		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-
-		tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-		tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
-
-		tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
-		tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
-
-		tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-		tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	if len(cipherSuites) > 0 {
+		config.CipherSuites = cipherSuites
+	} else {
+		config.CipherSuites = defaultCipherSuites
 	}
 
 	config.MinVersion = tls.VersionTLS12
 	config.SessionTicketsDisabled = false
 	config.ClientSessionCache = tls.NewLRUClientSessionCache(
 		DefaultLRUCapacity)
+	// advertise ALPNAuthProtocol alongside plain HTTPS so an ALPNListener
+	// wrapping this listener can tell the two kinds of client apart
+	config.NextProtos = []string{ALPNAuthProtocol, "http/1.1"}
 
 	return config, nil
 }
 
+// ALPNListener wraps a TLS listener and routes any connection that
+// negotiates ALPNAuthProtocol to sshHandler instead of returning it from
+// Accept, so a single HTTPS listener can also carry tunneled SSH traffic.
+// Connections that don't negotiate ALPNAuthProtocol (including ones from
+// clients that don't speak ALPN at all) are returned from Accept as usual.
+type ALPNListener struct {
+	net.Listener
+	sshHandler func(net.Conn)
+}
+
+// NewALPNListener wraps l, an already-listening TLS listener, with ALPN
+// based protocol routing.
+func NewALPNListener(l net.Listener, sshHandler func(net.Conn)) *ALPNListener {
+	return &ALPNListener{Listener: l, sshHandler: sshHandler}
+}
+
+// Accept blocks until it has a connection to hand back to the caller. SSH
+// connections are dispatched to sshHandler in the background and are never
+// returned; the loop moves on to the next one.
+func (l *ALPNListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			return conn, nil
+		}
+		if err := tlsConn.Handshake(); err != nil {
+			log.Debugf("[PROXY] ALPN handshake failed: %v", err)
+			conn.Close()
+			continue
+		}
+		if tlsConn.ConnectionState().NegotiatedProtocol == ALPNAuthProtocol {
+			go l.sshHandler(tlsConn)
+			continue
+		}
+		return tlsConn, nil
+	}
+}
+
 // TLSCredentials keeps the typical 3 components of a proper HTTPS configuration
 type TLSCredentials struct {
 	// PublicKey in PEM format
@@ -123,7 +253,7 @@ func GenerateSelfSignedCert(hostNames []string) (*TLSCredentials, error) {
 		NotAfter:              notAfter,
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
 		BasicConstraintsValid: true,
-		IsCA: true,
+		IsCA:                  true,
 	}
 
 	// collect IP addresses localhost resolves to and add them to the cert. template:
@@ -83,6 +83,12 @@ func IsShellFailedError(err error) bool {
 	return strings.Contains(err.Error(), "ssh: cound not start shell")
 }
 
+// IsCertExpiredError specifies whether this error indicates that an SSH
+// certificate presented during authentication has expired.
+func IsCertExpiredError(err error) bool {
+	return strings.Contains(trace.Unwrap(err).Error(), "cert has expired")
+}
+
 // PortList is a list of TCP port
 type PortList []string
 
@@ -0,0 +1,82 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// VersionSkewErrorPrefix marks an access-denied error returned by the
+// auth server as being caused by unsupported client/server version skew,
+// rather than an authorization failure, so CLI front ends can recognize
+// it and print a clearer message than the generic access-denied text.
+const VersionSkewErrorPrefix = "unsupported version skew"
+
+// VersionSkew describes the outcome of comparing a peer's reported
+// component version against this process's own.
+type VersionSkew struct {
+	// Compatible is false when the peer's version is far enough from
+	// ownVersion that the two shouldn't be expected to interoperate.
+	Compatible bool
+	// PeerVersion is the version string the peer reported, verbatim. It's
+	// empty if the peer didn't report one at all (e.g. an older release
+	// that predates this check).
+	PeerVersion string
+	// Message explains Compatible's value, suitable for logging or
+	// displaying to an operator.
+	Message string
+}
+
+// CheckVersionSkew compares peerVersion (e.g. a client's reported
+// teleport.Version) against ownVersion, this process's own version, and
+// reports whether they're within the supported N-1 minor version skew:
+// a peer one minor release behind or ahead is allowed, anything further
+// apart, or a different major version, is not. An empty or unparseable
+// peerVersion is treated as compatible rather than rejected outright,
+// since older Teleport releases never sent one, and a malformed version
+// string isn't a meaningful signal either way.
+func CheckVersionSkew(ownVersion, peerVersion string) VersionSkew {
+	if peerVersion == "" {
+		return VersionSkew{Compatible: true, Message: "peer did not report a version"}
+	}
+	own, err := semver.NewVersion(ownVersion)
+	if err != nil {
+		return VersionSkew{Compatible: true, PeerVersion: peerVersion, Message: fmt.Sprintf("could not parse own version %q: %v", ownVersion, err)}
+	}
+	peer, err := semver.NewVersion(peerVersion)
+	if err != nil {
+		return VersionSkew{Compatible: true, PeerVersion: peerVersion, Message: fmt.Sprintf("could not parse peer version %q: %v", peerVersion, err)}
+	}
+	if own.Major != peer.Major {
+		return VersionSkew{
+			Compatible:  false,
+			PeerVersion: peerVersion,
+			Message:     fmt.Sprintf("peer version %v is a different major version than this version %v", peerVersion, ownVersion),
+		}
+	}
+	diff := own.Minor - peer.Minor
+	if diff < -1 || diff > 1 {
+		return VersionSkew{
+			Compatible:  false,
+			PeerVersion: peerVersion,
+			Message:     fmt.Sprintf("peer version %v is more than one minor version away from this version %v", peerVersion, ownVersion),
+		}
+	}
+	return VersionSkew{Compatible: true, PeerVersion: peerVersion}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type VersionTestSuite struct {
+}
+
+var _ = check.Suite(&VersionTestSuite{})
+
+func (s *VersionTestSuite) TestCheckVersionSkew(c *check.C) {
+	// no version reported at all, e.g. an older peer: compatible
+	skew := CheckVersionSkew("2.2.3", "")
+	c.Assert(skew.Compatible, check.Equals, true)
+
+	// exact match
+	skew = CheckVersionSkew("2.2.3", "2.2.3")
+	c.Assert(skew.Compatible, check.Equals, true)
+
+	// one minor release behind and ahead: compatible
+	skew = CheckVersionSkew("2.2.3", "2.1.0")
+	c.Assert(skew.Compatible, check.Equals, true)
+	skew = CheckVersionSkew("2.2.3", "2.3.0")
+	c.Assert(skew.Compatible, check.Equals, true)
+
+	// two minor releases apart: incompatible
+	skew = CheckVersionSkew("2.2.3", "2.0.0")
+	c.Assert(skew.Compatible, check.Equals, false)
+
+	// different major version: incompatible
+	skew = CheckVersionSkew("2.2.3", "3.2.3")
+	c.Assert(skew.Compatible, check.Equals, false)
+
+	// unparseable peer version: treated as compatible
+	skew = CheckVersionSkew("2.2.3", "not-a-version")
+	c.Assert(skew.Compatible, check.Equals, true)
+}
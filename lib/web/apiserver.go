@@ -29,6 +29,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -65,6 +66,7 @@ type Handler struct {
 	auth                    *sessionCache
 	sites                   *ttlmap.TtlMap
 	sessionStreamPollPeriod time.Duration
+	keepAlivePeriod         time.Duration
 	clock                   clockwork.Clock
 }
 
@@ -83,6 +85,19 @@ func SetSessionStreamPollPeriod(period time.Duration) HandlerOption {
 	}
 }
 
+// SetKeepAlivePeriod sets how frequently the web terminal WebSocket sends a
+// keepalive frame to keep otherwise idle connections from being silently
+// dropped by intermediate proxies and load balancers.
+func SetKeepAlivePeriod(period time.Duration) HandlerOption {
+	return func(h *Handler) error {
+		if period < 0 {
+			return trace.BadParameter("period should be non zero")
+		}
+		h.keepAlivePeriod = period
+		return nil
+	}
+}
+
 // Config represents web handler configuration parameters
 type Config struct {
 	// Proxy is a reverse tunnel proxy that handles connections
@@ -100,6 +115,11 @@ type Config struct {
 	ProxySSHAddr utils.NetAddr
 	// ProxyWebAddr points to the web (HTTPS) address of the proxy
 	ProxyWebAddr utils.NetAddr
+	// VirtualHostConnectors maps an additional public hostname served via TLS
+	// SNI (see service.VirtualHost) to the connector its clients should
+	// authenticate against by default, overriding the cluster's global auth
+	// preference ConnectorName.
+	VirtualHostConnectors map[string]string
 }
 
 type RewritingHandler struct {
@@ -138,6 +158,10 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 		h.sessionStreamPollPeriod = sessionStreamPollPeriod
 	}
 
+	if h.keepAlivePeriod == 0 {
+		h.keepAlivePeriod = defaults.WebSocketKeepAlivePeriod
+	}
+
 	if h.clock == nil {
 		h.clock = clockwork.NewRealClock()
 	}
@@ -149,6 +173,11 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 	h.GET("/webapi/ping", httplib.MakeHandler(h.ping))
 	h.GET("/webapi/ping/:connector", httplib.MakeHandler(h.pingWithConnector))
 
+	// stable, unauthenticated CA export endpoints so OpenSSH interop can be
+	// set up with curl instead of copy-pasting `tctl auth export` output
+	h.GET("/webapi/host_ca", httplib.MakeHandler(h.hostCAKnownHosts))
+	h.GET("/webapi/user_ca", httplib.MakeHandler(h.userCATrustedKeys))
+
 	// Web sessions
 	h.POST("/webapi/sessions", httplib.MakeHandler(h.createSession))
 	h.DELETE("/webapi/sessions", h.WithAuth(h.deleteSession))
@@ -173,12 +202,17 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 	h.GET("/webapi/sites/:site/namespaces/:namespace/nodes", h.WithClusterAuth(h.getSiteNodes))
 	// connect to node via websocket (that's why it's a GET method)
 	h.GET("/webapi/sites/:site/namespaces/:namespace/connect", h.WithClusterAuth(h.siteNodeConnect))
+	// attach a web terminal to an already active session, so a supervisor
+	// can shadow it from the browser
+	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid/join", h.WithClusterAuth(h.siteSessionJoin))
 	// get session event stream
 	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid/events/stream", h.WithClusterAuth(h.siteSessionStream))
 	// generate a new session
 	h.POST("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionGenerate))
 	// update session parameters
 	h.PUT("/webapi/sites/:site/namespaces/:namespace/sessions/:sid", h.WithClusterAuth(h.siteSessionUpdate))
+	// grant or revoke a participant's write access to a shared session
+	h.PUT("/webapi/sites/:site/namespaces/:namespace/sessions/:sid/parties/:party", h.WithClusterAuth(h.siteSessionPartyUpdate))
 	// get the session list
 	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionsGet))
 	// get a session
@@ -189,6 +223,10 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid/stream", h.siteSessionStreamGet)
 	// search site events
 	h.GET("/webapi/sites/:site/events", h.WithClusterAuth(h.siteEventsGet))
+	// per type/day event counts, for the audit log overview chart
+	h.GET("/webapi/sites/:site/events/counts", h.WithClusterAuth(h.siteEventCountsGet))
+	// most active users by event count, for the audit log overview
+	h.GET("/webapi/sites/:site/events/topusers", h.WithClusterAuth(h.siteEventTopUsersGet))
 
 	// OIDC related callback handlers
 	h.GET("/webapi/oidc/login/web", httplib.MakeHandler(h.oidcLoginWeb))
@@ -214,6 +252,9 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 	h.GET("/webapi/user/status", h.WithAuth(h.getUserStatus))
 	h.GET("/webapi/user/context", h.WithAuth(h.getUserContext))
 
+	// cluster alerts (planned maintenance notices, security advisories)
+	h.GET("/webapi/clusteralerts", h.WithAuth(h.getClusterAlerts))
+
 	// if Web UI is enabled, check the assets dir:
 	var (
 		writeSettings http.HandlerFunc
@@ -314,7 +355,6 @@ func (m *Handler) getUserStatus(w http.ResponseWriter, r *http.Request, _ httpro
 // getUserContext returns user context
 //
 // GET /webapi/user/context
-//
 func (m *Handler) getUserContext(w http.ResponseWriter, r *http.Request, _ httprouter.Params, c *SessionContext) (interface{}, error) {
 	clt, err := c.GetClient()
 	if err != nil {
@@ -339,6 +379,25 @@ func (m *Handler) getUserContext(w http.ResponseWriter, r *http.Request, _ httpr
 	return userContext, nil
 }
 
+// getClusterAlerts returns the cluster alerts the logged-in user hasn't
+// already acknowledged and that haven't expired, e.g. a planned
+// maintenance notice or a security advisory.
+//
+// GET /webapi/clusteralerts
+func (m *Handler) getClusterAlerts(w http.ResponseWriter, r *http.Request, _ httprouter.Params, c *SessionContext) (interface{}, error) {
+	clt, err := c.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	alerts, err := clt.GetActiveClusterAlerts()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return alerts, nil
+}
+
 func localSettings(authClient auth.ClientI, cap services.AuthPreference) (client.AuthenticationSettings, error) {
 	as := client.AuthenticationSettings{
 		Type:         teleport.Local,
@@ -378,12 +437,27 @@ func samlSettings(connector services.SAMLConnector) client.AuthenticationSetting
 	}
 }
 
-func defaultAuthenticationSettings(authClient auth.ClientI) (client.AuthenticationSettings, error) {
+// connectorForHost returns the default connector name configured for the
+// virtual host the request arrived on (see Config.VirtualHostConnectors), or
+// "" if host isn't a known virtual host or has no override configured.
+func (m *Handler) connectorForHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	return m.cfg.VirtualHostConnectors[host]
+}
+
+func defaultAuthenticationSettings(authClient auth.ClientI, connectorOverride string) (client.AuthenticationSettings, error) {
 	cap, err := authClient.GetAuthPreference()
 	if err != nil {
 		return client.AuthenticationSettings{}, trace.Wrap(err)
 	}
 
+	connectorName := cap.GetConnectorName()
+	if connectorOverride != "" {
+		connectorName = connectorOverride
+	}
+
 	var as client.AuthenticationSettings
 
 	switch cap.GetType() {
@@ -393,8 +467,8 @@ func defaultAuthenticationSettings(authClient auth.ClientI) (client.Authenticati
 			return client.AuthenticationSettings{}, trace.Wrap(err)
 		}
 	case teleport.OIDC:
-		if cap.GetConnectorName() != "" {
-			oidcConnector, err := authClient.GetOIDCConnector(cap.GetConnectorName(), false)
+		if connectorName != "" {
+			oidcConnector, err := authClient.GetOIDCConnector(connectorName, false)
 			if err != nil {
 				return client.AuthenticationSettings{}, trace.Wrap(err)
 			}
@@ -412,8 +486,8 @@ func defaultAuthenticationSettings(authClient auth.ClientI) (client.Authenticati
 			as = oidcSettings(oidcConnectors[0])
 		}
 	case teleport.SAML:
-		if cap.GetConnectorName() != "" {
-			samlConnector, err := authClient.GetSAMLConnector(cap.GetConnectorName(), false)
+		if connectorName != "" {
+			samlConnector, err := authClient.GetSAMLConnector(connectorName, false)
 			if err != nil {
 				return client.AuthenticationSettings{}, trace.Wrap(err)
 			}
@@ -434,23 +508,41 @@ func defaultAuthenticationSettings(authClient auth.ClientI) (client.Authenticati
 		return client.AuthenticationSettings{}, trace.BadParameter("unknown type %v", cap.GetType())
 	}
 
+	// local login always applies to Type: local; for Type: oidc/saml it is
+	// only advertised if the cluster auth preference allows it, e.g. for
+	// break-glass admin accounts to use if the identity provider is down.
+	if cap.GetType() != teleport.Local {
+		as.LocalAuthEnabled = cap.GetAllowLocalAuth()
+	}
+
 	return as, nil
 }
 
 func (m *Handler) ping(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var err error
 
-	defaultSettings, err := defaultAuthenticationSettings(m.cfg.ProxyClient)
+	defaultSettings, err := defaultAuthenticationSettings(m.cfg.ProxyClient, m.connectorForHost(r.Host))
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
 	return client.PingResponse{
-		Auth:          defaultSettings,
-		ServerVersion: teleport.Version,
+		Auth:            defaultSettings,
+		ServerVersion:   teleport.Version,
+		MessageOfTheDay: messageOfTheDay(m.cfg.ProxyClient),
 	}, nil
 }
 
+// messageOfTheDay returns the cluster's configured pre-login banner, or an
+// empty string if the auth preference can't be fetched or none is set.
+func messageOfTheDay(authClient auth.ClientI) string {
+	cap, err := authClient.GetAuthPreference()
+	if err != nil {
+		return ""
+	}
+	return cap.GetMessageOfTheDay()
+}
+
 func (m *Handler) pingWithConnector(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	authClient := m.cfg.ProxyClient
 	connectorName := p.ByName("connector")
@@ -467,8 +559,9 @@ func (m *Handler) pingWithConnector(w http.ResponseWriter, r *http.Request, p ht
 		}
 
 		return &client.PingResponse{
-			Auth:          as,
-			ServerVersion: teleport.Version,
+			Auth:            as,
+			ServerVersion:   teleport.Version,
+			MessageOfTheDay: messageOfTheDay(authClient),
 		}, nil
 	}
 
@@ -476,8 +569,9 @@ func (m *Handler) pingWithConnector(w http.ResponseWriter, r *http.Request, p ht
 	oidcConnector, err := authClient.GetOIDCConnector(connectorName, false)
 	if err == nil {
 		return &client.PingResponse{
-			Auth:          oidcSettings(oidcConnector),
-			ServerVersion: teleport.Version,
+			Auth:            oidcSettings(oidcConnector),
+			ServerVersion:   teleport.Version,
+			MessageOfTheDay: messageOfTheDay(authClient),
 		}, nil
 	}
 
@@ -485,8 +579,9 @@ func (m *Handler) pingWithConnector(w http.ResponseWriter, r *http.Request, p ht
 	samlConnector, err := authClient.GetSAMLConnector(connectorName, false)
 	if err == nil {
 		return &client.PingResponse{
-			Auth:          samlSettings(samlConnector),
-			ServerVersion: teleport.Version,
+			Auth:            samlSettings(samlConnector),
+			ServerVersion:   teleport.Version,
+			MessageOfTheDay: messageOfTheDay(authClient),
 		}, nil
 	}
 
@@ -503,7 +598,7 @@ type webConfig struct {
 
 // getConfigurationSettings returns configuration for the web application.
 func (m *Handler) getConfigurationSettings(w http.ResponseWriter, r *http.Request) (interface{}, error) {
-	as, err := defaultAuthenticationSettings(m.cfg.ProxyClient)
+	as, err := defaultAuthenticationSettings(m.cfg.ProxyClient, m.connectorForHost(r.Host))
 	if err != nil {
 		log.Infof("Cannot retrieve cluster auth preferences: %v", err)
 	}
@@ -557,7 +652,7 @@ func (m *Handler) oidcLoginConsole(w http.ResponseWriter, r *http.Request, p htt
 	if req.RedirectURL == "" {
 		return nil, trace.BadParameter("missing RedirectURL")
 	}
-	if len(req.PublicKey) == 0 {
+	if len(req.PublicKey) == 0 && !req.SSOTestFlow {
 		return nil, trace.BadParameter("missing PublicKey")
 	}
 	if req.ConnectorID == "" {
@@ -569,8 +664,9 @@ func (m *Handler) oidcLoginConsole(w http.ResponseWriter, r *http.Request, p htt
 			ClientRedirectURL: req.RedirectURL,
 			PublicKey:         req.PublicKey,
 			CertTTL:           req.CertTTL,
-			CheckUser:         true,
+			CheckUser:         !req.SSOTestFlow,
 			Compatibility:     req.Compatibility,
+			SSOTestFlow:       req.SSOTestFlow,
 		})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -603,7 +699,7 @@ func (m *Handler) oidcCallback(w http.ResponseWriter, r *http.Request, p httprou
 		return nil, nil
 	}
 	log.Infof("oidcCallback redirecting to console login")
-	if len(response.Req.PublicKey) == 0 {
+	if len(response.Req.PublicKey) == 0 && !response.Req.SSOTestFlow {
 		return nil, trace.BadParameter("not a web or console oidc login request")
 	}
 	redirectURL, err := ConstructSSHResponse(AuthParams{
@@ -613,6 +709,7 @@ func (m *Handler) oidcCallback(w http.ResponseWriter, r *http.Request, p httprou
 		Session:           response.Session,
 		Cert:              response.Cert,
 		HostSigners:       response.HostSigners,
+		TestFlowResult:    response.TestFlowResult,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -637,6 +734,9 @@ type AuthParams struct {
 	HostSigners []services.CertAuthority
 	// ClientRedirectURL is a URL to redirect client to
 	ClientRedirectURL string
+	// TestFlowResult is set instead of Cert/Session for a `tctl sso test`
+	// dry run login.
+	TestFlowResult *services.SSOTestFlowResult
 }
 
 // ConstructSSHResponse creates a special SSH response for SSH login method
@@ -651,9 +751,10 @@ func ConstructSSHResponse(response AuthParams) (*url.URL, error) {
 		return nil, trace.Wrap(err)
 	}
 	consoleResponse := client.SSHLoginResponse{
-		Username:    response.Username,
-		Cert:        response.Cert,
-		HostSigners: signers,
+		Username:       response.Username,
+		Cert:           response.Cert,
+		HostSigners:    signers,
+		TestFlowResult: response.TestFlowResult,
 	}
 	out, err := json.Marshal(consoleResponse)
 	if err != nil {
@@ -754,10 +855,9 @@ func NewSessionResponse(ctx *SessionContext) (*CreateSessionResponse, error) {
 //
 // {"user": "alex", "pass": "abc123", "second_factor_token": "token", "second_factor_type": "totp"}
 //
-// Response
+// # Response
 //
 // {"type": "bearer", "token": "bearer token", "user": {"name": "alex", "allowed_logins": ["admin", "bob"]}, "expires_in": 20}
-//
 func (m *Handler) createSession(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *createSessionReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -805,7 +905,6 @@ func (m *Handler) createSession(w http.ResponseWriter, r *http.Request, p httpro
 // Response:
 //
 // {"message": "ok"}
-//
 func (m *Handler) deleteSession(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx *SessionContext) (interface{}, error) {
 	if err := ctx.Invalidate(); err != nil {
 		return nil, trace.Wrap(err)
@@ -822,11 +921,9 @@ func (m *Handler) deleteSession(w http.ResponseWriter, r *http.Request, _ httpro
 //
 // POST /v1/webapi/sessions/renew
 //
-// Response
+// # Response
 //
 // {"type": "bearer", "token": "bearer token", "user": {"name": "alex", "allowed_logins": ["admin", "bob"]}, "expires_in": 20}
-//
-//
 func (m *Handler) renewSession(w http.ResponseWriter, r *http.Request, _ httprouter.Params, ctx *SessionContext) (interface{}, error) {
 	newSess, err := ctx.ExtendWebSession()
 	if err != nil {
@@ -858,8 +955,6 @@ type renderUserInviteResponse struct {
 // Response:
 //
 // {"invite_token": "token", "user": "alex", qr: "base64-encoded-qr-code image"}
-//
-//
 func (m *Handler) renderUserInvite(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	token := p[0].Value
 	user, qrCodeBytes, err := m.auth.GetUserInviteInfo(token)
@@ -881,7 +976,6 @@ func (m *Handler) renderUserInvite(w http.ResponseWriter, r *http.Request, p htt
 // Response:
 //
 // {"version":"U2F_V2","challenge":"randombase64string","appId":"https://mycorp.com:3080"}
-//
 func (m *Handler) u2fRegisterRequest(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	token := p[0].Value
 	u2fRegisterRequest, err := m.auth.GetUserInviteU2FRegisterRequest(token)
@@ -901,7 +995,6 @@ func (m *Handler) u2fRegisterRequest(w http.ResponseWriter, r *http.Request, p h
 // Successful response:
 //
 // {"version":"U2F_V2","challenge":"randombase64string","keyHandle":"longbase64string","appId":"https://mycorp.com:3080"}
-//
 func (m *Handler) u2fSignRequest(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *client.U2fSignRequestReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -930,7 +1023,6 @@ type u2fSignResponseReq struct {
 // Successful response:
 //
 // {"type": "bearer", "token": "bearer token", "user": {"name": "alex", "allowed_logins": ["admin", "bob"]}, "expires_in": 20}
-//
 func (m *Handler) createSessionWithU2FSignResponse(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *u2fSignResponseReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1050,7 +1142,6 @@ func convertSites(rs []reversetunnel.RemoteSite) []site {
 // Sucessful response:
 //
 // {"sites": {"name": "localhost", "last_connected": "RFC3339 time", "status": "active"}}
-//
 func (m *Handler) getSites(w http.ResponseWriter, r *http.Request, _ httprouter.Params, c *SessionContext) (interface{}, error) {
 	return getSitesResponse{
 		Sites: convertSites(m.cfg.Proxy.GetSites()),
@@ -1061,7 +1152,8 @@ type getSiteNamespacesResponse struct {
 	Namespaces []services.Namespace `json:"namespaces"`
 }
 
-/* getSiteNamespaces returns a list of namespaces for a given site
+/*
+	getSiteNamespaces returns a list of namespaces for a given site
 
 GET /v1/webapi/namespaces/:namespace/sites/:site/nodes
 
@@ -1091,40 +1183,100 @@ type nodeWithSessions struct {
 
 type getSiteNodesResponse struct {
 	Nodes []nodeWithSessions `json:"nodes"`
+	// TotalCount is the number of nodes that matched the request's filters,
+	// before limit/offset were applied. The UI uses it to render pagination
+	// controls without having to fetch every page first.
+	TotalCount int `json:"totalCount"`
+}
+
+// defaultNodeListLimit caps how many nodes a single "GET .../nodes" request
+// returns when the caller doesn't specify a "limit", so a browser never gets
+// asked to render (or a proxy to ship) the entire node list of a large
+// cluster in one response.
+const defaultNodeListLimit = 100
+
+// parseNodeListQuery pulls the pagination and filter parameters recognized
+// by getSiteNodes out of the request's query string.
+func parseNodeListQuery(q url.Values) (limit int, offset int, search string, labels map[string]string, err error) {
+	limit = defaultNodeListLimit
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return 0, 0, "", nil, trace.BadParameter("limit: invalid value %q", v)
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, "", nil, trace.BadParameter("offset: invalid value %q", v)
+		}
+	}
+	search = strings.ToLower(strings.TrimSpace(q.Get("search")))
+	if labelValues, ok := q["label"]; ok {
+		labels = make(map[string]string, len(labelValues))
+		for _, kv := range labelValues {
+			parts := strings.SplitN(kv, ":", 2)
+			if len(parts) != 2 {
+				return 0, 0, "", nil, trace.BadParameter("label: expected key:value, got %q", kv)
+			}
+			labels[parts[0]] = parts[1]
+		}
+	}
+	return limit, offset, search, labels, nil
+}
+
+// matchesNodeFilter reports whether server's hostname contains search (a
+// case-insensitive substring match; an empty search matches everything) and
+// all of labels are present among the server's static and dynamic labels.
+func matchesNodeFilter(server services.Server, search string, labels map[string]string) bool {
+	if search != "" && !strings.Contains(strings.ToLower(server.GetHostname()), search) {
+		return false
+	}
+	if len(labels) > 0 {
+		serverLabels := server.GetAllLabels()
+		for k, v := range labels {
+			if serverLabels[k] != v {
+				return false
+			}
+		}
+	}
+	return true
 }
 
-/* getSiteNodes returns a list of nodes active in the site
+/*
+	getSiteNodes returns a list of nodes active in the site
 
 GET /v1/webapi/namespaces/:namespace/sites/:site/nodes
 
 Sucessful response:
 
 {"nodes": [
-  {
-    "node": {
-        "addr": "ip:port",
-        "hostname": "a.example.com",
-        "labels": {"role": "mysql"}, // static key value pairs set by user for every node
-        "cmd_labels": {
-            "db_status": {
-               "command": "mysql -c status", // command periodically executed on server
-               "result": "master",  // output of the command
-               "period": 1000000000 // microseconds between calls
-             }
-        }
-     },
-     "sessions": [{
-         "id": "unique session id",
-         "parties": [{ // parties is a list of currently active participants
-            "id": "party id",
-            "user": "alice", // teleport user
-            "server_addr": "127.0.0.1:3000",
-            "last_active": "time" // RFC3339 timestamp when user was last acive
-         }]
-     }]
-   }
-  ]
-}
+
+	  {
+	    "node": {
+	        "addr": "ip:port",
+	        "hostname": "a.example.com",
+	        "labels": {"role": "mysql"}, // static key value pairs set by user for every node
+	        "cmd_labels": {
+	            "db_status": {
+	               "command": "mysql -c status", // command periodically executed on server
+	               "result": "master",  // output of the command
+	               "period": 1000000000 // microseconds between calls
+	             }
+	        }
+	     },
+	     "sessions": [{
+	         "id": "unique session id",
+	         "parties": [{ // parties is a list of currently active participants
+	            "id": "party id",
+	            "user": "alice", // teleport user
+	            "server_addr": "127.0.0.1:3000",
+	            "last_active": "time" // RFC3339 timestamp when user was last acive
+	         }]
+	     }]
+	   }
+	  ]
+	}
 */
 func (m *Handler) getSiteNodes(w http.ResponseWriter, r *http.Request, p httprouter.Params, c *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	log.Debugf("[web] GET /nodes")
@@ -1136,6 +1288,12 @@ func (m *Handler) getSiteNodes(w http.ResponseWriter, r *http.Request, p httprou
 	if !services.IsValidNamespace(namespace) {
 		return nil, trace.BadParameter("invalid namespace %q", namespace)
 	}
+	limit, offset, search, labels, err := parseNodeListQuery(r.URL.Query())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// RBAC is enforced by clt, an API client scoped to the caller's roles:
+	// GetNodes only ever returns nodes the logged in user is allowed to see.
 	servers, err := clt.GetNodes(namespace)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -1144,9 +1302,28 @@ func (m *Handler) getSiteNodes(w http.ResponseWriter, r *http.Request, p httprou
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	nodeMap := make(map[string]*nodeWithSessions, len(servers))
-	for i := range servers {
-		nodeMap[servers[i].GetName()] = &nodeWithSessions{Node: *servers[i].V1()}
+
+	matched := make([]services.Server, 0, len(servers))
+	for _, server := range servers {
+		if matchesNodeFilter(server, search, labels) {
+			matched = append(matched, server)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].GetHostname() < matched[j].GetHostname()
+	})
+	totalCount := len(matched)
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if limit < len(matched) {
+		matched = matched[:limit]
+	}
+
+	nodeMap := make(map[string]*nodeWithSessions, len(matched))
+	for _, server := range matched {
+		nodeMap[server.GetName()] = &nodeWithSessions{Node: *server.V1()}
 	}
 	for i := range sessions {
 		sess := sessions[i]
@@ -1156,13 +1333,14 @@ func (m *Handler) getSiteNodes(w http.ResponseWriter, r *http.Request, p httprou
 			}
 		}
 	}
-	nodes := make([]nodeWithSessions, 0, len(nodeMap))
-	for key := range nodeMap {
-		nodes = append(nodes, *nodeMap[key])
+	nodes := make([]nodeWithSessions, 0, len(matched))
+	for _, server := range matched {
+		nodes = append(nodes, *nodeMap[server.GetName()])
 	}
 
 	return getSiteNodesResponse{
-		Nodes: nodes,
+		Nodes:      nodes,
+		TotalCount: totalCount,
 	}, nil
 }
 
@@ -1175,10 +1353,9 @@ func (m *Handler) getSiteNodes(w http.ResponseWriter, r *http.Request, p httprou
 //
 // {"server_id": "uuid", "login": "admin", "term": {"h": 120, "w": 100}, "sid": "123"}
 //
-// Session id can be empty
+// # Session id can be empty
 //
 // Sucessful response is a websocket stream that allows read write to the server
-//
 func (m *Handler) siteNodeConnect(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -1213,7 +1390,7 @@ func (m *Handler) siteNodeConnect(
 		return nil, trace.Wrap(err)
 	}
 
-	term, err := newTerminal(*req, clt, ctx)
+	term, err := newTerminal(*req, clt, ctx, m.keepAlivePeriod)
 	if err != nil {
 		log.Errorf("[WEB] Unable to create terminal: %v", err)
 		return nil, trace.Wrap(err)
@@ -1226,6 +1403,90 @@ func (m *Handler) siteNodeConnect(
 	return nil, nil
 }
 
+// siteSessionJoin attaches a web-based terminal to an already active
+// session, so a supervisor can shadow it from the browser rather than
+// typing 'tsh join <sid>' from a terminal.
+//
+// GET /v1/webapi/sites/:site/namespaces/:namespace/sessions/:sid/join?access_token=bearer_token
+//
+// Sucessful response is a websocket stream that allows read write to the
+// server and returns json events
+func (m *Handler) siteSessionJoin(
+	w http.ResponseWriter,
+	r *http.Request,
+	p httprouter.Params,
+	ctx *SessionContext,
+	site reversetunnel.RemoteSite) (interface{}, error) {
+
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+	sessionID, err := session.ParseID(p.ByName("sid"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	clt, err := ctx.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	sess, err := clt.GetSession(namespace, *sessionID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !sess.Active {
+		return nil, trace.BadParameter("session %v is not active", sessionID)
+	}
+
+	readonly, err := isSessionObserver(clt, ctx.GetUser(), namespace)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	req := terminalRequest{
+		Server:        sess.ServerID,
+		Login:         sess.Login,
+		Term:          sess.TerminalParams,
+		SessionID:     sess.ID,
+		Namespace:     namespace,
+		ProxyHostPort: m.ProxyHostPort(),
+		Cluster:       site.GetName(),
+		Readonly:      readonly,
+	}
+
+	term, err := newTerminal(req, clt, ctx, m.keepAlivePeriod)
+	if err != nil {
+		log.Errorf("[WEB] Unable to create terminal: %v", err)
+		return nil, trace.Wrap(err)
+	}
+
+	log.Infof("[WEB] joining session %v as %v", sessionID, ctx.GetUser())
+	term.Run(w, r)
+
+	return nil, nil
+}
+
+// isSessionObserver returns true if user's roles only grant read (not
+// update) access to the session resource, meaning they can shadow an
+// active session from the web UI but not type into it. Roles with update
+// access join as full participants.
+func isSessionObserver(clt auth.ClientI, username, namespace string) (bool, error) {
+	user, err := clt.GetUser(username)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	checker, err := services.FetchRoles(user.GetRoles(), clt, user.GetTraits())
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	if err := checker.CheckAccessToRule(namespace, services.KindSession, services.VerbUpdate); err != nil {
+		return true, nil
+	}
+	return false, nil
+}
+
 // sessionStreamEvent is sent over the session stream socket, it contains
 // last events that occured (only new events are sent)
 type sessionStreamEvent struct {
@@ -1240,7 +1501,6 @@ type sessionStreamEvent struct {
 //
 // Sucessful response is a websocket stream that allows read write to the server and returns
 // json events
-//
 func (m *Handler) siteSessionStream(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	sessionID, err := session.ParseID(p.ByName("sid"))
 	if err != nil {
@@ -1288,7 +1548,6 @@ type siteSessionGenerateResponse struct {
 // Response body:
 //
 // {"session": {"id": "session-id", "terminal_params": {"w": 100, "h": 100}, "login": "centos"}}
-//
 func (m *Handler) siteSessionGenerate(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	namespace := p.ByName("namespace")
 	if !services.IsValidNamespace(namespace) {
@@ -1322,7 +1581,6 @@ type siteSessionUpdateReq struct {
 // Response body:
 //
 // {"message": "ok"}
-//
 func (m *Handler) siteSessionUpdate(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	sessionID, err := session.ParseID(p.ByName("sid"))
 	if err != nil {
@@ -1353,6 +1611,43 @@ func (m *Handler) siteSessionUpdate(w http.ResponseWriter, r *http.Request, p ht
 	return ok(), nil
 }
 
+type siteSessionPartyUpdateReq struct {
+	CanWrite bool `json:"can_write"`
+}
+
+// siteSessionPartyUpdate grants or revokes a participant's write access to
+// a session the caller owns. The node (not this handler) enforces
+// ownership: the request rides over the caller's own already-open web
+// terminal, so the node sees it come from whichever Teleport user actually
+// started that connection.
+//
+// PUT /v1/webapi/sites/:site/namespaces/:namespace/sessions/:sid/parties/:party
+//
+// Request body:
+//
+// {"can_write": false}
+//
+// Response body:
+//
+// {"message": "ok"}
+func (m *Handler) siteSessionPartyUpdate(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
+	sessionID, err := session.ParseID(p.ByName("sid"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var req *siteSessionPartyUpdateReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if err := ctx.ModerateSessionParty(*sessionID, p.ByName("party"), req.CanWrite); err != nil {
+		log.Error(err)
+		return nil, trace.Wrap(err)
+	}
+	return ok(), nil
+}
+
 type siteSessionsGetResponse struct {
 	Sessions []session.Session `json:"sessions"`
 }
@@ -1390,7 +1685,6 @@ func (m *Handler) siteSessionsGet(w http.ResponseWriter, r *http.Request, p http
 // Response body:
 //
 // {"session": {"id": "sid", "terminal_params": {"w": 100, "h": 100}, "parties": [], "login": "bob"}}
-//
 func (m *Handler) siteSessionGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	sessionID, err := session.ParseID(p.ByName("sid"))
 	if err != nil {
@@ -1421,12 +1715,12 @@ const maxStreamBytes = 5 * 1024 * 1024
 // GET /v1/webapi/sites/:site/events
 //
 // Query parameters:
-//   "from"  : date range from, encoded as RFC3339
-//   "to"    : date range to, encoded as RFC3339
-//   ...     : the rest of the query string is passed to the search back-end as-is,
-//             the default backend performs exact search: ?key=value means "event
-//             with a field 'key' with value 'value'
 //
+//	"from"  : date range from, encoded as RFC3339
+//	"to"    : date range to, encoded as RFC3339
+//	...     : the rest of the query string is passed to the search back-end as-is,
+//	          the default backend performs exact search: ?key=value means "event
+//	          with a field 'key' with value 'value'
 func (m *Handler) siteEventsGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	query := r.URL.Query()
 	log.Infof("web.getEvents(%v)", r.URL.RawQuery)
@@ -1436,33 +1730,172 @@ func (m *Handler) siteEventsGet(w http.ResponseWriter, r *http.Request, p httpro
 		log.Error(err)
 		return nil, trace.Wrap(err)
 	}
-	to := time.Now().In(time.UTC)
-	from := to.AddDate(0, -1, 0) // one month ago
+	from, to, err := parseEventsTimeRange(query)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// remove to & from fields, and pass the rest of it directly to the back-end:
+	query.Del("to")
+	query.Del("from")
+
+	el, err := clt.SearchEvents(from, to, query.Encode())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return eventsListGetResponse{Events: el}, nil
+}
+
+// parseEventsTimeRange parses the "from"/"to" query parameters shared by all
+// of the audit log endpoints, defaulting to the last month if either is
+// missing.
+func parseEventsTimeRange(query url.Values) (from, to time.Time, err error) {
+	to = time.Now().In(time.UTC)
+	from = to.AddDate(0, -1, 0) // one month ago
 
-	// parse 'to' and 'from' params:
-	fromStr := query.Get("from")
-	if fromStr != "" {
+	if fromStr := query.Get("from"); fromStr != "" {
 		from, err = time.Parse(time.RFC3339, fromStr)
 		if err != nil {
-			return nil, trace.BadParameter("from")
+			return from, to, trace.BadParameter("from")
 		}
 	}
-	toStr := query.Get("to")
-	if toStr != "" {
+	if toStr := query.Get("to"); toStr != "" {
 		to, err = time.Parse(time.RFC3339, toStr)
 		if err != nil {
-			return nil, trace.BadParameter("to")
+			return from, to, trace.BadParameter("to")
 		}
 	}
-	// remove to & from fields, and pass the rest of it directly to the back-end:
-	query.Del("to")
-	query.Del("from")
+	return from, to, nil
+}
 
-	el, err := clt.SearchEvents(from, to, query.Encode())
+// eventCountsGetResponse is the response to siteEventCountsGet: for each
+// calendar day in the requested range, the number of events seen of each
+// event type.
+type eventCountsGetResponse struct {
+	// Days is ordered oldest first.
+	Days []eventCountsForDay `json:"days"`
+}
+
+type eventCountsForDay struct {
+	// Date is the day this entry covers, formatted as "2006-01-02" in UTC.
+	Date string `json:"date"`
+	// Counts maps event type (e.g. "session.start") to how many of that
+	// event happened on Date.
+	Counts map[string]int `json:"counts"`
+}
+
+// siteEventCountsGet returns, for a date range, how many events of each
+// type happened on each day. It's the data behind the audit log's activity
+// chart, computed by aggregating clt.SearchEvents ourselves since the
+// backend only exposes flat event search.
+//
+// GET /v1/webapi/sites/:site/events/counts
+//
+// Query parameters:
+//
+//	"from" : date range from, encoded as RFC3339
+//	"to"   : date range to, encoded as RFC3339
+func (m *Handler) siteEventCountsGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
+	clt, err := site.GetClient()
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return eventsListGetResponse{Events: el}, nil
+	from, to, err := parseEventsTimeRange(r.URL.Query())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	el, err := clt.SearchEvents(from, to, "")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	byDay := make(map[string]map[string]int)
+	for _, e := range el {
+		day := e.GetTime(events.EventTime).UTC().Format("2006-01-02")
+		counts, ok := byDay[day]
+		if !ok {
+			counts = make(map[string]int)
+			byDay[day] = counts
+		}
+		counts[e.GetType()]++
+	}
+
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	out := eventCountsGetResponse{Days: make([]eventCountsForDay, 0, len(days))}
+	for _, day := range days {
+		out.Days = append(out.Days, eventCountsForDay{Date: day, Counts: byDay[day]})
+	}
+	return out, nil
+}
+
+// eventTopUsersGetResponse is the response to siteEventTopUsersGet.
+type eventTopUsersGetResponse struct {
+	// Users is ordered by EventCount, descending.
+	Users []eventCountForUser `json:"users"`
+}
+
+type eventCountForUser struct {
+	User       string `json:"user"`
+	EventCount int    `json:"eventCount"`
+}
+
+// siteEventTopUsersGet returns the users with the most events in a date
+// range, so the audit log can highlight who's been most active without
+// requiring an admin to export and grep the raw log.
+//
+// GET /v1/webapi/sites/:site/events/topusers
+//
+// Query parameters:
+//
+//	"from"  : date range from, encoded as RFC3339
+//	"to"    : date range to, encoded as RFC3339
+//	"limit" : max number of users to return, defaults to 10
+func (m *Handler) siteEventTopUsersGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
+	clt, err := site.GetClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	query := r.URL.Query()
+	from, to, err := parseEventsTimeRange(query)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	limit := 10
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, trace.BadParameter("limit: invalid value %q", v)
+		}
+	}
+	el, err := clt.SearchEvents(from, to, "")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	counts := make(map[string]int)
+	for _, e := range el {
+		if user := e.GetString(events.EventUser); user != "" {
+			counts[user]++
+		}
+	}
+	users := make([]eventCountForUser, 0, len(counts))
+	for user, count := range counts {
+		users = append(users, eventCountForUser{User: user, EventCount: count})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].EventCount != users[j].EventCount {
+			return users[i].EventCount > users[j].EventCount
+		}
+		return users[i].User < users[j].User
+	})
+	if limit < len(users) {
+		users = users[:limit]
+	}
+	return eventTopUsersGetResponse{Users: users}, nil
 }
 
 type siteSessionStreamGetResponse struct {
@@ -1474,8 +1907,9 @@ type siteSessionStreamGetResponse struct {
 // GET /v1/webapi/sites/:site/namespaces/:namespace/sessions/:sid/stream?query
 //
 // Query parameters:
-//   "offset"   : bytes from the beginning
-//   "bytes"    : number of bytes to read (it won't return more than 512Kb)
+//
+//	"offset"   : bytes from the beginning
+//	"bytes"    : number of bytes to read (it won't return more than 512Kb)
 //
 // Unlike other request handlers, this one does not return JSON.
 // It returns the binary stream unencoded, directly in the respose body,
@@ -1574,13 +2008,13 @@ type eventsListGetResponse struct {
 // GET /v1/webapi/sites/:site/namespaces/:namespace/sessions/:sid/events?after=N
 //
 // Query:
-//    "after" : cursor value of an event to return "newer than" events
-//              good for repeated polling
+//
+//	"after" : cursor value of an event to return "newer than" events
+//	          good for repeated polling
 //
 // Response body (each event is an arbitrary JSON structure)
 //
 // {"events": [{...}, {...}, ...}
-//
 func (m *Handler) siteSessionEventsGet(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
 	sessionID, err := session.ParseID(p.ByName("sid"))
 	if err != nil {
@@ -1612,10 +2046,9 @@ func (m *Handler) siteSessionEventsGet(w http.ResponseWriter, r *http.Request, p
 //
 // { "user": "bob", "password": "pass", "otp_token": "tok", "pub_key": "key to sign", "ttl": 1000000000 }
 //
-// Success response
+// # Success response
 //
 // { "cert": "base64 encoded signed cert", "host_signers": [{"domain_name": "example.com", "checking_keys": ["base64 encoded public signing key"]}] }
-//
 func (h *Handler) createSSHCert(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *client.CreateSSHCertReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1656,10 +2089,9 @@ func (h *Handler) createSSHCert(w http.ResponseWriter, r *http.Request, p httpro
 //
 // { "user": "bob", "password": "pass", "u2f_sign_response": { "signatureData": "signatureinbase64", "clientData": "verylongbase64string", "challenge": "randombase64string" }, "pub_key": "key to sign", "ttl": 1000000000 }
 //
-// Success response
+// # Success response
 //
 // { "cert": "base64 encoded signed cert", "host_signers": [{"domain_name": "example.com", "checking_keys": ["base64 encoded public signing key"]}] }
-//
 func (h *Handler) createSSHCertWithU2FSignResponse(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var req *client.CreateSSHCertWithU2FReq
 	if err := httplib.ReadJSON(r, &req); err != nil {
@@ -1679,16 +2111,16 @@ func (h *Handler) createSSHCertWithU2FSignResponse(w http.ResponseWriter, r *htt
 //
 // * Request body:
 //
-// {
-//     "token": "foo",
-//     "certificate_authorities": ["AQ==", "Ag=="]
-// }
+//	{
+//	    "token": "foo",
+//	    "certificate_authorities": ["AQ==", "Ag=="]
+//	}
 //
 // * Response:
 //
-// {
-//     "certificate_authorities": ["AQ==", "Ag=="]
-// }
+//	{
+//	    "certificate_authorities": ["AQ==", "Ag=="]
+//	}
 func (h *Handler) validateTrustedCluster(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
 	var validateRequestRaw auth.ValidateTrustedClusterRequestRaw
 	if err := httplib.ReadJSON(r, &validateRequestRaw); err != nil {
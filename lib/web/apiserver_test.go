@@ -272,6 +272,18 @@ func (s *WebSuite) SetUpTest(c *C) {
 
 	c.Assert(s.node.Start(), IsNil)
 
+	// the node lands in ServerStatePending until approved; approve it so
+	// tests can open sessions to it right away. The node's first heartbeat
+	// is async, so retry until it's actually registered.
+	var approveErr error
+	for i := 0; i < 10; i++ {
+		if approveErr = s.authServer.ApproveNode(defaults.Namespace, s.srvID); approveErr == nil {
+			break
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	c.Assert(approveErr, IsNil)
+
 	// create reverse tunnel service:
 	revTunServer, err := reversetunnel.NewServer(
 		utils.NetAddr{
@@ -761,6 +773,42 @@ func (s *WebSuite) TestGetSiteNodes(c *C) {
 	c.Assert(nodes2, DeepEquals, nodes)
 }
 
+func (s *WebSuite) TestGetSiteNodesPaginationAndFilters(c *C) {
+	pack := s.authPack(c)
+	endpoint := pack.clt.Endpoint("webapi", "sites", s.domainName, "nodes")
+
+	// a limit of zero nodes still returns the total count
+	re, err := pack.clt.Get(endpoint, url.Values{"limit": []string{"0"}})
+	c.Assert(err, NotNil)
+
+	// limit that comfortably fits the one node in the test cluster
+	re, err = pack.clt.Get(endpoint, url.Values{"limit": []string{"10"}})
+	c.Assert(err, IsNil)
+	var nodes *getSiteNodesResponse
+	c.Assert(json.Unmarshal(re.Bytes(), &nodes), IsNil)
+	c.Assert(nodes.TotalCount, Equals, 1)
+	c.Assert(len(nodes.Nodes), Equals, 1)
+
+	// offset past the end of the (filtered) results returns an empty page
+	// but the correct total count
+	re, err = pack.clt.Get(endpoint, url.Values{"offset": []string{"1"}})
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(re.Bytes(), &nodes), IsNil)
+	c.Assert(nodes.TotalCount, Equals, 1)
+	c.Assert(len(nodes.Nodes), Equals, 0)
+
+	// a search that can't match any hostname returns no nodes
+	re, err = pack.clt.Get(endpoint, url.Values{"search": []string{"no-such-hostname"}})
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(re.Bytes(), &nodes), IsNil)
+	c.Assert(nodes.TotalCount, Equals, 0)
+	c.Assert(len(nodes.Nodes), Equals, 0)
+
+	// a malformed label filter is rejected
+	_, err = pack.clt.Get(endpoint, url.Values{"label": []string{"not-a-key-value-pair"}})
+	c.Assert(err, NotNil)
+}
+
 func (s *WebSuite) TestSiteNodeConnectInvalidSessionID(c *C) {
 	_, err := s.makeTerminal(s.authPack(c), session.ID("/../../../foo"))
 	c.Assert(err, NotNil)
@@ -1342,6 +1390,6 @@ func (s *WebSuite) makeTerminalHandler(login string, server string, v2Servers []
 		}
 
 		return servers
-	}), nil)
+	}), nil, 0)
 
 }
@@ -0,0 +1,98 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"github.com/julienschmidt/httprouter"
+)
+
+// authoritiesForCluster returns this proxy's known certificate authorities
+// of caType, optionally filtered down to a single cluster name via the
+// "cluster" query parameter. With no filter, every known cluster's CA of
+// that type is returned (the local cluster plus any trusted clusters).
+func (m *Handler) authoritiesForCluster(r *http.Request, caType services.CertAuthType) ([]services.CertAuthority, error) {
+	cas, err := m.cfg.ProxyClient.GetCertAuthorities(caType, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	clusterName := r.URL.Query().Get("cluster")
+	if clusterName == "" {
+		return cas, nil
+	}
+	var filtered []services.CertAuthority
+	for _, ca := range cas {
+		if ca.GetClusterName() == clusterName {
+			filtered = append(filtered, ca)
+		}
+	}
+	return filtered, nil
+}
+
+// hostCAKnownHosts serves this proxy's host certificate authorities in
+// OpenSSH known_hosts format, so an operator can automate:
+//
+//    curl https://proxy:3080/webapi/host_ca >> ~/.ssh/known_hosts
+//
+// instead of copy-pasting the output of `tctl auth export --type=host` from
+// the auth server. It's unauthenticated, like /webapi/ping: a cluster's
+// host CA is a public key, no different from what it already hands out to
+// every SSH client it talks to during the host key handshake.
+func (m *Handler) hostCAKnownHosts(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
+	cas, err := m.authoritiesForCluster(r, services.HostCA)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	for _, ca := range cas {
+		for _, keyBytes := range ca.GetCheckingKeys() {
+			fmt.Fprintf(w, "@cert-authority *.%s %s\n", ca.GetClusterName(), strings.TrimSpace(string(keyBytes)))
+		}
+	}
+	return nil, nil
+}
+
+// userCATrustedKeys serves this proxy's user certificate authorities in
+// the raw, one-key-per-line format sshd's TrustedUserCAKeys directive
+// expects, so a node can be told to accept Teleport-issued user
+// certificates for host-based auth without ever registering with the
+// cluster as a Teleport node:
+//
+//    curl https://proxy:3080/webapi/user_ca -o /etc/ssh/teleport_user_ca
+//    echo "TrustedUserCAKeys /etc/ssh/teleport_user_ca" >> /etc/ssh/sshd_config
+//
+// Unauthenticated for the same reason as hostCAKnownHosts: it's a public
+// key a verifier is meant to have in advance, not a secret.
+func (m *Handler) userCATrustedKeys(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
+	cas, err := m.authoritiesForCluster(r, services.UserCA)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	for _, ca := range cas {
+		for _, keyBytes := range ca.GetCheckingKeys() {
+			fmt.Fprintf(w, "%s\n", strings.TrimSpace(string(keyBytes)))
+		}
+	}
+	return nil, nil
+}
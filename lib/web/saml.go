@@ -49,7 +49,7 @@ func (m *Handler) samlSSOConsole(w http.ResponseWriter, r *http.Request, p httpr
 	if req.RedirectURL == "" {
 		return nil, trace.BadParameter("missing RedirectURL")
 	}
-	if len(req.PublicKey) == 0 {
+	if len(req.PublicKey) == 0 && !req.SSOTestFlow {
 		return nil, trace.BadParameter("missing PublicKey")
 	}
 	if req.ConnectorID == "" {
@@ -62,6 +62,7 @@ func (m *Handler) samlSSOConsole(w http.ResponseWriter, r *http.Request, p httpr
 			PublicKey:         req.PublicKey,
 			CertTTL:           req.CertTTL,
 			Compatibility:     req.Compatibility,
+			SSOTestFlow:       req.SSOTestFlow,
 		})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -100,7 +101,7 @@ func (m *Handler) samlACS(w http.ResponseWriter, r *http.Request, p httprouter.P
 		return nil, nil
 	}
 	l.Debugf("samlCallback redirecting to console login")
-	if len(response.Req.PublicKey) == 0 {
+	if len(response.Req.PublicKey) == 0 && !response.Req.SSOTestFlow {
 		return nil, trace.BadParameter("not a web or console oidc login request")
 	}
 	redirectURL, err := ConstructSSHResponse(AuthParams{
@@ -110,6 +111,7 @@ func (m *Handler) samlACS(w http.ResponseWriter, r *http.Request, p httprouter.P
 		Session:           response.Session,
 		Cert:              response.Cert,
 		HostSigners:       response.HostSigners,
+		TestFlowResult:    response.TestFlowResult,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -88,6 +88,16 @@ func (c *SessionContext) UpdateSessionTerminal(
 	return trace.Wrap(term.resizePTYWindow(params))
 }
 
+// ModerateSessionParty grants or revokes partyID's write access to an
+// active session, on behalf of that session's owner.
+func (c *SessionContext) ModerateSessionParty(sessionID session.ID, partyID string, canWrite bool) error {
+	term, err := c.getTerminal(sessionID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(term.moderateSession(partyID, canWrite))
+}
+
 func (c *SessionContext) AddClosers(closers ...io.Closer) {
 	c.Lock()
 	defer c.Unlock()
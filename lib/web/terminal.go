@@ -23,6 +23,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/client"
@@ -55,6 +56,10 @@ type terminalRequest struct {
 	ProxyHostPort string `json:"-"`
 	// Remote cluster name
 	Cluster string `json:"-"`
+	// Readonly, when joining an active session, attaches this terminal as
+	// an observer: output is streamed as usual but keystrokes typed into
+	// the browser are discarded rather than forwarded to the session.
+	Readonly bool `json:"-"`
 }
 
 type nodeProvider interface {
@@ -63,7 +68,7 @@ type nodeProvider interface {
 
 // newTerminal creates a web-based terminal based on WebSockets and returns a new
 // terminalHandler
-func newTerminal(req terminalRequest, provider nodeProvider, ctx *SessionContext) (*terminalHandler, error) {
+func newTerminal(req terminalRequest, provider nodeProvider, ctx *SessionContext, keepAlivePeriod time.Duration) (*terminalHandler, error) {
 	// make sure whatever session is requested is a valid session
 	_, err := session.ParseID(string(req.SessionID))
 	if err != nil {
@@ -114,10 +119,11 @@ func newTerminal(req terminalRequest, provider nodeProvider, ctx *SessionContext
 	}
 
 	return &terminalHandler{
-		params:   req,
-		ctx:      ctx,
-		hostName: hostName,
-		hostPort: hostPort,
+		params:          req,
+		ctx:             ctx,
+		hostName:        hostName,
+		hostPort:        hostPort,
+		keepAlivePeriod: keepAlivePeriod,
 	}, nil
 }
 
@@ -136,6 +142,10 @@ type terminalHandler struct {
 	hostPort int
 	// sshClient is initialized after an SSH connection to a node is established
 	sshSession *ssh.Session
+	// keepAlivePeriod is how frequently a keepalive frame is sent down the
+	// websocket while the session is running, to keep intermediate proxies
+	// from treating it as idle and closing it. Zero disables keepalives.
+	keepAlivePeriod time.Duration
 }
 
 func (t *terminalHandler) Close() error {
@@ -169,6 +179,78 @@ func (t *terminalHandler) resizePTYWindow(params session.TerminalParams) error {
 	return trace.Wrap(err)
 }
 
+// moderateSession is called when the session owner grants or revokes a
+// participant's write access from the web UI. Now the node needs to be
+// notified via SSH.
+func (t *terminalHandler) moderateSession(partyID string, canWrite bool) error {
+	if t.sshSession == nil {
+		return nil
+	}
+	_, err := t.sshSession.SendRequest(
+		sshutils.ModerateSessionReq,
+		false,
+		ssh.Marshal(sshutils.ModerateSessionReqParams{
+			PartyID:  partyID,
+			CanWrite: canWrite,
+		}))
+	if err != nil {
+		log.Error(err)
+	}
+	return trace.Wrap(err)
+}
+
+// startKeepAlives sends an empty websocket frame down ws every
+// keepAlivePeriod until the returned channel is closed. This is enough
+// traffic to keep proxies and load balancers that time out idle
+// connections from mistaking a quiet (but still active) session for a
+// dead one.
+//
+// This addresses the "connection gets killed while idle" half of proxies
+// dropping web terminal sessions. A true long-polling/HTTP2-stream
+// transport for browsers that can't complete a WebSocket handshake at all
+// through their corporate proxy is a separate, much larger change to the
+// web terminal's transport layer (and its JS client) and isn't attempted
+// here.
+func (t *terminalHandler) startKeepAlives(ws *websocket.Conn) chan struct{} {
+	stopC := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(t.keepAlivePeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := websocket.Message.Send(ws, ""); err != nil {
+					return
+				}
+			case <-stopC:
+				return
+			}
+		}
+	}()
+	return stopC
+}
+
+// discardReader drains r but never hands anything read back to its
+// caller. It backs the stdin side of an observer's web terminal: their
+// websocket still has to be read so the browser's connection doesn't
+// stall, but nothing they type should ever reach the session.
+type discardReader struct {
+	r io.Reader
+}
+
+func (d discardReader) Read(p []byte) (int, error) {
+	// never report bytes read without an error: io.Copy treats a (0, nil)
+	// return as no progress and will eventually give up with
+	// io.ErrNoProgress. Loop on a scratch buffer instead, only returning
+	// once d.r itself errors.
+	var buf [1024]byte
+	for {
+		if _, err := d.r.Read(buf[:]); err != nil {
+			return 0, err
+		}
+	}
+}
+
 // Run creates a new websocket connection to the SSH server and runs
 // the "loop" piping the input/output of the SSH session into the
 // js-based terminal.
@@ -193,6 +275,10 @@ func (t *terminalHandler) Run(w http.ResponseWriter, r *http.Request) {
 		}
 		// create teleport client:
 		output := utils.NewWebSockWrapper(ws, utils.WebSocketTextMode)
+		var stdin io.Reader = ws
+		if t.params.Readonly {
+			stdin = discardReader{r: ws}
+		}
 		tc, err := client.NewClient(&client.Config{
 			SkipLocalAuth:    true,
 			AuthMethods:      []ssh.AuthMethod{auth},
@@ -202,7 +288,7 @@ func (t *terminalHandler) Run(w http.ResponseWriter, r *http.Request) {
 			Namespace:        t.params.Namespace,
 			Stdout:           output,
 			Stderr:           output,
-			Stdin:            ws,
+			Stdin:            stdin,
 			SiteName:         t.params.Cluster,
 			ProxyHostPort:    t.params.ProxyHostPort,
 			Host:             t.hostName,
@@ -222,6 +308,10 @@ func (t *terminalHandler) Run(w http.ResponseWriter, r *http.Request) {
 			t.resizePTYWindow(t.params.Term)
 			return false, nil
 		}
+		if t.keepAlivePeriod > 0 {
+			stopKeepAlives := t.startKeepAlives(ws)
+			defer close(stopKeepAlives)
+		}
 		if err = tc.SSH(context.TODO(), nil, false); err != nil {
 			errToTerm(err, ws)
 			return
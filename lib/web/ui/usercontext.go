@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
 
 	"github.com/gravitational/trace"
@@ -13,6 +14,52 @@ type userContext struct {
 	Email string `json:"userEmail"`
 	// ACL is this user access control list
 	ACL RoleAccess `json:"userAcl"`
+	// Access is a per-resource-kind breakdown of what actions this user is
+	// permitted to take, so the UI can hide controls for actions the user
+	// can't complete instead of letting them fail server-side.
+	Access map[string]resourceAccess `json:"access"`
+}
+
+// resourceKindsForUI are the resource kinds the web UI checks individual
+// permissions for.
+var resourceKindsForUI = []string{
+	services.KindNode,
+	services.KindSession,
+	services.KindRole,
+	services.KindUser,
+	services.KindTrustedCluster,
+	services.KindOIDC,
+	services.KindSAML,
+	services.KindToken,
+}
+
+// resourceAccess describes which actions a user is permitted to take
+// against a resource kind (e.g. "node", "role").
+type resourceAccess struct {
+	List   bool `json:"list"`
+	Read   bool `json:"read"`
+	Create bool `json:"create"`
+	Edit   bool `json:"edit"`
+	Remove bool `json:"remove"`
+}
+
+// newResourceAccessMap computes, for each resource kind the web UI cares
+// about, which actions checker allows. Unlike RoleAccess.Admin (which only
+// ever inspects a role's allow rules), this goes through the same
+// AccessChecker lib/auth uses to authorize API requests, so deny rules are
+// honored too.
+func newResourceAccessMap(checker services.AccessChecker) map[string]resourceAccess {
+	access := make(map[string]resourceAccess, len(resourceKindsForUI))
+	for _, kind := range resourceKindsForUI {
+		access[kind] = resourceAccess{
+			List:   checker.CheckAccessToRule(defaults.Namespace, kind, services.VerbList) == nil,
+			Read:   checker.CheckAccessToRule(defaults.Namespace, kind, services.VerbRead) == nil,
+			Create: checker.CheckAccessToRule(defaults.Namespace, kind, services.VerbCreate) == nil,
+			Edit:   checker.CheckAccessToRule(defaults.Namespace, kind, services.VerbUpdate) == nil,
+			Remove: checker.CheckAccessToRule(defaults.Namespace, kind, services.VerbDelete) == nil,
+		}
+	}
+	return access
 }
 
 // NewUserContext returns userContext
@@ -37,7 +84,8 @@ func NewUserContext(user services.User, allRoles []services.Role) (*userContext,
 
 	userACL := MergeAccessSet(accessSet)
 	return &userContext{
-		Name: user.GetName(),
-		ACL:  userACL,
+		Name:   user.GetName(),
+		ACL:    userACL,
+		Access: newResourceAccessMap(services.RoleSet(allRoles)),
 	}, nil
 }
@@ -0,0 +1,156 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/trace"
+)
+
+// AccessCommand implements the `tctl access` family of commands, which help
+// admins debug RBAC decisions without having to hand-trace roles
+type AccessCommand struct {
+	config *service.Config
+
+	user  string
+	node  string
+	login string
+
+	matrixNamespace string
+	matrixCSV       bool
+
+	accessLS      *kingpin.CmdClause
+	accessExplain *kingpin.CmdClause
+	accessMatrix  *kingpin.CmdClause
+}
+
+// Initialize allows AccessCommand to plug itself into the CLI parser
+func (a *AccessCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	a.config = config
+	access := app.Command("access", "Inspect effective RBAC access for a user")
+
+	a.accessLS = access.Command("ls", "List the roles granted to a user")
+	a.accessLS.Flag("user", "Teleport user to inspect").Required().StringVar(&a.user)
+
+	a.accessExplain = access.Command("explain", "Explain whether a user can access a node as a given login")
+	a.accessExplain.Flag("user", "Teleport user to inspect").Required().StringVar(&a.user)
+	a.accessExplain.Flag("node", "Node name or hostname to check access to").Required().StringVar(&a.node)
+	a.accessExplain.Flag("login", "OS login to check access with").Required().StringVar(&a.login)
+
+	a.accessMatrix = access.Command("matrix", "Print the full user x node x login access matrix, for access reviews")
+	a.accessMatrix.Flag("namespace", "Namespace of the nodes to compute access for").Default(defaults.Namespace).StringVar(&a.matrixNamespace)
+	a.accessMatrix.Flag("csv", "Print as CSV instead of a table").Default("false").BoolVar(&a.matrixCSV)
+}
+
+// TryRun takes the CLI command as an argument (like "access explain") and executes it.
+func (a *AccessCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case a.accessLS.FullCommand():
+		err = a.List(client)
+	case a.accessExplain.FullCommand():
+		err = a.Explain(client)
+	case a.accessMatrix.FullCommand():
+		err = a.Matrix(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// List prints every role assigned to a.user, and the roles each grants
+func (a *AccessCommand) List(client *auth.TunClient) error {
+	user, err := client.GetUser(a.user)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("user %q is assigned roles: %v\n", user.GetName(), strings.Join(user.GetRoles(), ", "))
+	return nil
+}
+
+// Explain asks the auth server's RBAC simulation API whether a.user, logging
+// in as a.login, would be allowed to connect to a.node, and prints the
+// verdict without performing the access
+func (a *AccessCommand) Explain(client *auth.TunClient) error {
+	resp, err := client.CheckAccessToServer(auth.AccessCheckRequest{
+		User:      a.user,
+		Login:     a.login,
+		Node:      a.node,
+		Namespace: defaults.Namespace,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if resp.Allowed {
+		fmt.Printf("PERMIT: %q login %q access to %q: %v\n", a.user, a.login, a.node, resp.Reason)
+	} else {
+		fmt.Printf("DENY: %q login %q access to %q: %v\n", a.user, a.login, a.node, resp.Reason)
+	}
+	return nil
+}
+
+// accessMatrixPageSize is how many entries Matrix asks the auth server for
+// per page of the access matrix.
+const accessMatrixPageSize = 1000
+
+// Matrix prints the full user x node x login access matrix for
+// a.matrixNamespace, fetching it from the auth server a page at a time so
+// even a very large cluster's matrix never has to fit in memory at once.
+func (a *AccessCommand) Matrix(client *auth.TunClient) error {
+	var w *csv.Writer
+	if a.matrixCSV {
+		w = csv.NewWriter(os.Stdout)
+		defer w.Flush()
+		if err := w.Write([]string{"user", "node", "login"}); err != nil {
+			return trace.Wrap(err)
+		}
+	} else {
+		fmt.Printf("%-32v %-32v %v\n", "USER", "NODE", "LOGIN")
+	}
+
+	startKey := ""
+	for {
+		resp, err := client.GetAccessMatrix(auth.AccessMatrixRequest{
+			Namespace: a.matrixNamespace,
+			StartKey:  startKey,
+			Limit:     accessMatrixPageSize,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, e := range resp.Entries {
+			if a.matrixCSV {
+				if err := w.Write([]string{e.User, e.Node, e.Login}); err != nil {
+					return trace.Wrap(err)
+				}
+			} else {
+				fmt.Printf("%-32v %-32v %v\n", e.User, e.Node, e.Login)
+			}
+		}
+		if resp.NextKey == "" {
+			return nil
+		}
+		startKey = resp.NextKey
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+)
+
+// AccessRequestCommand implements the `tctl requests` group of commands
+type AccessRequestCommand struct {
+	config *service.Config
+
+	reason string
+
+	requestsList    *kingpin.CmdClause
+	requestsApprove *kingpin.CmdClause
+	requestsDeny    *kingpin.CmdClause
+
+	requestID string
+}
+
+// Initialize allows AccessRequestCommand to plug itself into the CLI parser
+func (c *AccessRequestCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	requests := app.Command("requests", "Manage user access requests")
+
+	c.requestsList = requests.Command("ls", "List access requests")
+
+	c.requestsApprove = requests.Command("approve", "Approve a pending access request")
+	c.requestsApprove.Arg("id", "ID of the access request to approve").Required().StringVar(&c.requestID)
+	c.requestsApprove.Flag("reason", "Reason for approving the request").StringVar(&c.reason)
+
+	c.requestsDeny = requests.Command("deny", "Deny a pending access request")
+	c.requestsDeny.Arg("id", "ID of the access request to deny").Required().StringVar(&c.requestID)
+	c.requestsDeny.Flag("reason", "Reason for denying the request").StringVar(&c.reason)
+}
+
+// TryRun takes the CLI command as an argument (like "requests ls") and executes it.
+func (c *AccessRequestCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.requestsList.FullCommand():
+		err = c.List(client)
+	case c.requestsApprove.FullCommand():
+		err = c.Resolve(client, services.AccessRequestApproved)
+	case c.requestsDeny.FullCommand():
+		err = c.Resolve(client, services.AccessRequestDenied)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// List prints every access request known to the cluster.
+func (c *AccessRequestCommand) List(client *auth.TunClient) error {
+	reqs, err := client.GetAccessRequests()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(reqs) == 0 {
+		fmt.Println("no access requests found")
+		return nil
+	}
+	for _, req := range reqs {
+		fmt.Printf("%v: %v requested %v (%v)\n", req.ID, req.User, req.Roles, req.State)
+		if !req.Start.IsZero() && !req.Activated {
+			fmt.Printf("  scheduled to start %v\n", req.Start.Format(time.RFC1123))
+		}
+		if req.Activated && !req.Expires.IsZero() {
+			fmt.Printf("  expires %v\n", req.Expires.Format(time.RFC1123))
+		}
+	}
+	return nil
+}
+
+// Resolve approves or denies the access request named by c.requestID.
+func (c *AccessRequestCommand) Resolve(client *auth.TunClient, state services.AccessRequestState) error {
+	req, err := client.ResolveAccessRequest(c.requestID, state, c.reason)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("access request %v %v\n", req.ID, req.State)
+	return nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// BackupCommand implements `tctl backup` group of commands, which dump
+// durable cluster configuration to a single encrypted file and restore it
+// again, so an operator can move a cluster's users, roles, CAs and
+// connectors onto a fresh auth server backed by a different backend
+// (e.g. dir -> DynamoDB) without recreating everything by hand.
+//
+// Nodes are intentionally not included: they're ephemeral, self-registering
+// identities that re-announce themselves over the heartbeat protocol, not
+// durable configuration an operator hand-authors or wants replayed onto a
+// new cluster.
+type BackupCommand struct {
+	config *service.Config
+
+	file    string
+	keyB64  string
+	secrets bool
+
+	backupExport *kingpin.CmdClause
+	backupImport *kingpin.CmdClause
+}
+
+// backupKinds are the resource kinds included in a backup, in the order
+// they're written to (and expected to be read from) the archive.
+var backupKinds = []string{
+	services.KindUser,
+	services.KindRole,
+	services.KindCertAuthority,
+	services.KindOIDCConnector,
+	services.KindSAMLConnector,
+	services.KindReverseTunnel,
+	services.KindTrustedCluster,
+}
+
+// Initialize allows BackupCommand to plug itself into the CLI parser
+func (b *BackupCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	b.config = config
+
+	backup := app.Command("backup", "Export or import cluster configuration")
+	b.backupExport = backup.Command("export", "Export cluster configuration to an encrypted archive")
+	b.backupExport.Flag("file", "Path to write the archive to").Required().StringVar(&b.file)
+	b.backupExport.Flag("with-secrets", "Include secrets, such as CA private keys and connector client secrets").Default("false").BoolVar(&b.secrets)
+
+	b.backupImport = backup.Command("import", "Restore cluster configuration from an encrypted archive")
+	b.backupImport.Flag("file", "Path to read the archive from").Required().StringVar(&b.file)
+	b.backupImport.Flag("key", "Base64-encoded decryption key printed by 'tctl backup export'").Required().StringVar(&b.keyB64)
+}
+
+// TryRun takes the CLI command as an argument and executes it, or returns
+// match=false if 'cmd' does not belong to it
+func (b *BackupCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case b.backupExport.FullCommand():
+		err = b.Export(client)
+	case b.backupImport.FullCommand():
+		err = b.Import(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Export dumps every resource in backupKinds to a single archive, encrypted
+// with a freshly generated key. The key is never written to disk; it's
+// printed once so the operator can store it alongside the archive.
+func (b *BackupCommand) Export(client *auth.TunClient) error {
+	var buf bytes.Buffer
+	for _, kind := range backupKinds {
+		resources, err := collectResources(client, kind, b.secrets)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, resource := range resources {
+			data, err := yaml.Marshal(resource)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			buf.WriteString("---\n")
+			buf.Write(data)
+		}
+	}
+
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return trace.Wrap(err)
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return trace.Wrap(err)
+	}
+
+	sealed := secretbox.Seal(nonce[:], buf.Bytes(), &nonce, &key)
+	if err := ioutil.WriteFile(b.file, sealed, 0600); err != nil {
+		return trace.Wrap(err)
+	}
+
+	fmt.Printf("wrote %v resources to %v\n", strings.Count(buf.String(), "---\n"), b.file)
+	fmt.Printf("decryption key (save this, it is not stored anywhere): %v\n", base64.StdEncoding.EncodeToString(key[:]))
+	return nil
+}
+
+// Import reads an archive written by Export and upserts every resource in
+// it against client, which may be a fresh auth server backed by a
+// different backend than the one the archive was taken from.
+func (b *BackupCommand) Import(client *auth.TunClient) error {
+	key, err := base64.StdEncoding.DecodeString(b.keyB64)
+	if err != nil {
+		return trace.BadParameter("invalid --key: %v", err)
+	}
+	if len(key) != 32 {
+		return trace.BadParameter("invalid --key: expected 32 bytes, got %v", len(key))
+	}
+	var keyArray [32]byte
+	copy(keyArray[:], key)
+
+	sealed, err := ioutil.ReadFile(b.file)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(sealed) < 24 {
+		return trace.BadParameter("%v is too short to be a backup archive", b.file)
+	}
+	var nonce [24]byte
+	copy(nonce[:], sealed[:24])
+
+	plaintext, ok := secretbox.Open(nil, sealed[24:], &nonce, &keyArray)
+	if !ok {
+		return trace.BadParameter("could not decrypt %v: wrong key or corrupted archive", b.file)
+	}
+
+	return applyResources(client, bytes.NewReader(plaintext))
+}
+
+// collectResources fetches every resource of the given kind from client,
+// mirroring the fetch side of ResourceCommand.getCollection but returning
+// the raw items instead of a display collection, since backups round-trip
+// through 'tctl create' rather than any of the text/JSON/YAML table views.
+func collectResources(client auth.ClientI, kind string, withSecrets bool) ([]interface{}, error) {
+	switch kind {
+	case services.KindUser:
+		users, err := client.GetUsers()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return toInterfaceSlice(len(users), func(i int) interface{} { return users[i] }), nil
+	case services.KindRole:
+		roles, err := client.GetRoles()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return toInterfaceSlice(len(roles), func(i int) interface{} { return roles[i] }), nil
+	case services.KindCertAuthority:
+		userCAs, err := client.GetCertAuthorities(services.UserCA, withSecrets)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		hostCAs, err := client.GetCertAuthorities(services.HostCA, withSecrets)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		cas := append(userCAs, hostCAs...)
+		return toInterfaceSlice(len(cas), func(i int) interface{} { return cas[i] }), nil
+	case services.KindOIDCConnector:
+		connectors, err := client.GetOIDCConnectors(withSecrets)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return toInterfaceSlice(len(connectors), func(i int) interface{} { return connectors[i] }), nil
+	case services.KindSAMLConnector:
+		connectors, err := client.GetSAMLConnectors(withSecrets)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return toInterfaceSlice(len(connectors), func(i int) interface{} { return connectors[i] }), nil
+	case services.KindReverseTunnel:
+		tunnels, err := client.GetReverseTunnels()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return toInterfaceSlice(len(tunnels), func(i int) interface{} { return tunnels[i] }), nil
+	case services.KindTrustedCluster:
+		clusters, err := client.GetTrustedClusters()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return toInterfaceSlice(len(clusters), func(i int) interface{} { return clusters[i] }), nil
+	default:
+		return nil, trace.BadParameter("%q is not a supported backup resource kind", kind)
+	}
+}
+
+// toInterfaceSlice adapts a slice of a concrete resource type to
+// []interface{} via an index-based accessor, since Go generics aren't
+// available and each Get* call above returns a different concrete slice
+// type.
+func toInterfaceSlice(n int, at func(i int) interface{}) []interface{} {
+	out := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		out[i] = at(i)
+	}
+	return out
+}
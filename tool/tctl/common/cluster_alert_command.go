@@ -0,0 +1,103 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+)
+
+// ClusterAlertCommand implements the `tctl alerts` group of commands
+type ClusterAlertCommand struct {
+	config *service.Config
+
+	severity string
+	message  string
+	ttl      time.Duration
+
+	alertsList *kingpin.CmdClause
+	alertsAdd  *kingpin.CmdClause
+}
+
+// Initialize allows ClusterAlertCommand to plug itself into the CLI parser
+func (c *ClusterAlertCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	alerts := app.Command("alerts", "Manage cluster alerts shown to tsh and web UI users")
+
+	c.alertsList = alerts.Command("ls", "List cluster alerts")
+
+	c.alertsAdd = alerts.Command("add", "Create a cluster alert")
+	c.alertsAdd.Arg("message", "Text of the alert").Required().StringVar(&c.message)
+	c.alertsAdd.Flag("severity", "Severity of the alert: info, warning, or critical").Default("info").StringVar(&c.severity)
+	c.alertsAdd.Flag("ttl", "How long the alert is shown before it expires on its own, e.g. 72h. Empty means it never expires on its own").DurationVar(&c.ttl)
+}
+
+// TryRun takes the CLI command as an argument (like "alerts ls") and executes it.
+func (c *ClusterAlertCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.alertsList.FullCommand():
+		err = c.List(client)
+	case c.alertsAdd.FullCommand():
+		err = c.Add(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// List prints every cluster alert.
+func (c *ClusterAlertCommand) List(client *auth.TunClient) error {
+	alerts, err := client.GetClusterAlerts()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(alerts) == 0 {
+		fmt.Println("no cluster alerts found")
+		return nil
+	}
+	for _, alert := range alerts {
+		fmt.Printf("%v: [%v] %v\n", alert.ID, alert.Severity, alert.Message)
+		if !alert.Expires.IsZero() {
+			fmt.Printf("  expires %v\n", alert.Expires.Format(time.RFC1123))
+		}
+		if len(alert.AcknowledgedBy) > 0 {
+			fmt.Printf("  acknowledged by: %v\n", alert.AcknowledgedBy)
+		}
+	}
+	return nil
+}
+
+// Add creates a new cluster alert from the command's flags.
+func (c *ClusterAlertCommand) Add(client *auth.TunClient) error {
+	var expires time.Time
+	if c.ttl != 0 {
+		expires = time.Now().UTC().Add(c.ttl)
+	}
+	alert, err := client.CreateClusterAlert(services.AlertSeverity(c.severity), c.message, expires)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("cluster alert %v created\n", alert.ID)
+	return nil
+}
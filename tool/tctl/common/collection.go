@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/gravitational/teleport/lib/services"
 
@@ -42,18 +43,19 @@ type roleCollection struct {
 
 func (r *roleCollection) writeText(w io.Writer) error {
 	t := goterm.NewTable(0, 10, 5, ' ', 0)
-	printHeader(t, []string{"Role", "Allowed to login as", "Namespaces", "Node Labels", "Access to resources"})
+	printHeader(t, []string{"Role", "Allowed to login as", "Namespaces", "Node Labels", "Access to resources", "Labels"})
 	if len(r.roles) == 0 {
 		_, err := io.WriteString(w, t.String())
 		return trace.Wrap(err)
 	}
 	for _, r := range r.roles {
-		fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\n",
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\t%v\n",
 			r.GetMetadata().Name,
 			strings.Join(r.GetLogins(services.Allow), ","),
 			strings.Join(r.GetNamespaces(services.Allow), ","),
 			printNodeLabels(r.GetNodeLabels(services.Allow)),
-			printActions(r.GetRules(services.Allow)))
+			printActions(r.GetRules(services.Allow)),
+			printMetadataLabels(r.GetMetadata().Labels))
 	}
 	_, err := io.WriteString(w, t.String())
 	return trace.Wrap(err)
@@ -149,19 +151,34 @@ func printNodeLabels(labels map[string]string) string {
 	return strings.Join(pairs, ",")
 }
 
+// printMetadataLabels formats the generic, free-form labels every resource
+// carries on its Metadata (as opposed to e.g. a role's RBAC node labels),
+// for display in `tctl get` text listings.
+func printMetadataLabels(labels map[string]string) string {
+	pairs := []string{}
+	for key, val := range labels {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", key, val))
+	}
+	return strings.Join(pairs, ",")
+}
+
 type serverCollection struct {
 	servers []services.Server
 }
 
 func (s *serverCollection) writeText(w io.Writer) error {
 	t := goterm.NewTable(0, 10, 5, ' ', 0)
-	printHeader(t, []string{"Hostname", "UUID", "Address", "Labels"})
+	printHeader(t, []string{"Hostname", "UUID", "Address", "Labels", "State"})
 	if len(s.servers) == 0 {
 		_, err := io.WriteString(w, t.String())
 		return trace.Wrap(err)
 	}
 	for _, s := range s.servers {
-		fmt.Fprintf(t, "%v\t%v\t%v\t%v\n", s.GetHostname(), s.GetName(), s.GetAddr(), s.LabelsString())
+		state := s.GetState()
+		if state == "" {
+			state = services.ServerStateApproved
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\n", s.GetHostname(), s.GetName(), s.GetAddr(), s.LabelsString(), state)
 	}
 	_, err := io.WriteString(w, t.String())
 	return trace.Wrap(err)
@@ -198,13 +215,18 @@ type userCollection struct {
 
 func (s *userCollection) writeText(w io.Writer) error {
 	t := goterm.NewTable(0, 10, 5, ' ', 0)
-	printHeader(t, []string{"User", "Roles", "Created By"})
+	printHeader(t, []string{"User", "Roles", "Created By", "Expires", "Labels"})
 	if len(s.users) == 0 {
 		_, err := io.WriteString(w, t.String())
 		return trace.Wrap(err)
 	}
 	for _, u := range s.users {
-		fmt.Fprintf(t, "%v\t%v\t%v\n", u.GetName(), strings.Join(u.GetRoles(), ","), u.GetCreatedBy().String())
+		expires := "never"
+		if e := u.Expiry(); !e.IsZero() {
+			expires = e.Format(time.RFC822)
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\n", u.GetName(), strings.Join(u.GetRoles(), ","), u.GetCreatedBy().String(),
+			expires, printMetadataLabels(u.GetMetadata().Labels))
 	}
 	_, err := io.WriteString(w, t.String())
 	return trace.Wrap(err)
@@ -331,9 +353,10 @@ type oidcCollection struct {
 
 func (c *oidcCollection) writeText(w io.Writer) error {
 	t := goterm.NewTable(0, 10, 5, ' ', 0)
-	printHeader(t, []string{"Name", "Issuer URL", "Additional Scope"})
+	printHeader(t, []string{"Name", "Issuer URL", "Additional Scope", "Labels"})
 	for _, conn := range c.connectors {
-		fmt.Fprintf(t, "%v\t%v\t%v\n", conn.GetName(), conn.GetIssuerURL(), strings.Join(conn.GetScope(), ","))
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\n", conn.GetName(), conn.GetIssuerURL(), strings.Join(conn.GetScope(), ","),
+			printMetadataLabels(conn.GetMetadata().Labels))
 	}
 	_, err := io.WriteString(w, t.String())
 	return trace.Wrap(err)
@@ -370,9 +393,9 @@ type samlCollection struct {
 
 func (c *samlCollection) writeText(w io.Writer) error {
 	t := goterm.NewTable(0, 10, 5, ' ', 0)
-	printHeader(t, []string{"Name", "SSO URL"})
+	printHeader(t, []string{"Name", "SSO URL", "Labels"})
 	for _, conn := range c.connectors {
-		fmt.Fprintf(t, "%v\t%v\n", conn.GetName(), conn.GetSSO())
+		fmt.Fprintf(t, "%v\t%v\t%v\n", conn.GetName(), conn.GetSSO(), printMetadataLabels(conn.GetMetadata().Labels))
 	}
 	_, err := io.WriteString(w, t.String())
 	return trace.Wrap(err)
@@ -475,3 +498,89 @@ func (c *authPreferenceCollection) writeYAML(w io.Writer) error {
 	_, err = w.Write(data)
 	return trace.Wrap(err)
 }
+
+type tokenCollection struct {
+	tokens []services.ProvisionToken
+}
+
+func (c *tokenCollection) writeText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	printHeader(t, []string{"Token", "Roles", "Expiry"})
+	if len(c.tokens) == 0 {
+		_, err := io.WriteString(w, t.String())
+		return trace.Wrap(err)
+	}
+	for _, tok := range c.tokens {
+		fmt.Fprintf(t, "%v\t%v\t%v\n", tok.Token, tok.Roles.String(), tok.Expires)
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+func (c *tokenCollection) writeJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(c.toMarshal(), "", "    ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = w.Write(data)
+	return trace.Wrap(err)
+}
+
+func (c *tokenCollection) toMarshal() interface{} {
+	if len(c.tokens) == 1 {
+		return c.tokens[0]
+	}
+	return c.tokens
+}
+
+func (c *tokenCollection) writeYAML(w io.Writer) error {
+	data, err := yaml.Marshal(c.toMarshal())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = w.Write(data)
+	return trace.Wrap(err)
+}
+
+// multiCollection concatenates several collections so `tctl get all` can
+// dump every supported resource kind as a single document stream, the same
+// way `kubectl get all` does.
+type multiCollection struct {
+	collections []collection
+}
+
+func (m *multiCollection) writeText(w io.Writer) error {
+	for _, c := range m.collections {
+		if err := c.writeText(w); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (m *multiCollection) writeJSON(w io.Writer) error {
+	marshal := make([]interface{}, len(m.collections))
+	for i, c := range m.collections {
+		marshal[i] = c.(interface{ toMarshal() interface{} }).toMarshal()
+	}
+	data, err := json.MarshalIndent(marshal, "", "    ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = w.Write(data)
+	return trace.Wrap(err)
+}
+
+func (m *multiCollection) writeYAML(w io.Writer) error {
+	for i, c := range m.collections {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		if err := c.writeYAML(w); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
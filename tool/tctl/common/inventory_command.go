@@ -0,0 +1,104 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/buger/goterm"
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// InventoryCommand implements `tctl inventory`, listing every connected
+// node and proxy along with the version and OS it last announced and how
+// long it's been up, so operators can spot agents that are stuck on an
+// old release or are about to fall outside the supported version skew.
+type InventoryCommand struct {
+	config *service.Config
+	// namespace is the namespace of the nodes being reported on
+	namespace string
+
+	// CLI clause
+	inventory *kingpin.CmdClause
+}
+
+// Initialize allows InventoryCommand to plug itself into the CLI parser
+func (c *InventoryCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+	c.inventory = app.Command("inventory", "List connected nodes and proxies with their version, OS and uptime")
+	c.inventory.Flag("namespace", "Namespace of the nodes being reported on").Default(defaults.Namespace).StringVar(&c.namespace)
+}
+
+// TryRun takes the CLI command as an argument (like "inventory") and executes it.
+func (c *InventoryCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.inventory.FullCommand():
+		err = c.List(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// List prints a table of every connected node and proxy with the version
+// and OS it last reported, its uptime, and whether it's outside the
+// cluster's supported version skew.
+func (c *InventoryCommand) List(client *auth.TunClient) error {
+	nodes, err := client.GetNodes(c.namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	proxies, err := client.GetProxies()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	printHeader(t, []string{"Kind", "Hostname", "UUID", "Version", "OS", "Uptime", "Status"})
+	printInventoryRows(t, "node", nodes)
+	printInventoryRows(t, "proxy", proxies)
+	_, err = os.Stdout.WriteString(t.String())
+	return trace.Wrap(err)
+}
+
+func printInventoryRows(t *goterm.Table, kind string, servers []services.Server) {
+	now := time.Now().UTC()
+	for _, server := range servers {
+		uptime := "-"
+		if startTime := server.GetStartTime(); !startTime.IsZero() {
+			uptime = now.Sub(startTime).Round(time.Second).String()
+		}
+		status := "ok"
+		skew := utils.CheckVersionSkew(teleport.Version, server.GetTeleportVersion())
+		if !skew.Compatible {
+			status = "upgrade needed"
+		} else if server.GetTeleportVersion() != teleport.Version {
+			status = "upgrade available"
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			kind, server.GetHostname(), server.GetName(), server.GetTeleportVersion(), server.GetOS(), uptime, status)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/trace"
 )
 
@@ -46,10 +47,24 @@ type NodeCommand struct {
 	ttl time.Duration
 	// namespace is node namespace
 	namespace string
+	// hostname is used to find a node by hostname for 'nodes rm'
+	hostname string
+	// staleAfter is used by 'nodes ls --prune-stale' to select nodes that
+	// haven't sent a heartbeat in at least this long
+	staleAfter time.Duration
+	// pruneStale, if set, deletes stale nodes instead of just listing them
+	pruneStale bool
+	// removeAll, if set, removes every node in --namespace via 'nodes rm --all'
+	removeAll bool
+	// name is the node name/hostname passed to 'nodes approve'/'nodes quarantine'
+	name string
 
 	// CLI subcommands (clauses)
-	nodeAdd  *kingpin.CmdClause
-	nodeList *kingpin.CmdClause
+	nodeAdd        *kingpin.CmdClause
+	nodeList       *kingpin.CmdClause
+	nodeRemove     *kingpin.CmdClause
+	nodeApprove    *kingpin.CmdClause
+	nodeQuarantine *kingpin.CmdClause
 }
 
 // Initialize allows NodeCommand to plug itself into the CLI parser
@@ -67,7 +82,22 @@ func (c *NodeCommand) Initialize(app *kingpin.Application, config *service.Confi
 
 	c.nodeList = nodes.Command("ls", "List all active SSH nodes within the cluster")
 	c.nodeList.Flag("namespace", "Namespace of the nodes").Default(defaults.Namespace).StringVar(&c.namespace)
+	c.nodeList.Flag("prune-stale", "Delete nodes that haven't heartbeated within --stale-after instead of listing them").BoolVar(&c.pruneStale)
+	c.nodeList.Flag("stale-after", "Nodes with no heartbeat for longer than this are considered stale").Default("24h").DurationVar(&c.staleAfter)
 	c.nodeList.Alias(ListNodesHelp)
+
+	c.nodeRemove = nodes.Command("rm", "Remove a node from the cluster")
+	c.nodeRemove.Arg("hostname", "Hostname or name of the node to remove").StringVar(&c.hostname)
+	c.nodeRemove.Flag("namespace", "Namespace of the node").Default(defaults.Namespace).StringVar(&c.namespace)
+	c.nodeRemove.Flag("all", "Remove every node in --namespace instead of a single one").BoolVar(&c.removeAll)
+
+	c.nodeApprove = nodes.Command("approve", "Approve a pending or quarantined node so it can receive sessions")
+	c.nodeApprove.Arg("name", "Name of the node to approve").Required().StringVar(&c.name)
+	c.nodeApprove.Flag("namespace", "Namespace of the node").Default(defaults.Namespace).StringVar(&c.namespace)
+
+	c.nodeQuarantine = nodes.Command("quarantine", "Quarantine a node, immediately blocking new sessions to it")
+	c.nodeQuarantine.Arg("name", "Name of the node to quarantine").Required().StringVar(&c.name)
+	c.nodeQuarantine.Flag("namespace", "Namespace of the node").Default(defaults.Namespace).StringVar(&c.namespace)
 }
 
 // TryRun takes the CLI command as an argument (like "nodes ls") and executes it.
@@ -77,6 +107,12 @@ func (c *NodeCommand) TryRun(cmd string, client *auth.TunClient) (match bool, er
 		err = c.Invite(client)
 	case c.nodeList.FullCommand():
 		err = c.ListActive(client)
+	case c.nodeRemove.FullCommand():
+		err = c.Remove(client)
+	case c.nodeApprove.FullCommand():
+		err = c.Approve(client)
+	case c.nodeQuarantine.FullCommand():
+		err = c.Quarantine(client)
 
 	default:
 		return false, nil
@@ -138,7 +174,74 @@ func (c *NodeCommand) ListActive(client *auth.TunClient) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if c.pruneStale {
+		return c.prune(client, nodes)
+	}
 	coll := &serverCollection{servers: nodes}
 	coll.writeText(os.Stdout)
 	return nil
 }
+
+// prune deletes every node in the given list that hasn't renewed its
+// heartbeat lease within --stale-after. A node's heartbeat expiry (set each
+// time it re-announces) is the closest proxy we have to "last seen"; nodes
+// that never expire (Expires is zero, e.g. manually registered) are never
+// considered stale.
+func (c *NodeCommand) prune(client *auth.TunClient, nodes []services.Server) error {
+	cutoff := time.Now().UTC().Add(-c.staleAfter)
+	var pruned int
+	for _, node := range nodes {
+		meta := node.GetMetadata()
+		expires := meta.Expiry()
+		if expires.IsZero() || expires.After(cutoff) {
+			continue
+		}
+		if err := client.DeleteNode(c.namespace, node.GetName()); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("pruned stale node %q (heartbeat lease expired %v)\n", node.GetHostname(), expires)
+		pruned++
+	}
+	fmt.Printf("pruned %v stale node(s)\n", pruned)
+	return nil
+}
+
+// Remove deletes a single node from the cluster by hostname or node name,
+// or every node in --namespace if --all was given
+func (c *NodeCommand) Remove(client *auth.TunClient) error {
+	if c.removeAll {
+		if err := client.DeleteAllNodes(c.namespace); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("all nodes in namespace %q have been removed\n", c.namespace)
+		return nil
+	}
+	if c.hostname == "" {
+		return trace.BadParameter("specify a node name/hostname or pass --all")
+	}
+	if err := client.DeleteNode(c.namespace, c.hostname); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("node %q has been removed\n", c.hostname)
+	return nil
+}
+
+// Approve marks a pending or quarantined node as approved, allowing it to
+// receive sessions again
+func (c *NodeCommand) Approve(client *auth.TunClient) error {
+	if err := client.ApproveNode(c.namespace, c.name); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("node %q has been approved\n", c.name)
+	return nil
+}
+
+// Quarantine marks a node as quarantined, immediately blocking new sessions
+// to it until it's approved again
+func (c *NodeCommand) Quarantine(client *auth.TunClient) error {
+	if err := client.QuarantineNode(c.namespace, c.name); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("node %q has been quarantined\n", c.name)
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2016 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/trace"
+)
+
+// OktaCommand implements the `tctl okta` group of commands
+type OktaCommand struct {
+	config *service.Config
+
+	// apply, if set, has drift found by "okta drift" applied instead of
+	// only reported
+	apply bool
+
+	oktaDrift *kingpin.CmdClause
+}
+
+// Initialize allows OktaCommand to plug itself into the CLI parser
+func (c *OktaCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	okta := app.Command("okta", "Manage the Okta group sync service")
+
+	c.oktaDrift = okta.Command("drift", "Report (or, with --apply, close) drift between Okta group membership and Teleport users' roles")
+	c.oktaDrift.Flag("apply", "Create/update users to close the reported drift, instead of only reporting it").BoolVar(&c.apply)
+}
+
+// TryRun takes the CLI command as an argument (like "okta drift") and executes it.
+func (c *OktaCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.oktaDrift.FullCommand():
+		err = c.Drift(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Drift fetches the cluster's OktaConfig and runs a sync against it,
+// printing a report of the drift found. Unless --apply was given, the
+// sync is a dry run: nothing in Teleport is changed.
+func (c *OktaCommand) Drift(client *auth.TunClient) error {
+	cfg, err := client.GetOktaConfig()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	report, err := client.SyncOktaGroups(cfg, !c.apply)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if len(report.Changes) == 0 {
+		fmt.Println("no drift found, Teleport roles already match Okta group membership")
+		return nil
+	}
+
+	verb := "would set"
+	if !report.DryRun {
+		verb = "set"
+	}
+	for _, change := range report.Changes {
+		status := "updated"
+		if change.New {
+			status = "new"
+		}
+		fmt.Printf("%v (%v): %v roles %v -> %v\n", change.User, status, verb, change.OldRoles, change.NewRoles)
+	}
+	return nil
+}
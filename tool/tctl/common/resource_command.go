@@ -117,6 +117,14 @@ func (u *ResourceCommand) Create(client *auth.TunClient) error {
 	} else {
 		reader = ioutil.NopCloser(os.Stdin)
 	}
+	return applyResources(client, reader)
+}
+
+// applyResources reads a stream of YAML or JSON resource documents from
+// reader and upserts each one against client, in the order it appears in
+// the stream. It's shared by `tctl create` and `tctl backup import`, which
+// both need to turn a resource dump back into live cluster state.
+func applyResources(client *auth.TunClient, reader io.Reader) error {
 	decoder := kyaml.NewYAMLOrJSONDecoder(reader, 32*1024)
 	count := 0
 	for {
@@ -212,6 +220,15 @@ func (u *ResourceCommand) Create(client *auth.TunClient) error {
 				return trace.Wrap(err)
 			}
 			fmt.Printf("created trusted cluster: %q\n", tc.GetName())
+		case services.KindClusterAuthPreference:
+			cap, err := services.GetAuthPreferenceMarshaler().Unmarshal(raw.Raw)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if err := client.SetAuthPreference(cap); err != nil {
+				return trace.Wrap(err)
+			}
+			fmt.Printf("updated cluster authentication preference\n")
 		case "":
 			return trace.BadParameter("missing resource kind")
 		default:
@@ -250,6 +267,25 @@ func (d *ResourceCommand) Delete(client *auth.TunClient) error {
 			return trace.Wrap(err)
 		}
 		fmt.Printf("reverse tunnel %v has been deleted\n", d.ref.Name)
+	case services.KindCertAuthority:
+		// a CA's domain name doesn't tell us whether it's a user or host CA,
+		// so try both kinds and only complain if neither existed
+		userErr := client.DeleteCertAuthority(services.CertAuthID{Type: services.UserCA, DomainName: d.ref.Name})
+		hostErr := client.DeleteCertAuthority(services.CertAuthID{Type: services.HostCA, DomainName: d.ref.Name})
+		if userErr != nil && hostErr != nil {
+			return trace.Wrap(hostErr)
+		}
+		fmt.Printf("cert authority %v has been deleted\n", d.ref.Name)
+	case services.KindNode:
+		if err := client.DeleteNode(d.namespace, d.ref.Name); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("node %v has been deleted\n", d.ref.Name)
+	case services.KindToken:
+		if err := client.DeleteToken(d.ref.Name); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("token %v has been deleted\n", d.ref.Name)
 	case services.KindRole:
 		if err := client.DeleteRole(d.ref.Name); err != nil {
 			return trace.Wrap(err)
@@ -371,11 +407,70 @@ func (g *ResourceCommand) getCollection(client auth.ClientI) (collection, error)
 			return nil, trace.Wrap(err)
 		}
 		return &trustedClusterCollection{trustedClusters: []services.TrustedCluster{trustedCluster}}, nil
+	case services.KindToken:
+		tokens, err := client.GetTokens()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if g.ref.Name == "" {
+			return &tokenCollection{tokens: tokens}, nil
+		}
+		for _, tok := range tokens {
+			if tok.Token == g.ref.Name {
+				return &tokenCollection{tokens: []services.ProvisionToken{tok}}, nil
+			}
+		}
+		return nil, trace.NotFound("token %q is not found", g.ref.Name)
+	case services.KindClusterAuthPreference:
+		cap, err := client.GetAuthPreference()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &authPreferenceCollection{cap}, nil
+	case resourceKindAll:
+		return g.getAllCollection(client)
 	}
 
 	return nil, trace.BadParameter("'%v' is not supported", g.ref.Kind)
 }
 
+// resourceKindAll is a pseudo resource kind recognized only by `tctl get`,
+// used to export every supported resource in one shot (`tctl get all`).
+const resourceKindAll = "all"
+
+// allResourceKinds lists every kind exportable via `tctl get all`, in the
+// order they're printed. Resources that are scoped to a single namespace or
+// that don't round-trip through `tctl create` (nodes, auth servers, proxies,
+// tokens) are deliberately left out, matching what a full cluster config
+// backup is expected to contain.
+var allResourceKinds = []string{
+	services.KindUser,
+	services.KindRole,
+	services.KindNamespace,
+	services.KindTrustedCluster,
+	services.KindCertAuthority,
+	services.KindOIDCConnector,
+	services.KindSAMLConnector,
+	services.KindReverseTunnel,
+	services.KindClusterAuthPreference,
+}
+
+// getAllCollection builds a multiCollection out of every kind listed in
+// allResourceKinds, for `tctl get all`.
+func (g *ResourceCommand) getAllCollection(client auth.ClientI) (collection, error) {
+	var collections []collection
+	for _, kind := range allResourceKinds {
+		ref := services.Ref{Kind: kind}
+		getter := &ResourceCommand{ref: ref, namespace: g.namespace, withSecrets: g.withSecrets}
+		c, err := getter.getCollection(client)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		collections = append(collections, c)
+	}
+	return &multiCollection{collections: collections}, nil
+}
+
 const (
 	formatYAML = "yaml"
 	formatText = "text"
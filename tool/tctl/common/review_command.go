@@ -0,0 +1,152 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+)
+
+// ReviewCommand implements the `tctl review` group of commands
+type ReviewCommand struct {
+	config *service.Config
+
+	name      string
+	reviewers string
+	deadline  string
+
+	campaignID string
+	user       string
+	reason     string
+
+	reviewStart  *kingpin.CmdClause
+	reviewLS     *kingpin.CmdClause
+	reviewKeep   *kingpin.CmdClause
+	reviewRevoke *kingpin.CmdClause
+	reviewClose  *kingpin.CmdClause
+}
+
+// Initialize allows ReviewCommand to plug itself into the CLI parser
+func (c *ReviewCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	review := app.Command("review", "Manage periodic access review campaigns")
+
+	c.reviewStart = review.Command("start", "Start a new review campaign, snapshotting every user's current roles")
+	c.reviewStart.Flag("name", "Human-readable name for the campaign").Required().StringVar(&c.name)
+	c.reviewStart.Flag("reviewers", "Comma-separated list of usernames allowed to vote on this campaign").Required().StringVar(&c.reviewers)
+	c.reviewStart.Flag("deadline", "When the campaign closes and its decisions take effect, RFC3339").Required().StringVar(&c.deadline)
+
+	c.reviewLS = review.Command("ls", "List review campaigns")
+
+	c.reviewKeep = review.Command("keep", "Vote to keep a user's reviewed role grant")
+	c.reviewKeep.Arg("id", "ID of the review campaign").Required().StringVar(&c.campaignID)
+	c.reviewKeep.Flag("user", "User whose grant to vote on").Required().StringVar(&c.user)
+	c.reviewKeep.Flag("reason", "Reason for the vote").StringVar(&c.reason)
+
+	c.reviewRevoke = review.Command("revoke", "Vote to revoke a user's reviewed role grant")
+	c.reviewRevoke.Arg("id", "ID of the review campaign").Required().StringVar(&c.campaignID)
+	c.reviewRevoke.Flag("user", "User whose grant to vote on").Required().StringVar(&c.user)
+	c.reviewRevoke.Flag("reason", "Reason for the vote").StringVar(&c.reason)
+
+	c.reviewClose = review.Command("close", "Close a review campaign early, applying its votes")
+	c.reviewClose.Arg("id", "ID of the review campaign to close").Required().StringVar(&c.campaignID)
+}
+
+// TryRun takes the CLI command as an argument (like "review ls") and executes it.
+func (c *ReviewCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.reviewStart.FullCommand():
+		err = c.Start(client)
+	case c.reviewLS.FullCommand():
+		err = c.List(client)
+	case c.reviewKeep.FullCommand():
+		err = c.Vote(client, services.ReviewKeep)
+	case c.reviewRevoke.FullCommand():
+		err = c.Vote(client, services.ReviewRevoke)
+	case c.reviewClose.FullCommand():
+		err = c.Close(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Start creates a new review campaign.
+func (c *ReviewCommand) Start(client *auth.TunClient) error {
+	deadline, err := time.Parse(time.RFC3339, c.deadline)
+	if err != nil {
+		return trace.BadParameter("invalid --deadline: %v", err)
+	}
+	reviewers := strings.Split(c.reviewers, ",")
+	for i := range reviewers {
+		reviewers[i] = strings.TrimSpace(reviewers[i])
+	}
+
+	campaign, err := client.CreateReviewCampaign(c.name, reviewers, deadline)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("created review campaign %v: %v grants awaiting review, closes %v\n",
+		campaign.ID, len(campaign.Grants), campaign.Deadline.Format(time.RFC1123))
+	return nil
+}
+
+// List prints every review campaign known to the cluster.
+func (c *ReviewCommand) List(client *auth.TunClient) error {
+	campaigns, err := client.GetReviewCampaigns()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(campaigns) == 0 {
+		fmt.Println("no review campaigns found")
+		return nil
+	}
+	for _, campaign := range campaigns {
+		fmt.Printf("%v: %q %v grants, closes %v (%v)\n",
+			campaign.ID, campaign.Name, len(campaign.Grants), campaign.Deadline.Format(time.RFC1123), campaign.State)
+	}
+	return nil
+}
+
+// Vote records the caller's decision on c.user's grant in the campaign
+// named by c.campaignID.
+func (c *ReviewCommand) Vote(client *auth.TunClient, decision services.ReviewDecision) error {
+	campaign, err := client.RecordReviewVote(c.campaignID, c.user, decision, c.reason)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("recorded %v vote on %v's grant in review campaign %v\n", decision, c.user, campaign.ID)
+	return nil
+}
+
+// Close closes the review campaign named by c.campaignID early.
+func (c *ReviewCommand) Close(client *auth.TunClient) error {
+	campaign, err := client.CloseReviewCampaign(c.campaignID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("closed review campaign %v: revoked roles for %v user(s)\n", campaign.ID, len(campaign.Revoked))
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/trace"
+)
+
+// SessionsCommand implements `tctl sessions` group of commands
+type SessionsCommand struct {
+	config *service.Config
+
+	// namespace is the session namespace to operate on
+	namespace string
+	// user, if set, restricts a purge to sessions whose Login matches it
+	user string
+	// olderThan, if set, restricts a purge to sessions whose last activity
+	// is older than this duration
+	olderThan time.Duration
+
+	sessionsPurge *kingpin.CmdClause
+}
+
+// Initialize allows SessionsCommand to plug itself into the CLI parser
+func (c *SessionsCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	sessions := app.Command("sessions", "Manage recorded sessions")
+
+	c.sessionsPurge = sessions.Command("purge", "Permanently delete recordings and event logs of matching sessions")
+	c.sessionsPurge.Flag("namespace", "Namespace of the sessions").Default(defaults.Namespace).StringVar(&c.namespace)
+	c.sessionsPurge.Flag("user", "Only purge sessions logged in as this OS user").Default("").StringVar(&c.user)
+	c.sessionsPurge.Flag("older-than", "Only purge sessions inactive for longer than this").DurationVar(&c.olderThan)
+}
+
+// TryRun takes the CLI command as an argument (like "sessions purge") and executes it.
+func (c *SessionsCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.sessionsPurge.FullCommand():
+		err = c.Purge(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Purge deletes the recording and event log of every session in
+// --namespace that matches --user and/or --older-than. It is meant for
+// enforcing a data retention policy or honoring a right-to-erasure
+// request; each deletion is recorded as a session.purge audit event by
+// the auth server so the purge itself leaves a trace.
+func (c *SessionsCommand) Purge(client *auth.TunClient) error {
+	if c.user == "" && c.olderThan == 0 {
+		return trace.BadParameter("specify --user and/or --older-than to select sessions to purge")
+	}
+	sessions, err := client.GetSessions(c.namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cutoff := time.Now().UTC().Add(-c.olderThan)
+	var purged int
+	for _, sess := range sessions {
+		if c.user != "" && sess.Login != c.user {
+			continue
+		}
+		if c.olderThan != 0 && sess.LastActive.After(cutoff) {
+			continue
+		}
+		if err := client.PurgeSessionData(c.namespace, sess.ID); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("purged session %v (login=%v, last active %v)\n", sess.ID, sess.Login, sess.LastActive)
+		purged++
+	}
+	fmt.Printf("purged %v session(s)\n", purged)
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+	kyaml "k8s.io/client-go/pkg/util/yaml"
+)
+
+// SSOCommand implements `tctl sso` group of commands
+type SSOCommand struct {
+	config *service.Config
+
+	// connectorPath is the path to the connector resource file to test
+	connectorPath string
+	// proxyAddr is the web proxy the identity provider redirects back to
+	proxyAddr string
+	// insecure disables TLS certificate verification against the proxy
+	insecure bool
+
+	ssoTest *kingpin.CmdClause
+}
+
+// Initialize allows SSOCommand to plug itself into the CLI parser
+func (c *SSOCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	sso := app.Command("sso", "Debug OIDC/SAML connectors")
+
+	c.ssoTest = sso.Command("test", "Run a real sign-in against an OIDC or SAML connector and print the resulting claims and role mapping, without saving the connector")
+	c.ssoTest.Arg("connector", "Path to a YAML file with the OIDC or SAML connector to test").Required().StringVar(&c.connectorPath)
+	c.ssoTest.Flag("proxy", "Web proxy address the identity provider will redirect back to").Required().StringVar(&c.proxyAddr)
+	c.ssoTest.Flag("insecure", "Do not verify the proxy's certificate and host name").Default("false").BoolVar(&c.insecure)
+}
+
+// TryRun takes the CLI command as an argument (like "sso test") and executes it.
+func (c *SSOCommand) TryRun(cmd string, tc *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.ssoTest.FullCommand():
+		err = c.Test(tc)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Test parses the given connector, temporarily saves it so the auth server
+// can drive a real sign-in against the identity provider, and prints the
+// claims/assertions it received and the roles and traits its mappings
+// produced. The connector is removed again once the test completes.
+func (c *SSOCommand) Test(tc *auth.TunClient) error {
+	reader, err := utils.OpenFile(c.connectorPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+
+	var raw services.UnknownResource
+	if err := kyaml.NewYAMLOrJSONDecoder(reader, 32*1024).Decode(&raw); err != nil {
+		return trace.Wrap(err)
+	}
+
+	var connectorID, protocol string
+	switch raw.Kind {
+	case services.KindOIDCConnector:
+		conn, err := services.GetOIDCConnectorMarshaler().UnmarshalOIDCConnector(raw.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := tc.UpsertOIDCConnector(conn); err != nil {
+			return trace.Wrap(err)
+		}
+		defer tc.DeleteOIDCConnector(conn.GetName())
+		connectorID, protocol = conn.GetName(), teleport.OIDC
+	case services.KindSAMLConnector:
+		conn, err := services.GetSAMLConnectorMarshaler().UnmarshalSAMLConnector(raw.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := conn.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		if err := tc.UpsertSAMLConnector(conn); err != nil {
+			return trace.Wrap(err)
+		}
+		defer tc.DeleteSAMLConnector(conn.GetName())
+		connectorID, protocol = conn.GetName(), teleport.SAML
+	default:
+		return trace.BadParameter("unsupported connector kind %q, expected %q or %q",
+			raw.Kind, services.KindOIDCConnector, services.KindSAMLConnector)
+	}
+
+	response, err := client.SSOTestFlowLogin(c.proxyAddr, connectorID, c.insecure, nil, protocol)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if response.TestFlowResult == nil {
+		return trace.BadParameter("auth server did not return a test result")
+	}
+
+	out, err := json.MarshalIndent(response.TestFlowResult, "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/trace"
+)
+
+// StatusCommand implements `tctl status`, a one-shot report of this
+// tctl binary's version and whether it's within the auth server's
+// supported version skew window (see utils.CheckVersionSkew).
+type StatusCommand struct {
+	config *service.Config
+
+	// CLI clause
+	status *kingpin.CmdClause
+}
+
+// Initialize allows StatusCommand to plug itself into the CLI parser
+func (c *StatusCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+	c.status = app.Command("status", "Report cluster version and this tctl's version skew against it")
+}
+
+// TryRun takes the CLI command as an argument (like "status") and executes it.
+func (c *StatusCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.status.FullCommand():
+		err = c.Status(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Status prints this tctl's own version and confirms, by calling the auth
+// server, that it's within the supported version skew window. The auth
+// API has no call that reports the server's version back to the caller,
+// so a successful call is the only skew signal available here: the auth
+// server's withAuth middleware already rejects requests from a client
+// outside the N-1 minor version window before this call can return.
+func (c *StatusCommand) Status(client *auth.TunClient) error {
+	fmt.Printf("tctl version: %v\n", teleport.Version)
+
+	if _, err := client.GetDomainName(); err != nil {
+		return trace.Wrap(err, "this tctl could not reach the auth server; it may be outside the supported version skew")
+	}
+	fmt.Println("this tctl is within the supported version skew of the cluster")
+	return nil
+}
@@ -86,13 +86,17 @@ func (c *TokenCommand) List(client *auth.TunClient) error {
 	}
 	tokensView := func() string {
 		table := goterm.NewTable(0, 10, 5, ' ', 0)
-		printHeader(table, []string{"Token", "Role", "Expiry Time (UTC)"})
+		printHeader(table, []string{"Token", "Role", "Expiry Time (UTC)", "Created By", "Uses"})
 		for _, t := range tokens {
 			expiry := "never"
 			if t.Expires.Unix() > 0 {
 				expiry = t.Expires.Format(time.RFC822)
 			}
-			fmt.Fprintf(table, "%v\t%v\t%s\n", t.Token, t.Roles.String(), expiry)
+			createdBy := t.CreatedBy
+			if createdBy == "" {
+				createdBy = "unknown"
+			}
+			fmt.Fprintf(table, "%v\t%v\t%s\t%v\t%v\n", t.Token, t.Roles.String(), expiry, createdBy, t.Uses)
 		}
 		return table.String()
 	}
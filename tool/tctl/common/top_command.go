@@ -0,0 +1,118 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/trace"
+)
+
+// TopCommand implements "tctl top", a live, periodically refreshed view of
+// the cluster's state
+type TopCommand struct {
+	config *service.Config
+	// namespace is the namespace of the nodes/sessions being displayed
+	namespace string
+	// refresh is how often the view is redrawn
+	refresh time.Duration
+
+	// CLI clause
+	top *kingpin.CmdClause
+}
+
+// Initialize allows TopCommand to plug itself into the CLI parser
+func (c *TopCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+	c.top = app.Command("top", "Report cluster health and load on a periodic basis")
+	c.top.Flag("namespace", "Namespace of the nodes/sessions").Default(defaults.Namespace).StringVar(&c.namespace)
+	c.top.Flag("refresh", "Refresh period").Default("2s").DurationVar(&c.refresh)
+}
+
+// TryRun takes the CLI command as an argument (like "top") and executes it.
+func (c *TopCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.top.FullCommand():
+		err = c.Top(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Top polls the auth server for cluster state and redraws a summary table
+// until interrupted. It intentionally sticks to the data already exposed
+// over the admin API (nodes, auth servers, proxies, sessions); the richer
+// backend/cache counters published on the diagnostic /metrics endpoint are
+// only reachable over HTTP today and are not wired into tctl yet.
+func (c *TopCommand) Top(client *auth.TunClient) error {
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, os.Interrupt)
+
+	ticker := time.NewTicker(c.refresh)
+	defer ticker.Stop()
+
+	if err := c.render(client); err != nil {
+		return trace.Wrap(err)
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.render(client); err != nil {
+				return trace.Wrap(err)
+			}
+		case <-sigC:
+			return nil
+		}
+	}
+}
+
+func (c *TopCommand) render(client *auth.TunClient) error {
+	nodes, err := client.GetNodes(c.namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	proxies, err := client.GetProxies()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	authServers, err := client.GetAuthServers()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	sessions, err := client.GetSessions(c.namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// clear the screen and move the cursor to the top-left corner
+	fmt.Print("\033[2J\033[H")
+	fmt.Printf("cluster status as of %v\n\n", time.Now().Format(time.RFC3339))
+	fmt.Printf("%-20v%v\n", "nodes online:", len(nodes))
+	fmt.Printf("%-20v%v\n", "proxies online:", len(proxies))
+	fmt.Printf("%-20v%v\n", "auth servers online:", len(authServers))
+	fmt.Printf("%-20v%v\n", "active sessions:", len(sessions))
+	fmt.Printf("\n(refreshing every %v, ctrl-c to exit)\n", c.refresh)
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2017 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+)
+
+// TrustedHostKeyCommand implements the `tctl trustedhostkeys` group of
+// commands used to import raw OpenSSH host keys as trusted during a
+// migration to Teleport.
+type TrustedHostKeyCommand struct {
+	config *service.Config
+
+	hostname string
+	pubKeys  []string
+
+	trustedHostKeysAdd *kingpin.CmdClause
+	trustedHostKeysLs  *kingpin.CmdClause
+	trustedHostKeysRm  *kingpin.CmdClause
+}
+
+// Initialize allows TrustedHostKeyCommand to plug itself into the CLI parser
+func (c *TrustedHostKeyCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	trustedHostKeys := app.Command("trustedhostkeys", "Manage trusted OpenSSH host keys for migrations")
+
+	c.trustedHostKeysAdd = trustedHostKeys.Command("add", "Register a node's existing OpenSSH host key as trusted")
+	c.trustedHostKeysAdd.Arg("hostname", "Hostname the key is valid for").Required().StringVar(&c.hostname)
+	c.trustedHostKeysAdd.Arg("pubkey-file", "Path to the node's public host key(s), one per line, authorized_keys format").Required().StringsVar(&c.pubKeys)
+
+	c.trustedHostKeysLs = trustedHostKeys.Command("ls", "List trusted OpenSSH host keys")
+
+	c.trustedHostKeysRm = trustedHostKeys.Command("rm", "Remove a trusted OpenSSH host key")
+	c.trustedHostKeysRm.Arg("hostname", "Hostname to stop trusting").Required().StringVar(&c.hostname)
+}
+
+// TryRun takes the CLI command as an argument (like "trustedhostkeys ls") and executes it.
+func (c *TrustedHostKeyCommand) TryRun(cmd string, client *auth.TunClient) (match bool, err error) {
+	switch cmd {
+	case c.trustedHostKeysAdd.FullCommand():
+		err = c.Add(client)
+	case c.trustedHostKeysLs.FullCommand():
+		err = c.List(client)
+	case c.trustedHostKeysRm.FullCommand():
+		err = c.Remove(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Add reads the public key file(s) given on the command line and registers
+// them as trusted for c.hostname.
+func (c *TrustedHostKeyCommand) Add(client *auth.TunClient) error {
+	var authorizedKeys [][]byte
+	for _, path := range c.pubKeys {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		authorizedKeys = append(authorizedKeys, contents)
+	}
+
+	key := services.TrustedHostKey{
+		Hostname:       c.hostname,
+		AuthorizedKeys: authorizedKeys,
+	}
+	if err := key.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := client.UpsertTrustedHostKey(key); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("trusted host key for %v registered\n", c.hostname)
+	return nil
+}
+
+// List prints every trusted host key known to the cluster.
+func (c *TrustedHostKeyCommand) List(client *auth.TunClient) error {
+	keys, err := client.GetTrustedHostKeys()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(keys) == 0 {
+		fmt.Println("no trusted host keys found")
+		return nil
+	}
+	for _, key := range keys {
+		fmt.Printf("%v: %v key(s)\n", key.Hostname, len(key.AuthorizedKeys))
+	}
+	return nil
+}
+
+// Remove deletes the trusted host key registered for c.hostname.
+func (c *TrustedHostKeyCommand) Remove(client *auth.TunClient) error {
+	if err := client.DeleteTrustedHostKey(c.hostname); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("trusted host key for %v removed\n", c.hostname)
+	return nil
+}
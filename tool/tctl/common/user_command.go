@@ -22,14 +22,19 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/service"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/web"
+
 	"github.com/gravitational/trace"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 // UserCommand implements `tctl users` set of commands
@@ -40,11 +45,25 @@ type UserCommand struct {
 	allowedLogins string
 	roles         string
 	identities    []string
+	// format is the output format for 'users ls', e.g. "text" or "json"
+	format string
+	// expires, if set via --set-expires, is how long from now the user
+	// being added/updated should remain valid for
+	expires time.Duration
+	// expiringWithin, if set via 'users ls --expiring', restricts 'users ls'
+	// to accounts that expire within the given duration
+	expiringWithin time.Duration
+	// bootstrapAdmin, if set via 'users add --bootstrap-admin', creates the
+	// user directly with a password and TOTP secret instead of generating a
+	// signup token, for clusters whose proxy isn't reachable yet to complete
+	// the usual token-URL flow (e.g. a freshly provisioned, headless install)
+	bootstrapAdmin bool
 
 	userAdd    *kingpin.CmdClause
 	userUpdate *kingpin.CmdClause
 	userList   *kingpin.CmdClause
 	userDelete *kingpin.CmdClause
+	userReset  *kingpin.CmdClause
 }
 
 // Initialize allows UserCommand to plug itself into the CLI parser
@@ -56,18 +75,32 @@ func (u *UserCommand) Initialize(app *kingpin.Application, config *service.Confi
 	u.userAdd.Arg("account", "Teleport user account name").Required().StringVar(&u.login)
 	u.userAdd.Arg("local-logins", "Local UNIX users this account can log in as [login]").
 		Default("").StringVar(&u.allowedLogins)
+	u.userAdd.Flag("bootstrap-admin", "Create the account directly with a password and TOTP secret, instead of a signup token, for use before a proxy is reachable").
+		BoolVar(&u.bootstrapAdmin)
+	u.userAdd.Flag("roles", "Comma-separated list of roles to assign, required with --bootstrap-admin").
+		Default("").StringVar(&u.roles)
 	u.userAdd.Alias(AddUserHelp)
 
-	u.userUpdate = users.Command("update", "Update properties for existing user").Hidden()
+	u.userUpdate = users.Command("update", "Update properties for existing user")
 	u.userUpdate.Arg("login", "Teleport user login").Required().StringVar(&u.login)
 	u.userUpdate.Flag("set-roles", "Roles to assign to this user").
 		Default("").StringVar(&u.roles)
+	u.userUpdate.Flag("set-logins", "Comma-separated list of allowed local logins for this user").
+		Default("").StringVar(&u.allowedLogins)
+	u.userUpdate.Flag("set-expires", "Deactivate this user this far into the future, e.g. 720h for 30 days. Pass 0 to clear an existing expiry").
+		Default("-1ns").DurationVar(&u.expires)
 
 	u.userList = users.Command("ls", "List all user accounts")
+	u.userList.Flag("format", "Output format, 'text' or 'json'").Default("text").StringVar(&u.format)
+	u.userList.Flag("expiring", "Only list accounts that expire within this long from now, e.g. 168h for one week").
+		DurationVar(&u.expiringWithin)
 
 	u.userDelete = users.Command("rm", "Deletes user accounts").Alias("del")
 	u.userDelete.Arg("logins", "Comma-separated list of user logins to delete").
 		Required().StringVar(&u.login)
+
+	u.userReset = users.Command("reset", "Reset a user's password and issue a new reset token")
+	u.userReset.Arg("login", "Teleport user login").Required().StringVar(&u.login)
 }
 
 // TryRun takes the CLI command as an argument (like "users add") and executes it.
@@ -81,6 +114,8 @@ func (u *UserCommand) TryRun(cmd string, client *auth.TunClient) (match bool, er
 		err = u.List(client)
 	case u.userDelete.FullCommand():
 		err = u.Delete(client)
+	case u.userReset.FullCommand():
+		err = u.Reset(client)
 	default:
 		return false, nil
 	}
@@ -90,6 +125,9 @@ func (u *UserCommand) TryRun(cmd string, client *auth.TunClient) (match bool, er
 // Add creates a new sign-up token and prints a token URL to stdout.
 // A user is not created until he visits the sign-up URL and completes the process
 func (u *UserCommand) Add(client *auth.TunClient) error {
+	if u.bootstrapAdmin {
+		return u.addBootstrapAdmin(client)
+	}
 	// if no local logins were specified, default to 'login'
 	if u.allowedLogins == "" {
 		u.allowedLogins = u.login
@@ -123,11 +161,13 @@ func (u *UserCommand) Add(client *auth.TunClient) error {
 	return nil
 }
 
-// Update updates existing user
-func (u *UserCommand) Update(client *auth.TunClient) error {
-	user, err := client.GetUser(u.login)
-	if err != nil {
-		return trace.Wrap(err)
+// addBootstrapAdmin creates a user with a password and TOTP secret set
+// directly, skipping the signup-token/web-URL dance entirely. This is the
+// only way to get a first admin onto a cluster whose proxy isn't reachable
+// yet, e.g. a freshly provisioned headless install with no browser handy.
+func (u *UserCommand) addBootstrapAdmin(client *auth.TunClient) error {
+	if u.roles == "" {
+		return trace.BadParameter("--roles is required with --bootstrap-admin")
 	}
 	roles := strings.Split(u.roles, ",")
 	for _, role := range roles {
@@ -135,7 +175,105 @@ func (u *UserCommand) Update(client *auth.TunClient) error {
 			return trace.Wrap(err)
 		}
 	}
+	if u.allowedLogins == "" {
+		u.allowedLogins = u.login
+	}
+
+	password, err := readPasswordTwice()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	user, err := services.NewUser(u.login)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	user.SetRoles(roles)
+	user.SetTraits(map[string][]string{teleport.TraitLogins: strings.Split(u.allowedLogins, ",")})
+	user.SetCreatedBy(services.CreatedBy{
+		User: services.UserRef{Name: "tctl"},
+		Time: time.Now().UTC(),
+	})
+	if err := client.UpsertUser(user); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := client.UpsertPassword(u.login, password); err != nil {
+		return trace.Wrap(err)
+	}
+
+	otpKey, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      "Teleport",
+		AccountName: u.login,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := client.UpsertTOTP(u.login, otpKey.Secret()); err != nil {
+		return trace.Wrap(err)
+	}
+
+	fmt.Printf("User %q has been created with roles %v.\n\nAdd this secret to a TOTP authenticator app (no QR code is shown because this runs headless):\n%v\n", u.login, strings.Join(roles, ","), otpKey.Secret())
+	return nil
+}
+
+// readPasswordTwice prompts for a password on the terminal without echoing
+// it, asking a second time to catch typos before it's sent to the server.
+func readPasswordTwice() ([]byte, error) {
+	fmt.Print("Enter a password for this user: ")
+	password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	fmt.Print("Confirm password: ")
+	confirm, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if string(password) != string(confirm) {
+		return nil, trace.BadParameter("passwords do not match")
+	}
+	if len(password) == 0 {
+		return nil, trace.BadParameter("password cannot be empty")
+	}
+	return password, nil
+}
+
+// Update updates properties of an existing user: roles and/or allowed logins
+func (u *UserCommand) Update(client *auth.TunClient) error {
+	user, err := client.GetUser(u.login)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if u.roles != "" {
+		roles := strings.Split(u.roles, ",")
+		for _, role := range roles {
+			if _, err := client.GetRole(role); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		user.SetRoles(roles)
+	}
+	if u.allowedLogins != "" {
+		logins := strings.Split(u.allowedLogins, ",")
+		traits := user.GetTraits()
+		if traits == nil {
+			traits = make(map[string][]string)
+		}
+		traits[teleport.TraitLogins] = logins
+		user.SetTraits(traits)
+	}
+	if u.expires >= 0 {
+		if u.expires == 0 {
+			user.SetAccountExpiry(time.Time{})
+		} else {
+			user.SetAccountExpiry(time.Now().UTC().Add(u.expires))
+		}
+	}
+	if u.roles == "" && u.allowedLogins == "" && u.expires < 0 {
+		return trace.BadParameter("nothing to update, specify --set-roles, --set-logins and/or --set-expires")
+	}
 	if err := client.UpsertUser(user); err != nil {
 		return trace.Wrap(err)
 	}
@@ -143,14 +281,59 @@ func (u *UserCommand) Update(client *auth.TunClient) error {
 	return nil
 }
 
-// List prints all existing user accounts
+// List prints all existing user accounts, or only the ones expiring within
+// --expiring of now
 func (u *UserCommand) List(client *auth.TunClient) error {
 	users, err := client.GetUsers()
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if u.expiringWithin > 0 {
+		cutoff := time.Now().UTC().Add(u.expiringWithin)
+		var expiring []services.User
+		for _, user := range users {
+			expires := user.Expiry()
+			if !expires.IsZero() && expires.Before(cutoff) {
+				expiring = append(expiring, user)
+			}
+		}
+		users = expiring
+	}
 	coll := &userCollection{users: users}
-	coll.writeText(os.Stdout)
+	switch u.format {
+	case "json":
+		return coll.writeJSON(os.Stdout)
+	default:
+		return coll.writeText(os.Stdout)
+	}
+}
+
+// Reset issues a new password reset token for an existing user, reusing the
+// same signup-token mechanism as 'users add'
+func (u *UserCommand) Reset(client *auth.TunClient) error {
+	if _, err := client.GetUser(u.login); err != nil {
+		return trace.Wrap(err)
+	}
+	token, err := client.CreateSignupToken(services.UserV1{Name: u.login})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	proxies, err := client.GetProxies()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	hostname := "teleport-proxy"
+	if len(proxies) == 0 {
+		fmt.Printf("\x1b[1mWARNING\x1b[0m: this Teleport cluster does not have any proxy servers online.\n\n")
+	} else {
+		hostname = proxies[0].GetHostname()
+	}
+	_, proxyPort, err := net.SplitHostPort(u.config.Proxy.WebAddr.Addr)
+	if err != nil {
+		proxyPort = strconv.Itoa(defaults.HTTPListenPort)
+	}
+	url := web.CreateSignupLink(net.JoinHostPort(hostname, proxyPort), token)
+	fmt.Printf("Password reset token has been created and is valid for %v seconds. Share this URL with the user:\n%v\n\nNOTE: make sure '%s' is accessible!\n", defaults.MaxSignupTokenTTL.Seconds(), url, hostname)
 	return nil
 }
 
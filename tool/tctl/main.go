@@ -27,6 +27,19 @@ func main() {
 		&common.TokenCommand{},
 		&common.AuthCommand{},
 		&common.ResourceCommand{},
+		&common.BackupCommand{},
+		&common.TopCommand{},
+		&common.StatusCommand{},
+		&common.InventoryCommand{},
+		&common.AccessCommand{},
+		&common.SessionsCommand{},
+		&common.AuditCommand{},
+		&common.SSOCommand{},
+		&common.OktaCommand{},
+		&common.AccessRequestCommand{},
+		&common.ReviewCommand{},
+		&common.ClusterAlertCommand{},
+		&common.TrustedHostKeyCommand{},
 	}
 	common.Run("Community Edition", commands)
 }
@@ -18,6 +18,7 @@ package common
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/user"
@@ -62,7 +63,39 @@ func Run(cmdlineArgs []string, distro string, testRun bool) (executedCommand str
 	// define commands:
 	start := app.Command("start", "Starts the Teleport service.")
 	status := app.Command("status", "Print the status of the current SSH session.")
-	dump := app.Command("configure", "Print the sample config file into stdout.")
+	dump := app.Command("configure", "Print the sample config file into stdout, or generate a working one from flags.")
+	dump.Flag("test", "Validate a configuration file and print the effective config instead of printing a sample").
+		BoolVar(&ccf.ConfigureTest)
+	dump.Flag("config",
+		fmt.Sprintf("Path to the configuration file to validate with --test [%v]", defaults.ConfigFilePath)).
+		Short('c').ExistingFileVar(&ccf.ConfigFile)
+	dump.Flag("roles",
+		fmt.Sprintf("Comma-separated list of roles to generate a working config for [%s]", strings.Join(defaults.StartRoles, ","))).
+		Short('r').StringVar(&ccf.Roles)
+	dump.Flag("auth-server",
+		"Address of an existing cluster's auth server to join, instead of running a local auth service").
+		StringVar(&ccf.AuthServerAddr)
+	dump.Flag("token",
+		"Join token to use with --auth-server, or to print in the generated config for other nodes to join this one [generated]").
+		StringVar(&ccf.AuthToken)
+	dump.Flag("data-dir",
+		fmt.Sprintf("Path to the data directory [%v]", defaults.DataDir)).
+		StringVar(&ccf.DataDir)
+	dump.Flag("nodename",
+		"Name of this node, defaults to hostname").
+		StringVar(&ccf.NodeName)
+	dump.Flag("public-addr",
+		"Public-facing address of the proxy, e.g. teleport.example.com:443").
+		StringVar(&ccf.ConfigurePublicAddr)
+	dump.Flag("acme",
+		"Enable automatic TLS certificate acquisition for the proxy via ACME (requires --public-addr)").
+		BoolVar(&ccf.ConfigureACME)
+	dump.Flag("acme-email",
+		"Contact address for the ACME account").
+		StringVar(&ccf.ConfigureACMEEmail)
+	dump.Flag("output",
+		"Write the generated config to this file instead of stdout").
+		Short('o').StringVar(&ccf.ConfigureOutput)
 	ver := app.Command("version", "Print the version.")
 	scpc := app.Command("scp", "server-side implementation of scp").Hidden()
 	app.HelpFlag.Short('h')
@@ -110,6 +143,10 @@ func Run(cmdlineArgs []string, distro string, testRun bool) (executedCommand str
 		"Start diangonstic endpoint on this address").Hidden().StringVar(&ccf.DiagnosticAddr)
 	start.Flag("permit-user-env",
 		"Enables reading of ~/.tsh/environment when creating a session").BoolVar(&ccf.PermitUserEnvironment)
+	start.Flag("fips",
+		"Start Teleport in FIPS 140-2 mode, restricting SSH and TLS algorithms to the FIPS-approved subset").BoolVar(&ccf.FIPS)
+	start.Flag("offline",
+		"Start Teleport in offline mode, refusing to start if a feature (e.g. ACME) requires outbound network calls beyond this cluster's configured addresses").BoolVar(&ccf.Offline)
 
 	// define start's usage info (we use kingpin's "alias" field for this)
 	start.Alias(usageNotes + usageExamples)
@@ -176,7 +213,7 @@ func Run(cmdlineArgs []string, distro string, testRun bool) (executedCommand str
 	case status.FullCommand():
 		err = onStatus()
 	case dump.FullCommand():
-		onConfigDump()
+		err = onConfigDump(&ccf)
 	case ver.FullCommand():
 		utils.PrintVersion(distro)
 	}
@@ -234,10 +271,79 @@ func onStatus() error {
 	return nil
 }
 
-// onConfigDump is the handler for "configure" CLI command
-func onConfigDump() {
+// onConfigDump is the handler for the "configure" CLI command. With --test
+// it validates a real config file (at --config, or the default path) and
+// prints the effective, fully-merged configuration. With any bootstrap flag
+// (--roles, --auth-server, --token, --data-dir, --nodename, --public-addr,
+// --acme, --acme-email) it generates a working config file from those
+// choices. With neither, it falls back to printing the illustrative sample
+// it always has.
+func onConfigDump(ccf *config.CommandLineFlags) error {
+	if ccf.ConfigureTest {
+		return onConfigDumpTest(ccf)
+	}
+	if configureFlagsSet(ccf) {
+		return onConfigDumpGenerate(ccf)
+	}
 	sfc := config.MakeSampleFileConfig()
 	fmt.Printf("%s\n%s\n", sampleConfComment, sfc.DebugDumpToYAML())
+	return nil
+}
+
+// configureFlagsSet reports whether any 'teleport configure' bootstrap flag
+// was passed, i.e. the user wants a working config generated from choices
+// rather than the illustrative sample.
+func configureFlagsSet(ccf *config.CommandLineFlags) bool {
+	return ccf.Roles != "" || ccf.AuthServerAddr != "" || ccf.AuthToken != "" ||
+		ccf.DataDir != "" || ccf.NodeName != "" || ccf.ConfigurePublicAddr != "" ||
+		ccf.ConfigureACME || ccf.ConfigureACMEEmail != "" || ccf.ConfigureOutput != ""
+}
+
+// onConfigDumpGenerate generates a working config file from 'teleport
+// configure' bootstrap flags and either prints it or writes it to
+// --output.
+func onConfigDumpGenerate(ccf *config.CommandLineFlags) error {
+	fc, err := config.MakeConfigureFileConfig(config.ConfigureParams{
+		Roles:       ccf.Roles,
+		AuthServer:  ccf.AuthServerAddr,
+		Token:       ccf.AuthToken,
+		DataDir:     ccf.DataDir,
+		NodeName:    ccf.NodeName,
+		ACMEEnabled: ccf.ConfigureACME,
+		ACMEEmail:   ccf.ConfigureACMEEmail,
+		PublicAddr:  ccf.ConfigurePublicAddr,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	yaml := fc.DebugDumpToYAML()
+	if ccf.ConfigureOutput == "" {
+		fmt.Print(yaml)
+		return nil
+	}
+	if err := ioutil.WriteFile(ccf.ConfigureOutput, []byte(yaml), 0644); err != nil {
+		return trace.Wrap(err, "failed to write %v", ccf.ConfigureOutput)
+	}
+	fmt.Printf("Wrote config to %v\n", ccf.ConfigureOutput)
+	return nil
+}
+
+// onConfigDumpTest is the handler for 'teleport configure --test'.
+func onConfigDumpTest(ccf *config.CommandLineFlags) error {
+	configPath := ccf.ConfigFile
+	if configPath == "" {
+		configPath = defaults.ConfigFilePath
+	}
+	fc, err := config.ReadFromFile(configPath)
+	if err != nil {
+		return trace.Wrap(err, "configuration at %v is invalid", configPath)
+	}
+	conf := service.MakeDefaultConfig()
+	if err := config.ApplyFileConfig(fc, conf); err != nil {
+		return trace.Wrap(err, "configuration at %v is invalid", configPath)
+	}
+	fmt.Printf("configuration at %v is valid\n\n%s\n", configPath, conf.DebugDumpToYAML())
+	return nil
 }
 
 // onSCP implements handling of 'scp' requests on the server side. When the teleport SSH daemon
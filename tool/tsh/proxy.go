@@ -0,0 +1,109 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// onProxySSH executes 'tsh proxy ssh': it opens a local listener and, for
+// every connection accepted on it, tunnels the raw bytes to the target
+// node's SSH port through the Teleport proxy (authenticated with the
+// caller's Teleport certs). This lets a native OpenSSH client (or tools
+// like Ansible that shell out to one) reach the node with its own key by
+// pointing at localhost, without knowing anything about Teleport.
+//
+// Database and application protocol tunneling are not implemented: this
+// version of Teleport has no database or application access backend to
+// tunnel to.
+func onProxySSH(cf *CLIConf) {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	listener, err := net.Listen("tcp", cf.ProxyListenAddr)
+	if err != nil {
+		utils.FatalError(trace.Wrap(err))
+	}
+	defer listener.Close()
+
+	fmt.Printf("Listening on %v, tunneling to %v through the Teleport proxy. Ctrl-C to exit.\n",
+		listener.Addr(), cf.UserHost)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			utils.FatalError(trace.Wrap(err))
+		}
+		go handleProxySSHConn(tc, conn)
+	}
+}
+
+// onDaemon executes 'tsh daemon': it holds one authenticated connection to
+// the proxy open on a control socket so that other tsh commands running as
+// the same user (currently just "tsh proxy ssh" against the default
+// namespace/cluster) can reuse it instead of authenticating from scratch.
+func onDaemon(cf *CLIConf) {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	socketPath := client.ControlPath(tc.Config.ProxySSHHostPort())
+	d := client.NewControlDaemon(tc)
+	if err := d.Listen(socketPath); err != nil {
+		utils.FatalError(trace.Wrap(err))
+	}
+	defer d.Close()
+
+	fmt.Printf("tsh daemon listening on %v for proxy %v. Ctrl-C to exit.\n", socketPath, tc.Config.ProxySSHHostPort())
+	if err := d.Serve(); err != nil {
+		utils.FatalError(trace.Wrap(err))
+	}
+}
+
+func handleProxySSHConn(tc *client.TeleportClient, conn net.Conn) {
+	defer conn.Close()
+
+	remote, err := tc.DialHostViaProxy(context.TODO())
+	if err != nil {
+		log.Errorf("tsh proxy ssh: failed dialing target host: %v", err)
+		return
+	}
+	defer remote.Close()
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(remote, conn)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(conn, remote)
+		errCh <- err
+	}()
+	<-errCh
+}
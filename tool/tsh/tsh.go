@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -33,9 +34,9 @@ import (
 
 	"golang.org/x/crypto/ssh"
 
+	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/defaults"
-	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/sshutils"
 	"github.com/gravitational/teleport/lib/teleagent"
@@ -45,6 +46,7 @@ import (
 	"github.com/buger/goterm"
 	gops "github.com/google/gops/agent"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 )
 
 // CLIConf stores command line arguments and flags:
@@ -65,6 +67,10 @@ type CLIConf struct {
 	NodeLogin string
 	// InsecureSkipVerify bypasses verification of HTTPS certificate when talking to web proxy
 	InsecureSkipVerify bool
+	// TLSRoutingEnabled tunnels the SSH protocol inside a TLS connection to
+	// the proxy's HTTPS port instead of dialing its SSH port directly, for
+	// networks that only allow outbound HTTPS
+	TLSRoutingEnabled bool
 	// IsUnderTest is set to true for unit testing
 	IsUnderTest bool
 	// AgentSocketAddr is address for agent listeing socket
@@ -113,6 +119,12 @@ type CLIConf struct {
 	// Compatibility flags, --compat, specifies OpenSSH compatibility flags.
 	Compatibility string
 
+	// Reason is an optional incident/ticket reference explaining why this
+	// session is being started, passed with --reason. Some roles require
+	// one (see services.RequestReason) to tie node access to whatever
+	// paged the user.
+	Reason string
+
 	// IdentityFileOut is an argument to -out flag
 	IdentityFileOut string
 	// IdentityFormat (used for --format flag for 'tsh login') defines which
@@ -121,6 +133,22 @@ type CLIConf struct {
 
 	// AuthConnector is the name of the connector to use.
 	AuthConnector string
+
+	// Format controls the output format of "tsh ls"/"tsh clusters", one of
+	// "text" (default), "json", "yaml", or "names".
+	Format string
+
+	// ProxyListenAddr is the local address "tsh proxy" listens on.
+	ProxyListenAddr string
+
+	// KeyStoreType selects where "tsh login" stores this cluster's keys.
+	// Only client.KeyStoreFile (default) is implemented in this build; see
+	// client.NewLocalKeyStore.
+	KeyStoreType string
+
+	// LocalRecordSession tells "tsh ssh" to locally record the session and
+	// upload it to the auth server afterwards.
+	LocalRecordSession bool
 }
 
 func main() {
@@ -158,6 +186,7 @@ func Run(args []string, underTest bool) {
 	app.Flag("identity", "Identity file").Short('i').StringVar(&cf.IdentityFileIn)
 	app.Flag("compat", "OpenSSH compatibility flag").StringVar(&cf.Compatibility)
 	app.Flag("insecure", "Do not verify server's certificate and host name. Use only in test environments").Default("false").BoolVar(&cf.InsecureSkipVerify)
+	app.Flag("tls-routing", "Tunnel SSH through the proxy's HTTPS port instead of its SSH port. Use this from networks that only allow outbound HTTPS").Envar("TELEPORT_TLS_ROUTING").BoolVar(&cf.TLSRoutingEnabled)
 	app.Flag("auth", "Specify the type of authentication connector to use.").StringVar(&cf.AuthConnector)
 	app.Flag("namespace", "Namespace of the cluster").Default(defaults.Namespace).Hidden().StringVar(&cf.Namespace)
 	app.Flag("gops", "Start gops endpoint on a given address").Hidden().BoolVar(&cf.Gops)
@@ -173,6 +202,8 @@ func Run(args []string, underTest bool) {
 	ssh.Flag("forward", "Forward localhost connections to remote server").Short('L').StringsVar(&cf.LocalForwardPorts)
 	ssh.Flag("local", "Execute command on localhost after connecting to SSH node").Default("false").BoolVar(&cf.LocalExec)
 	ssh.Flag("tty", "Allocate TTY").Short('t').BoolVar(&cf.Interactive)
+	ssh.Flag("record-session", "Locally record this session and upload it to the auth server afterwards (for agentless/plain OpenSSH nodes, or when proxy recording is off)").BoolVar(&cf.LocalRecordSession)
+	ssh.Flag("reason", "Incident or ticket reference for this session, recorded in the audit log. Required by roles that set request_reason: required").StringVar(&cf.Reason)
 	// join
 	join := app.Command("join", "Join the active SSH session")
 	join.Arg("session-id", "ID of the session to join").Required().StringVar(&cf.SessionID)
@@ -188,9 +219,11 @@ func Run(args []string, underTest bool) {
 	// ls
 	ls := app.Command("ls", "List remote SSH nodes")
 	ls.Arg("labels", "List of labels to filter node list").StringVar(&cf.UserHost)
+	ls.Flag("format", "Output format: 'text' (default), 'json', 'yaml', or 'names'").Default(teleport.TextFormat).StringVar(&cf.Format)
 	// clusters
 	clusters := app.Command("clusters", "List available Teleport clusters")
 	clusters.Flag("quiet", "Quiet mode").Short('q').BoolVar(&cf.Quiet)
+	clusters.Flag("format", "Output format: 'text' (default), 'json', 'yaml', or 'names'").Default(teleport.TextFormat).StringVar(&cf.Format)
 	// agent (SSH agent listening on unix socket)
 	agent := app.Command("agent", "Start SSH agent on unix socket [deprecating soon]")
 	agent.Flag("socket", "SSH agent listening socket address, e.g. unix:///tmp/teleport.agent.sock").SetValue(&cf.AgentSocketAddr)
@@ -203,12 +236,14 @@ func Run(args []string, underTest bool) {
 	login.Flag("format", fmt.Sprintf("Identity format [%s] or %s (for OpenSSH compatibility)",
 		client.DefaultIdentityFormat,
 		client.IdentityFormatOpenSSH)).Default(string(client.DefaultIdentityFormat)).StringVar((*string)(&cf.IdentityFormat))
+	login.Flag("key-store", fmt.Sprintf("Where to store this cluster's keys: %q (default, the only backend this build implements); %q/%q are reserved for future hardware/OS-keychain support and will fail",
+		client.KeyStoreFile, client.KeyStorePIV, client.KeyStoreOSKeychain)).StringVar(&cf.KeyStoreType)
 
 	// logout deletes obtained session certificates in ~/.tsh
 	logout := app.Command("logout", "Delete a cluster certificate")
 
 	// bench
-	bench := app.Command("bench", "Run shell or execute a command on a remote SSH node").Hidden()
+	bench := app.Command("bench", "Benchmark the load an SSH command puts on a proxy/auth server, reporting an HDR latency histogram")
 	bench.Arg("[user@]host", "Remote hostname and the login to use").Required().StringVar(&cf.UserHost)
 	bench.Arg("command", "Command to execute on a remote host").Required().StringsVar(&cf.RemoteCommand)
 	bench.Flag("port", "SSH port on a remote host").Short('p').Int16Var(&cf.NodePort)
@@ -217,10 +252,33 @@ func Run(args []string, underTest bool) {
 	bench.Flag("rate", "Requests per second rate").Default("10").IntVar(&cf.BenchRate)
 	bench.Flag("interactive", "Create interactive SSH session").BoolVar(&cf.BenchInteractive)
 
+	// proxy
+	proxy := app.Command("proxy", "Run a local proxy for tunneling raw protocol traffic through the Teleport proxy")
+	proxySSH := proxy.Command("ssh", "Start a local listener that tunnels raw SSH traffic to a node, for native OpenSSH clients")
+	proxySSH.Arg("host", "Remote hostname to tunnel to").Required().StringVar(&cf.UserHost)
+	proxySSH.Flag("port", "SSH port on a remote host").Short('p').Int16Var(&cf.NodePort)
+	proxySSH.Flag("listen", "Local address to listen on").Default("127.0.0.1:0").StringVar(&cf.ProxyListenAddr)
+	proxyDB := proxy.Command("db", "Start a local listener that tunnels raw database protocol traffic to a database")
+	proxyDB.Arg("db", "Name of the database to tunnel to").Required().StringVar(&cf.UserHost)
+	proxyApp := proxy.Command("app", "Start a local listener that tunnels raw application traffic to an app")
+	proxyApp.Arg("app", "Name of the application to tunnel to").Required().StringVar(&cf.UserHost)
+
+	// daemon holds one authenticated connection to the proxy open and shares
+	// it with other tsh invocations over a control socket (control-master
+	// semantics), so heavy CLI users pay for the proxy handshake and any SSO
+	// prompt once instead of on every command.
+	daemon := app.Command("daemon", "Run a background daemon that keeps one proxy connection open for reuse by other tsh commands").Hidden()
+
 	// show key
 	show := app.Command("show", "Read an identity from file and print to stdout").Hidden()
 	show.Arg("identity_file", "The file containing a public key or a certificate").Required().StringVar(&cf.IdentityFileIn)
 
+	// kube is the first consumer of the protocolCommands framework: every
+	// non-SSH access protocol (kube, and eventually db/apps) gets the same
+	// login/ls/connect verb set and shares CLIConf's profile/cert handling,
+	// instead of bolting a one-off flat command onto the top level like ssh/scp did.
+	kube := newProtocolCommands(app, "kube", "Access Kubernetes clusters through Teleport")
+
 	// parse CLI commands+flags:
 	command, err := app.Parse(args)
 	if err != nil {
@@ -260,6 +318,14 @@ func Run(args []string, underTest bool) {
 		onSSH(&cf)
 	case bench.FullCommand():
 		onBenchmark(&cf)
+	case proxySSH.FullCommand():
+		onProxySSH(&cf)
+	case proxyDB.FullCommand():
+		utils.FatalError(trace.BadParameter("database access is not supported by this version of Teleport"))
+	case proxyApp.FullCommand():
+		utils.FatalError(trace.BadParameter("application access is not supported by this version of Teleport"))
+	case daemon.FullCommand():
+		onDaemon(&cf)
 	case join.FullCommand():
 		onJoin(&cf)
 	case scp.FullCommand():
@@ -280,6 +346,12 @@ func Run(args []string, underTest bool) {
 		onLogout(&cf)
 	case show.FullCommand():
 		onShow(&cf)
+	case kube.login.FullCommand():
+		onProtocolLogin(&cf, kube)
+	case kube.ls.FullCommand():
+		onProtocolLs(&cf, kube)
+	case kube.connect.FullCommand():
+		onProtocolConnect(&cf, kube)
 	}
 }
 
@@ -333,6 +405,17 @@ func onLogin(cf *CLIConf) {
 		return
 	}
 
+	// if no login was explicitly requested, adopt the single login this
+	// cluster granted us in the certificate we just got back, rather than
+	// keeping whatever default NewClient had to guess before we had a cert
+	// to inspect. This is what future "tsh ssh host" (no "user@host") calls
+	// against this profile will use.
+	if cf.NodeLogin == "" {
+		if principals, err := key.Principals(); err == nil && len(principals) == 1 {
+			tc.HostLogin = principals[0]
+		}
+	}
+
 	// regular login (without -i flag)
 	tc.SaveProfile("")
 	if tc.SiteName != "" {
@@ -340,6 +423,33 @@ func onLogin(cf *CLIConf) {
 	} else {
 		fmt.Printf("\nYou are now logged in\n")
 	}
+
+	showClusterAlerts(tc)
+}
+
+// showClusterAlerts prints any cluster alerts the logged-in user hasn't
+// already acknowledged, e.g. a planned maintenance notice or a security
+// advisory. Failing to fetch them doesn't fail the login -- the user is
+// already authenticated at this point.
+func showClusterAlerts(tc *client.TeleportClient) {
+	proxyClient, err := tc.ConnectToProxy()
+	if err != nil {
+		return
+	}
+	defer proxyClient.Close()
+
+	site, err := proxyClient.ConnectToSite(context.TODO(), true)
+	if err != nil {
+		return
+	}
+	alerts, err := site.GetActiveClusterAlerts()
+	if err != nil || len(alerts) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, alert := range alerts {
+		fmt.Printf("[%v] %v\n", alert.Severity, alert.Message)
+	}
 }
 
 // onLogout deletes a "session certificate" from ~/.tsh for a given proxy
@@ -374,18 +484,38 @@ func onListNodes(cf *CLIConf) {
 	if err != nil {
 		utils.FatalError(err)
 	}
-	nodesView := func(nodes []services.Server) string {
+
+	type node struct {
+		Hostname string            `json:"hostname" yaml:"hostname"`
+		UUID     string            `json:"uuid" yaml:"uuid"`
+		Address  string            `json:"address" yaml:"address"`
+		Labels   map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	}
+	nodes := make([]node, 0, len(servers))
+	for _, n := range servers {
+		nodes = append(nodes, node{
+			Hostname: n.GetHostname(),
+			UUID:     n.GetName(),
+			Address:  n.GetAddr(),
+			Labels:   n.GetAllLabels(),
+		})
+	}
+
+	switch cf.Format {
+	case teleport.NamesFormat:
+		for _, n := range nodes {
+			fmt.Println(n.Hostname)
+		}
+	case teleport.JSONFormat, teleport.YAMLFormat:
+		printListing(cf.Format, nodes)
+	default:
 		t := goterm.NewTable(0, 10, 5, ' ', 0)
 		printHeader(t, []string{"Node Name", "Node ID", "Address", "Labels"})
-		if len(nodes) == 0 {
-			return t.String()
-		}
-		for _, n := range nodes {
+		for _, n := range servers {
 			fmt.Fprintf(t, "%v\t%v\t%v\t%v\n", n.GetHostname(), n.GetName(), n.GetAddr(), n.LabelsString())
 		}
-		return t.String()
+		fmt.Print(t.String())
 	}
-	fmt.Printf(nodesView(servers))
 }
 
 // onListSites executes 'tsh sites' command
@@ -422,12 +552,51 @@ func onListSites(cf *CLIConf) {
 		}
 		return strings.Join(names, "\n")
 	}
+
+	type cluster struct {
+		Name   string `json:"name" yaml:"name"`
+		Status string `json:"status" yaml:"status"`
+	}
+	switch cf.Format {
+	case teleport.NamesFormat:
+		fmt.Println(quietSitesView())
+		return
+	case teleport.JSONFormat, teleport.YAMLFormat:
+		clusters := make([]cluster, 0, len(sites))
+		for _, site := range sites {
+			clusters = append(clusters, cluster{Name: site.Name, Status: site.Status})
+		}
+		printListing(cf.Format, clusters)
+		return
+	}
+
 	if cf.Quiet {
 		sitesView = quietSitesView
 	}
 	fmt.Printf(sitesView())
 }
 
+// printListing marshals listing into the requested format (JSON or YAML) and
+// prints it to stdout. Callers only reach this for teleport.JSONFormat and
+// teleport.YAMLFormat; teleport.TextFormat and teleport.NamesFormat have their
+// own rendering that doesn't need a marshaler.
+func printListing(format string, listing interface{}) {
+	var out []byte
+	var err error
+	switch format {
+	case teleport.JSONFormat:
+		out, err = json.MarshalIndent(listing, "", "  ")
+	case teleport.YAMLFormat:
+		out, err = yaml.Marshal(listing)
+	default:
+		utils.FatalError(trace.BadParameter("unsupported format: %q", format))
+	}
+	if err != nil {
+		utils.FatalError(trace.Wrap(err))
+	}
+	fmt.Println(string(out))
+}
+
 // onSSH executes 'tsh ssh' command
 func onSSH(cf *CLIConf) {
 	tc, err := makeClient(cf, false)
@@ -454,10 +623,11 @@ func onBenchmark(cf *CLIConf) {
 		utils.FatalError(err)
 	}
 	result, err := tc.Benchmark(cf.Context, client.Benchmark{
-		Command:  cf.RemoteCommand,
-		Threads:  cf.BenchThreads,
-		Duration: cf.BenchDuration,
-		Rate:     cf.BenchRate,
+		Command:     cf.RemoteCommand,
+		Threads:     cf.BenchThreads,
+		Duration:    cf.BenchDuration,
+		Rate:        cf.BenchRate,
+		Interactive: cf.BenchInteractive,
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
@@ -580,8 +750,29 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (tc *client.TeleportClient, e
 			hostLogin = parts[0]
 			cf.UserHost = parts[1]
 		}
+		// does the host match one of the user's configured proxy templates
+		// (~/.tsh/config/config.yaml)? if so, it's resolved to a node
+		// search query and/or leaf cluster instead of a literal hostname,
+		// so muscle-memory hostnames like "db1.prod" keep working without
+		// the user having to remember which cluster "db1" actually lives in
+		tshConfig, err := client.LoadTSHConfig(filepath.Join(client.FullProfilePath(""), client.TSHConfigPath))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if newHost, query, cluster, matched := tshConfig.ApplyProxyTemplates(cf.UserHost); matched {
+			cf.UserHost = newHost
+			if query != "" {
+				labels, err = client.ParseLabelSpec(query)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if cluster != "" && cf.SiteName == "" {
+				cf.SiteName = cluster
+			}
+		}
 		// see if remote host is specified as a set of labels
-		if strings.Contains(cf.UserHost, "=") {
+		if labels == nil && strings.Contains(cf.UserHost, "=") {
 			labels, err = client.ParseLabelSpec(cf.UserHost)
 			if err != nil {
 				return nil, err
@@ -648,6 +839,10 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (tc *client.TeleportClient, e
 	if cf.SiteName != "" {
 		c.SiteName = cf.SiteName
 	}
+	if cf.KeyStoreType != "" {
+		c.KeyStoreType = cf.KeyStoreType
+	}
+	c.LocalRecordSession = cf.LocalRecordSession
 	// if host logins stored in profiles must be ignored...
 	if !useProfileLogin {
 		c.HostLogin = ""
@@ -660,10 +855,17 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (tc *client.TeleportClient, e
 	c.Labels = labels
 	c.KeyTTL = time.Minute * time.Duration(cf.MinsToLive)
 	c.InsecureSkipVerify = cf.InsecureSkipVerify
+	c.TLSRoutingEnabled = cf.TLSRoutingEnabled
 	c.Interactive = cf.Interactive
 	if !cf.NoCache {
 		c.CachePolicy = &client.CachePolicy{}
 	}
+	if cf.Reason != "" {
+		if c.Env == nil {
+			c.Env = make(map[string]string)
+		}
+		c.Env[sshutils.SessionReasonEnvVar] = cf.Reason
+	}
 
 	// parse compatibility parameter
 	compatibility, err := utils.CheckCompatibilityFlag(cf.Compatibility)